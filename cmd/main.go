@@ -2,16 +2,24 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"Gym_StrongCode/config"
 	_ "Gym_StrongCode/docs"
+	"Gym_StrongCode/internal/cache"
+	"Gym_StrongCode/internal/fiscal"
 	"Gym_StrongCode/internal/handler"
 	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/models"
 	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/internal/service"
 	"Gym_StrongCode/internal/utils"
@@ -45,35 +53,105 @@ func main() {
 
 	// Репозитории
 	userRepo := repository.NewUserRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
 	gymRepo := repository.NewGymRepository(db)
 	membershipRepo := repository.NewMembershipRepository(db)
 	trainerRepo := repository.NewTrainerRepository(db)
 	classRepo := repository.NewClassRepository(db)
 	bookingRepo := repository.NewBookingRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
+	currencyRepo := repository.NewCurrencyRepository(db)
+	taxRepo := repository.NewTaxRepository(db)
+	fiscalRepo := repository.NewFiscalRepository(db)
+	installmentRepo := repository.NewInstallmentRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	signingKeyRepo := repository.NewSigningKeyRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	campaignRepo := repository.NewCampaignRepository(db)
+	// Транзакции, охватывающие несколько репозиториев
+	uow := repository.NewUnitOfWork(db)
+
+	// Redis необязателен: без него сессии и счётчики входов хранятся в памяти процесса
+	redisClient := newRedisClient(cfg)
 
 	// Сервисы
-	authService := service.NewAuthService(userRepo, cfg.JWTSecret)
-	notificationService := service.NewNotificationService(cfg)
+	keyService := service.NewKeyService(signingKeyRepo, cfg.JWTAlgorithm, cfg.JWTKeyRotation, cfg.JWTKeyOverlap)
+	if err := keyService.Init(context.Background()); err != nil {
+		logger.Fatal("Failed to initialize JWT signing keys", zap.Error(err))
+	}
+	passwordPolicy := service.NewPasswordPolicy(cfg.PasswordMinLength, cfg.PasswordMinCharClasses, cfg.BcryptCost)
+	authService := service.NewAuthService(userRepo, tokenRepo, uow, keyService, passwordPolicy, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	linkSecret := newLinkSecret(cfg)
+	notificationService := service.NewNotificationService(notificationRepo, uow, newNotificationRouter(cfg), newUnsubscribeLinks(cfg, linkSecret), cfg.NotificationWorkers)
+	// Серверные сессии (AUTH_MODE=session); в режиме JWT их нет
+	var sessionStore cache.SessionStore
+	if cfg.AuthMode == "session" {
+		sessionStore = newSessionStore(cfg, redisClient)
+	}
+	accountService := service.NewAccountService(userRepo, tokenRepo, uow, authService, sessionStore, notificationService, cfg.AppURL, cfg.PasswordResetTTL, cfg.EmailVerificationTTL)
+	currencyService := service.NewCurrencyService(currencyRepo, cfg.BaseCurrency)
+	taxService := service.NewTaxService(taxRepo, paymentRepo)
+	fiscalService := service.NewFiscalService(fiscalRepo, paymentRepo, newFiscalSender(cfg.FiscalProvider))
 	gymService := service.NewGymService(gymRepo)
-	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, db, notificationService)
+	adminAlerts := service.NewAdminAlerts(notificationService, currencyService, newAlertRules(cfg), cfg.AdminAlertLargePaymentCents)
+	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, uow, notificationService, currencyService, taxService, fiscalService, adminAlerts)
 	trainerService := service.NewTrainerService(trainerRepo)
-	classService := service.NewClassService(classRepo, trainerRepo, gymRepo)
-	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, notificationService)
-	paymentService := service.NewPaymentService(paymentRepo)
+	classService := service.NewClassService(classRepo, trainerRepo, gymRepo, bookingRepo, uow, notificationService)
+	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, uow, notificationService, adminAlerts)
+	paymentService := service.NewPaymentService(paymentRepo, uow, currencyService, taxService, fiscalService, adminAlerts)
+	reportService := service.NewReportService(reportRepo, paymentRepo)
+	roleService := service.NewRoleService(roleRepo, userRepo)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	twoFactorService := service.NewTwoFactorService(userRepo, tokenRepo, uow)
+	oidcService := service.NewOIDCService(cfg.OIDCProviders, newOIDCStateStore(redisClient), userRepo, identityRepo, uow)
+	loginGuard := service.NewLoginGuard(newLoginAttemptStore(redisClient), userRepo, notificationService,
+		cfg.LoginMaxAttempts, cfg.LoginMaxAttemptsPerIP, cfg.LoginLockout, cfg.LoginMaxLockout)
+	installmentService := service.NewInstallmentService(installmentRepo, membershipRepo, uow, membershipService, paymentService, adminAlerts, cfg.InstallmentGraceDays)
+	campaignService := service.NewCampaignService(campaignRepo, uow, notificationService, newOpenTracker(cfg, linkSecret))
+	classReminderService := service.NewClassReminderService(bookingRepo, uow, notificationService, time.Duration(cfg.ClassReminderHours)*time.Hour)
 
 	// Запуск background worker для email
-	go notificationService.StartWorker()
+	notificationService.StartWorker()
+	fiscalService.StartWorker()
+	installmentService.StartWorker()
+	classReminderService.StartWorker()
+	campaignService.StartWorker()
+	keyService.StartWorker()
 
 	// Хендлеры
-	authHandler := handler.NewAuthHandler(authService)
+	authHandler := handler.NewAuthHandler(authService, accountService, loginGuard)
 	userHandler := handler.NewUserHandler(userRepo)
 	gymHandler := handler.NewGymHandler(gymService)
-	membershipHandler := handler.NewMembershipHandler(membershipService)
+	membershipHandler := handler.NewMembershipHandler(membershipService, userRepo)
 	trainerHandler := handler.NewTrainerHandler(trainerService)
 	classHandler := handler.NewClassHandler(classService)
 	bookingHandler := handler.NewBookingHandler(bookingService, userRepo)
 	paymentHandler := handler.NewPaymentHandler(paymentService)
+	currencyHandler := handler.NewCurrencyHandler(currencyService)
+	taxHandler := handler.NewTaxHandler(taxService)
+	fiscalHandler := handler.NewFiscalHandler(fiscalService)
+	installmentHandler := handler.NewInstallmentHandler(installmentService)
+	reportHandler := handler.NewReportHandler(reportService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	campaignHandler := handler.NewCampaignHandler(campaignService)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+	jwksHandler := handler.NewJWKSHandler(keyService)
+
+	// Аутентификация: stateless JWT или серверные сессии
+	authMiddleware := middleware.AuthMiddleware(keyService, authService)
+	var sessionService *service.SessionService
+	var sessionHandler *handler.SessionHandler
+	if cfg.AuthMode == "session" {
+		sessionService = service.NewSessionService(authService, sessionStore)
+		sessionHandler = handler.NewSessionHandler(sessionService, loginGuard, cfg.Environment == "production")
+		authMiddleware = middleware.SessionAuthMiddleware(sessionStore, authService)
+	}
+	oidcHandler := handler.NewOIDCHandler(oidcService, authService, sessionService, cfg.Environment == "production")
 
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -98,8 +176,10 @@ func main() {
 
 	r.Use(middleware.LoggingMiddleware(logger))
 	r.Use(middleware.RateLimitMiddleware())
+	r.Use(middleware.RequestTimeout(newRouteTimeouts(cfg)))
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	r.GET("/.well-known/jwks.json", jwksHandler.JWKS)
 
 	api := r.Group("/api")
 	{
@@ -107,65 +187,182 @@ func main() {
 
 		// Публичные
 		api.POST("/users/register", authHandler.Register)
-		api.POST("/users/login", authHandler.Login)
+		api.POST("/users/forgot-password", authHandler.ForgotPassword)
+		api.POST("/users/reset-password", authHandler.ResetPassword)
+		api.POST("/users/verify-email", authHandler.VerifyEmail)
+		api.GET("/notifications/unsubscribe", notificationHandler.UnsubscribeInfo)
+		api.POST("/notifications/unsubscribe", notificationHandler.Unsubscribe)
+		api.GET("/notifications/open", campaignHandler.TrackOpen)
+		if sessionHandler != nil {
+			api.POST("/users/login", sessionHandler.Login)
+			api.POST("/users/login/2fa", sessionHandler.LoginMFA)
+		} else {
+			api.POST("/users/login", authHandler.Login)
+			api.POST("/users/login/2fa", authHandler.LoginMFA)
+			api.POST("/users/refresh", authHandler.Refresh)
+		}
+		api.GET("/auth/oidc/providers", oidcHandler.Providers)
+		api.GET("/auth/oidc/:provider/login", oidcHandler.Login)
+		api.GET("/auth/oidc/:provider/callback", oidcHandler.Callback)
+		api.POST("/auth/oidc/:provider/callback", oidcHandler.Callback)
 		api.GET("/classes", classHandler.List)
 		api.GET("/gyms", gymHandler.List)
 		api.GET("/memberships", membershipHandler.List)
+		api.GET("/memberships/:id/prices", membershipHandler.ListPrices)
 		api.GET("/trainers", trainerHandler.List)
 
 		// Авторизованные
 		authorized := api.Group("")
-		authorized.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+		authorized.Use(authMiddleware)
 		{
+			if sessionHandler != nil {
+				authorized.POST("/users/logout", sessionHandler.Logout)
+				authorized.POST("/users/logout-all", sessionHandler.LogoutAll)
+				authorized.GET("/me/sessions", sessionHandler.List)
+				authorized.DELETE("/me/sessions/:id", sessionHandler.Revoke)
+				authorized.PUT("/me/password", sessionHandler.ChangePassword)
+			} else {
+				authorized.POST("/users/logout", authHandler.Logout)
+				authorized.POST("/users/logout-all", authHandler.LogoutAll)
+				authorized.PUT("/me/password", authHandler.ChangePassword)
+			}
+
+			authorized.POST("/users/verify-email/resend", authHandler.ResendVerification)
+
+			authorized.POST("/me/2fa/setup", twoFactorHandler.Setup)
+			authorized.POST("/me/2fa/enable", twoFactorHandler.Enable)
+			authorized.POST("/me/2fa/disable", twoFactorHandler.Disable)
+			authorized.POST("/me/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+
 			authorized.GET("/me", userHandler.GetCurrent)
 			authorized.PUT("/me", userHandler.Update)
+			authorized.PUT("/me/push-token", userHandler.SetPushToken)
+			authorized.DELETE("/me/push-token", userHandler.DeletePushToken)
+			authorized.GET("/me/notifications", notificationHandler.GetPreferences)
+			authorized.PUT("/me/notifications", notificationHandler.UpdatePreferences)
 
-			authorized.POST("/bookings", bookingHandler.Create)
 			authorized.GET("/bookings", bookingHandler.ListUser)
 			authorized.DELETE("/bookings/:id", bookingHandler.Cancel)
 
-			authorized.POST("/memberships/buy", membershipHandler.Buy)
+			// Бронирования и покупки; при REQUIRE_EMAIL_VERIFICATION — только с подтверждённым email
+			verified := authorized.Group("")
+			if cfg.RequireEmailVerification {
+				verified.Use(middleware.RequireVerifiedEmail(accountService))
+			}
+			verified.POST("/bookings", bookingHandler.Create)
+			verified.POST("/memberships/buy", membershipHandler.Buy)
+			verified.POST("/memberships/installments", installmentHandler.Create)
+
+			authorized.GET("/installment-plans", installmentHandler.ListMine)
+			authorized.GET("/installment-plans/:id", installmentHandler.GetMine)
 			authorized.POST("/payments", paymentHandler.CreateStandalone)
 		}
 
-		// Админ
+		// Админ: доступ по ролям, права проверяются для каждой группы маршрутов.
+		// Права, выданные на конкретный зал, действуют только на маршрутах с RequireGymPermission.
+		// Интеграции обращаются сюда по API-ключу, права ключа задаются его scopes.
 		admin := api.Group("/admin")
-		admin.Use(middleware.AuthMiddleware(cfg.JWTSecret))
-		admin.Use(middleware.AdminOnly())
+		admin.Use(middleware.AllowAPIKeys(apiKeyService, authMiddleware))
+		if cfg.RequireAdmin2FA {
+			admin.Use(middleware.RequireTwoFactor(twoFactorService))
+		}
 		{
-			// Users
-			admin.GET("/users", userHandler.List)
-			admin.DELETE("/users/:id", userHandler.Delete)
+			// Users & roles
+			users := admin.Group("/users")
+			users.GET("", middleware.RequirePermission(roleService, models.PermUsersView), userHandler.List)
+			users.DELETE("/:id", middleware.RequirePermission(roleService, models.PermUsersManage), userHandler.Delete)
+			users.POST("/:id/unlock", middleware.RequirePermission(roleService, models.PermUsersManage), authHandler.UnlockLogin)
+			users.GET("/:id/roles", middleware.RequirePermission(roleService, models.PermRolesManage), roleHandler.List)
+			users.POST("/:id/roles", middleware.RequireGymPermission(roleService, models.PermRolesManage, middleware.GymFromJSONBody("gym_id")), roleHandler.Assign)
+			users.DELETE("/:id/roles/:role_id", middleware.RequireGymPermission(roleService, models.PermRolesManage, middleware.GymFromLookup("role_id", roleService.GymOf)), roleHandler.Revoke)
+
+			// API keys
+			apiKeys := admin.Group("/api-keys", middleware.RequirePermission(roleService, models.PermAPIKeysManage))
+			apiKeys.POST("", apiKeyHandler.Create)
+			apiKeys.GET("", apiKeyHandler.List)
+			apiKeys.DELETE("/:id", apiKeyHandler.Revoke)
 
 			// Gyms
-			admin.POST("/gyms", gymHandler.Create)
-			admin.PUT("/gyms/:id", gymHandler.Update)
-			admin.DELETE("/gyms/:id", gymHandler.Delete)
-
-			// Memberships
-			admin.POST("/memberships", membershipHandler.Create)
-			admin.PUT("/memberships/:id", membershipHandler.Update)
-			admin.DELETE("/memberships/:id", membershipHandler.Delete)
+			gymsManage := middleware.RequirePermission(roleService, models.PermGymsManage)
+			admin.POST("/gyms", gymsManage, gymHandler.Create)
+			admin.PUT("/gyms/:id", middleware.RequireGymPermission(roleService, models.PermGymsManage, middleware.GymFromParam("id")), gymHandler.Update)
+			admin.DELETE("/gyms/:id", gymsManage, gymHandler.Delete)
+
+			// Memberships, currencies, taxes
+			catalog := admin.Group("", middleware.RequirePermission(roleService, models.PermCatalogManage))
+			catalog.POST("/memberships", membershipHandler.Create)
+			catalog.PUT("/memberships/:id", membershipHandler.Update)
+			catalog.DELETE("/memberships/:id", membershipHandler.Delete)
+			catalog.PUT("/memberships/:id/prices/:currency", membershipHandler.SetPrice)
+			catalog.DELETE("/memberships/:id/prices/:currency", membershipHandler.DeletePrice)
+			catalog.GET("/exchange-rates", currencyHandler.ListRates)
+			catalog.PUT("/exchange-rates/:currency", currencyHandler.SetRate)
+			catalog.DELETE("/exchange-rates/:currency", currencyHandler.DeleteRate)
+			catalog.GET("/tax-rates", taxHandler.List)
+			catalog.POST("/tax-rates", taxHandler.Create)
+			catalog.PUT("/tax-rates/:id", taxHandler.Update)
+			catalog.DELETE("/tax-rates/:id", taxHandler.Delete)
 
 			// Trainers
-			admin.POST("/trainers", trainerHandler.Create)
-			admin.PUT("/trainers/:id", trainerHandler.Update)
-			admin.DELETE("/trainers/:id", trainerHandler.Delete)
+			trainers := admin.Group("/trainers", middleware.RequirePermission(roleService, models.PermTrainersManage))
+			trainers.POST("", trainerHandler.Create)
+			trainers.PUT("/:id", trainerHandler.Update)
+			trainers.DELETE("/:id", trainerHandler.Delete)
 
 			// Classes
-			admin.POST("/classes", classHandler.Create)
-			admin.PUT("/classes/:id", classHandler.Update)
-			admin.DELETE("/classes/:id", classHandler.Delete)
-
-			// Payments & Bookings (read-only)
-			admin.GET("/payments", paymentHandler.ListAll)
-			admin.GET("/bookings", bookingHandler.ListAll)
+			classGym := middleware.RequireGymPermission(roleService, models.PermClassesManage, middleware.GymFromLookup("id", classService.GymOf))
+			// Перенос занятия в другой зал требует прав и в новом зале
+			classTargetGym := middleware.RequireGymPermission(roleService, models.PermClassesManage, middleware.GymFromJSONBody("gym_id"))
+			admin.POST("/classes", middleware.RequireGymPermission(roleService, models.PermClassesManage, middleware.GymFromJSONBody("gym_id")), classHandler.Create)
+			admin.PUT("/classes/:id", classGym, classTargetGym, classHandler.Update)
+			admin.DELETE("/classes/:id", classGym, classHandler.Delete)
+
+			// Payments & Bookings
+			admin.GET("/payments", middleware.RequireGymPermission(roleService, models.PermPaymentsView, middleware.GymFromQuery("gym_id")), paymentHandler.ListAll)
+			admin.GET("/payments/summary", middleware.RequirePermission(roleService, models.PermPaymentsView), paymentHandler.Summary)
+			admin.POST("/payments/:id/refund", middleware.RequireGymPermission(roleService, models.PermPaymentsRefund, middleware.GymFromLookup("id", paymentService.GymOf)), paymentHandler.Refund)
+			admin.GET("/bookings", middleware.RequireGymPermission(roleService, models.PermBookingsView, middleware.GymFromQuery("gym_id")), bookingHandler.ListAll)
+
+			// Reports
+			reports := admin.Group("/reports", middleware.RequirePermission(roleService, models.PermReportsView))
+			reports.GET("/tax", taxHandler.Summary)
+			reports.GET("/revenue", reportHandler.Revenue)
+			reports.POST("/reconciliation", reportHandler.Reconcile)
+
+			// Installments
+			installments := admin.Group("/installment-plans", middleware.RequirePermission(roleService, models.PermInstallmentsView))
+			installments.GET("", installmentHandler.ListAll)
+			installments.GET("/:id", installmentHandler.Get)
+
+			// Fiscal receipts
+			outbox := admin.Group("/fiscal/outbox", middleware.RequirePermission(roleService, models.PermFiscalManage))
+			outbox.GET("", fiscalHandler.List)
+			outbox.POST("/:id/retry", fiscalHandler.Retry)
+
+			// Notification queue
+			notifications := admin.Group("/notifications", middleware.RequirePermission(roleService, models.PermNotificationsManage))
+			notifications.GET("/outbox", notificationHandler.List)
+			notifications.POST("/outbox/:id/resend", notificationHandler.Resend)
+			notifications.GET("/templates", notificationHandler.ListTemplates)
+			notifications.POST("/templates/:id/preview", notificationHandler.PreviewTemplate)
+
+			// Campaigns
+			campaigns := admin.Group("/campaigns", middleware.RequirePermission(roleService, models.PermCampaignsManage))
+			campaigns.POST("/segment-preview", campaignHandler.PreviewSegment)
+			campaigns.GET("", campaignHandler.List)
+			campaigns.POST("", campaignHandler.Create)
+			campaigns.GET("/:id", campaignHandler.Get)
+			campaigns.POST("/:id/schedule", campaignHandler.Schedule)
+			campaigns.POST("/:id/cancel", campaignHandler.Cancel)
 		}
 	}
 
+	// Базовый контекст всех запросов: отменяется, если они не завершились за время остановки сервера
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
 	srv := &http.Server{
-		Addr:    cfg.ServerAddress,
-		Handler: r,
+		Addr:        cfg.ServerAddress,
+		Handler:     r,
+		BaseContext: func(net.Listener) context.Context { return requestsCtx },
 	}
 
 	go func() {
@@ -184,11 +381,142 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown", zap.Error(err))
+		logger.Error("Server forced to shutdown", zap.Error(err))
 	}
+	cancelRequests()
 
-	// Останавливаем worker
+	// Останавливаем workers
 	notificationService.StopWorker()
+	fiscalService.StopWorker()
+	installmentService.StopWorker()
+	classReminderService.StopWorker()
+	campaignService.StopWorker()
+	keyService.StopWorker()
 
 	logger.Info("Server stopped")
 }
+
+// newNotificationRouter подключает настроенные каналы уведомлений. Без SMTP_HOST и FROM_EMAIL
+// письма только пишутся в лог; SMS, push и webhook подключаются, только если задан их URL.
+func newNotificationRouter(cfg *config.Config) *notification.Router {
+	routes, err := notification.ParseRoutes(cfg.NotificationRoutes)
+	if err != nil {
+		utils.GetLogger().Fatal("Invalid NOTIFICATION_ROUTES", zap.Error(err))
+	}
+
+	var channels []notification.Channel
+	if cfg.SMTPHost == "" || cfg.FromEmail == "" {
+		utils.GetLogger().Warn("SMTP not configured - notifications will be logged only")
+		channels = append(channels, notification.NewLogChannel(notification.ChannelEmail))
+	} else {
+		channels = append(channels, notification.NewSMTPChannel(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.FromEmail))
+	}
+	if cfg.SMSGatewayURL != "" {
+		channels = append(channels, notification.NewSMSChannel(cfg.SMSGatewayURL, cfg.SMSGatewayToken, cfg.SMSSender))
+	}
+	if cfg.PushGatewayURL != "" {
+		channels = append(channels, notification.NewPushChannel(cfg.PushGatewayURL, cfg.PushServerKey))
+	}
+	if cfg.NotificationWebhookURL != "" {
+		channels = append(channels, notification.NewWebhookChannel(cfg.NotificationWebhookURL, cfg.NotificationWebhookSecret))
+	}
+	return notification.NewRouter(routes, channels...)
+}
+
+// newRouteTimeouts разбирает дедлайны запросов из REQUEST_TIMEOUT и ROUTE_TIMEOUTS
+func newRouteTimeouts(cfg *config.Config) *middleware.RouteTimeouts {
+	timeouts, err := middleware.ParseRouteTimeouts(cfg.RouteTimeouts, cfg.RequestTimeout)
+	if err != nil {
+		utils.GetLogger().Fatal("Invalid ROUTE_TIMEOUTS", zap.Error(err))
+	}
+	return timeouts
+}
+
+// newAlertRules разбирает ADMIN_ALERT_RULES; без адресов оповещения администраторам выключены
+func newAlertRules(cfg *config.Config) notification.AlertRules {
+	rules, err := notification.ParseAlertRules(cfg.AdminAlertRules, cfg.NotifyAdminEmail)
+	if err != nil {
+		utils.GetLogger().Fatal("Invalid ADMIN_ALERT_RULES", zap.Error(err))
+	}
+	if len(rules) == 0 {
+		utils.GetLogger().Warn("No admin alert recipients configured - admin alerts are disabled")
+	}
+	return rules
+}
+
+// newLinkSecret возвращает ключ подписи ссылок в письмах (отписка, пиксель открытий):
+// UNSUBSCRIBE_SECRET, а без него — случайный ключ процесса
+func newLinkSecret(cfg *config.Config) string {
+	// Значение-заглушка из примера публично: подписанные им ссылки может подделать кто угодно
+	if cfg.UnsubscribeSecret == "change-me" {
+		utils.GetLogger().Fatal("UNSUBSCRIBE_SECRET must not be the example placeholder")
+	}
+	if cfg.UnsubscribeSecret != "" {
+		return cfg.UnsubscribeSecret
+	}
+	utils.GetLogger().Warn("UNSUBSCRIBE_SECRET not set - unsubscribe links will stop working after restart")
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		utils.GetLogger().Fatal("Failed to generate unsubscribe secret", zap.Error(err))
+	}
+	return hex.EncodeToString(b)
+}
+
+func newUnsubscribeLinks(cfg *config.Config, secret string) *notification.UnsubscribeLinks {
+	return notification.NewUnsubscribeLinks(strings.TrimRight(cfg.AppURL, "/")+"/api/notifications/unsubscribe", secret)
+}
+
+func newOpenTracker(cfg *config.Config, secret string) *notification.OpenTracker {
+	return notification.NewOpenTracker(strings.TrimRight(cfg.AppURL, "/")+"/api/notifications/open", secret)
+}
+
+// newFiscalSender выбирает клиента ОФД по настройке FISCAL_PROVIDER
+func newFiscalSender(provider string) service.FiscalReceiptSender {
+	switch provider {
+	case "":
+		return nil
+	case "fake":
+		return fiscal.NewFakeSender()
+	default:
+		utils.GetLogger().Fatal("Unknown fiscal provider", zap.String("provider", provider))
+		return nil
+	}
+}
+
+// newRedisClient подключается к Redis, если задан REDIS_ADDR; иначе возвращает nil
+func newRedisClient(cfg *config.Config) *cache.RedisClient {
+	if cfg.RedisAddr == "" {
+		utils.GetLogger().Warn("REDIS_ADDR is not set, sessions, login attempts and sign-in state are stored in memory")
+		return nil
+	}
+
+	redisClient, err := cache.NewRedisClient(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+	if err != nil {
+		utils.GetLogger().Fatal("Failed to connect to Redis", zap.Error(err))
+	}
+	return redisClient
+}
+
+// newSessionStore выбирает хранилище сессий: Redis, если он настроен, иначе память процесса
+func newSessionStore(cfg *config.Config, redisClient *cache.RedisClient) cache.SessionStore {
+	if redisClient == nil {
+		return cache.NewMemorySessionStore(cfg.SessionTTL)
+	}
+	return cache.NewSessionManager(redisClient, cfg.SessionTTL)
+}
+
+// newLoginAttemptStore — счётчики неудачных входов в Redis, чтобы блокировка действовала на всех инстансах
+func newLoginAttemptStore(redisClient *cache.RedisClient) cache.LoginAttemptStore {
+	if redisClient == nil {
+		return cache.NewMemoryLoginAttemptStore()
+	}
+	return cache.NewRedisLoginAttemptStore(redisClient)
+}
+
+// newOIDCStateStore — state входа через внешних провайдеров в Redis, чтобы callback мог прийти на любой инстанс
+func newOIDCStateStore(redisClient *cache.RedisClient) cache.OIDCStateStore {
+	if redisClient == nil {
+		return cache.NewMemoryOIDCStateStore()
+	}
+	return cache.NewRedisOIDCStateStore(redisClient)
+}
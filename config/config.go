@@ -2,14 +2,70 @@ package config
 
 import (
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
 	"log"
+	"strings"
+	"time"
 )
 
+// OIDCProvider — внешний провайдер входа OpenID Connect (Google, Apple, IdP корпоративного клиента)
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// Apple при запросе email/name присылает callback POST-формой (response_mode=form_post)
+	ResponseMode string
+}
+
 type Config struct {
 	DatabasePath   string
 	ServerAddress  string
-	JWTSecret      string
 	Environment    string
+	// Дедлайн обработки запроса и переопределения по маршрутам: "/api/admin/reports/*=60s;POST /api/memberships/buy=15s"
+	RequestTimeout time.Duration
+	RouteTimeouts  string
+
+	// Подпись JWT: алгоритм (RS256 или EdDSA), период ротации ключа и сколько прежний ключ ещё принимается
+	JWTAlgorithm   string
+	JWTKeyRotation time.Duration
+	JWTKeyOverlap  time.Duration
+
+	// Время жизни access- и refresh-токенов
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Режим аутентификации: "jwt" — stateless токены, "session" — серверные сессии
+	AuthMode      string
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	SessionTTL    time.Duration
+
+	// Защита от перебора паролей: порог неудач на аккаунт и на IP, начальная и максимальная блокировка
+	LoginMaxAttempts      int
+	LoginMaxAttemptsPerIP int
+	LoginLockout          time.Duration
+	LoginMaxLockout       time.Duration
+
+	// Политика паролей: минимальная длина, сколько классов символов обязательно и стоимость bcrypt
+	PasswordMinLength     int
+	PasswordMinCharClasses int
+	BcryptCost            int
+
+	// Адрес фронтенда для ссылок в письмах сброса пароля и подтверждения email
+	AppURL               string
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+	// Запрещать покупки и бронирования пользователям без подтверждённого email
+	RequireEmailVerification bool
+	// Требовать 2FA для доступа к админским маршрутам
+	RequireAdmin2FA bool
+
+	// Провайдеры входа OpenID Connect из OIDC_PROVIDERS
+	OIDCProviders []OIDCProvider
 
 	// SMTP для уведомлений
 	SMTPHost       string
@@ -18,6 +74,36 @@ type Config struct {
 	SMTPPass       string
 	FromEmail      string
 	NotifyAdminEmail string
+	// Оповещения администраторам: "large_payment;refund=finance@gym.kz" (пусто — все на NotifyAdminEmail)
+	// и порог крупного платежа в базовой валюте, 0 — не оповещать
+	AdminAlertRules             string
+	AdminAlertLargePaymentCents int
+	// Сколько писем из очереди уведомлений отправляется параллельно
+	NotificationWorkers int
+	// Другие каналы уведомлений; канал подключается, если задан его URL
+	SMSGatewayURL             string
+	SMSGatewayToken           string
+	SMSSender                 string
+	PushGatewayURL            string
+	PushServerKey             string
+	NotificationWebhookURL    string
+	NotificationWebhookSecret string
+	// Каналы по шаблонам в порядке предпочтения: "booking_confirmed=push,email;*=email"
+	NotificationRoutes string
+	// Ключ подписи ссылок отписки и пикселя открытий; без него ссылки перестают работать после перезапуска
+	UnsubscribeSecret string
+
+	// Валюта, в которой хранятся базовые цены и сводятся отчёты
+	BaseCurrency string
+
+	// Оператор фискальных данных: "" — чеки только копятся в очереди, "fake" — локальная заглушка
+	FiscalProvider string
+
+	// Сколько дней просроченный платёж по рассрочке не блокирует подписку
+	InstallmentGraceDays int
+
+	// За сколько часов до занятия напоминать записавшимся; 0 — не напоминать
+	ClassReminderHours int
 }
 
 func Load() *Config {
@@ -34,14 +120,53 @@ func Load() *Config {
 	cfg := &Config{
 		DatabasePath:     viper.GetString("DATABASE_PATH"),
 		ServerAddress:    viper.GetString("SERVER_ADDRESS"),
-		JWTSecret:        viper.GetString("JWT_SECRET"),
+		RequestTimeout:   viper.GetDuration("REQUEST_TIMEOUT"),
+		RouteTimeouts:    viper.GetString("ROUTE_TIMEOUTS"),
+		JWTAlgorithm:     viper.GetString("JWT_ALGORITHM"),
+		JWTKeyRotation:   viper.GetDuration("JWT_KEY_ROTATION"),
+		JWTKeyOverlap:    viper.GetDuration("JWT_KEY_OVERLAP"),
 		Environment:      viper.GetString("ENVIRONMENT"),
+		AccessTokenTTL:   viper.GetDuration("ACCESS_TOKEN_TTL"),
+		RefreshTokenTTL:  viper.GetDuration("REFRESH_TOKEN_TTL"),
+		AuthMode:         viper.GetString("AUTH_MODE"),
+		RedisAddr:        viper.GetString("REDIS_ADDR"),
+		RedisPassword:    viper.GetString("REDIS_PASSWORD"),
+		RedisDB:          viper.GetInt("REDIS_DB"),
+		SessionTTL:       viper.GetDuration("SESSION_TTL"),
+		LoginMaxAttempts:      viper.GetInt("LOGIN_MAX_ATTEMPTS"),
+		LoginMaxAttemptsPerIP: viper.GetInt("LOGIN_MAX_ATTEMPTS_PER_IP"),
+		LoginLockout:          viper.GetDuration("LOGIN_LOCKOUT"),
+		LoginMaxLockout:       viper.GetDuration("LOGIN_MAX_LOCKOUT"),
+		PasswordMinLength:      viper.GetInt("PASSWORD_MIN_LENGTH"),
+		PasswordMinCharClasses: viper.GetInt("PASSWORD_MIN_CHAR_CLASSES"),
+		BcryptCost:             viper.GetInt("BCRYPT_COST"),
+		AppURL:           viper.GetString("APP_URL"),
+		PasswordResetTTL: viper.GetDuration("PASSWORD_RESET_TTL"),
+		EmailVerificationTTL:     viper.GetDuration("EMAIL_VERIFICATION_TTL"),
+		RequireEmailVerification: viper.GetBool("REQUIRE_EMAIL_VERIFICATION"),
+		RequireAdmin2FA:          viper.GetBool("REQUIRE_ADMIN_2FA"),
 		SMTPHost:         viper.GetString("SMTP_HOST"),
 		SMTPPort:         viper.GetString("SMTP_PORT"),
 		SMTPUser:         viper.GetString("SMTP_USER"),
 		SMTPPass:         viper.GetString("SMTP_PASS"),
+		NotificationWorkers: viper.GetInt("NOTIFICATION_WORKERS"),
+		SMSGatewayURL:             viper.GetString("SMS_GATEWAY_URL"),
+		SMSGatewayToken:           viper.GetString("SMS_GATEWAY_TOKEN"),
+		SMSSender:                 viper.GetString("SMS_SENDER"),
+		PushGatewayURL:            viper.GetString("PUSH_GATEWAY_URL"),
+		PushServerKey:             viper.GetString("PUSH_SERVER_KEY"),
+		NotificationWebhookURL:    viper.GetString("NOTIFICATION_WEBHOOK_URL"),
+		NotificationWebhookSecret: viper.GetString("NOTIFICATION_WEBHOOK_SECRET"),
+		NotificationRoutes:        viper.GetString("NOTIFICATION_ROUTES"),
+		UnsubscribeSecret:         viper.GetString("UNSUBSCRIBE_SECRET"),
 		FromEmail:        viper.GetString("FROM_EMAIL"),
 		NotifyAdminEmail: viper.GetString("NOTIFY_ADMIN_EMAIL"),
+		AdminAlertRules:             viper.GetString("ADMIN_ALERT_RULES"),
+		AdminAlertLargePaymentCents: viper.GetInt("ADMIN_ALERT_LARGE_PAYMENT_CENTS"),
+		BaseCurrency:     viper.GetString("BASE_CURRENCY"),
+		FiscalProvider:   viper.GetString("FISCAL_PROVIDER"),
+		InstallmentGraceDays: viper.GetInt("INSTALLMENT_GRACE_DAYS"),
+		ClassReminderHours: viper.GetInt("CLASS_REMINDER_HOURS"),
 	}
 
 	// Дефолтные значения
@@ -51,12 +176,115 @@ func Load() *Config {
 	if cfg.ServerAddress == "" {
 		cfg.ServerAddress = ":8080"
 	}
-	if cfg.JWTSecret == "" {
-		log.Fatal("JWT_SECRET is required")
-	}
 	if cfg.Environment == "" {
 		cfg.Environment = "development"
 	}
+	if cfg.RequestTimeout == 0 {
+		cfg.RequestTimeout = 30 * time.Second
+	}
+	if cfg.AccessTokenTTL == 0 {
+		cfg.AccessTokenTTL = 15 * time.Minute
+	}
+	if cfg.RefreshTokenTTL == 0 {
+		cfg.RefreshTokenTTL = 30 * 24 * time.Hour
+	}
+	if cfg.JWTAlgorithm == "" {
+		cfg.JWTAlgorithm = "RS256"
+	}
+	if cfg.JWTAlgorithm != "RS256" && cfg.JWTAlgorithm != "EdDSA" {
+		log.Fatal("JWT_ALGORITHM must be RS256 or EdDSA")
+	}
+	if cfg.JWTKeyRotation == 0 {
+		cfg.JWTKeyRotation = 30 * 24 * time.Hour
+	}
+	if cfg.JWTKeyOverlap == 0 {
+		cfg.JWTKeyOverlap = 24 * time.Hour
+	}
+	// Токены, подписанные прежним ключом, должны успеть истечь до его удаления
+	if cfg.JWTKeyOverlap < cfg.AccessTokenTTL {
+		log.Fatal("JWT_KEY_OVERLAP must not be shorter than ACCESS_TOKEN_TTL")
+	}
+	if cfg.AuthMode == "" {
+		cfg.AuthMode = "jwt"
+	}
+	if cfg.AuthMode != "jwt" && cfg.AuthMode != "session" {
+		log.Fatal("AUTH_MODE must be jwt or session")
+	}
+	if cfg.SessionTTL == 0 {
+		cfg.SessionTTL = 24 * time.Hour
+	}
+	if cfg.PasswordMinLength == 0 {
+		cfg.PasswordMinLength = 10
+	}
+	if cfg.PasswordMinCharClasses == 0 {
+		cfg.PasswordMinCharClasses = 3
+	}
+	if cfg.PasswordMinCharClasses < 1 || cfg.PasswordMinCharClasses > 4 {
+		log.Fatal("PASSWORD_MIN_CHAR_CLASSES must be between 1 and 4")
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = 12
+	}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		log.Fatalf("BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if cfg.AppURL == "" {
+		cfg.AppURL = "http://localhost:8080"
+	}
+	if cfg.PasswordResetTTL == 0 {
+		cfg.PasswordResetTTL = time.Hour
+	}
+	if cfg.EmailVerificationTTL == 0 {
+		cfg.EmailVerificationTTL = 48 * time.Hour
+	}
+	if cfg.BaseCurrency == "" {
+		cfg.BaseCurrency = "KZT"
+	}
+	if cfg.NotificationWorkers == 0 {
+		cfg.NotificationWorkers = 4
+	}
+	if !viper.IsSet("INSTALLMENT_GRACE_DAYS") {
+		cfg.InstallmentGraceDays = 3
+	}
+	if !viper.IsSet("CLASS_REMINDER_HOURS") {
+		cfg.ClassReminderHours = 24
+	}
+	if !viper.IsSet("ADMIN_ALERT_LARGE_PAYMENT_CENTS") {
+		cfg.AdminAlertLargePaymentCents = 20000000
+	}
+	cfg.OIDCProviders = loadOIDCProviders(cfg.AppURL)
 
 	return cfg
-}
\ No newline at end of file
+}
+
+// loadOIDCProviders читает провайдеров из OIDC_PROVIDERS=google,corp и переменных OIDC_<NAME>_*
+func loadOIDCProviders(appURL string) []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(viper.GetString("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		p := OIDCProvider{
+			Name:         name,
+			Issuer:       strings.TrimSuffix(viper.GetString(prefix+"ISSUER"), "/"),
+			ClientID:     viper.GetString(prefix + "CLIENT_ID"),
+			ClientSecret: viper.GetString(prefix + "CLIENT_SECRET"),
+			RedirectURL:  viper.GetString(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(strings.ReplaceAll(viper.GetString(prefix+"SCOPES"), ",", " ")),
+			ResponseMode: viper.GetString(prefix + "RESPONSE_MODE"),
+		}
+		if p.Issuer == "" || p.ClientID == "" {
+			log.Fatalf("%sISSUER and %sCLIENT_ID are required", prefix, prefix)
+		}
+		if p.RedirectURL == "" {
+			p.RedirectURL = strings.TrimSuffix(appURL, "/") + "/api/auth/oidc/" + name + "/callback"
+		}
+		if len(p.Scopes) == 0 {
+			p.Scopes = []string{"openid", "email", "profile"}
+		}
+		providers = append(providers, p)
+	}
+	return providers
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// LoginAttempts — счётчик неудачных входов по аккаунту или IP
+type LoginAttempts struct {
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// LoginAttemptStore хранит счётчики неудачных входов.
+// Increment атомарен: параллельные неудачи не теряются, и блокировка решается по возвращённому счётчику.
+type LoginAttemptStore interface {
+	GetAttempts(ctx context.Context, key string) (*LoginAttempts, error) // nil, если записи нет
+	// Increment добавляет неудачу и возвращает новый счётчик; запись живёт не меньше ttl
+	Increment(ctx context.Context, key string, ttl time.Duration) (int, error)
+	// Lock блокирует вход до until; запись живёт не меньше ttl
+	Lock(ctx context.Context, key string, until time.Time, ttl time.Duration) error
+	ResetAttempts(ctx context.Context, key string) error
+}
+
+// RedisLoginAttemptStore — счётчики в Redis, общие для всех инстансов
+type RedisLoginAttemptStore struct {
+	redisClient *RedisClient
+}
+
+func NewRedisLoginAttemptStore(redisClient *RedisClient) *RedisLoginAttemptStore {
+	return &RedisLoginAttemptStore{redisClient: redisClient}
+}
+
+// Счётчик неудач и срок блокировки хранятся в разных ключах, чтобы счётчик менялся через INCR
+func loginFailuresKey(key string) string {
+	return fmt.Sprintf("login_failures:%s", key)
+}
+
+func loginLockedKey(key string) string {
+	return fmt.Sprintf("login_locked_until:%s", key)
+}
+
+// extendTTL продлевает ключ до ARGV[1] мс, но не укорачивает его
+const extendTTL = `
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[1]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end`
+
+var (
+	incrementScript = redis.NewScript(`local n = redis.call('INCR', KEYS[1])` + extendTTL + `
+return n`)
+	lockScript = redis.NewScript(`redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[1])` + extendTTL + `
+return 1`)
+)
+
+func (s *RedisLoginAttemptStore) GetAttempts(ctx context.Context, key string) (*LoginAttempts, error) {
+	r := s.redisClient
+	failures, err := r.client.Get(ctx, loginFailuresKey(key)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	exists := err == nil
+
+	var attempts LoginAttempts
+	unix, err := r.client.Get(ctx, loginLockedKey(key)).Int64()
+	switch {
+	case err == nil:
+		attempts.LockedUntil = time.UnixMilli(unix)
+		exists = true
+	case !errors.Is(err, redis.Nil):
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+	attempts.Failures = failures
+	return &attempts, nil
+}
+
+func (s *RedisLoginAttemptStore) Increment(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	r := s.redisClient
+	return incrementScript.Run(ctx, r.client, []string{loginFailuresKey(key)}, ttl.Milliseconds()).Int()
+}
+
+func (s *RedisLoginAttemptStore) Lock(ctx context.Context, key string, until time.Time, ttl time.Duration) error {
+	r := s.redisClient
+	return lockScript.Run(ctx, r.client, []string{loginFailuresKey(key), loginLockedKey(key)},
+		ttl.Milliseconds(), until.UnixMilli()).Err()
+}
+
+func (s *RedisLoginAttemptStore) ResetAttempts(ctx context.Context, key string) error {
+	r := s.redisClient
+	return r.client.Del(ctx, loginFailuresKey(key), loginLockedKey(key)).Err()
+}
+
+// MemoryLoginAttemptStore — счётчики в памяти процесса, для одного инстанса
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]memoryLoginAttempts
+}
+
+type memoryLoginAttempts struct {
+	LoginAttempts
+	expiresAt time.Time
+}
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{attempts: make(map[string]memoryLoginAttempts)}
+}
+
+func (s *MemoryLoginAttemptStore) GetAttempts(ctx context.Context, key string) (*LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entry(key, time.Now())
+	if !ok {
+		return nil, nil
+	}
+	attempts := entry.LoginAttempts
+	return &attempts, nil
+}
+
+// entry возвращает действующую запись; вызывается под mu
+func (s *MemoryLoginAttemptStore) entry(key string, now time.Time) (memoryLoginAttempts, bool) {
+	entry, ok := s.attempts[key]
+	if ok && now.After(entry.expiresAt) {
+		delete(s.attempts, key)
+		return memoryLoginAttempts{}, false
+	}
+	return entry, ok
+}
+
+// update изменяет запись под mu от чтения до записи и продлевает её не меньше чем на ttl
+func (s *MemoryLoginAttemptStore) update(key string, ttl time.Duration, fn func(a *LoginAttempts)) LoginAttempts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Заодно чистим истёкшие записи, чтобы карта не росла от перебора случайных email
+	now := time.Now()
+	for k, entry := range s.attempts {
+		if now.After(entry.expiresAt) {
+			delete(s.attempts, k)
+		}
+	}
+	entry := s.attempts[key]
+	fn(&entry.LoginAttempts)
+	if expiresAt := now.Add(ttl); expiresAt.After(entry.expiresAt) {
+		entry.expiresAt = expiresAt
+	}
+	s.attempts[key] = entry
+	return entry.LoginAttempts
+}
+
+func (s *MemoryLoginAttemptStore) Increment(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	attempts := s.update(key, ttl, func(a *LoginAttempts) { a.Failures++ })
+	return attempts.Failures, nil
+}
+
+func (s *MemoryLoginAttemptStore) Lock(ctx context.Context, key string, until time.Time, ttl time.Duration) error {
+	s.update(key, ttl, func(a *LoginAttempts) {
+		// Параллельная неудача могла уже выставить более долгую блокировку
+		if until.After(a.LockedUntil) {
+			a.LockedUntil = until
+		}
+	})
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) ResetAttempts(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemorySessionStore — SessionStore в памяти процесса.
+// Подходит для разработки и тестов: сессии не переживают перезапуск и не видны другим инстансам.
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	sessions map[string]models.Session
+}
+
+func NewMemorySessionStore(ttl time.Duration) *MemorySessionStore {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	return &MemorySessionStore{
+		ttl:      ttl,
+		sessions: make(map[string]models.Session),
+	}
+}
+
+func (m *MemorySessionStore) CreateSession(ctx context.Context, userID int, userEmail string, isAdmin bool, tokenVersion int, userAgent, ip string) (*models.Session, error) {
+	session, err := newSession(userID, userEmail, isAdmin, tokenVersion, userAgent, ip, m.ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.ID] = *session
+	return session, nil
+}
+
+// get возвращает живую сессию; вызывается под m.mu
+func (m *MemorySessionStore) get(sessionID string) (models.Session, bool) {
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return session, false
+	}
+	if time.Now().After(session.ExpiresAt) {
+		delete(m.sessions, sessionID)
+		return session, false
+	}
+	return session, true
+}
+
+func (m *MemorySessionStore) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.get(sessionID)
+	if !ok {
+		return nil, fmt.Errorf("session not found")
+	}
+	return &session, nil
+}
+
+func (m *MemorySessionStore) ExtendSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.get(sessionID)
+	if !ok {
+		return nil, fmt.Errorf("session not found")
+	}
+	now := time.Now()
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(m.ttl)
+	m.sessions[sessionID] = session
+	return &session, nil
+}
+
+func (m *MemorySessionStore) DeleteSession(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+func (m *MemorySessionStore) ListUserSessions(ctx context.Context, userID int) ([]models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sessions []models.Session
+	for id, s := range m.sessions {
+		if s.UserID != userID {
+			continue
+		}
+		if session, ok := m.get(id); ok {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.Before(sessions[j].CreatedAt) })
+	return sessions, nil
+}
+
+func (m *MemorySessionStore) DeleteUserSessions(ctx context.Context, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, s := range m.sessions {
+		if s.UserID == userID {
+			delete(m.sessions, id)
+		}
+	}
+	return nil
+}
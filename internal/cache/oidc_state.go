@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// OIDCState — данные запроса авторизации у внешнего провайдера, нужные при обработке callback
+type OIDCState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// OIDCStateStore хранит state между редиректом к провайдеру и callback; каждый state используется один раз
+type OIDCStateStore interface {
+	SaveOIDCState(state string, data *OIDCState, ttl time.Duration) error
+	TakeOIDCState(state string) (*OIDCState, error) // nil, если state неизвестен или истёк
+}
+
+// RedisOIDCStateStore — state в Redis, чтобы callback мог прийти на любой инстанс
+type RedisOIDCStateStore struct {
+	redisClient *RedisClient
+}
+
+func NewRedisOIDCStateStore(redisClient *RedisClient) *RedisOIDCStateStore {
+	return &RedisOIDCStateStore{redisClient: redisClient}
+}
+
+func oidcStateKey(state string) string {
+	return fmt.Sprintf("oidc_state:%s", state)
+}
+
+func (s *RedisOIDCStateStore) SaveOIDCState(state string, data *OIDCState, ttl time.Duration) error {
+	return s.redisClient.Set(oidcStateKey(state), data, ttl)
+}
+
+func (s *RedisOIDCStateStore) TakeOIDCState(state string) (*OIDCState, error) {
+	var data OIDCState
+	if err := s.redisClient.GetDel(oidcStateKey(state), &data); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &data, nil
+}
+
+// MemoryOIDCStateStore — state в памяти процесса, для одного инстанса
+type MemoryOIDCStateStore struct {
+	mu     sync.Mutex
+	states map[string]memoryOIDCState
+}
+
+type memoryOIDCState struct {
+	OIDCState
+	expiresAt time.Time
+}
+
+func NewMemoryOIDCStateStore() *MemoryOIDCStateStore {
+	return &MemoryOIDCStateStore{states: make(map[string]memoryOIDCState)}
+}
+
+func (s *MemoryOIDCStateStore) SaveOIDCState(state string, data *OIDCState, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range s.states {
+		if now.After(entry.expiresAt) {
+			delete(s.states, k)
+		}
+	}
+	s.states[state] = memoryOIDCState{OIDCState: *data, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryOIDCStateStore) TakeOIDCState(state string) (*OIDCState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.states[state]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.states, state)
+	if time.Now().After(entry.expiresAt) {
+		return nil, nil
+	}
+	data := entry.OIDCState
+	return &data, nil
+}
@@ -36,6 +36,11 @@ func NewRedisClient(addr, password string, db int) (*RedisClient, error) {
     }, nil
 }
 
+// WithContext возвращает клиента, все команды которого выполняются в ctx
+func (r *RedisClient) WithContext(ctx context.Context) *RedisClient {
+    return &RedisClient{client: r.client, ctx: ctx}
+}
+
 // Set сохраняет значение с TTL
 func (r *RedisClient) Set(key string, value interface{}, ttl time.Duration) error {
     jsonData, err := json.Marshal(value)
@@ -56,6 +61,16 @@ func (r *RedisClient) Get(key string, dest interface{}) error {
     return json.Unmarshal([]byte(val), dest)
 }
 
+// GetDel атомарно получает значение и удаляет ключ (для одноразовых значений)
+func (r *RedisClient) GetDel(key string, dest interface{}) error {
+    val, err := r.client.GetDel(r.ctx, key).Result()
+    if err != nil {
+        return err
+    }
+
+    return json.Unmarshal([]byte(val), dest)
+}
+
 // Delete удаляет ключ
 func (r *RedisClient) Delete(key string) error {
     return r.client.Del(r.ctx, key).Err()
@@ -182,6 +197,38 @@ func (r *RedisClient) SAdd(key string, members ...interface{}) error {
     return r.client.SAdd(r.ctx, key, redisMembers...).Err()
 }
 
+// SMembers возвращает элементы множества, добавленные через SAdd
+func (r *RedisClient) SMembers(key string) ([]string, error) {
+    vals, err := r.client.SMembers(r.ctx, key).Result()
+    if err != nil {
+        return nil, err
+    }
+
+    members := make([]string, 0, len(vals))
+    for _, val := range vals {
+        var member string
+        if err := json.Unmarshal([]byte(val), &member); err != nil {
+            return nil, err
+        }
+        members = append(members, member)
+    }
+    return members, nil
+}
+
+// SRem удаляет элементы из множества
+func (r *RedisClient) SRem(key string, members ...interface{}) error {
+    redisMembers := make([]interface{}, len(members))
+    for i, member := range members {
+        jsonData, err := json.Marshal(member)
+        if err != nil {
+            return err
+        }
+        redisMembers[i] = jsonData
+    }
+
+    return r.client.SRem(r.ctx, key, redisMembers...).Err()
+}
+
 // SIsMember проверяет наличие элемента в множестве
 func (r *RedisClient) SIsMember(key string, member interface{}) (bool, error) {
     jsonData, err := json.Marshal(member)
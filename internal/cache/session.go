@@ -2,69 +2,81 @@ package cache
 
 import (
 	"Gym_StrongCode/internal/models"
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"time"
 )
 
-const sessionTTL = 24 * time.Hour
+const DefaultSessionTTL = 24 * time.Hour
+
+// SessionStore хранит серверные сессии; у пользователя может быть несколько сессий одновременно.
+// tokenVersion — версия токенов пользователя на момент входа: после её смены сессия недействительна.
+type SessionStore interface {
+	CreateSession(ctx context.Context, userID int, userEmail string, isAdmin bool, tokenVersion int, userAgent, ip string) (*models.Session, error)
+	GetSession(ctx context.Context, sessionID string) (*models.Session, error)
+	ExtendSession(ctx context.Context, sessionID string) (*models.Session, error)
+	DeleteSession(ctx context.Context, sessionID string) error
+	ListUserSessions(ctx context.Context, userID int) ([]models.Session, error)
+	DeleteUserSessions(ctx context.Context, userID int) error
+}
 
+// SessionManager — SessionStore в Redis. Сессия хранится в session:<id> со скользящим TTL,
+// идентификаторы сессий пользователя — в множестве user_sessions:<user_id>.
 type SessionManager struct {
 	redisClient *RedisClient
+	ttl         time.Duration
 }
 
-func NewSessionManager(redisClient *RedisClient) *SessionManager {
+func NewSessionManager(redisClient *RedisClient, ttl time.Duration) *SessionManager {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
 	return &SessionManager{
 		redisClient: redisClient,
+		ttl:         ttl,
 	}
 }
 
-// CreateSession создает новую сессию (1 пользователь = 1 сессия)
-func (sm *SessionManager) CreateSession(
-	userID int,
-	userEmail string,
-	isAdmin bool,
-) (string, error) {
+func sessionKey(sessionID string) string {
+	return fmt.Sprintf("session:%s", sessionID)
+}
 
-	sessionID, err := generateSessionID()
+func userSessionsKey(userID int) string {
+	return fmt.Sprintf("user_sessions:%d", userID)
+}
+
+// CreateSession создает новую сессию, не затрагивая остальные сессии пользователя
+func (sm *SessionManager) CreateSession(ctx context.Context, userID int, userEmail string, isAdmin bool, tokenVersion int, userAgent, ip string) (*models.Session, error) {
+	session, err := newSession(userID, userEmail, isAdmin, tokenVersion, userAgent, ip, sm.ttl)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	rc := sm.redisClient.WithContext(ctx)
 
-	session := models.Session{
-		ID:        sessionID,
-		UserID:    userID,
-		UserEmail: userEmail,
-		IsAdmin:   isAdmin,
-		CreatedAt: time.Now(),
+	if err := rc.Set(sessionKey(session.ID), session, sm.ttl); err != nil {
+		return nil, err
 	}
 
-	sessionKey := fmt.Sprintf("session:%s", sessionID)
-	userKey := fmt.Sprintf("user_session:%d", userID)
-
-	// сохраняем сессию
-	if err := sm.redisClient.Set(sessionKey, session, sessionTTL); err != nil {
-		return "", err
+	// привязка user -> sessions
+	userKey := userSessionsKey(userID)
+	if err := rc.SAdd(userKey, session.ID); err != nil {
+		_ = rc.Delete(sessionKey(session.ID))
+		return nil, err
 	}
-
-	// привязка user -> session
-	if err := sm.redisClient.Set(userKey, sessionID, sessionTTL); err != nil {
-		_ = sm.redisClient.Delete(sessionKey)
-		return "", err
+	if err := rc.Expire(userKey, sm.ttl); err != nil {
+		return nil, err
 	}
 
-	return sessionID, nil
+	return session, nil
 }
 
 // GetSession возвращает сессию по sessionID
-func (sm *SessionManager) GetSession(sessionID string) (*models.Session, error) {
+func (sm *SessionManager) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
 	var session models.Session
 
-	err := sm.redisClient.Get(
-		fmt.Sprintf("session:%s", sessionID),
-		&session,
-	)
+	err := sm.redisClient.WithContext(ctx).Get(sessionKey(sessionID), &session)
 	if err != nil {
 		return nil, fmt.Errorf("session not found")
 	}
@@ -72,52 +84,96 @@ func (sm *SessionManager) GetSession(sessionID string) (*models.Session, error)
 	return &session, nil
 }
 
-// GetUserSession возвращает активную сессию пользователя
-func (sm *SessionManager) GetUserSession(userID int) (*models.Session, error) {
-	var sessionID string
-
-	err := sm.redisClient.Get(
-		fmt.Sprintf("user_session:%d", userID),
-		&sessionID,
-	)
+// ExtendSession продлевает сессию на полный TTL от текущего момента
+func (sm *SessionManager) ExtendSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	session, err := sm.GetSession(ctx, sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("no active session")
+		return nil, err
+	}
+	rc := sm.redisClient.WithContext(ctx)
+
+	now := time.Now()
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(sm.ttl)
+
+	if err := rc.Set(sessionKey(sessionID), session, sm.ttl); err != nil {
+		return nil, err
+	}
+	if err := rc.Expire(userSessionsKey(session.UserID), sm.ttl); err != nil {
+		return nil, err
 	}
 
-	return sm.GetSession(sessionID)
+	return session, nil
 }
 
-// ExtendSession продлевает сессию
-func (sm *SessionManager) ExtendSession(sessionID string) error {
-	session, err := sm.GetSession(sessionID)
-	if err != nil {
-		return err
+// DeleteSession удаляет сессию
+func (sm *SessionManager) DeleteSession(ctx context.Context, sessionID string) error {
+	rc := sm.redisClient.WithContext(ctx)
+	session, err := sm.GetSession(ctx, sessionID)
+	if err == nil {
+		_ = rc.SRem(userSessionsKey(session.UserID), sessionID)
 	}
 
-	sessionKey := fmt.Sprintf("session:%s", sessionID)
-	userKey := fmt.Sprintf("user_session:%d", session.UserID)
+	return rc.Delete(sessionKey(sessionID))
+}
 
-	if err := sm.redisClient.Set(sessionKey, session, sessionTTL); err != nil {
-		return err
+// ListUserSessions возвращает активные сессии пользователя; истёкшие убираются из множества
+func (sm *SessionManager) ListUserSessions(ctx context.Context, userID int) ([]models.Session, error) {
+	rc := sm.redisClient.WithContext(ctx)
+	userKey := userSessionsKey(userID)
+	ids, err := rc.SMembers(userKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []models.Session
+	for _, id := range ids {
+		session, err := sm.GetSession(ctx, id)
+		if err != nil {
+			_ = rc.SRem(userKey, id)
+			continue
+		}
+		sessions = append(sessions, *session)
 	}
+	return sessions, nil
+}
 
-	if err := sm.redisClient.Set(userKey, sessionID, sessionTTL); err != nil {
+// DeleteUserSessions удаляет все сессии пользователя
+func (sm *SessionManager) DeleteUserSessions(ctx context.Context, userID int) error {
+	rc := sm.redisClient.WithContext(ctx)
+	userKey := userSessionsKey(userID)
+	ids, err := rc.SMembers(userKey)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	for _, id := range ids {
+		if err := rc.Delete(sessionKey(id)); err != nil {
+			return err
+		}
+	}
+	return rc.Delete(userKey)
 }
 
-// DeleteSession удаляет сессию
-func (sm *SessionManager) DeleteSession(sessionID string) error {
-	session, err := sm.GetSession(sessionID)
-	if err == nil {
-		_ = sm.redisClient.Delete(
-			fmt.Sprintf("user_session:%d", session.UserID),
-		)
+func newSession(userID int, userEmail string, isAdmin bool, tokenVersion int, userAgent, ip string, ttl time.Duration) (*models.Session, error) {
+	sessionID, err := generateSessionID()
+	if err != nil {
+		return nil, err
 	}
 
-	return sm.redisClient.Delete(fmt.Sprintf("session:%s", sessionID))
+	now := time.Now()
+	return &models.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		UserEmail:  userEmail,
+		IsAdmin:    isAdmin,
+		Version:    tokenVersion,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}, nil
 }
 
 func generateSessionID() (string, error) {
@@ -0,0 +1,62 @@
+package fiscal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"Gym_StrongCode/internal/models"
+)
+
+// ErrFakeUnavailable — ошибка, которую FakeSender возвращает в режиме отказа
+var ErrFakeUnavailable = errors.New("fake fiscal operator unavailable")
+
+// FakeSender — локальная реализация ОФД для тестов и разработки.
+// Выдаёт последовательные фискальные признаки и умеет имитировать отказы.
+type FakeSender struct {
+	mu       sync.Mutex
+	failNext int
+	counter  int
+	sent     []models.FiscalReceipt
+}
+
+func NewFakeSender() *FakeSender {
+	return &FakeSender{}
+}
+
+// FailNext заставляет следующие n вызовов SendReceipt завершиться ошибкой
+func (f *FakeSender) FailNext(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failNext = n
+}
+
+func (f *FakeSender) SendReceipt(ctx context.Context, receipt *models.FiscalReceipt) (*models.FiscalRegistration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failNext > 0 {
+		f.failNext--
+		return nil, ErrFakeUnavailable
+	}
+
+	f.counter++
+	f.sent = append(f.sent, *receipt)
+	sign := fmt.Sprintf("FAKE%012d", f.counter)
+	return &models.FiscalRegistration{
+		FiscalSign: sign,
+		URL:        "https://ofd.fake.local/receipt/" + sign,
+	}, nil
+}
+
+// Sent возвращает копию зарегистрированных чеков
+func (f *FakeSender) Sent() []models.FiscalReceipt {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]models.FiscalReceipt(nil), f.sent...)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	GymID     *int       `json:"gym_id"`     // пусто — ключ действует во всех залах
+	ExpiresAt *time.Time `json:"expires_at"` // пусто — бессрочный ключ
+}
+
+type createAPIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKey godoc
+// @Summary      Create API key
+// @Description  Issue an API key for an integration. The key is returned only once; send it in the X-API-Key header or as "Authorization: ApiKey <key>"
+// @Tags         api-keys
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.createAPIKeyRequest  true  "API key"
+// @Success      201   {object}  handler.createAPIKeyResponse
+// @Failure      400   {object}  map[string]string
+// @Router       /admin/api-keys [post]
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, rawKey, err := h.apiKeyService.Create(c.Request.Context(), userID, req.Name, req.Scopes, req.GymID, req.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPIKeyScope) || errors.Is(err, service.ErrInvalidAPIKeyExpiry) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, createAPIKeyResponse{APIKey: *key, Key: rawKey})
+}
+
+// ListAPIKeys godoc
+// @Summary      List API keys
+// @Description  List issued API keys with their scopes, expiry and last use
+// @Tags         api-keys
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {array}   models.APIKey
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/api-keys [get]
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.apiKeyService.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke API key
+// @Description  Revoke an API key; requests with it are rejected immediately
+// @Tags         api-keys
+// @Security     Bearer
+// @Param        id   path      int  true  "API key ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	if err := h.apiKeyService.Revoke(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
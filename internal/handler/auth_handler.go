@@ -1,30 +1,62 @@
 package handler
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
+	"Gym_StrongCode/internal/middleware"
 	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 type AuthHandler struct {
-	authService *service.AuthService
+	authService    *service.AuthService
+	accountService *service.AccountService
+	loginGuard     *service.LoginGuard
 }
 
-func NewAuthHandler(authService *service.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+func NewAuthHandler(authService *service.AuthService, accountService *service.AccountService, loginGuard *service.LoginGuard) *AuthHandler {
+	return &AuthHandler{authService: authService, accountService: accountService, loginGuard: loginGuard}
+}
+
+// checkLoginLocked отвечает 429 с Retry-After, если вход для аккаунта или IP заблокирован
+func checkLoginLocked(c *gin.Context, guard *service.LoginGuard, email string) bool {
+	var locked *service.LoginLockedError
+	if err := guard.Check(c.Request.Context(), email, c.ClientIP()); errors.As(err, &locked) {
+		seconds := int(math.Ceil(locked.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": locked.Error(), "retry_after": seconds})
+		return true
+	}
+	return false
+}
+
+func recordLoginFailure(c *gin.Context, guard *service.LoginGuard, email string) {
+	if err := guard.Failure(c.Request.Context(), email, c.ClientIP()); err != nil {
+		utils.GetLogger().Error("Failed to record login failure", zap.Error(err))
+	}
+}
+
+func recordLoginSuccess(c *gin.Context, guard *service.LoginGuard, email string) {
+	if err := guard.Success(c.Request.Context(), email); err != nil {
+		utils.GetLogger().Error("Failed to reset login attempts", zap.Error(err))
+	}
 }
 
 type registerRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 // Register godoc
 // @Summary      Register new user
-// @Description  Create a new user account
+// @Description  Create a new user account. The password must satisfy the password policy (length, character classes, not common, not containing the name or email)
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -39,12 +71,17 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.authService.Register(req.Name, req.Email, req.Password)
+	user, err := h.authService.Register(c.Request.Context(), req.Name, req.Email, req.Password)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Письмо с подтверждением можно запросить повторно, поэтому ошибка отправки не ломает регистрацию
+	if err := h.accountService.SendVerification(c.Request.Context(), user.ID); err != nil {
+		utils.GetLogger().Warn("Failed to send verification email", zap.Int("user_id", user.ID), zap.Error(err))
+	}
+
 	c.JSON(http.StatusCreated, user)
 }
 
@@ -55,13 +92,14 @@ type loginRequest struct {
 
 // Login godoc
 // @Summary      Login user
-// @Description  Authenticate user and get JWT token
+// @Description  Authenticate user and get a short-lived access token and a refresh token. If two-factor authentication is enabled, only mfa_required and mfa_token are returned; exchange them at /users/login/2fa.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        body  body      handler.loginRequest  true  "Login credentials"
-// @Success      200   {object}  map[string]string
+// @Success      200   {object}  models.TokenPair
 // @Failure      401   {object}  map[string]string
+// @Failure      429   {object}  map[string]interface{}
 // @Router       /users/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req loginRequest
@@ -69,12 +107,337 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if checkLoginLocked(c, h.loginGuard, req.Email) {
+		return
+	}
 
-	token, err := h.authService.Login(req.Email, req.Password)
+	tokens, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
+		recordLoginFailure(c, h.loginGuard, req.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
+	// При 2FA счётчик сбрасывается только после второго шага
+	if !tokens.MFARequired {
+		recordLoginSuccess(c, h.loginGuard, req.Email)
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+type loginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP-код или код восстановления
+}
+
+// LoginMFA godoc
+// @Summary      Complete two-factor login
+// @Description  Exchange the mfa_token from /users/login and a TOTP or recovery code for a token pair
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.loginMFARequest  true  "MFA token and code"
+// @Success      200   {object}  models.TokenPair
+// @Failure      401   {object}  map[string]string
+// @Failure      429   {object}  map[string]interface{}
+// @Router       /users/login/2fa [post]
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req loginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.authService.MFAUser(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": service.ErrInvalidMFAToken.Error()})
+		return
+	}
+	if checkLoginLocked(c, h.loginGuard, user.Email) {
+		return
+	}
+
+	tokens, err := h.authService.LoginMFA(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMFAToken) || errors.Is(err, service.ErrInvalidTwoFactorCode) {
+			if errors.Is(err, service.ErrInvalidTwoFactorCode) {
+				recordLoginFailure(c, h.loginGuard, user.Email)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordLoginSuccess(c, h.loginGuard, user.Email)
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// UnlockLogin godoc
+// @Summary      Unlock user login
+// @Description  Clear failed login attempts and remove the temporary lockout of a user account
+// @Tags         users
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/users/{id}/unlock [post]
+func (h *AuthHandler) UnlockLogin(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	if err := h.loginGuard.Unlock(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "login unlocked"})
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh godoc
+// @Summary      Refresh tokens
+// @Description  Exchange a refresh token for a new token pair; each refresh token can be used only once
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.refreshRequest  true  "Refresh token"
+// @Success      200   {object}  models.TokenPair
+// @Failure      401   {object}  map[string]string
+// @Router       /users/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Logout godoc
+// @Summary      Logout
+// @Description  Revoke the current access token and, if given, the refresh token of this login
+// @Tags         auth
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.logoutRequest  false  "Refresh token"
+// @Success      200   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /users/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	jti, expiresAt := middleware.GetTokenID(c)
+
+	var req logoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := h.authService.Logout(c.Request.Context(), userID, jti, expiresAt, req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// LogoutAll godoc
+// @Summary      Logout everywhere
+// @Description  Revoke all access and refresh tokens of the current user
+// @Tags         auth
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.authService.LogoutAll(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out everywhere"})
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// changePasswordError переводит ошибки смены пароля в HTTP-ответы
+func changePasswordError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidPassword) || errors.Is(err, service.ErrWeakPassword) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// ChangePassword godoc
+// @Summary      Change password
+// @Description  Change the password of the current user. Requires the current password; the new one must satisfy the password policy. All tokens are revoked, so sign in again afterwards.
+// @Tags         users
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.changePasswordRequest  true  "Current and new password"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Router       /me/password [put]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.ChangePassword(c.Request.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		changePasswordError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password updated, sign in again"})
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ForgotPassword godoc
+// @Summary      Request password reset
+// @Description  Send a single-use password reset link to the email; the response is the same whether or not the email is registered
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.forgotPasswordRequest  true  "Email"
+// @Success      202   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Router       /users/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	if err := h.accountService.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the email is registered, a reset link has been sent"})
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Set a new password using the token from the reset email; all sessions of the user are ended
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.resetPasswordRequest  true  "Token and new password"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Router       /users/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		if errors.Is(err, service.ErrInvalidUserToken) || errors.Is(err, service.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password updated"})
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyEmail godoc
+// @Summary      Verify email
+// @Description  Confirm the email address using the token from the verification email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.verifyEmailRequest  true  "Verification token"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Router       /users/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req verifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		if errors.Is(err, service.ErrInvalidUserToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// ResendVerification godoc
+// @Summary      Resend verification email
+// @Description  Send a new email verification link to the current user
+// @Tags         auth
+// @Security     Bearer
+// @Produce      json
+// @Success      202  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/verify-email/resend [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.accountService.SendVerification(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, service.ErrEmailAlreadyVerified) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification email sent"})
 }
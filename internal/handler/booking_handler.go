@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/models"
 	"Gym_StrongCode/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -13,10 +14,10 @@ import (
 
 type BookingHandler struct {
 	bookingService *service.BookingService
-	userRepo       *repository.UserRepository // для получения email пользователя
+	userRepo       repository.UserStore // для получения email пользователя
 }
 
-func NewBookingHandler(bookingService *service.BookingService, userRepo *repository.UserRepository) *BookingHandler {
+func NewBookingHandler(bookingService *service.BookingService, userRepo repository.UserStore) *BookingHandler {
 	return &BookingHandler{bookingService: bookingService, userRepo: userRepo}
 }
 
@@ -45,14 +46,14 @@ func (h *BookingHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Получаем email пользователя для уведомления
-	user, err := h.userRepo.GetByID(userID)
+	// Получаем email и язык пользователя для уведомления
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
 		return
 	}
 
-	if err := h.bookingService.Create(userID, req.ClassID, user.Email); err != nil {
+	if err := h.bookingService.Create(c.Request.Context(), user, req.ClassID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -71,7 +72,7 @@ func (h *BookingHandler) Create(c *gin.Context) {
 // @Router       /bookings [get]
 func (h *BookingHandler) ListUser(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
-	bookings, err := h.bookingService.ListUser(userID)
+	bookings, err := h.bookingService.ListUser(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -81,15 +82,22 @@ func (h *BookingHandler) ListUser(c *gin.Context) {
 
 // ListAllBookings godoc
 // @Summary      List all bookings
-// @Description  Get all bookings in the system (admin only)
+// @Description  Get all bookings in the system or in one gym (staff only)
 // @Tags         bookings
 // @Security     Bearer
 // @Produce      json
+// @Param        gym_id  query     int  false  "Gym ID; required for gym-scoped staff"
 // @Success      200  {array}   models.Booking
 // @Failure      500  {object}  map[string]string
 // @Router       /admin/bookings [get]
-func (h *BookingHandler) ListAll(c *gin.Context) { // admin only
-	bookings, err := h.bookingService.ListAll()
+func (h *BookingHandler) ListAll(c *gin.Context) {
+	var bookings []models.Booking
+	var err error
+	if gymID, convErr := strconv.Atoi(c.Query("gym_id")); convErr == nil {
+		bookings, err = h.bookingService.ListByGym(c.Request.Context(), gymID)
+	} else {
+		bookings, err = h.bookingService.ListAll(c.Request.Context())
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -111,7 +119,7 @@ func (h *BookingHandler) Cancel(c *gin.Context) {
 	idStr := c.Param("id")
 	id, _ := strconv.Atoi(idStr)
 
-	if err := h.bookingService.Cancel(id, userID); err != nil {
+	if err := h.bookingService.Cancel(c.Request.Context(), id, userID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
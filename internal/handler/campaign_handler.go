@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// openPixel — прозрачный GIF 1x1 для подсчёта открытий
+var openPixel = []byte("GIF89a\x01\x00\x01\x00\x80\x00\x00\x00\x00\x00\x00\x00\x00!\xf9\x04\x01\x00\x00\x00\x00,\x00\x00\x00\x00\x01\x00\x01\x00\x00\x02\x02D\x01\x00;")
+
+type CampaignHandler struct {
+	campaignService *service.CampaignService
+}
+
+func NewCampaignHandler(campaignService *service.CampaignService) *CampaignHandler {
+	return &CampaignHandler{campaignService: campaignService}
+}
+
+func campaignError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCampaign):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCampaignNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCampaignState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// PreviewSegment godoc
+// @Summary      Preview campaign segment
+// @Description  Count users matching the segment and return the first of them. Conditions are combined with AND; an empty segment matches everyone. Gym members are users who booked a class or paid in the gym.
+// @Tags         campaigns
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      models.Segment  true  "Segment"
+// @Success      200   {object}  models.SegmentPreview
+// @Failure      400   {object}  map[string]string
+// @Router       /admin/campaigns/segment-preview [post]
+func (h *CampaignHandler) PreviewSegment(c *gin.Context) {
+	var seg models.Segment
+	if err := c.ShouldBindJSON(&seg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.campaignService.PreviewSegment(c.Request.Context(), &seg)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+type createCampaignRequest struct {
+	Name          string         `json:"name" binding:"required"`
+	Title         string         `json:"title" binding:"required"`
+	Body          string         `json:"body" binding:"required"`
+	Segment       models.Segment `json:"segment"`
+	ScheduledAt   string         `json:"scheduled_at"`
+	RatePerMinute int            `json:"rate_per_minute"`
+}
+
+// CreateCampaign godoc
+// @Summary      Create campaign
+// @Description  Create a marketing message to a segment. With scheduled_at (RFC 3339) the campaign is scheduled, otherwise saved as a draft. Messages are sent at most rate_per_minute per minute (60 by default) and respect recipients' subscriptions and quiet hours.
+// @Tags         campaigns
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.createCampaignRequest  true  "Campaign"
+// @Success      201   {object}  models.Campaign
+// @Failure      400   {object}  map[string]string
+// @Router       /admin/campaigns [post]
+func (h *CampaignHandler) Create(c *gin.Context) {
+	var req createCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _ := middleware.GetUserID(c)
+
+	campaign, err := h.campaignService.Create(c.Request.Context(), &models.Campaign{
+		Name:          req.Name,
+		Title:         req.Title,
+		Body:          req.Body,
+		Segment:       req.Segment,
+		ScheduledAt:   req.ScheduledAt,
+		RatePerMinute: req.RatePerMinute,
+	}, userID)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, campaign)
+}
+
+// ListCampaigns godoc
+// @Summary      List campaigns
+// @Tags         campaigns
+// @Security     Bearer
+// @Produce      json
+// @Param        status  query     string  false  "draft, scheduled, sending, sent or cancelled"
+// @Success      200     {array}   models.Campaign
+// @Failure      500     {object}  map[string]string
+// @Router       /admin/campaigns [get]
+func (h *CampaignHandler) List(c *gin.Context) {
+	campaigns, err := h.campaignService.List(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if campaigns == nil {
+		campaigns = []models.Campaign{}
+	}
+	c.JSON(http.StatusOK, campaigns)
+}
+
+// GetCampaign godoc
+// @Summary      Get campaign
+// @Description  Campaign with delivery and open statistics
+// @Tags         campaigns
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Campaign ID"
+// @Success      200  {object}  models.Campaign
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/campaigns/{id} [get]
+func (h *CampaignHandler) Get(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	campaign, err := h.campaignService.Get(c.Request.Context(), id)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
+
+type scheduleCampaignRequest struct {
+	ScheduledAt string `json:"scheduled_at"`
+}
+
+// ScheduleCampaign godoc
+// @Summary      Schedule campaign
+// @Description  Schedule a draft or move a scheduled campaign to scheduled_at (RFC 3339); without it the campaign starts right away
+// @Tags         campaigns
+// @Security     Bearer
+// @Accept       json
+// @Param        id    path      int                              true   "Campaign ID"
+// @Param        body  body      handler.scheduleCampaignRequest  false  "Send time"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Router       /admin/campaigns/{id}/schedule [post]
+func (h *CampaignHandler) Schedule(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var req scheduleCampaignRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := h.campaignService.Schedule(c.Request.Context(), id, req.ScheduledAt); err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "campaign scheduled"})
+}
+
+// CancelCampaign godoc
+// @Summary      Cancel campaign
+// @Description  Stop a draft, scheduled or sending campaign; messages already queued are still delivered
+// @Tags         campaigns
+// @Security     Bearer
+// @Param        id   path      int  true  "Campaign ID"
+// @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/campaigns/{id}/cancel [post]
+func (h *CampaignHandler) Cancel(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	if err := h.campaignService.Cancel(c.Request.Context(), id); err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "campaign cancelled"})
+}
+
+// TrackOpen godoc
+// @Summary      Campaign open pixel
+// @Description  Transparent 1x1 GIF embedded in campaign emails; records the first open. The image is returned even for invalid tokens.
+// @Tags         campaigns
+// @Produce      image/gif
+// @Param        token  query  string  true  "Token from the pixel URL"
+// @Success      200
+// @Router       /notifications/open [get]
+func (h *CampaignHandler) TrackOpen(c *gin.Context) {
+	// Неверный токен не должен ломать картинку в письме, поэтому ошибка только логируется
+	if err := h.campaignService.TrackOpen(c.Request.Context(), c.Query("token")); err != nil {
+		utils.GetLogger().Debug("Campaign open not tracked", zap.Error(err))
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/gif", openPixel)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -27,7 +28,7 @@ func NewClassHandler(classService *service.ClassService) *ClassHandler {
 // @Failure      500  {object}  map[string]string
 // @Router       /classes [get]
 func (h *ClassHandler) List(c *gin.Context) {
-	classes, err := h.classService.List()
+	classes, err := h.classService.List(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -73,7 +74,7 @@ func (h *ClassHandler) Create(c *gin.Context) {
 		Capacity:    req.Capacity,
 	}
 
-	created, err := h.classService.Create(class)
+	created, err := h.classService.Create(c.Request.Context(), class)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -84,7 +85,7 @@ func (h *ClassHandler) Create(c *gin.Context) {
 
 // UpdateClass godoc
 // @Summary      Update class
-// @Description  Update fitness class details (admin only)
+// @Description  Update fitness class details (admin only); booked members are notified when the time or trainer changes
 // @Tags         classes
 // @Security     Bearer
 // @Accept       json
@@ -93,25 +94,56 @@ func (h *ClassHandler) Create(c *gin.Context) {
 // @Param        body  body      handler.createClassRequest  true  "Updated class data"
 // @Success      200   {object}  map[string]string
 // @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
 // @Router       /admin/classes/{id} [put]
 func (h *ClassHandler) Update(c *gin.Context) {
-	// аналогично Create, с парсингом id
-	// ... (реализация похожа)
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var req createClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	class := &models.Class{
+		Title:       req.Title,
+		Description: req.Description,
+		TrainerID:   req.TrainerID,
+		GymID:       req.GymID,
+		StartTime:   req.StartTime,
+		DurationMin: req.DurationMin,
+		Capacity:    req.Capacity,
+	}
+
+	if err := h.classService.Update(c.Request.Context(), id, class); err != nil {
+		if errors.Is(err, service.ErrClassNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "class updated"})
 }
 
 // DeleteClass godoc
 // @Summary      Delete class
-// @Description  Delete fitness class (admin only)
+// @Description  Cancel fitness class (admin only); booked members are notified and their bookings removed
 // @Tags         classes
 // @Security     Bearer
 // @Param        id   path      int  true  "Class ID"
 // @Success      200  {object}  map[string]string
 // @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
 // @Router       /admin/classes/{id} [delete]
 func (h *ClassHandler) Delete(c *gin.Context) {
 	idStr := c.Param("id")
 	id, _ := strconv.Atoi(idStr)
-	if err := h.classService.Delete(id); err != nil {
+	if err := h.classService.Delete(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrClassNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+
+	"Gym_StrongCode/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CurrencyHandler struct {
+	currencyService *service.CurrencyService
+}
+
+func NewCurrencyHandler(currencyService *service.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{currencyService: currencyService}
+}
+
+// ListExchangeRates godoc
+// @Summary      List exchange rates
+// @Description  Get exchange rates to the base currency (admin only)
+// @Tags         currencies
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {array}   models.ExchangeRate
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/exchange-rates [get]
+func (h *CurrencyHandler) ListRates(c *gin.Context) {
+	rates, err := h.currencyService.ListRates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"base_currency": h.currencyService.BaseCurrency(),
+		"rates":         rates,
+	})
+}
+
+type setExchangeRateRequest struct {
+	Rate float64 `json:"rate" binding:"required,gt=0"`
+}
+
+// SetExchangeRate godoc
+// @Summary      Set exchange rate
+// @Description  Create or update the rate of a currency to the base currency (admin only)
+// @Tags         currencies
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        currency  path      string                          true  "Currency code (ISO 4217)"
+// @Param        body      body      handler.setExchangeRateRequest  true  "Units of base currency per 1 unit"
+// @Success      200       {object}  models.ExchangeRate
+// @Failure      400       {object}  map[string]string
+// @Router       /admin/exchange-rates/{currency} [put]
+func (h *CurrencyHandler) SetRate(c *gin.Context) {
+	var req setExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.currencyService.SetRate(c.Request.Context(), c.Param("currency"), req.Rate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// DeleteExchangeRate godoc
+// @Summary      Delete exchange rate
+// @Description  Remove a currency from the rate table (admin only)
+// @Tags         currencies
+// @Security     Bearer
+// @Param        currency  path      string  true  "Currency code (ISO 4217)"
+// @Success      200       {object}  map[string]string
+// @Failure      400       {object}  map[string]string
+// @Router       /admin/exchange-rates/{currency} [delete]
+func (h *CurrencyHandler) DeleteRate(c *gin.Context) {
+	if err := h.currencyService.DeleteRate(c.Request.Context(), c.Param("currency")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "exchange rate deleted"})
+}
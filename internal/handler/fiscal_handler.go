@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"Gym_StrongCode/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FiscalHandler struct {
+	fiscalService *service.FiscalService
+}
+
+func NewFiscalHandler(fiscalService *service.FiscalService) *FiscalHandler {
+	return &FiscalHandler{fiscalService: fiscalService}
+}
+
+// ListFiscalOutbox godoc
+// @Summary      List fiscal receipt queue
+// @Description  Get fiscal receipt outbox entries, optionally filtered by status (admin only)
+// @Tags         fiscal
+// @Security     Bearer
+// @Produce      json
+// @Param        status  query     string  false  "pending, sent or failed"
+// @Success      200     {array}   models.FiscalOutboxEntry
+// @Failure      500     {object}  map[string]string
+// @Router       /admin/fiscal/outbox [get]
+func (h *FiscalHandler) List(c *gin.Context) {
+	entries, err := h.fiscalService.List(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// RetryFiscalReceipt godoc
+// @Summary      Retry fiscal receipt
+// @Description  Put a pending or failed receipt back to the queue for immediate sending (admin only)
+// @Tags         fiscal
+// @Security     Bearer
+// @Param        id   path      int  true  "Outbox entry ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Router       /admin/fiscal/outbox/{id}/retry [post]
+func (h *FiscalHandler) Retry(c *gin.Context) {
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+
+	if err := h.fiscalService.Retry(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "receipt queued"})
+}
@@ -26,7 +26,7 @@ func NewGymHandler(gymService *service.GymService) *GymHandler {
 // @Failure      500  {object}  map[string]string
 // @Router       /gyms [get]
 func (h *GymHandler) List(c *gin.Context) {
-	gyms, err := h.gymService.List()
+	gyms, err := h.gymService.List(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -57,7 +57,7 @@ func (h *GymHandler) Create(c *gin.Context) {
 		return
 	}
 
-	gym, err := h.gymService.Create(req.Name, req.Address)
+	gym, err := h.gymService.Create(c.Request.Context(), req.Name, req.Address)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -93,7 +93,7 @@ func (h *GymHandler) Update(c *gin.Context) {
 		return
 	}
 
-	if err := h.gymService.Update(id, req.Name, req.Address); err != nil {
+	if err := h.gymService.Update(c.Request.Context(), id, req.Name, req.Address); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -114,7 +114,7 @@ func (h *GymHandler) Delete(c *gin.Context) {
 	idStr := c.Param("id")
 	id, _ := strconv.Atoi(idStr)
 
-	if err := h.gymService.Delete(id); err != nil {
+	if err := h.gymService.Delete(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InstallmentHandler struct {
+	installmentService *service.InstallmentService
+}
+
+func NewInstallmentHandler(installmentService *service.InstallmentService) *InstallmentHandler {
+	return &InstallmentHandler{installmentService: installmentService}
+}
+
+type createInstallmentPlanRequest struct {
+	MembershipID int    `json:"membership_id" binding:"required"`
+	Installments int    `json:"installments" binding:"required"`
+	Method       string `json:"method" binding:"required"`
+	Currency     string `json:"currency"` // пусто — базовая валюта
+	GymID        *int   `json:"gym_id"`
+}
+
+// CreateInstallmentPlan godoc
+// @Summary      Buy membership in installments
+// @Description  Split the membership price into scheduled payments; the first one is charged immediately
+// @Tags         installments
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.createInstallmentPlanRequest  true  "Plan data"
+// @Success      201   {object}  models.InstallmentPlan
+// @Failure      400   {object}  map[string]string
+// @Router       /memberships/installments [post]
+func (h *InstallmentHandler) Create(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req createInstallmentPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.installmentService.CreatePlan(c.Request.Context(), userID, req.MembershipID, req.Installments, req.Method, req.Currency, req.GymID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, plan)
+}
+
+// ListMyInstallmentPlans godoc
+// @Summary      List my installment plans
+// @Description  Get installment plans of the current user
+// @Tags         installments
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {array}   models.InstallmentPlan
+// @Failure      500  {object}  map[string]string
+// @Router       /installment-plans [get]
+func (h *InstallmentHandler) ListMine(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	plans, err := h.installmentService.ListForUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+// GetMyInstallmentPlan godoc
+// @Summary      Get my installment plan
+// @Description  Get an installment plan of the current user with its payment schedule
+// @Tags         installments
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Plan ID"
+// @Success      200  {object}  models.InstallmentPlan
+// @Failure      404  {object}  map[string]string
+// @Router       /installment-plans/{id} [get]
+func (h *InstallmentHandler) GetMine(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+
+	plan, err := h.installmentService.GetForUser(c.Request.Context(), id, userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// ListInstallmentPlans godoc
+// @Summary      List installment plans
+// @Description  Get all installment plans, optionally filtered by status (admin only)
+// @Tags         installments
+// @Security     Bearer
+// @Produce      json
+// @Param        status  query     string  false  "active, suspended, completed or cancelled"
+// @Success      200     {array}   models.InstallmentPlan
+// @Failure      500     {object}  map[string]string
+// @Router       /admin/installment-plans [get]
+func (h *InstallmentHandler) ListAll(c *gin.Context) {
+	plans, err := h.installmentService.ListAll(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+// GetInstallmentPlan godoc
+// @Summary      Get installment plan
+// @Description  Get any installment plan with its payment schedule (admin only)
+// @Tags         installments
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "Plan ID"
+// @Success      200  {object}  models.InstallmentPlan
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/installment-plans/{id} [get]
+func (h *InstallmentHandler) Get(c *gin.Context) {
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+
+	plan, err := h.installmentService.Get(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *InstallmentHandler) respondError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInstallmentPlanNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
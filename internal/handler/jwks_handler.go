@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"Gym_StrongCode/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keyService *service.KeyService
+}
+
+func NewJWKSHandler(keyService *service.KeyService) *JWKSHandler {
+	return &JWKSHandler{keyService: keyService}
+}
+
+// JWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  Public keys for verifying access tokens issued by this service (RFC 7517)
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  models.JWKS
+// @Router       /.well-known/jwks.json [get]
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	// Новый ключ начинает подписывать не раньше, чем истечёт этот кэш
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(service.JWKSMaxAge.Seconds())))
+	c.JSON(http.StatusOK, h.keyService.JWKS())
+}
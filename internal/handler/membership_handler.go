@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -12,10 +13,11 @@ import (
 
 type MembershipHandler struct {
 	membershipService *service.MembershipService
+	userRepo          repository.UserStore // покупатель получает чек на свои адреса
 }
 
-func NewMembershipHandler(membershipService *service.MembershipService) *MembershipHandler {
-	return &MembershipHandler{membershipService: membershipService}
+func NewMembershipHandler(membershipService *service.MembershipService, userRepo repository.UserStore) *MembershipHandler {
+	return &MembershipHandler{membershipService: membershipService, userRepo: userRepo}
 }
 
 // ListMemberships godoc
@@ -27,7 +29,7 @@ func NewMembershipHandler(membershipService *service.MembershipService) *Members
 // @Failure      500  {object}  map[string]string
 // @Router       /memberships [get]
 func (h *MembershipHandler) List(c *gin.Context) {
-	memberships, err := h.membershipService.List()
+	memberships, err := h.membershipService.List(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -38,6 +40,8 @@ func (h *MembershipHandler) List(c *gin.Context) {
 type buyMembershipRequest struct {
 	MembershipID int    `json:"membership_id" binding:"required"`
 	Method       string `json:"method" binding:"required"`
+	Currency     string `json:"currency"` // пусто — базовая валюта
+	GymID        *int   `json:"gym_id"`   // зал продажи, влияет на ставку налога
 }
 
 // BuyMembership godoc
@@ -60,7 +64,13 @@ func (h *MembershipHandler) Buy(c *gin.Context) {
 		return
 	}
 
-	result, err := h.membershipService.Buy(userID, req.MembershipID, req.Method)
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		return
+	}
+
+	result, err := h.membershipService.Buy(c.Request.Context(), user, req.MembershipID, req.Method, req.Currency, req.GymID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -94,7 +104,7 @@ func (h *MembershipHandler) Create(c *gin.Context) {
 		return
 	}
 
-	m, err := h.membershipService.Create(req.Name, req.DurationDays, req.PriceCents)
+	m, err := h.membershipService.Create(c.Request.Context(), req.Name, req.DurationDays, req.PriceCents)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -125,7 +135,7 @@ func (h *MembershipHandler) Update(c *gin.Context) {
 		return
 	}
 
-	if err := h.membershipService.Update(id, req.Name, req.DurationDays, req.PriceCents); err != nil {
+	if err := h.membershipService.Update(c.Request.Context(), id, req.Name, req.DurationDays, req.PriceCents); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -146,10 +156,89 @@ func (h *MembershipHandler) Delete(c *gin.Context) {
 	idStr := c.Param("id")
 	id, _ := strconv.Atoi(idStr)
 
-	if err := h.membershipService.Delete(id); err != nil {
+	if err := h.membershipService.Delete(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "membership deleted"})
 }
+
+// ListMembershipPrices godoc
+// @Summary      List membership prices
+// @Description  Get prices of a membership plan in additional currencies
+// @Tags         memberships
+// @Produce      json
+// @Param        id   path      int  true  "Membership ID"
+// @Success      200  {array}   models.MembershipPrice
+// @Failure      404  {object}  map[string]string
+// @Router       /memberships/{id}/prices [get]
+func (h *MembershipHandler) ListPrices(c *gin.Context) {
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+
+	prices, err := h.membershipService.ListPrices(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, prices)
+}
+
+type setMembershipPriceRequest struct {
+	PriceCents int `json:"price_cents" binding:"required,gt=0"`
+}
+
+// SetMembershipPrice godoc
+// @Summary      Set membership price
+// @Description  Create or update membership plan price in a currency (admin only)
+// @Tags         memberships
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                                true  "Membership ID"
+// @Param        currency  path      string                             true  "Currency code (ISO 4217)"
+// @Param        body      body      handler.setMembershipPriceRequest  true  "Price in minor units"
+// @Success      200       {object}  models.MembershipPrice
+// @Failure      400       {object}  map[string]string
+// @Router       /admin/memberships/{id}/prices/{currency} [put]
+func (h *MembershipHandler) SetPrice(c *gin.Context) {
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+
+	var req setMembershipPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	price, err := h.membershipService.SetPrice(c.Request.Context(), id, c.Param("currency"), req.PriceCents)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, price)
+}
+
+// DeleteMembershipPrice godoc
+// @Summary      Delete membership price
+// @Description  Remove membership plan price in a currency (admin only)
+// @Tags         memberships
+// @Security     Bearer
+// @Param        id        path      int     true  "Membership ID"
+// @Param        currency  path      string  true  "Currency code (ISO 4217)"
+// @Success      200       {object}  map[string]string
+// @Failure      400       {object}  map[string]string
+// @Router       /admin/memberships/{id}/prices/{currency} [delete]
+func (h *MembershipHandler) DeletePrice(c *gin.Context) {
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+
+	if err := h.membershipService.DeletePrice(c.Request.Context(), id, c.Param("currency")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "membership price deleted"})
+}
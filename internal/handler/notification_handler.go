@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// ListNotificationOutbox godoc
+// @Summary      List notification queue
+// @Description  Get queued notifications, optionally filtered by status; dead are the ones that ran out of retries. Message bodies are not returned.
+// @Tags         notifications
+// @Security     Bearer
+// @Produce      json
+// @Param        status  query     string  false  "pending, sending, sent or dead"
+// @Success      200     {array}   models.NotificationOutboxEntry
+// @Failure      500     {object}  map[string]string
+// @Router       /admin/notifications/outbox [get]
+func (h *NotificationHandler) List(c *gin.Context) {
+	entries, err := h.notificationService.List(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if entries == nil {
+		entries = []models.NotificationOutboxEntry{}
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// ResendNotification godoc
+// @Summary      Resend notification
+// @Description  Put a dead (or waiting for retry) notification back to the queue with a fresh retry budget
+// @Tags         notifications
+// @Security     Bearer
+// @Param        id   path      int  true  "Outbox entry ID"
+// @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/notifications/outbox/{id}/resend [post]
+func (h *NotificationHandler) Resend(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	if err := h.notificationService.Resend(c.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotificationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotificationDelivered):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "notification queued"})
+}
+
+// ListNotificationTemplates godoc
+// @Summary      List notification templates
+// @Description  Get email templates with the languages they are translated to and sample data for preview
+// @Tags         notifications
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {array}  notification.TemplateInfo
+// @Router       /admin/notifications/templates [get]
+func (h *NotificationHandler) ListTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, h.notificationService.Templates())
+}
+
+// PreviewNotificationTemplate godoc
+// @Summary      Preview notification template
+// @Description  Render a template in the given language (ru by default) on sample data; fields passed in the body override the sample ones
+// @Tags         notifications
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string  true   "Template ID"
+// @Param        locale  query     string  false  "ru, kk or en"
+// @Param        body    body      object  false  "Template data"
+// @Success      200     {object}  notification.Rendered
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Router       /admin/notifications/templates/{id}/preview [post]
+func (h *NotificationHandler) PreviewTemplate(c *gin.Context) {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rendered, err := h.notificationService.PreviewTemplate(c.Param("id"), c.DefaultQuery("locale", notification.DefaultLocale), data)
+	if err != nil {
+		switch {
+		case errors.Is(err, notification.ErrUnknownTemplate):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, notification.ErrUnsupportedLocale), errors.Is(err, notification.ErrInvalidTemplateData):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, rendered)
+}
+
+// GetNotificationPreferences godoc
+// @Summary      Get notification preferences
+// @Description  Timezone, quiet hours and subscriptions of the current user per category and channel. Transactional notifications (receipts, password reset) are always sent and not listed.
+// @Tags         notifications
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  models.NotificationSettings
+// @Failure      500  {object}  map[string]string
+// @Router       /me/notifications [get]
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	settings, err := h.notificationService.Preferences(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateNotificationPreferences godoc
+// @Summary      Update notification preferences
+// @Description  Set timezone (IANA, e.g. Asia/Almaty), quiet hours (HH:MM in that timezone, both empty to disable) and the listed subscriptions; subscriptions not listed keep their value
+// @Tags         notifications
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      models.NotificationSettings  true  "Preferences"
+// @Success      200   {object}  models.NotificationSettings
+// @Failure      400   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /me/notifications [put]
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.NotificationSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.notificationService.UpdatePreferences(c.Request.Context(), userID, &req); err != nil {
+		if errors.Is(err, service.ErrInvalidNotificationPreference) || errors.Is(err, service.ErrTransactionalOptOut) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.GetPreferences(c)
+}
+
+// UnsubscribeInfo godoc
+// @Summary      Check unsubscribe link
+// @Description  Return the category of a signed unsubscribe link without changing anything, so that mail scanners following the link do not unsubscribe the user
+// @Tags         notifications
+// @Produce      json
+// @Param        token  query     string  true  "Token from the link"
+// @Success      200    {object}  map[string]string
+// @Failure      400    {object}  map[string]string
+// @Router       /notifications/unsubscribe [get]
+func (h *NotificationHandler) UnsubscribeInfo(c *gin.Context) {
+	category, err := h.notificationService.UnsubscribeCategory(c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"category": category})
+}
+
+// Unsubscribe godoc
+// @Summary      Unsubscribe by link
+// @Description  One-click unsubscribe (RFC 8058) from the link category in all channels; no authentication, the link is signed
+// @Tags         notifications
+// @Produce      json
+// @Param        token  query     string  true  "Token from the link"
+// @Success      200    {object}  map[string]string
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /notifications/unsubscribe [post]
+func (h *NotificationHandler) Unsubscribe(c *gin.Context) {
+	category, err := h.notificationService.Unsubscribe(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		if errors.Is(err, notification.ErrInvalidUnsubscribeLink) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "unsubscribed", "category": category})
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type OIDCHandler struct {
+	oidcService    *service.OIDCService
+	authService    *service.AuthService
+	sessionService *service.SessionService
+	secureCookie   bool
+}
+
+// NewOIDCHandler создаёт хендлер входа через внешних провайдеров; sessionService задаётся только в режиме сессий
+func NewOIDCHandler(oidcService *service.OIDCService, authService *service.AuthService, sessionService *service.SessionService, secureCookie bool) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService:    oidcService,
+		authService:    authService,
+		sessionService: sessionService,
+		secureCookie:   secureCookie,
+	}
+}
+
+// ListOIDCProviders godoc
+// @Summary      List identity providers
+// @Description  Names of the configured OpenID Connect providers available for sign-in
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  map[string][]string
+// @Router       /auth/oidc/providers [get]
+func (h *OIDCHandler) Providers(c *gin.Context) {
+	providers := h.oidcService.Providers()
+	if providers == nil {
+		providers = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"providers": providers})
+}
+
+// OIDCLogin godoc
+// @Summary      Sign in with identity provider
+// @Description  Redirect to the OpenID Connect provider (authorization code flow with PKCE)
+// @Tags         auth
+// @Param        provider  path  string  true  "Provider name"
+// @Success      302
+// @Failure      404  {object}  map[string]string
+// @Failure      502  {object}  map[string]string
+// @Router       /auth/oidc/{provider}/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, err := h.oidcService.AuthURL(c.Param("provider"))
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownOIDCProvider) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		utils.GetLogger().Error("OIDC login failed", zap.String("provider", c.Param("provider")), zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "identity provider is unavailable"})
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback godoc
+// @Summary      Identity provider callback
+// @Description  Complete sign-in: the account is linked by verified email or created. Returns tokens (or a session in session mode); if 2FA is enabled, returns mfa_token for /users/login/2fa
+// @Tags         auth
+// @Produce      json
+// @Param        provider  path      string  true  "Provider name"
+// @Param        code      query     string  true  "Authorization code"
+// @Param        state     query     string  true  "State"
+// @Success      200       {object}  models.TokenPair
+// @Failure      400       {object}  map[string]string
+// @Failure      403       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Router       /auth/oidc/{provider}/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	// Провайдер может вернуть ошибку (например, пользователь отказался)
+	if providerErr := formValue(c, "error"); providerErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": providerErr})
+		return
+	}
+
+	user, err := h.oidcService.Callback(c.Request.Context(), c.Param("provider"), formValue(c, "code"), formValue(c, "state"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnknownOIDCProvider):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidOIDCState), errors.Is(err, service.ErrInvalidIDToken), errors.Is(err, service.ErrOIDCCodeExchange):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrOIDCEmailNotVerified), errors.Is(err, service.ErrOIDCAccountNotVerified):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			utils.GetLogger().Error("OIDC callback failed", zap.String("provider", c.Param("provider")), zap.Error(err))
+			c.JSON(http.StatusBadGateway, gin.H{"error": "identity provider sign-in failed"})
+		}
+		return
+	}
+
+	// При включённой 2FA в обоих режимах выдаётся mfa_token для /users/login/2fa
+	if h.sessionService != nil && !user.TOTPEnabled {
+		session, err := h.sessionService.StartSession(c.Request.Context(), user, c.Request.UserAgent(), c.ClientIP())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		writeSession(c, session, h.secureCookie)
+		return
+	}
+
+	tokens, err := h.authService.LoginUser(c.Request.Context(), user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// formValue читает параметр из query или из формы (response_mode=form_post)
+func formValue(c *gin.Context, key string) string {
+	if v := c.Query(key); v != "" {
+		return v
+	}
+	return c.PostForm(key)
+}
@@ -2,8 +2,10 @@ package handler
 
 import (
 	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/models"
 	"Gym_StrongCode/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,6 +21,10 @@ func NewPaymentHandler(paymentService *service.PaymentService) *PaymentHandler {
 type createPaymentRequest struct {
 	AmountCents int    `json:"amount_cents" binding:"required,gt=0"`
 	Method      string `json:"method" binding:"required"`
+	Currency    string `json:"currency"`     // пусто — базовая валюта
+	ProductType string `json:"product_type"` // пусто — other
+	GymID       *int   `json:"gym_id"`
+	Description string `json:"description"`
 }
 
 // CreatePayment godoc
@@ -41,7 +47,15 @@ func (h *PaymentHandler) CreateStandalone(c *gin.Context) {
 		return
 	}
 
-	payment, err := h.paymentService.Create(userID, req.AmountCents, req.Method, "", "")
+	payment, err := h.paymentService.Charge(c.Request.Context(), &models.Payment{
+		UserID:      userID,
+		AmountCents: req.AmountCents,
+		Currency:    req.Currency,
+		Method:      req.Method,
+		Description: req.Description,
+		ProductType: req.ProductType,
+		GymID:       req.GymID,
+	})
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -52,15 +66,22 @@ func (h *PaymentHandler) CreateStandalone(c *gin.Context) {
 
 // ListAllPayments godoc
 // @Summary      List all payments
-// @Description  Get all payments in the system (admin only)
+// @Description  Get all payments in the system or in one gym (staff only)
 // @Tags         payments
 // @Security     Bearer
 // @Produce      json
+// @Param        gym_id  query     int  false  "Gym ID; required for gym-scoped staff"
 // @Success      200  {array}   models.Payment
 // @Failure      500  {object}  map[string]string
 // @Router       /admin/payments [get]
 func (h *PaymentHandler) ListAll(c *gin.Context) {
-	payments, err := h.paymentService.ListAll()
+	var payments []models.Payment
+	var err error
+	if gymID, convErr := strconv.Atoi(c.Query("gym_id")); convErr == nil {
+		payments, err = h.paymentService.ListByGym(c.Request.Context(), gymID)
+	} else {
+		payments, err = h.paymentService.ListAll(c.Request.Context())
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -68,3 +89,58 @@ func (h *PaymentHandler) ListAll(c *gin.Context) {
 
 	c.JSON(http.StatusOK, payments)
 }
+
+type refundPaymentRequest struct {
+	AmountCents int `json:"amount_cents" binding:"min=0"` // 0 — вернуть оставшуюся сумму
+}
+
+// RefundPayment godoc
+// @Summary      Refund payment
+// @Description  Refund a completed payment fully or partially (admin only)
+// @Tags         payments
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                          true   "Payment ID"
+// @Param        body  body      handler.refundPaymentRequest  false  "Refund amount"
+// @Success      200   {object}  models.Payment
+// @Failure      400   {object}  map[string]string
+// @Router       /admin/payments/{id}/refund [post]
+func (h *PaymentHandler) Refund(c *gin.Context) {
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+
+	var req refundPaymentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	payment, err := h.paymentService.Refund(c.Request.Context(), id, req.AmountCents)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, payment)
+}
+
+// PaymentSummary godoc
+// @Summary      Payment summary
+// @Description  Revenue (completed payments net of refunds) per currency and normalized to the base currency (admin only)
+// @Tags         payments
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  models.PaymentSummary
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/payments/summary [get]
+func (h *PaymentHandler) Summary(c *gin.Context) {
+	summary, err := h.paymentService.Summary(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
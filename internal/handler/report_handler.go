@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+
+	"Gym_StrongCode/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	reportService *service.ReportService
+}
+
+func NewReportHandler(reportService *service.ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+// RevenueReport godoc
+// @Summary      Revenue report
+// @Description  Payments for a period aggregated by day, week, month, method, gym, plan or status with refunds netted out (admin only)
+// @Tags         reports
+// @Security     Bearer
+// @Produce      json
+// @Param        from      query     string  true   "Start date (YYYY-MM-DD, inclusive)"
+// @Param        to        query     string  true   "End date (YYYY-MM-DD, inclusive)"
+// @Param        group_by  query     string  false  "day (default), week, month, method, gym, plan or status"
+// @Success      200       {array}   models.RevenueReportRow
+// @Failure      400       {object}  map[string]string
+// @Router       /admin/reports/revenue [get]
+func (h *ReportHandler) Revenue(c *gin.Context) {
+	groupBy := c.DefaultQuery("group_by", "day")
+
+	rows, err := h.reportService.Revenue(c.Request.Context(), groupBy, c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     c.Query("from"),
+		"to":       c.Query("to"),
+		"group_by": groupBy,
+		"rows":     rows,
+	})
+}
+
+// ReconcilePayments godoc
+// @Summary      Reconcile payments
+// @Description  Compare payments for a period with a CSV statement (columns payment_id, amount_cents and optional currency) and list mismatches (admin only)
+// @Tags         reports
+// @Security     Bearer
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        from    query     string  true   "Start date (YYYY-MM-DD, inclusive)"
+// @Param        to      query     string  true   "End date (YYYY-MM-DD, inclusive)"
+// @Param        method  query     string  false  "Payment method to reconcile; by default all except cash"
+// @Param        file    formData  file    true   "CSV statement"
+// @Success      200     {object}  models.ReconciliationResult
+// @Failure      400     {object}  map[string]string
+// @Router       /admin/reports/reconciliation [post]
+func (h *ReportHandler) Reconcile(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "statement file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	result, err := h.reportService.Reconcile(c.Request.Context(), file, c.Query("from"), c.Query("to"), c.Query("method"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
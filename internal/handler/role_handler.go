@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	roleService *service.RoleService
+}
+
+func NewRoleHandler(roleService *service.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// ListUserRoles godoc
+// @Summary      List user roles
+// @Description  Get roles assigned to a user
+// @Tags         roles
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {array}   models.UserRole
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/users/{id}/roles [get]
+func (h *RoleHandler) List(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+
+	roles, err := h.roleService.ListForUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+type assignRoleRequest struct {
+	Role  string `json:"role" binding:"required"`
+	GymID *int   `json:"gym_id"` // пусто — роль во всех залах
+}
+
+// AssignRole godoc
+// @Summary      Assign role
+// @Description  Assign a role to a user, optionally limited to one gym. Managers can assign trainer and front_desk roles in their gym; only owners can assign manager and owner.
+// @Tags         roles
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                       true  "User ID"
+// @Param        body  body      handler.assignRoleRequest  true  "Role"
+// @Success      201   {object}  models.UserRole
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Router       /admin/users/{id}/roles [post]
+func (h *RoleHandler) Assign(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+	grantorID, _ := middleware.GetUserID(c)
+
+	var req assignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.Assign(c.Request.Context(), grantorID, middleware.IsAdmin(c), userID, req.Role, req.GymID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRoleGrantDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrRoleAlreadyExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, role)
+}
+
+// RevokeRole godoc
+// @Summary      Revoke role
+// @Description  Remove a role from a user
+// @Tags         roles
+// @Security     Bearer
+// @Produce      json
+// @Param        id       path      int  true  "User ID"
+// @Param        role_id  path      int  true  "Role assignment ID"
+// @Success      200      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Router       /admin/users/{id}/roles/{role_id} [delete]
+func (h *RoleHandler) Revoke(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+	roleID, _ := strconv.Atoi(c.Param("role_id"))
+	grantorID, _ := middleware.GetUserID(c)
+
+	if err := h.roleService.Revoke(c.Request.Context(), grantorID, middleware.IsAdmin(c), userID, roleID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrRoleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrRoleGrantDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "role revoked"})
+}
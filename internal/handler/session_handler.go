@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	sessionService *service.SessionService
+	loginGuard     *service.LoginGuard
+	secureCookie   bool
+}
+
+// NewSessionHandler создаёт хендлер сессий; secureCookie — выставлять cookie только для HTTPS
+func NewSessionHandler(sessionService *service.SessionService, loginGuard *service.LoginGuard, secureCookie bool) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService, loginGuard: loginGuard, secureCookie: secureCookie}
+}
+
+type sessionLoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"` // TOTP-код или код восстановления, если включена 2FA
+}
+
+// SessionLogin godoc
+// @Summary      Login user (session mode)
+// @Description  Authenticate user and create a server-side session; the session ID is returned and set as the session_id cookie
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.sessionLoginRequest  true  "Login credentials"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      401   {object}  map[string]interface{}
+// @Failure      429   {object}  map[string]interface{}
+// @Router       /users/login [post]
+func (h *SessionHandler) Login(c *gin.Context) {
+	var req sessionLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if checkLoginLocked(c, h.loginGuard, req.Email) {
+		return
+	}
+
+	session, err := h.sessionService.Login(c.Request.Context(), req.Email, req.Password, req.Code, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTwoFactorRequired):
+			// Пароль верный, просто не передан код — это не попытка перебора
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "mfa_required": true})
+		case errors.Is(err, service.ErrInvalidTwoFactorCode):
+			recordLoginFailure(c, h.loginGuard, req.Email)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			recordLoginFailure(c, h.loginGuard, req.Email)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		}
+		return
+	}
+	recordLoginSuccess(c, h.loginGuard, req.Email)
+
+	writeSession(c, session, h.secureCookie)
+}
+
+// writeSession выставляет cookie сессии и возвращает её идентификатор
+func writeSession(c *gin.Context, session *models.Session, secureCookie bool) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(middleware.SessionCookieName, session.ID, 0, "/", "", secureCookie, true)
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": session.ID,
+		"expires_at": session.ExpiresAt,
+	})
+}
+
+// SessionLoginMFA godoc
+// @Summary      Complete login with 2FA (session mode)
+// @Description  Exchange the mfa_token (issued e.g. after an external identity provider login) and a TOTP or recovery code for a session
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.loginMFARequest  true  "MFA token and code"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      401   {object}  map[string]string
+// @Failure      429   {object}  map[string]interface{}
+// @Router       /users/login/2fa [post]
+func (h *SessionHandler) LoginMFA(c *gin.Context) {
+	var req loginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.sessionService.MFAUser(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": service.ErrInvalidMFAToken.Error()})
+		return
+	}
+	if checkLoginLocked(c, h.loginGuard, user.Email) {
+		return
+	}
+
+	session, err := h.sessionService.LoginMFA(c.Request.Context(), req.MFAToken, req.Code, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMFAToken) || errors.Is(err, service.ErrInvalidTwoFactorCode) {
+			if errors.Is(err, service.ErrInvalidTwoFactorCode) {
+				recordLoginFailure(c, h.loginGuard, user.Email)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordLoginSuccess(c, h.loginGuard, user.Email)
+
+	writeSession(c, session, h.secureCookie)
+}
+
+// SessionLogout godoc
+// @Summary      Logout (session mode)
+// @Description  Delete the current session
+// @Tags         auth
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/logout [post]
+func (h *SessionHandler) Logout(c *gin.Context) {
+	if err := h.sessionService.Logout(c.Request.Context(), middleware.GetSessionID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.SetCookie(middleware.SessionCookieName, "", -1, "/", "", h.secureCookie, true)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// SessionLogoutAll godoc
+// @Summary      Logout everywhere (session mode)
+// @Description  Delete all sessions of the current user
+// @Tags         auth
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/logout-all [post]
+func (h *SessionHandler) LogoutAll(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.sessionService.LogoutAll(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.SetCookie(middleware.SessionCookieName, "", -1, "/", "", h.secureCookie, true)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out everywhere"})
+}
+
+// SessionChangePassword godoc
+// @Summary      Change password (session mode)
+// @Description  Change the password of the current user. Requires the current password; the new one must satisfy the password policy. All sessions are ended, so sign in again afterwards.
+// @Tags         users
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.changePasswordRequest  true  "Current and new password"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Router       /me/password [put]
+func (h *SessionHandler) ChangePassword(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.sessionService.ChangePassword(c.Request.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		changePasswordError(c, err)
+		return
+	}
+
+	c.SetCookie(middleware.SessionCookieName, "", -1, "/", "", h.secureCookie, true)
+	c.JSON(http.StatusOK, gin.H{"message": "password updated, sign in again"})
+}
+
+// ListSessions godoc
+// @Summary      List my sessions
+// @Description  Get active sessions of the current user (session mode)
+// @Tags         auth
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {array}   models.Session
+// @Failure      500  {object}  map[string]string
+// @Router       /me/sessions [get]
+func (h *SessionHandler) List(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	sessions, err := h.sessionService.List(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary      Revoke session
+// @Description  Delete one of the current user's sessions (session mode)
+// @Tags         auth
+// @Security     Bearer
+// @Produce      json
+// @Param        id   path      string  true  "Session ID"
+// @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /me/sessions/{id} [delete]
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.sessionService.Revoke(c.Request.Context(), userID, c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxHandler struct {
+	taxService *service.TaxService
+}
+
+func NewTaxHandler(taxService *service.TaxService) *TaxHandler {
+	return &TaxHandler{taxService: taxService}
+}
+
+// ListTaxRates godoc
+// @Summary      List tax rates
+// @Description  Get configured tax rates per product type and gym (admin only)
+// @Tags         taxes
+// @Security     Bearer
+// @Produce      json
+// @Success      200  {array}   models.TaxRate
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/tax-rates [get]
+func (h *TaxHandler) List(c *gin.Context) {
+	rates, err := h.taxService.ListRates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rates)
+}
+
+type taxRateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	ProductType string `json:"product_type" binding:"required"`
+	GymID       *int   `json:"gym_id"`
+	RateBP      int    `json:"rate_bp" binding:"min=0,max=10000"`
+	Inclusive   bool   `json:"inclusive"`
+}
+
+func (r taxRateRequest) toModel() *models.TaxRate {
+	return &models.TaxRate{
+		Name:        r.Name,
+		ProductType: r.ProductType,
+		GymID:       r.GymID,
+		RateBP:      r.RateBP,
+		Inclusive:   r.Inclusive,
+	}
+}
+
+// CreateTaxRate godoc
+// @Summary      Create tax rate
+// @Description  Create a tax rate for a product type, optionally for one gym (admin only)
+// @Tags         taxes
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      handler.taxRateRequest  true  "Tax rate data"
+// @Success      201   {object}  models.TaxRate
+// @Failure      400   {object}  map[string]string
+// @Router       /admin/tax-rates [post]
+func (h *TaxHandler) Create(c *gin.Context) {
+	var req taxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.taxService.CreateRate(c.Request.Context(), req.toModel())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// UpdateTaxRate godoc
+// @Summary      Update tax rate
+// @Description  Update tax rate (admin only)
+// @Tags         taxes
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                     true  "Tax rate ID"
+// @Param        body  body      handler.taxRateRequest  true  "Updated tax rate data"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Router       /admin/tax-rates/{id} [put]
+func (h *TaxHandler) Update(c *gin.Context) {
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+
+	var req taxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.taxService.UpdateRate(c.Request.Context(), id, req.toModel()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "tax rate updated"})
+}
+
+// DeleteTaxRate godoc
+// @Summary      Delete tax rate
+// @Description  Delete tax rate (admin only)
+// @Tags         taxes
+// @Security     Bearer
+// @Param        id   path      int  true  "Tax rate ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Router       /admin/tax-rates/{id} [delete]
+func (h *TaxHandler) Delete(c *gin.Context) {
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+
+	if err := h.taxService.DeleteRate(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "tax rate deleted"})
+}
+
+// TaxSummary godoc
+// @Summary      Tax summary report
+// @Description  Net, tax and gross totals of completed payments net of refunds for a period, grouped by product type, rate and currency (admin only)
+// @Tags         taxes
+// @Security     Bearer
+// @Produce      json
+// @Param        from  query     string  true  "Start date (YYYY-MM-DD, inclusive)"
+// @Param        to    query     string  true  "End date (YYYY-MM-DD, inclusive)"
+// @Success      200   {array}   models.TaxSummaryRow
+// @Failure      400   {object}  map[string]string
+// @Router       /admin/reports/tax [get]
+func (h *TaxHandler) Summary(c *gin.Context) {
+	summary, err := h.taxService.Summary(c.Request.Context(), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": c.Query("from"),
+		"to":   c.Query("to"),
+		"rows": summary,
+	})
+}
@@ -26,7 +26,7 @@ func NewTrainerHandler(trainerService *service.TrainerService) *TrainerHandler {
 // @Failure      500  {object}  map[string]string
 // @Router       /trainers [get]
 func (h *TrainerHandler) List(c *gin.Context) {
-	trainers, err := h.trainerService.List()
+	trainers, err := h.trainerService.List(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -57,7 +57,7 @@ func (h *TrainerHandler) Create(c *gin.Context) {
 		return
 	}
 
-	trainer, err := h.trainerService.Create(req.Name, req.Bio)
+	trainer, err := h.trainerService.Create(c.Request.Context(), req.Name, req.Bio)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -93,7 +93,7 @@ func (h *TrainerHandler) Update(c *gin.Context) {
 		return
 	}
 
-	if err := h.trainerService.Update(id, req.Name, req.Bio); err != nil {
+	if err := h.trainerService.Update(c.Request.Context(), id, req.Name, req.Bio); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -114,7 +114,7 @@ func (h *TrainerHandler) Delete(c *gin.Context) {
 	idStr := c.Param("id")
 	id, _ := strconv.Atoi(idStr)
 
-	if err := h.trainerService.Delete(id); err != nil {
+	if err := h.trainerService.Delete(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -11,15 +11,22 @@ import (
 )
 
 type UserHandler struct {
-	userRepo *repository.UserRepository
+	userRepo repository.UserStore
 }
 
 type updateUserRequest struct {
-	Name  string `json:"name" binding:"required"`
-	Email string `json:"email" binding:"required,email"`
+	Name   string `json:"name" binding:"required"`
+	Email  string `json:"email" binding:"required,email"`
+	Locale string `json:"locale" binding:"omitempty,oneof=ru kk en"`
+	// Телефон для SMS в формате E.164; пустая строка удаляет его, без поля — не меняется
+	Phone *string `json:"phone" binding:"omitempty,e164|len=0"`
 }
 
-func NewUserHandler(userRepo *repository.UserRepository) *UserHandler {
+type pushTokenRequest struct {
+	Token string `json:"token" binding:"required,max=4096"`
+}
+
+func NewUserHandler(userRepo repository.UserStore) *UserHandler {
 	return &UserHandler{userRepo: userRepo}
 }
 
@@ -34,7 +41,7 @@ func NewUserHandler(userRepo *repository.UserRepository) *UserHandler {
 // @Router       /me [get]
 func (h *UserHandler) GetCurrent(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
-	user, err := h.userRepo.GetByID(userID)
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
 		return
@@ -44,7 +51,7 @@ func (h *UserHandler) GetCurrent(c *gin.Context) {
 
 // Update godoc
 // @Summary      Update user profile
-// @Description  Update name and email of current user
+// @Description  Update name, email and, optionally, notification language (ru, kk, en) and phone for SMS (E.164, empty to remove) of current user
 // @Tags         users
 // @Security     Bearer
 // @Accept       json
@@ -64,15 +71,72 @@ func (h *UserHandler) Update(c *gin.Context) {
 		return
 	}
 
-	if err := h.userRepo.Update(userID, req.Name, req.Email); err != nil {
+	if err := h.userRepo.Update(c.Request.Context(), userID, req.Name, req.Email); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
 		return
 	}
+	if req.Locale != "" {
+		if err := h.userRepo.SetLocale(c.Request.Context(), userID, req.Locale); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+			return
+		}
+	}
+	if req.Phone != nil {
+		if err := h.userRepo.SetPhone(c.Request.Context(), userID, *req.Phone); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+			return
+		}
+	}
 
-	user, _ := h.userRepo.GetByID(userID)
+	user, _ := h.userRepo.GetByID(c.Request.Context(), userID)
 	c.JSON(http.StatusOK, user)
 }
 
+// SetPushToken godoc
+// @Summary      Register push token
+// @Description  Save the device token of the mobile app for push notifications; replaces the previous one
+// @Tags         users
+// @Security     Bearer
+// @Accept       json
+// @Produce      json
+// @Param        body  body      pushTokenRequest  true  "Device token"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /me/push-token [put]
+func (h *UserHandler) SetPushToken(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req pushTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.userRepo.SetPushToken(c.Request.Context(), userID, req.Token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "push token saved"})
+}
+
+// DeletePushToken godoc
+// @Summary      Remove push token
+// @Description  Stop push notifications to the mobile app (e.g. on sign-out from the device)
+// @Tags         users
+// @Security     Bearer
+// @Success      200  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /me/push-token [delete]
+func (h *UserHandler) DeletePushToken(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.userRepo.SetPushToken(c.Request.Context(), userID, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "push token removed"})
+}
+
 // ListUsers godoc
 // @Summary      List all users
 // @Description  Get all users (admin only)
@@ -83,7 +147,7 @@ func (h *UserHandler) Update(c *gin.Context) {
 // @Failure      500  {object}  map[string]string
 // @Router       /admin/users [get]
 func (h *UserHandler) List(c *gin.Context) {
-	users, err := h.userRepo.List()
+	users, err := h.userRepo.List(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -103,7 +167,7 @@ func (h *UserHandler) List(c *gin.Context) {
 func (h *UserHandler) Delete(c *gin.Context) {
 	idStr := c.Param("id")
 	id, _ := strconv.Atoi(idStr)
-	if err := h.userRepo.Delete(id); err != nil {
+	if err := h.userRepo.Delete(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"Gym_StrongCode/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator проверяет ключ интеграции; реализуется APIKeyService
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// AllowAPIKeys принимает ключ из X-API-Key или "Authorization: ApiKey <key>" вместо JWT.
+// Запросы без ключа передаются в next (обычную аутентификацию пользователя).
+func AllowAPIKeys(authenticator APIKeyAuthenticator, next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader(APIKeyHeader)
+		if rawKey == "" {
+			if parts := strings.Split(c.GetHeader("Authorization"), " "); len(parts) == 2 && parts[0] == "ApiKey" {
+				rawKey = parts[1]
+			}
+		}
+		if rawKey == "" {
+			next(c)
+			return
+		}
+
+		key, err := authenticator.AuthenticateAPIKey(c.Request.Context(), rawKey)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+			return
+		}
+		c.Set("api_key", key)
+		c.Next()
+	}
+}
+
+// GetAPIKey возвращает ключ, которым аутентифицирован запрос
+func GetAPIKey(c *gin.Context) (*models.APIKey, bool) {
+	key, exists := c.Get("api_key")
+	if !exists {
+		return nil, false
+	}
+	return key.(*models.APIKey), true
+}
@@ -1,14 +1,27 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(secret string) gin.HandlerFunc {
+// TokenChecker проверяет, не отозван ли access-токен (реализуется AuthService)
+type TokenChecker interface {
+	CheckAccessToken(ctx context.Context, userID int, jti string, version int) error
+}
+
+// TokenVerifier проверяет подпись, алгоритм и срок действия JWT (реализуется KeyService)
+type TokenVerifier interface {
+	ParseToken(ctx context.Context, tokenStr string) (jwt.MapClaims, error)
+}
+
+// AuthMiddleware проверяет JWT; если checker не nil, отозванные токены отклоняются
+func AuthMiddleware(verifier TokenVerifier, checker TokenChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -23,30 +36,34 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 		}
 
 		tokenStr := parts[1]
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := verifier.ParseToken(c.Request.Context(), tokenStr)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			return
-		}
-
 		userIDFloat, ok := claims["user_id"].(float64)
 		if !ok {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in token"})
 			return
 		}
 
+		jti, _ := claims["jti"].(string)
+		if checker != nil {
+			version, _ := claims["ver"].(float64)
+			if err := checker.CheckAccessToken(c.Request.Context(), int(userIDFloat), jti, int(version)); err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+				return
+			}
+		}
+
 		c.Set("user_id", int(userIDFloat))
 		isAdmin, _ := claims["is_admin"].(bool)
 		c.Set("is_admin", isAdmin)
+		c.Set("token_jti", jti)
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			c.Set("token_exp", exp.Time)
+		}
 
 		c.Next()
 	}
@@ -60,6 +77,14 @@ func GetUserID(c *gin.Context) (int, bool) {
 	return id.(int), true
 }
 
+// GetTokenID возвращает jti и срок действия текущего access-токена
+func GetTokenID(c *gin.Context) (string, time.Time) {
+	jti := c.GetString("token_jti")
+	exp, _ := c.Get("token_exp")
+	expiresAt, _ := exp.(time.Time)
+	return jti, expiresAt
+}
+
 func IsAdmin(c *gin.Context) bool {
 	admin, exists := c.Get("is_admin")
 	if !exists {
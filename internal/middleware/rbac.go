@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"Gym_StrongCode/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorizer сообщает, где у пользователя есть право: во всех залах или в перечисленных.
+// Реализуется RoleService.
+type Authorizer interface {
+	PermissionScope(ctx context.Context, userID int, isAdmin bool, perm string) (global bool, gymIDs []int, err error)
+}
+
+// GymResolver определяет зал, к которому относится запрос
+type GymResolver func(c *gin.Context) (int, bool)
+
+// RequirePermission пропускает только пользователей, у которых право perm есть во всех залах
+func RequirePermission(authz Authorizer, perm string) gin.HandlerFunc {
+	return requirePermission(authz, perm, nil)
+}
+
+// RequireGymPermission дополнительно пропускает пользователей с правом perm
+// в том зале, к которому относится запрос (например, управляющего филиалом)
+func RequireGymPermission(authz Authorizer, perm string, resolve GymResolver) gin.HandlerFunc {
+	return requirePermission(authz, perm, resolve)
+}
+
+func requirePermission(authz Authorizer, perm string, resolve GymResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var global bool
+		var gymIDs []int
+		if key, ok := GetAPIKey(c); ok {
+			global, gymIDs = apiKeyScope(key, perm)
+		} else {
+			userID, _ := GetUserID(c)
+
+			var err error
+			global, gymIDs, err = authz.PermissionScope(c.Request.Context(), userID, IsAdmin(c), perm)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if global {
+			c.Next()
+			return
+		}
+
+		if resolve != nil && len(gymIDs) > 0 {
+			if gymID, ok := resolve(c); ok {
+				for _, id := range gymIDs {
+					if id == gymID {
+						c.Next()
+						return
+					}
+				}
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+	}
+}
+
+// apiKeyScope — права ключа: во всех залах или только в зале, к которому привязан ключ
+func apiKeyScope(key *models.APIKey, perm string) (bool, []int) {
+	if !key.HasScope(perm) {
+		return false, nil
+	}
+	if key.GymID == nil {
+		return true, nil
+	}
+	return false, []int{*key.GymID}
+}
+
+// GymFromParam берёт ID зала из параметра пути
+func GymFromParam(name string) GymResolver {
+	return func(c *gin.Context) (int, bool) {
+		id, err := strconv.Atoi(c.Param(name))
+		return id, err == nil
+	}
+}
+
+// GymFromQuery берёт ID зала из query-параметра
+func GymFromQuery(name string) GymResolver {
+	return func(c *gin.Context) (int, bool) {
+		id, err := strconv.Atoi(c.Query(name))
+		return id, err == nil
+	}
+}
+
+// GymFromJSONBody берёт ID зала из поля JSON-тела; тело восстанавливается для хендлера
+func GymFromJSONBody(field string) GymResolver {
+	return func(c *gin.Context) (int, bool) {
+		if c.Request.Body == nil {
+			return 0, false
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return 0, false
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return 0, false
+		}
+		var id int
+		if err := json.Unmarshal(fields[field], &id); err != nil {
+			return 0, false
+		}
+		return id, true
+	}
+}
+
+// GymFromLookup находит зал ресурса по его ID из параметра пути
+func GymFromLookup(param string, lookup func(ctx context.Context, id int) (int, error)) GymResolver {
+	return func(c *gin.Context) (int, bool) {
+		id, err := strconv.Atoi(c.Param(param))
+		if err != nil {
+			return 0, false
+		}
+		gymID, err := lookup(c.Request.Context(), id)
+		return gymID, err == nil
+	}
+}
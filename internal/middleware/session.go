@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"Gym_StrongCode/internal/cache"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	SessionCookieName = "session_id"
+	SessionHeaderName = "X-Session-ID"
+)
+
+// SessionChecker сверяет сессию с текущим состоянием пользователя (реализуется AuthService)
+type SessionChecker interface {
+	CheckSession(ctx context.Context, userID, version int) (isAdmin bool, err error)
+}
+
+// SessionAuthMiddleware находит сессию по cookie или заголовку X-Session-ID, проверяет,
+// что пользователь не удалён и не выходил везде, и продлевает её (скользящий срок действия).
+// Признак администратора берётся из пользователя, а не из сессии.
+func SessionAuthMiddleware(store cache.SessionStore, checker SessionChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID := c.GetHeader(SessionHeaderName)
+		if sessionID == "" {
+			sessionID, _ = c.Cookie(SessionCookieName)
+		}
+		if sessionID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session required"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		session, err := store.GetSession(ctx, sessionID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid session"})
+			return
+		}
+		isAdmin, err := checker.CheckSession(ctx, session.UserID, session.Version)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+			return
+		}
+		if _, err := store.ExtendSession(ctx, sessionID); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid session"})
+			return
+		}
+
+		c.Set("user_id", session.UserID)
+		c.Set("is_admin", isAdmin)
+		c.Set("session_id", session.ID)
+
+		c.Next()
+	}
+}
+
+func GetSessionID(c *gin.Context) string {
+	return c.GetString("session_id")
+}
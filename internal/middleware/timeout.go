@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteTimeouts — дедлайны обработки запросов: общий и переопределения по маршрутам
+type RouteTimeouts struct {
+	def   time.Duration
+	rules []timeoutRule
+}
+
+type timeoutRule struct {
+	method  string // пусто — любой метод
+	path    string
+	prefix  bool
+	timeout time.Duration
+}
+
+// ParseRouteTimeouts разбирает ROUTE_TIMEOUTS вида "/api/admin/reports/*=60s;POST /api/memberships/buy=15s".
+// Маршрут задаётся шаблоном gin (/api/classes/:id), метод необязателен, "*" на конце — все маршруты
+// с этим префиксом, 0 — без дедлайна. Остальным маршрутам достаётся def.
+func ParseRouteTimeouts(spec string, def time.Duration) (*RouteTimeouts, error) {
+	t := &RouteTimeouts{def: def}
+	for _, rule := range strings.Split(spec, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid route timeout %q", rule)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid timeout in %q", rule)
+		}
+
+		r := timeoutRule{timeout: timeout}
+		fields := strings.Fields(route)
+		switch len(fields) {
+		case 1:
+			r.path = fields[0]
+		case 2:
+			r.method, r.path = strings.ToUpper(fields[0]), fields[1]
+		default:
+			return nil, fmt.Errorf("invalid route in %q", rule)
+		}
+		if !strings.HasPrefix(r.path, "/") {
+			return nil, fmt.Errorf("route must start with / in %q", rule)
+		}
+		if strings.HasSuffix(r.path, "*") {
+			r.path, r.prefix = strings.TrimSuffix(r.path, "*"), true
+		}
+		t.rules = append(t.rules, r)
+	}
+	return t, nil
+}
+
+// For возвращает дедлайн маршрута. Точное совпадение важнее префикса, длинный префикс —
+// короткого, правило с методом — правила без него.
+func (t *RouteTimeouts) For(method, path string) time.Duration {
+	timeout, best := t.def, -1
+	for _, r := range t.rules {
+		if r.method != "" && r.method != method {
+			continue
+		}
+		if r.prefix && !strings.HasPrefix(path, r.path) || !r.prefix && path != r.path {
+			continue
+		}
+		score := len(r.path) * 4
+		if !r.prefix {
+			score += 2
+		}
+		if r.method != "" {
+			score++
+		}
+		if score > best {
+			timeout, best = r.timeout, score
+		}
+	}
+	return timeout
+}
+
+// RequestTimeout ограничивает время обработки запроса дедлайном его маршрута.
+// Контекст запроса отменяется по дедлайну (и при обрыве соединения клиентом),
+// вместе с ним прерываются запросы к БД; если обработчик не успел ответить — 504.
+func RequestTimeout(timeouts *RouteTimeouts) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d := timeouts.For(c.Request.Method, c.FullPath())
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "request timed out"})
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorChecker сообщает, включена ли у пользователя 2FA; реализуется TwoFactorService
+type TwoFactorChecker interface {
+	IsTwoFactorEnabled(ctx context.Context, userID int) (bool, error)
+}
+
+// RequireTwoFactor закрывает маршруты для пользователей без включённой 2FA.
+// Вход таких пользователей не блокируется, чтобы они могли подключить 2FA через /me/2fa.
+// Запросы по API-ключу пропускаются: у интеграций нет второго фактора.
+func RequireTwoFactor(checker TwoFactorChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAPIKey(c); ok {
+			c.Next()
+			return
+		}
+
+		userID, _ := GetUserID(c)
+
+		enabled, err := checker.IsTwoFactorEnabled(c.Request.Context(), userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !enabled {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "two-factor authentication required"})
+			return
+		}
+		c.Next()
+	}
+}
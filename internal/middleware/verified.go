@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerificationChecker сообщает, подтвердил ли пользователь email; реализуется AccountService
+type EmailVerificationChecker interface {
+	IsEmailVerified(ctx context.Context, userID int) (bool, error)
+}
+
+// RequireVerifiedEmail пропускает только пользователей с подтверждённым email.
+// Ставится после AuthMiddleware на маршруты покупок и бронирований.
+func RequireVerifiedEmail(checker EmailVerificationChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := GetUserID(c)
+
+		verified, err := checker.IsEmailVerified(c.Request.Context(), userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !verified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "email is not verified"})
+			return
+		}
+		c.Next()
+	}
+}
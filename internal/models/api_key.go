@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// APIKeyScopes — права, которые можно выдать API-ключу. Управление пользователями,
+// ролями и самими ключами остаётся только за людьми.
+var APIKeyScopes = []string{
+	PermUsersView, PermGymsManage, PermCatalogManage, PermTrainersManage, PermClassesManage,
+	PermBookingsView, PermPaymentsView, PermReportsView, PermInstallmentsView, PermFiscalManage,
+}
+
+// APIKey — ключ интеграции. Сам ключ показывается один раз при создании, хранится только его хеш.
+type APIKey struct {
+	ID         int        `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	GymID      *int       `json:"gym_id,omitempty" db:"gym_id"`
+	CreatedBy  int        `json:"created_by" db:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+func (k *APIKey) HasScope(perm string) bool {
+	for _, s := range k.Scopes {
+		if s == perm {
+			return true
+		}
+	}
+	return false
+}
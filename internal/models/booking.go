@@ -1,9 +1,19 @@
 package models
 
+import "time"
+
 type Booking struct {
 	ID        int    `json:"id" db:"id"`
 	UserID    int    `json:"user_id" db:"user_id"`
 	ClassID   int    `json:"class_id" db:"class_id"`
 	Status    string `json:"status" db:"status"`
 	CreatedAt string `json:"created_at" db:"created_at"`
-}
\ No newline at end of file
+}
+// BookingReminder — бронирование, по которому пора напомнить о занятии
+type BookingReminder struct {
+	BookingID  int
+	User       User
+	ClassTitle string
+	StartTime  string
+	StartsAt   time.Time
+}
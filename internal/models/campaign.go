@@ -0,0 +1,55 @@
+package models
+
+// Segment — условия отбора получателей рассылки; все заданные условия должны выполняться.
+// Пустой сегмент — все пользователи.
+type Segment struct {
+	// Клиенты залов: бронировали там занятия или платили
+	GymIDs []int `json:"gym_ids,omitempty"`
+	// true — есть действующий абонемент, false — нет
+	ActiveMembership *bool `json:"active_membership,omitempty"`
+	// Действующий абонемент одного из тарифов
+	MembershipIDs []int `json:"membership_ids,omitempty"`
+	// Действующий абонемент заканчивается в этом интервале (YYYY-MM-DD, включительно)
+	MembershipExpiresFrom string `json:"membership_expires_from,omitempty"`
+	MembershipExpiresTo   string `json:"membership_expires_to,omitempty"`
+	// Записаны на занятия с названием, содержащим строку, или на конкретные занятия
+	BookedClassTitle string `json:"booked_class_title,omitempty"`
+	BookedClassIDs   []int  `json:"booked_class_ids,omitempty"`
+	// Язык писем пользователя
+	Locales []string `json:"locales,omitempty"`
+}
+
+// Campaign — рассылка по сегменту. Статусы: draft, scheduled, sending, sent, cancelled.
+type Campaign struct {
+	ID            int            `json:"id" db:"id"`
+	Name          string         `json:"name" db:"name"`
+	Title         string         `json:"title" db:"title"`
+	Body          string         `json:"body" db:"body"`
+	Segment       Segment        `json:"segment" db:"segment"`
+	Status        string         `json:"status" db:"status"`
+	ScheduledAt   string         `json:"scheduled_at,omitempty" db:"scheduled_at"`
+	RatePerMinute int            `json:"rate_per_minute" db:"rate_per_minute"`
+	CreatedBy     int            `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt     string         `json:"created_at" db:"created_at"`
+	StartedAt     string         `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt    string         `json:"finished_at,omitempty" db:"finished_at"`
+	Stats         *CampaignStats `json:"stats,omitempty"`
+}
+
+// CampaignStats — судьба писем кампании. Queued — в очереди уведомлений, Sent — доставлены,
+// Failed — не доставлены после всех попыток, Skipped — получатель отписался или без адреса.
+type CampaignStats struct {
+	Recipients int `json:"recipients"`
+	Pending    int `json:"pending"`
+	Skipped    int `json:"skipped"`
+	Queued     int `json:"queued"`
+	Sent       int `json:"sent"`
+	Failed     int `json:"failed"`
+	Opened     int `json:"opened"`
+}
+
+// SegmentPreview — размер сегмента и первые получатели
+type SegmentPreview struct {
+	Count  int    `json:"count"`
+	Sample []User `json:"sample"`
+}
@@ -0,0 +1,7 @@
+package models
+
+type ExchangeRate struct {
+	Currency  string  `json:"currency" db:"currency"`
+	Rate      float64 `json:"rate" db:"rate"` // единиц базовой валюты за 1 единицу currency
+	UpdatedAt string  `json:"updated_at" db:"updated_at"`
+}
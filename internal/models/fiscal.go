@@ -0,0 +1,33 @@
+package models
+
+// FiscalReceipt — данные чека, передаваемые оператору фискальных данных (ОФД)
+type FiscalReceipt struct {
+	PaymentID   int    `json:"payment_id"`
+	UserID      int    `json:"user_id"`
+	AmountCents int    `json:"amount_cents"`
+	NetCents    int    `json:"net_cents"`
+	TaxCents    int    `json:"tax_cents"`
+	TaxRateBP   int    `json:"tax_rate_bp"`
+	Currency    string `json:"currency"`
+	Method      string `json:"method"`
+	ProductType string `json:"product_type"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// FiscalRegistration — ответ ОФД о регистрации чека
+type FiscalRegistration struct {
+	FiscalSign string `json:"fiscal_sign"`
+	URL        string `json:"url"`
+}
+
+type FiscalOutboxEntry struct {
+	ID            int    `json:"id" db:"id"`
+	PaymentID     int    `json:"payment_id" db:"payment_id"`
+	Status        string `json:"status" db:"status"` // pending, sending, sent, failed
+	Attempts      int    `json:"attempts" db:"attempts"`
+	NextAttemptAt string `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     string `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     string `json:"created_at" db:"created_at"`
+	UpdatedAt     string `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+// InstallmentPlan — покупка тарифа в рассрочку
+type InstallmentPlan struct {
+	ID                int           `json:"id" db:"id"`
+	UserID            int           `json:"user_id" db:"user_id"`
+	MembershipID      int           `json:"membership_id" db:"membership_id"`
+	UserMembershipID  *int          `json:"user_membership_id,omitempty" db:"user_membership_id"`
+	TotalCents        int           `json:"total_cents" db:"total_cents"`
+	Currency          string        `json:"currency" db:"currency"`
+	Method            string        `json:"method" db:"method"`
+	GymID             *int          `json:"gym_id,omitempty" db:"gym_id"`
+	InstallmentsCount int           `json:"installments_count" db:"installments_count"`
+	Status            string        `json:"status" db:"status"` // active, suspended, completed, cancelled
+	CreatedAt         string        `json:"created_at" db:"created_at"`
+	Installments      []Installment `json:"installments,omitempty"`
+}
+
+type Installment struct {
+	ID              int     `json:"id" db:"id"`
+	PlanID          int     `json:"plan_id" db:"plan_id"`
+	Seq             int     `json:"seq" db:"seq"`
+	AmountCents     int     `json:"amount_cents" db:"amount_cents"`
+	DueDate         string  `json:"due_date" db:"due_date"`
+	Status          string  `json:"status" db:"status"` // pending, paid
+	Attempts        int     `json:"attempts" db:"attempts"`
+	NextAttemptDate string  `json:"next_attempt_date" db:"next_attempt_date"`
+	PaymentID       *int    `json:"payment_id,omitempty" db:"payment_id"`
+	LastError       string  `json:"last_error,omitempty" db:"last_error"`
+	PaidAt          *string `json:"paid_at,omitempty" db:"paid_at"`
+}
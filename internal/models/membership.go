@@ -6,4 +6,12 @@ type Membership struct {
 	DurationDays int    `json:"duration_days" db:"duration_days"`
 	PriceCents   int    `json:"price_cents" db:"price_cents"`
 	CreatedAt    string `json:"created_at" db:"created_at"`
-}
\ No newline at end of file
+}
+
+type MembershipPrice struct {
+	ID           int    `json:"id" db:"id"`
+	MembershipID int    `json:"membership_id" db:"membership_id"`
+	Currency     string `json:"currency" db:"currency"`
+	PriceCents   int    `json:"price_cents" db:"price_cents"`
+	CreatedAt    string `json:"created_at" db:"created_at"`
+}
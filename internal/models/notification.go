@@ -0,0 +1,39 @@
+package models
+
+// NotificationOutboxEntry — письмо в очереди отправки. Текст письма в API не отдаётся:
+// в нём бывают одноразовые ссылки (сброс пароля, подтверждение email).
+type NotificationOutboxEntry struct {
+	ID             int     `json:"id" db:"id"`
+	Channel        string  `json:"channel" db:"channel"` // email, sms, push, webhook
+	Recipient      string  `json:"recipient" db:"recipient"`
+	Fallback       string  `json:"-" db:"fallback"` // запасные каналы: JSON-массив notification.Delivery
+	Template       string  `json:"template" db:"template"`
+	Locale         string  `json:"locale" db:"locale"`
+	Subject        string  `json:"subject" db:"subject"`
+	TextBody       string  `json:"-" db:"text_body"`
+	Body           string  `json:"-" db:"body"` // HTML-версия
+	UnsubscribeURL string  `json:"-" db:"unsubscribe_url"`
+	Status         string  `json:"status" db:"status"` // pending, sending, sent, dead
+	Attempts       int     `json:"attempts" db:"attempts"`
+	NextAttemptAt  string  `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError      string  `json:"last_error,omitempty" db:"last_error"`
+	SentAt         *string `json:"sent_at,omitempty" db:"sent_at"`
+	CreatedAt      string  `json:"created_at" db:"created_at"`
+	UpdatedAt      string  `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationPreference — подписка пользователя на категорию уведомлений в канале
+type NotificationPreference struct {
+	Category string `json:"category" binding:"required"`
+	Channel  string `json:"channel" binding:"required"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// NotificationSettings — настройки уведомлений пользователя: часовой пояс, тихие часы (HH:MM)
+// и подписки по категориям и каналам
+type NotificationSettings struct {
+	Timezone        string                   `json:"timezone"`
+	QuietHoursStart string                   `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   string                   `json:"quiet_hours_end,omitempty"`
+	Preferences     []NotificationPreference `json:"preferences"`
+}
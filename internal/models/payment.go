@@ -1,13 +1,39 @@
 package models
 
+// Типы продуктов, для которых настраиваются налоговые ставки
+const (
+	ProductMembership       = "membership"
+	ProductPersonalTraining = "personal_training"
+	ProductMerchandise      = "merchandise"
+	ProductOther            = "other"
+)
+
 type Payment struct {
-	ID          int    `json:"id" db:"id"`
-	UserID      int    `json:"user_id" db:"user_id"`
-	AmountCents int    `json:"amount_cents" db:"amount_cents"`
-	Currency    string `json:"currency" db:"currency"`
-	Method      string `json:"method" db:"method"`
-	Status      string `json:"status" db:"status"`
-	Description string `json:"description" db:"description"`
-	ReferenceID string `json:"reference_id" db:"reference_id"`
-	CreatedAt   string `json:"created_at" db:"created_at"`
+	ID            int     `json:"id" db:"id"`
+	UserID        int     `json:"user_id" db:"user_id"`
+	AmountCents   int     `json:"amount_cents" db:"amount_cents"` // брутто, с учётом налога
+	Currency      string  `json:"currency" db:"currency"`
+	Method        string  `json:"method" db:"method"`
+	Status        string  `json:"status" db:"status"`
+	Description   string  `json:"description" db:"description"`
+	ReferenceID   string  `json:"reference_id" db:"reference_id"`
+	ProductType   string  `json:"product_type" db:"product_type"`
+	GymID         *int    `json:"gym_id,omitempty" db:"gym_id"`
+	NetCents      int     `json:"net_cents" db:"net_cents"`
+	TaxCents      int     `json:"tax_cents" db:"tax_cents"`
+	TaxRateBP     int     `json:"tax_rate_bp" db:"tax_rate_bp"`
+	FiscalSign    string  `json:"fiscal_sign,omitempty" db:"fiscal_sign"`
+	FiscalURL     string  `json:"fiscal_url,omitempty" db:"fiscal_url"`
+	MembershipID  *int    `json:"membership_id,omitempty" db:"membership_id"`
+	RefundedCents int     `json:"refunded_cents" db:"refunded_cents"`
+	RefundedAt    *string `json:"refunded_at,omitempty" db:"refunded_at"`
+	CreatedAt     string  `json:"created_at" db:"created_at"`
+}
+
+// PaymentSummary — итоги по завершённым платежам, сведённые к базовой валюте
+type PaymentSummary struct {
+	BaseCurrency   string         `json:"base_currency"`
+	TotalBaseCents int            `json:"total_base_cents"`
+	ByCurrency     map[string]int `json:"by_currency"`
+	MissingRates   []string       `json:"missing_rates,omitempty"`
 }
@@ -0,0 +1,47 @@
+package models
+
+// RevenueReportRow — строка отчёта по выручке; возвраты вычтены в RevenueCents
+type RevenueReportRow struct {
+	Key           string `json:"key"`
+	Label         string `json:"label,omitempty"`
+	Currency      string `json:"currency"`
+	PaymentsCount int    `json:"payments_count"`
+	GrossCents    int    `json:"gross_cents"`
+	RefundedCents int    `json:"refunded_cents"`
+	RevenueCents  int    `json:"revenue_cents"`
+}
+
+// StatementRow — строка выписки банка или платёжного провайдера
+type StatementRow struct {
+	Line        int    `json:"line"`
+	PaymentID   int    `json:"payment_id"`
+	AmountCents int    `json:"amount_cents"`
+	Currency    string `json:"currency,omitempty"`
+}
+
+// Виды расхождений при сверке
+const (
+	MismatchMissingInStatement = "missing_in_statement"
+	MismatchMissingInRecords   = "missing_in_records"
+	MismatchAmount             = "amount_mismatch"
+	MismatchCurrency           = "currency_mismatch"
+	MismatchDuplicate          = "duplicate_in_statement"
+)
+
+type ReconciliationMismatch struct {
+	Kind                 string `json:"kind"`
+	PaymentID            int    `json:"payment_id"`
+	Line                 int    `json:"line,omitempty"` // строка выписки, 0 — платежа нет в выписке
+	RecordedAmountCents  int    `json:"recorded_amount_cents,omitempty"`
+	StatementAmountCents int    `json:"statement_amount_cents,omitempty"`
+	RecordedCurrency     string `json:"recorded_currency,omitempty"`
+	StatementCurrency    string `json:"statement_currency,omitempty"`
+}
+
+type ReconciliationResult struct {
+	From          string                   `json:"from"`
+	To            string                   `json:"to"`
+	StatementRows int                      `json:"statement_rows"`
+	Matched       int                      `json:"matched"`
+	Mismatches    []ReconciliationMismatch `json:"mismatches"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// Роли персонала и клиентов
+const (
+	RoleMember    = "member"
+	RoleTrainer   = "trainer"
+	RoleFrontDesk = "front_desk"
+	RoleManager   = "manager"
+	RoleOwner     = "owner"
+)
+
+// Права, которые проверяются на маршрутах
+const (
+	PermUsersView           = "users.view"
+	PermUsersManage         = "users.manage"
+	PermRolesManage         = "roles.manage"
+	PermGymsManage          = "gyms.manage"
+	PermCatalogManage       = "catalog.manage" // тарифы, цены, курсы валют, налоги
+	PermTrainersManage      = "trainers.manage"
+	PermClassesManage       = "classes.manage"
+	PermBookingsView        = "bookings.view"
+	PermPaymentsView        = "payments.view"
+	PermPaymentsRefund      = "payments.refund"
+	PermReportsView         = "reports.view"
+	PermInstallmentsView    = "installments.view"
+	PermFiscalManage        = "fiscal.manage"
+	PermAPIKeysManage       = "api_keys.manage"
+	PermNotificationsManage = "notifications.manage" // очередь уведомлений
+	PermCampaignsManage     = "campaigns.manage"     // рассылки по сегментам
+)
+
+// UserRole — роль пользователя; GymID == nil означает, что роль действует во всех залах
+type UserRole struct {
+	ID        int    `json:"id" db:"id"`
+	UserID    int    `json:"user_id" db:"user_id"`
+	Role      string `json:"role" db:"role"`
+	GymID     *int   `json:"gym_id,omitempty" db:"gym_id"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+}
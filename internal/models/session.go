@@ -3,9 +3,14 @@ package models
 import "time"
 
 type Session struct {
-	ID        string    `json:"id"`
-	UserID    int       `json:"user_id"`
-	UserEmail string    `json:"user_email"`
-	IsAdmin   bool      `json:"is_admin"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         string    `json:"id"`
+	UserID     int       `json:"user_id"`
+	UserEmail  string    `json:"user_email"`
+	IsAdmin    bool      `json:"is_admin"`
+	Version    int       `json:"token_version"` // версия токенов пользователя на момент входа
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
 }
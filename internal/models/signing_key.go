@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// SigningKey — ключ подписи JWT. Новые токены подписываются ключом без RotatedAt;
+// после ротации ключ остаётся в JWKS до ExpiresAt, чтобы выданные им токены продолжали проверяться.
+type SigningKey struct {
+	ID         int        `json:"id" db:"id"`
+	KID        string     `json:"kid" db:"kid"`
+	Algorithm  string     `json:"algorithm" db:"algorithm"`
+	PrivateKey string     `json:"-" db:"private_key"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+}
+
+// JWK — открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519) и EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS — набор открытых ключей для /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
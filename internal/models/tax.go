@@ -0,0 +1,31 @@
+package models
+
+type TaxRate struct {
+	ID          int    `json:"id" db:"id"`
+	Name        string `json:"name" db:"name"`
+	ProductType string `json:"product_type" db:"product_type"`
+	GymID       *int   `json:"gym_id,omitempty" db:"gym_id"` // nil — для всех залов
+	RateBP      int    `json:"rate_bp" db:"rate_bp"`         // базисные пункты: 1200 = 12%
+	Inclusive   bool   `json:"inclusive" db:"inclusive"`     // цена уже включает налог
+	CreatedAt   string `json:"created_at" db:"created_at"`
+}
+
+// TaxBreakdown — разложение суммы на нетто, налог и брутто
+type TaxBreakdown struct {
+	NetCents   int  `json:"net_cents"`
+	TaxCents   int  `json:"tax_cents"`
+	GrossCents int  `json:"gross_cents"`
+	RateBP     int  `json:"rate_bp"`
+	Inclusive  bool `json:"inclusive"`
+}
+
+// TaxSummaryRow — строка налогового отчёта за период
+type TaxSummaryRow struct {
+	ProductType   string `json:"product_type"`
+	TaxRateBP     int    `json:"tax_rate_bp"`
+	Currency      string `json:"currency"`
+	PaymentsCount int    `json:"payments_count"`
+	NetCents      int    `json:"net_cents"`
+	TaxCents      int    `json:"tax_cents"`
+	GrossCents    int    `json:"gross_cents"`
+}
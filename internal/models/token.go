@@ -0,0 +1,37 @@
+package models
+
+// TokenPair — результат входа или обновления токенов.
+// Если у пользователя включена 2FA, вход возвращает только MFARequired и MFAToken,
+// который вместе с кодом обменивается на токены через /users/login/2fa.
+type TokenPair struct {
+	AccessToken  string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type,omitempty"`
+	ExpiresAt    string `json:"expires_at,omitempty"` // срок действия access-токена, RFC 3339
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
+
+// TwoFactorSetup — данные для добавления аккаунта в приложение-аутентификатор
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type RefreshToken struct {
+	ID         int     `json:"id" db:"id"`
+	UserID     int     `json:"user_id" db:"user_id"`
+	FamilyID   string  `json:"family_id" db:"family_id"`
+	TokenHash  string  `json:"-" db:"token_hash"`
+	ExpiresAt  string  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *string `json:"revoked_at,omitempty" db:"revoked_at"`
+	ReplacedBy *int    `json:"replaced_by,omitempty" db:"replaced_by"`
+	CreatedAt  string  `json:"created_at" db:"created_at"`
+	Expired    bool    `json:"-" db:"-"`
+}
+
+// Назначение одноразовых токенов, отправляемых пользователю по email
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
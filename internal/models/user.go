@@ -1,10 +1,15 @@
 package models
 
 type User struct {
-	ID           int    `json:"id" db:"id"`
-	Name         string `json:"name" db:"name"`
-	Email        string `json:"email" db:"email"`
-	PasswordHash string `json:"-" db:"password_hash"`
-	IsAdmin      bool   `json:"is_admin" db:"is_admin"`
-	CreatedAt    string `json:"created_at" db:"created_at"`
+	ID            int    `json:"id" db:"id"`
+	Name          string `json:"name" db:"name"`
+	Email         string `json:"email" db:"email"`
+	PasswordHash  string `json:"-" db:"password_hash"`
+	IsAdmin       bool   `json:"is_admin" db:"is_admin"`
+	EmailVerified bool   `json:"email_verified" db:"email_verified"`
+	TOTPEnabled   bool   `json:"two_factor_enabled" db:"totp_enabled"`
+	Locale        string `json:"locale" db:"locale"` // язык писем: ru, kk, en
+	Phone         string `json:"phone,omitempty" db:"phone"`
+	PushToken     string `json:"-" db:"push_token"`
+	CreatedAt     string `json:"created_at" db:"created_at"`
 }
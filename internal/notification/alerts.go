@@ -0,0 +1,81 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// Виды оповещений администраторам
+const (
+	AlertLargePayment    = "large_payment"
+	AlertRefund          = "refund"
+	AlertFailedRenewal   = "failed_renewal"
+	AlertCapacityReached = "capacity_reached"
+)
+
+// AlertKinds — все виды оповещений администраторам
+var AlertKinds = []string{AlertLargePayment, AlertRefund, AlertFailedRenewal, AlertCapacityReached}
+
+var ErrUnknownAlert = errors.New("unknown admin alert")
+
+// AlertRules — какие оповещения администраторам включены и на какие адреса они уходят
+type AlertRules map[string][]string
+
+// ParseAlertRules разбирает ADMIN_ALERT_RULES вида "large_payment;refund=finance@gym.kz,owner@gym.kz".
+// Правило без адресов уходит на defaultTo; пустая строка включает все оповещения на defaultTo.
+// Без адресов оповещение не включается.
+func ParseAlertRules(spec, defaultTo string) (AlertRules, error) {
+	var defaults []string
+	if defaultTo != "" {
+		defaults = []string{defaultTo}
+	}
+
+	rules := AlertRules{}
+	if strings.TrimSpace(spec) == "" {
+		for _, kind := range AlertKinds {
+			if len(defaults) > 0 {
+				rules[kind] = defaults
+			}
+		}
+		return rules, nil
+	}
+
+	for _, rule := range strings.Split(spec, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		kind, list, hasList := strings.Cut(rule, "=")
+		kind = strings.TrimSpace(kind)
+		if !isKnownAlert(kind) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownAlert, kind)
+		}
+
+		to := defaults
+		if hasList {
+			to = nil
+			for _, addr := range strings.Split(list, ",") {
+				addr = strings.TrimSpace(addr)
+				if _, err := mail.ParseAddress(addr); err != nil {
+					return nil, fmt.Errorf("invalid address %q in alert rule %s", addr, kind)
+				}
+				to = append(to, addr)
+			}
+		}
+		if len(to) > 0 {
+			rules[kind] = to
+		}
+	}
+	return rules, nil
+}
+
+func isKnownAlert(kind string) bool {
+	for _, k := range AlertKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
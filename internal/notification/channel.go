@@ -0,0 +1,60 @@
+package notification
+
+import (
+	"context"
+	"errors"
+)
+
+// Имена каналов доставки
+const (
+	ChannelEmail   = "email"
+	ChannelSMS     = "sms"
+	ChannelPush    = "push"
+	ChannelWebhook = "webhook"
+)
+
+// Channels — все известные каналы
+var Channels = []string{ChannelEmail, ChannelSMS, ChannelPush, ChannelWebhook}
+
+var ErrUnknownChannel = errors.New("unknown notification channel")
+
+// Envelope — сообщение, готовое к отправке в конкретный канал.
+// To — адрес в этом канале: email, телефон или push-токен устройства; для webhook — email получателя.
+type Envelope struct {
+	To       string `json:"to"`
+	Template string `json:"template"`
+	Locale   string `json:"locale"`
+	Subject  string `json:"subject"`
+	Text     string `json:"text"`
+	HTML     string `json:"html,omitempty"`
+	// Ссылка отписки в один клик (RFC 8058); пусто для транзакционных сообщений
+	UnsubscribeURL string `json:"unsubscribe_url,omitempty"`
+}
+
+// Channel доставляет сообщение одним способом (email, SMS, push, webhook)
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, env Envelope) error
+}
+
+// Recipient — получатель уведомления: адреса во всех каналах и язык сообщений
+type Recipient struct {
+	UserID    int
+	Email     string
+	Phone     string
+	PushToken string
+	Locale    string
+}
+
+// Address возвращает адрес получателя в канале или "", если его там нет
+func (r Recipient) Address(channel string) string {
+	switch channel {
+	case ChannelEmail, ChannelWebhook:
+		return r.Email
+	case ChannelSMS:
+		return r.Phone
+	case ChannelPush:
+		return r.PushToken
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrFakeUnavailable — ошибка, которую FakeChannel возвращает в режиме отказа
+var ErrFakeUnavailable = errors.New("fake notification channel unavailable")
+
+// FakeChannel запоминает сообщения вместо отправки и умеет имитировать отказы; для тестов
+type FakeChannel struct {
+	name     string
+	mu       sync.Mutex
+	failNext int
+	sent     []Envelope
+}
+
+func NewFakeChannel(name string) *FakeChannel {
+	return &FakeChannel{name: name}
+}
+
+func (f *FakeChannel) Name() string {
+	return f.name
+}
+
+// FailNext заставляет следующие n вызовов Send завершиться ошибкой
+func (f *FakeChannel) FailNext(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failNext = n
+}
+
+func (f *FakeChannel) Send(ctx context.Context, env Envelope) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failNext > 0 {
+		f.failNext--
+		return ErrFakeUnavailable
+	}
+	f.sent = append(f.sent, env)
+	return nil
+}
+
+// Sent возвращает копию принятых сообщений
+func (f *FakeChannel) Sent() []Envelope {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Envelope(nil), f.sent...)
+}
@@ -0,0 +1,136 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const httpChannelTimeout = 10 * time.Second
+
+// postJSON отправляет payload и считает ошибкой любой ответ кроме 2xx
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// SMSChannel отправляет текстовую версию сообщения через HTTP API SMS-шлюза
+type SMSChannel struct {
+	url    string
+	token  string
+	sender string
+	client *http.Client
+}
+
+// NewSMSChannel создаёт канал; token передаётся как Bearer, sender — имя отправителя в SMS
+func NewSMSChannel(url, token, sender string) *SMSChannel {
+	return &SMSChannel{url: url, token: token, sender: sender, client: &http.Client{Timeout: httpChannelTimeout}}
+}
+
+func (c *SMSChannel) Name() string {
+	return ChannelSMS
+}
+
+func (c *SMSChannel) Send(ctx context.Context, env Envelope) error {
+	body, err := json.Marshal(map[string]string{
+		"from": c.sender,
+		"to":   env.To,
+		"text": env.Text,
+	})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, c.client, c.url, map[string]string{"Authorization": "Bearer " + c.token}, body)
+}
+
+// PushChannel отправляет уведомление на устройство через push-шлюз (FCM-совместимый HTTP API)
+type PushChannel struct {
+	url       string
+	serverKey string
+	client    *http.Client
+}
+
+func NewPushChannel(url, serverKey string) *PushChannel {
+	return &PushChannel{url: url, serverKey: serverKey, client: &http.Client{Timeout: httpChannelTimeout}}
+}
+
+func (c *PushChannel) Name() string {
+	return ChannelPush
+}
+
+func (c *PushChannel) Send(ctx context.Context, env Envelope) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"to": env.To,
+		"notification": map[string]string{
+			"title": env.Subject,
+			"body":  env.Text,
+		},
+		"data": map[string]string{"template": env.Template},
+	})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, c.client, c.url, map[string]string{"Authorization": "Bearer " + c.serverKey}, body)
+}
+
+// WebhookChannel отправляет сообщение целиком (JSON Envelope) на внешний URL.
+// Тело подписывается HMAC-SHA256 от "<timestamp>.<body>" секретом, чтобы получатель мог проверить отправителя.
+type WebhookChannel struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhookChannel(url, secret string) *WebhookChannel {
+	return &WebhookChannel{url: url, secret: secret, client: &http.Client{Timeout: httpChannelTimeout}}
+}
+
+func (c *WebhookChannel) Name() string {
+	return ChannelWebhook
+}
+
+func (c *WebhookChannel) Send(ctx context.Context, env Envelope) error {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	return postJSON(ctx, c.client, c.url, map[string]string{
+		"X-Webhook-Timestamp": timestamp,
+		"X-Webhook-Signature": "sha256=" + WebhookSignature(c.secret, timestamp, body),
+	}, body)
+}
+
+// WebhookSignature вычисляет подпись тела вебхука; получатель сравнивает её с X-Webhook-Signature
+func WebhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,32 @@
+package notification
+
+import (
+	"context"
+
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// LogChannel только пишет сообщения в лог — для разработки без SMTP и шлюзов
+type LogChannel struct {
+	name string
+}
+
+func NewLogChannel(name string) *LogChannel {
+	return &LogChannel{name: name}
+}
+
+func (c *LogChannel) Name() string {
+	return c.name
+}
+
+func (c *LogChannel) Send(ctx context.Context, env Envelope) error {
+	utils.GetLogger().Info("Notification logged (channel not configured):",
+		zap.String("channel", c.name),
+		zap.String("to", env.To),
+		zap.String("subject", env.Subject),
+		zap.String("text", env.Text),
+	)
+	return nil
+}
@@ -0,0 +1,201 @@
+package notification
+
+import "time"
+
+// ID шаблонов писем; файлы лежат в templates/<язык>/<id>.tmpl
+const (
+	TemplateBookingConfirmed    = "booking_confirmed"
+	TemplatePasswordReset       = "password_reset"
+	TemplateEmailVerification   = "email_verification"
+	TemplateLoginLocked         = "login_locked"
+	TemplateMembershipActivated = "membership_activated"
+	TemplateClassReminder       = "class_reminder"
+	TemplateClassChanged        = "class_changed"
+	TemplateClassCancelled      = "class_cancelled"
+	TemplateCampaign            = "campaign"
+	TemplateLargePaymentAlert   = "large_payment_alert"
+	TemplateRefundAlert         = "refund_alert"
+	TemplateRenewalFailedAlert  = "renewal_failed_alert"
+	TemplateClassFullAlert      = "class_full_alert"
+)
+
+// Категории уведомлений: от transactional (чеки, сброс пароля, безопасность) отписаться нельзя,
+// и они не откладываются на тихие часы
+const (
+	CategoryTransactional = "transactional"
+	CategoryReminders     = "reminders"
+	CategoryMarketing     = "marketing"
+)
+
+// Categories — все категории
+var Categories = []string{CategoryTransactional, CategoryReminders, CategoryMarketing}
+
+// Message — данные письма; каждый тип относится к одному шаблону и одной категории
+type Message interface {
+	TemplateID() string
+	Category() string
+}
+
+// Expiring — сообщение, которое бессмысленно доставлять после Deadline (нулевое — без срока)
+type Expiring interface {
+	Deadline() time.Time
+}
+
+type BookingConfirmed struct {
+	Name       string `json:"name"`
+	ClassTitle string `json:"class_title"`
+	StartTime  string `json:"start_time"`
+}
+
+func (BookingConfirmed) TemplateID() string { return TemplateBookingConfirmed }
+func (BookingConfirmed) Category() string   { return CategoryTransactional }
+
+type PasswordReset struct {
+	Name     string        `json:"name"`
+	Link     string        `json:"link"`
+	ValidFor time.Duration `json:"valid_for"`
+}
+
+func (PasswordReset) TemplateID() string { return TemplatePasswordReset }
+func (PasswordReset) Category() string   { return CategoryTransactional }
+
+type EmailVerification struct {
+	Name     string        `json:"name"`
+	Link     string        `json:"link"`
+	ValidFor time.Duration `json:"valid_for"`
+}
+
+func (EmailVerification) TemplateID() string { return TemplateEmailVerification }
+func (EmailVerification) Category() string   { return CategoryTransactional }
+
+type LoginLocked struct {
+	Name      string        `json:"name"`
+	LockedFor time.Duration `json:"locked_for"`
+}
+
+func (LoginLocked) TemplateID() string { return TemplateLoginLocked }
+func (LoginLocked) Category() string   { return CategoryTransactional }
+
+// MembershipActivated — чек о покупке абонемента: тариф, срок действия и оплаченная сумма
+type MembershipActivated struct {
+	Name         string `json:"name"`
+	PlanName     string `json:"plan_name"`
+	DurationDays int    `json:"duration_days"`
+	ValidFrom    string `json:"valid_from"`
+	ValidUntil   string `json:"valid_until"`
+	Amount       string `json:"amount"`
+}
+
+func (MembershipActivated) TemplateID() string { return TemplateMembershipActivated }
+func (MembershipActivated) Category() string   { return CategoryTransactional }
+
+// ClassReminder — напоминание о занятии; после StartsAt оно уже не нужно
+type ClassReminder struct {
+	Name       string    `json:"name"`
+	ClassTitle string    `json:"class_title"`
+	StartTime  string    `json:"start_time"`
+	StartsAt   time.Time `json:"-"`
+}
+
+func (ClassReminder) TemplateID() string    { return TemplateClassReminder }
+func (ClassReminder) Category() string      { return CategoryReminders }
+func (m ClassReminder) Deadline() time.Time { return m.StartsAt }
+
+// ClassChanged — занятие перенесли или сменили тренера. OldStartTime пустое, если время не менялось;
+// Trainer — новый тренер (пустое — ещё не назначен).
+type ClassChanged struct {
+	Name           string `json:"name"`
+	ClassTitle     string `json:"class_title"`
+	StartTime      string `json:"start_time"`
+	OldStartTime   string `json:"old_start_time"`
+	TrainerChanged bool   `json:"trainer_changed"`
+	Trainer        string `json:"trainer"`
+}
+
+func (ClassChanged) TemplateID() string { return TemplateClassChanged }
+func (ClassChanged) Category() string   { return CategoryTransactional }
+
+type ClassCancelled struct {
+	Name       string `json:"name"`
+	ClassTitle string `json:"class_title"`
+	StartTime  string `json:"start_time"`
+}
+
+func (ClassCancelled) TemplateID() string { return TemplateClassCancelled }
+func (ClassCancelled) Category() string   { return CategoryTransactional }
+
+// Campaign — письмо рассылки: заголовок и текст пишет менеджер, OpenURL — пиксель открытий
+type Campaign struct {
+	Name    string `json:"name"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	OpenURL string `json:"open_url"`
+}
+
+func (Campaign) TemplateID() string { return TemplateCampaign }
+func (Campaign) Category() string   { return CategoryMarketing }
+
+// Оповещения администраторам уходят на адреса из ADMIN_ALERT_RULES, а не пользователям
+
+// LargePaymentAlert — платёж на сумму не меньше порога
+type LargePaymentAlert struct {
+	PaymentID   int    `json:"payment_id"`
+	UserID      int    `json:"user_id"`
+	Amount      string `json:"amount"`
+	Description string `json:"description"`
+}
+
+func (LargePaymentAlert) TemplateID() string { return TemplateLargePaymentAlert }
+func (LargePaymentAlert) Category() string   { return CategoryTransactional }
+
+// RefundAlert — оформлен возврат; Refunded — сумма этого возврата, Remaining — что осталось от платежа
+type RefundAlert struct {
+	PaymentID int    `json:"payment_id"`
+	UserID    int    `json:"user_id"`
+	Refunded  string `json:"refunded"`
+	Remaining string `json:"remaining"`
+}
+
+func (RefundAlert) TemplateID() string { return TemplateRefundAlert }
+func (RefundAlert) Category() string   { return CategoryTransactional }
+
+// RenewalFailedAlert — не удалось списать очередной платёж по рассрочке
+type RenewalFailedAlert struct {
+	PlanID  int    `json:"plan_id"`
+	UserID  int    `json:"user_id"`
+	Seq     int    `json:"seq"`
+	Amount  string `json:"amount"`
+	DueDate string `json:"due_date"`
+	Error   string `json:"error"`
+}
+
+func (RenewalFailedAlert) TemplateID() string { return TemplateRenewalFailedAlert }
+func (RenewalFailedAlert) Category() string   { return CategoryTransactional }
+
+// ClassFullAlert — на занятие записалось столько, сколько в нём мест
+type ClassFullAlert struct {
+	ClassID    int    `json:"class_id"`
+	ClassTitle string `json:"class_title"`
+	StartTime  string `json:"start_time"`
+	Capacity   int    `json:"capacity"`
+}
+
+func (ClassFullAlert) TemplateID() string { return TemplateClassFullAlert }
+func (ClassFullAlert) Category() string   { return CategoryTransactional }
+
+// samples — данные для предпросмотра шаблонов в админке
+var samples = []Message{
+	BookingConfirmed{Name: "Айгерим", ClassTitle: "Yoga Flow", StartTime: "2025-01-15 18:00"},
+	PasswordReset{Name: "Айгерим", Link: "https://example.com/reset-password?token=sample", ValidFor: time.Hour},
+	EmailVerification{Name: "Айгерим", Link: "https://example.com/verify-email?token=sample", ValidFor: 24 * time.Hour},
+	LoginLocked{Name: "Айгерим", LockedFor: 15 * time.Minute},
+	MembershipActivated{Name: "Айгерим", PlanName: "Безлимит", DurationDays: 30, ValidFrom: "2025-01-15", ValidUntil: "2025-02-14", Amount: "25 000.00 KZT"},
+	ClassReminder{Name: "Айгерим", ClassTitle: "Yoga Flow", StartTime: "2025-01-15 18:00"},
+	ClassChanged{Name: "Айгерим", ClassTitle: "Yoga Flow", StartTime: "2025-01-15 19:00", OldStartTime: "2025-01-15 18:00", TrainerChanged: true, Trainer: "Алия"},
+	ClassCancelled{Name: "Айгерим", ClassTitle: "Yoga Flow", StartTime: "2025-01-15 18:00"},
+	Campaign{Name: "Айгерим", Title: "Новое расписание", Body: "С понедельника в северном филиале открываются утренние группы."},
+	LargePaymentAlert{PaymentID: 1042, UserID: 7, Amount: "450 000.00 KZT", Description: "membership purchase"},
+	RefundAlert{PaymentID: 1042, UserID: 7, Refunded: "150 000.00 KZT", Remaining: "300 000.00 KZT"},
+	RenewalFailedAlert{PlanID: 12, UserID: 7, Seq: 2, Amount: "12 500.00 KZT", DueDate: "2025-02-15", Error: "card declined"},
+	ClassFullAlert{ClassID: 31, ClassTitle: "Yoga Flow", StartTime: "2025-01-15 18:00", Capacity: 20},
+}
@@ -0,0 +1,123 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	// Часовые пояса пользователей не должны зависеть от tzdata на сервере
+	_ "time/tzdata"
+)
+
+// DefaultTimezone — часовой пояс пользователя, пока он не задал свой
+const DefaultTimezone = "Asia/Almaty"
+
+var (
+	ErrInvalidQuietHours      = errors.New("quiet hours must be HH:MM")
+	ErrInvalidUnsubscribeLink = errors.New("invalid unsubscribe link")
+)
+
+// ParseClock разбирает время суток "HH:MM" в минуты от полуночи
+func ParseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	if !ok || len(h) != 2 || len(m) != 2 {
+		return 0, ErrInvalidQuietHours
+	}
+	hours, err1 := strconv.Atoi(h)
+	minutes, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || hours > 23 || minutes > 59 || hours < 0 || minutes < 0 {
+		return 0, ErrInvalidQuietHours
+	}
+	return hours*60 + minutes, nil
+}
+
+// QuietHours — интервал по местному времени пользователя, когда нетранзакционные
+// уведомления не отправляются. Может переходить через полночь (22:00–08:00).
+type QuietHours struct {
+	Start    int // минуты от полуночи
+	End      int
+	Location *time.Location
+}
+
+// NewQuietHours собирает тихие часы из настроек; без start/end тихих часов нет (nil)
+func NewQuietHours(start, end, timezone string) (*QuietHours, error) {
+	if start == "" || end == "" {
+		return nil, nil
+	}
+	s, err := ParseClock(start)
+	if err != nil {
+		return nil, err
+	}
+	e, err := ParseClock(end)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	return &QuietHours{Start: s, End: e, Location: loc}, nil
+}
+
+// Defer возвращает момент, когда можно отправить сообщение, созданное в now:
+// конец тихих часов, если now попадает в них, иначе само now
+func (q *QuietHours) Defer(now time.Time) time.Time {
+	if q == nil || q.Start == q.End {
+		return now
+	}
+	local := now.In(q.Location)
+	minute := local.Hour()*60 + local.Minute()
+
+	var quiet bool
+	if q.Start < q.End {
+		quiet = minute >= q.Start && minute < q.End
+	} else {
+		quiet = minute >= q.Start || minute < q.End
+	}
+	if !quiet {
+		return now
+	}
+
+	end := time.Date(local.Year(), local.Month(), local.Day(), q.End/60, q.End%60, 0, 0, q.Location)
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// UnsubscribeLinks выдаёт и проверяет подписанные ссылки отписки от категории.
+// Ссылки бессрочные и не хранятся: в токене user_id и категория, подпись — HMAC-SHA256.
+type UnsubscribeLinks struct {
+	baseURL string
+	secret  []byte
+}
+
+// NewUnsubscribeLinks создаёт генератор ссылок вида baseURL?token=...
+func NewUnsubscribeLinks(baseURL, secret string) *UnsubscribeLinks {
+	return &UnsubscribeLinks{baseURL: baseURL, secret: []byte(secret)}
+}
+
+// URL возвращает ссылку отписки пользователя от категории
+func (l *UnsubscribeLinks) URL(userID int, category string) string {
+	return l.baseURL + "?token=" + l.Token(userID, category)
+}
+
+// Token — подписанный токен для ссылки отписки
+func (l *UnsubscribeLinks) Token(userID int, category string) string {
+	return signToken(l.secret, "unsubscribe", fmt.Sprintf("%d.%s", userID, category))
+}
+
+// Parse проверяет подпись токена и возвращает пользователя и категорию
+func (l *UnsubscribeLinks) Parse(token string) (int, string, error) {
+	payload, ok := verifyToken(l.secret, "unsubscribe", token)
+	if !ok {
+		return 0, "", ErrInvalidUnsubscribeLink
+	}
+	id, category, ok := strings.Cut(payload, ".")
+	userID, err := strconv.Atoi(id)
+	if !ok || err != nil {
+		return 0, "", ErrInvalidUnsubscribeLink
+	}
+	return userID, category, nil
+}
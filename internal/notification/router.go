@@ -0,0 +1,105 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// defaultRouteKey — маршрут для шаблонов, у которых нет своего
+const defaultRouteKey = "*"
+
+// Routes — каналы по ID шаблона в порядке предпочтения; следующий канал — запасной для предыдущего
+type Routes map[string][]string
+
+// Delivery — канал и адрес получателя в нём
+type Delivery struct {
+	Channel string `json:"channel"`
+	To      string `json:"to"`
+}
+
+// ParseRoutes разбирает NOTIFICATION_ROUTES вида "booking_confirmed=push,email;*=email"
+func ParseRoutes(spec string) (Routes, error) {
+	routes := Routes{}
+	for _, rule := range strings.Split(spec, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		id, list, ok := strings.Cut(rule, "=")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid notification route %q", rule)
+		}
+		if _, known := defaultTemplates.samples[id]; !known && id != defaultRouteKey {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, id)
+		}
+
+		var channels []string
+		for _, name := range strings.Split(list, ",") {
+			name = strings.TrimSpace(name)
+			if !isKnownChannel(name) {
+				return nil, fmt.Errorf("%w: %q in route %s", ErrUnknownChannel, name, id)
+			}
+			channels = append(channels, name)
+		}
+		routes[id] = channels
+	}
+	return routes, nil
+}
+
+func isKnownChannel(name string) bool {
+	for _, c := range Channels {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Router выбирает каналы для сообщения по маршрутам и отправляет в нужный канал
+type Router struct {
+	channels map[string]Channel
+	routes   Routes
+}
+
+// NewRouter создаёт маршрутизатор; без маршрута для шаблона сообщения уходят только по email
+func NewRouter(routes Routes, channels ...Channel) *Router {
+	r := &Router{channels: map[string]Channel{}, routes: Routes{defaultRouteKey: {ChannelEmail}}}
+	for id, list := range routes {
+		r.routes[id] = list
+	}
+	for _, c := range channels {
+		r.channels[c.Name()] = c
+	}
+	return r
+}
+
+// Plan возвращает доставки в порядке предпочтения: каналы маршрута, которые подключены
+// и в которых у получателя есть адрес. Первая — основная, остальные — запасные.
+func (r *Router) Plan(templateID string, rcpt Recipient) []Delivery {
+	route, ok := r.routes[templateID]
+	if !ok {
+		route = r.routes[defaultRouteKey]
+	}
+
+	var deliveries []Delivery
+	for _, name := range route {
+		if _, ok := r.channels[name]; !ok {
+			continue
+		}
+		if to := rcpt.Address(name); to != "" {
+			deliveries = append(deliveries, Delivery{Channel: name, To: to})
+		}
+	}
+	return deliveries
+}
+
+// Send отправляет сообщение в канал по имени
+func (r *Router) Send(ctx context.Context, channel string, env Envelope) error {
+	c, ok := r.channels[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+	return c.Send(ctx, env)
+}
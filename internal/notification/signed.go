@@ -0,0 +1,37 @@
+package notification
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// signToken упаковывает payload в токен для ссылки: base64url(payload) + "." + HMAC-SHA256.
+// purpose входит в подпись, чтобы токен одной ссылки нельзя было подставить в другую.
+func signToken(secret []byte, purpose, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + tokenSignature(secret, purpose, payload)
+}
+
+// verifyToken проверяет подпись и возвращает payload
+func verifyToken(secret []byte, purpose, token string) (string, bool) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+	payload := string(raw)
+	if !hmac.Equal([]byte(signature), []byte(tokenSignature(secret, purpose, payload))) {
+		return "", false
+	}
+	return payload, true
+}
+
+func tokenSignature(secret []byte, purpose, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose + "." + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,47 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+
+	"github.com/jordan-wright/email"
+)
+
+// SMTPChannel отправляет письма через SMTP-сервер
+type SMTPChannel struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPChannel(host, port, user, pass, from string) *SMTPChannel {
+	return &SMTPChannel{
+		addr: fmt.Sprintf("%s:%s", host, port),
+		auth: smtp.PlainAuth("", user, pass, host),
+		from: from,
+	}
+}
+
+func (s *SMTPChannel) Name() string {
+	return ChannelEmail
+}
+
+// Send отправляет письмо с текстовой и HTML-версией (multipart/alternative)
+func (s *SMTPChannel) Send(ctx context.Context, env Envelope) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	e := email.NewEmail()
+	e.From = s.from
+	e.To = []string{env.To}
+	e.Subject = env.Subject
+	e.Text = []byte(env.Text)
+	e.HTML = []byte(env.HTML)
+	if env.UnsubscribeURL != "" {
+		e.Headers.Set("List-Unsubscribe", "<"+env.UnsubscribeURL+">")
+		e.Headers.Set("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	return e.Send(s.addr, s.auth)
+}
@@ -0,0 +1,291 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"reflect"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// DefaultLocale — язык, на который откатываемся, если язык пользователя не поддерживается
+const DefaultLocale = "ru"
+
+// Locales — языки, на которых есть шаблоны писем
+var Locales = []string{"ru", "kk", "en"}
+
+var (
+	ErrUnknownTemplate     = errors.New("unknown notification template")
+	ErrUnsupportedLocale   = errors.New("unsupported locale")
+	ErrInvalidTemplateData = errors.New("invalid template data")
+)
+
+// templateFS — шаблоны писем; all: нужен, иначе embed пропустит подвалы на "_"
+//
+//go:embed all:templates
+var templateFS embed.FS
+
+// Rendered — письмо, готовое к постановке в очередь
+type Rendered struct {
+	Template string `json:"template"`
+	Locale   string `json:"locale"`
+	Subject  string `json:"subject"`
+	Text     string `json:"text"`
+	HTML     string `json:"html"`
+}
+
+// TemplateInfo — описание шаблона для админки
+type TemplateInfo struct {
+	ID       string   `json:"id"`
+	Category string   `json:"category"`
+	Locales  []string `json:"locales"`
+	Sample   Message  `json:"sample"`
+}
+
+// unsubscribeFooter — имя файла с подвалом "отписаться", который добавляется к нетранзакционным письмам
+const unsubscribeFooter = "_unsubscribe"
+
+// previewUnsubscribeURL — ссылка отписки в предпросмотре
+const previewUnsubscribeURL = "https://example.com/api/notifications/unsubscribe?token=sample"
+
+// localized — один шаблон на одном языке. Файл содержит блоки subject, text и html;
+// первые два исполняются text/template, html — html/template с экранированием.
+type localized struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Templates — шаблоны писем по ID и языку
+type Templates struct {
+	byID    map[string]map[string]*localized
+	footers map[string]*localized
+	samples map[string]Message
+}
+
+var defaultTemplates = mustLoadTemplates()
+
+// DefaultTemplates возвращает встроенные в бинарник шаблоны
+func DefaultTemplates() *Templates {
+	return defaultTemplates
+}
+
+func mustLoadTemplates() *Templates {
+	t, err := LoadTemplates(templateFS)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// LoadTemplates читает templates/<язык>/<id>.tmpl для всех известных шаблонов.
+// Версия на DefaultLocale обязательна, остальные языки — по мере перевода.
+func LoadTemplates(fsys fs.FS) (*Templates, error) {
+	t := &Templates{byID: map[string]map[string]*localized{}, footers: map[string]*localized{}, samples: map[string]Message{}}
+
+	for _, locale := range Locales {
+		footer, err := loadLocalized(fsys, locale, unsubscribeFooter, "text", "html")
+		if err != nil {
+			return nil, err
+		}
+		if footer != nil {
+			t.footers[locale] = footer
+		}
+	}
+
+	for _, sample := range samples {
+		id := sample.TemplateID()
+		t.samples[id] = sample
+		t.byID[id] = map[string]*localized{}
+
+		for _, locale := range Locales {
+			tpl, err := loadLocalized(fsys, locale, id, "subject", "text", "html")
+			if err != nil {
+				return nil, err
+			}
+			if tpl != nil {
+				t.byID[id][locale] = tpl
+			}
+		}
+	}
+	return t, nil
+}
+
+// loadLocalized разбирает templates/<locale>/<name>.tmpl; отсутствующий перевод (nil) допустим
+// везде, кроме DefaultLocale
+func loadLocalized(fsys fs.FS, locale, name string, blocks ...string) (*localized, error) {
+	src, err := fs.ReadFile(fsys, fmt.Sprintf("templates/%s/%s.tmpl", locale, name))
+	if errors.Is(err, fs.ErrNotExist) && locale != DefaultLocale {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := templateFuncs(locale)
+	text, err := texttemplate.New(name).Funcs(texttemplate.FuncMap(funcs)).Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("template %s/%s: %w", locale, name, err)
+	}
+	html, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs)).Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("template %s/%s: %w", locale, name, err)
+	}
+	for _, block := range blocks {
+		if text.Lookup(block) == nil {
+			return nil, fmt.Errorf("template %s/%s: missing %q block", locale, name, block)
+		}
+	}
+	return &localized{text: text, html: html}, nil
+}
+
+// IsSupportedLocale сообщает, есть ли шаблоны на этом языке
+func IsSupportedLocale(locale string) bool {
+	for _, l := range Locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeLocale приводит "en-US", "KK" и т.п. к поддерживаемому языку или DefaultLocale
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	if IsSupportedLocale(locale) {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Render собирает письмо на языке пользователя; если перевода нет — на DefaultLocale.
+// С unsubscribeURL в конец письма добавляется ссылка отписки.
+func (t *Templates) Render(locale string, msg Message, unsubscribeURL string) (*Rendered, error) {
+	versions, ok := t.byID[msg.TemplateID()]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, msg.TemplateID())
+	}
+	locale = NormalizeLocale(locale)
+	tpl, ok := versions[locale]
+	if !ok {
+		locale = DefaultLocale
+		tpl = versions[locale]
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tpl.text.ExecuteTemplate(&subject, "subject", msg); err != nil {
+		return nil, err
+	}
+	if err := tpl.text.ExecuteTemplate(&text, "text", msg); err != nil {
+		return nil, err
+	}
+	if err := tpl.html.ExecuteTemplate(&html, "html", msg); err != nil {
+		return nil, err
+	}
+	if unsubscribeURL != "" {
+		footer, ok := t.footers[locale]
+		if !ok {
+			footer = t.footers[DefaultLocale]
+		}
+		data := struct{ URL string }{unsubscribeURL}
+		text.WriteString("\n\n")
+		if err := footer.text.ExecuteTemplate(&text, "text", data); err != nil {
+			return nil, err
+		}
+		html.WriteString("\n")
+		if err := footer.html.ExecuteTemplate(&html, "html", data); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Rendered{
+		Template: msg.TemplateID(),
+		Locale:   locale,
+		Subject:  strings.TrimSpace(subject.String()),
+		Text:     strings.TrimSpace(text.String()),
+		HTML:     strings.TrimSpace(html.String()),
+	}, nil
+}
+
+// Preview рендерит шаблон на примерных данных; поля из data (JSON) заменяют примерные.
+// Нетранзакционные письма показываются со ссылкой отписки.
+func (t *Templates) Preview(id, locale string, data []byte) (*Rendered, error) {
+	sample, ok := t.samples[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, id)
+	}
+	if !IsSupportedLocale(locale) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLocale, locale)
+	}
+
+	msg := sample
+	if len(bytes.TrimSpace(data)) > 0 {
+		v := reflect.New(reflect.TypeOf(sample))
+		v.Elem().Set(reflect.ValueOf(sample))
+		if err := json.Unmarshal(data, v.Interface()); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTemplateData, err)
+		}
+		msg = v.Elem().Interface().(Message)
+	}
+	unsubscribeURL := ""
+	if msg.Category() != CategoryTransactional {
+		unsubscribeURL = previewUnsubscribeURL
+	}
+	return t.Render(locale, msg, unsubscribeURL)
+}
+
+// List возвращает шаблоны с языками, на которые они переведены
+func (t *Templates) List() []TemplateInfo {
+	infos := make([]TemplateInfo, 0, len(samples))
+	for _, sample := range samples {
+		id := sample.TemplateID()
+		info := TemplateInfo{ID: id, Category: sample.Category(), Sample: sample}
+		for _, locale := range Locales {
+			if _, ok := t.byID[id][locale]; ok {
+				info.Locales = append(info.Locales, locale)
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// durationUnits — обозначения часов и минут по языкам
+var durationUnits = map[string][2]string{
+	"ru": {"ч", "мин"},
+	"kk": {"сағ", "мин"},
+	"en": {"h", "min"},
+}
+
+func templateFuncs(locale string) map[string]interface{} {
+	return map[string]interface{}{
+		"duration": func(d time.Duration) string { return formatDuration(d, locale) },
+	}
+}
+
+// formatDuration выводит срок вида "1 ч 30 мин" на нужном языке
+func formatDuration(d time.Duration, locale string) string {
+	units, ok := durationUnits[locale]
+	if !ok {
+		units = durationUnits[DefaultLocale]
+	}
+	d = d.Round(time.Minute)
+	hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+
+	switch {
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%d %s %d %s", hours, units[0], minutes, units[1])
+	case hours > 0:
+		return fmt.Sprintf("%d %s", hours, units[0])
+	default:
+		return fmt.Sprintf("%d %s", minutes, units[1])
+	}
+}
@@ -0,0 +1,47 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidTrackingLink = errors.New("invalid tracking link")
+
+// OpenTracker выдаёт ссылки на пиксель, по которому считаются открытия писем рассылки.
+// Как и ссылки отписки, они не хранятся: в токене кампания и пользователь, подпись — HMAC.
+type OpenTracker struct {
+	baseURL string
+	secret  []byte
+}
+
+// NewOpenTracker создаёт генератор ссылок вида baseURL?token=...
+func NewOpenTracker(baseURL, secret string) *OpenTracker {
+	return &OpenTracker{baseURL: baseURL, secret: []byte(secret)}
+}
+
+// URL возвращает адрес пикселя для письма кампании пользователю
+func (t *OpenTracker) URL(campaignID, userID int) string {
+	return t.baseURL + "?token=" + t.Token(campaignID, userID)
+}
+
+// Token — подписанный токен пикселя
+func (t *OpenTracker) Token(campaignID, userID int) string {
+	return signToken(t.secret, "open", fmt.Sprintf("%d.%d", campaignID, userID))
+}
+
+// Parse проверяет подпись токена и возвращает кампанию и пользователя
+func (t *OpenTracker) Parse(token string) (int, int, error) {
+	payload, ok := verifyToken(t.secret, "open", token)
+	if !ok {
+		return 0, 0, ErrInvalidTrackingLink
+	}
+	c, u, ok := strings.Cut(payload, ".")
+	campaignID, err1 := strconv.Atoi(c)
+	userID, err2 := strconv.Atoi(u)
+	if !ok || err1 != nil || err2 != nil {
+		return 0, 0, ErrInvalidTrackingLink
+	}
+	return campaignID, userID, nil
+}
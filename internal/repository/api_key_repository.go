@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Не чаще этого обновлять last_used_at, чтобы не писать в БД на каждый запрос
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyRepository struct {
+	db DBTX
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *APIKeyRepository) WithTx(tx *sql.Tx) APIKeyStore {
+	if tx == nil {
+		return r
+	}
+	return &APIKeyRepository{db: tx}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, gym_id, created_by, expires_at, last_used_at, revoked_at, created_at`
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &key.GymID, &key.CreatedBy,
+		&expiresAt, &lastUsedAt, &revokedAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Fields(scopes)
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, name, prefix, keyHash string, scopes []string, gymID *int, createdBy int, expiresAt *time.Time) (*models.APIKey, error) {
+	var expires interface{}
+	if expiresAt != nil {
+		expires = expiresAt.UTC().Format(sqliteTimeLayout)
+	}
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, gym_id, created_by, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		name, prefix, keyHash, strings.Join(scopes, " "), gymID, createdBy, expires)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res.LastInsertId()
+	return r.GetByID(ctx, int(id))
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id int) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ?`, id))
+}
+
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = ?`, prefix))
+}
+
+func (r *APIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke отзывает ключ; false, если ключа нет или он уже отозван
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// TouchLastUsed отмечает использование ключа не чаще раза в apiKeyTouchInterval
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys SET last_used_at = ?
+		WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)`,
+		now.UTC().Format(sqliteTimeLayout), id, now.Add(-apiKeyTouchInterval).UTC().Format(sqliteTimeLayout))
+	return err
+}
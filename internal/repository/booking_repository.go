@@ -2,19 +2,29 @@ package repository
 
 import (
 	"Gym_StrongCode/internal/models"
+	"context"
 	"database/sql"
+	"time"
 )
 
 type BookingRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewBookingRepository(db *sql.DB) *BookingRepository {
 	return &BookingRepository{db: db}
 }
 
-func (r *BookingRepository) Create(userID, classID int) (int64, error) {
-	res, err := r.db.Exec(`
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *BookingRepository) WithTx(tx *sql.Tx) BookingStore {
+	if tx == nil {
+		return r
+	}
+	return &BookingRepository{db: tx}
+}
+
+func (r *BookingRepository) Create(ctx context.Context, userID, classID int) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
 		INSERT INTO bookings (user_id, class_id) VALUES (?, ?)`, userID, classID)
 	if err != nil {
 		return 0, err
@@ -22,14 +32,14 @@ func (r *BookingRepository) Create(userID, classID int) (int64, error) {
 	return res.LastInsertId()
 }
 
-func (r *BookingRepository) Exists(userID, classID int) (bool, error) {
+func (r *BookingRepository) Exists(ctx context.Context, userID, classID int) (bool, error) {
 	var count int
-	err := r.db.QueryRow(`SELECT COUNT(*) FROM bookings WHERE user_id = ? AND class_id = ?`, userID, classID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM bookings WHERE user_id = ? AND class_id = ?`, userID, classID).Scan(&count)
 	return count > 0, err
 }
 
-func (r *BookingRepository) GetByUser(userID int) ([]models.Booking, error) {
-	rows, err := r.db.Query(`
+func (r *BookingRepository) GetByUser(ctx context.Context, userID int) ([]models.Booking, error) {
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT id, user_id, class_id, status, created_at
 		FROM bookings WHERE user_id = ? ORDER BY created_at DESC`, userID)
 	if err != nil {
@@ -48,8 +58,29 @@ func (r *BookingRepository) GetByUser(userID int) ([]models.Booking, error) {
 	return bookings, nil
 }
 
-func (r *BookingRepository) ListAll() ([]models.Booking, error) {
-	rows, err := r.db.Query(`SELECT id, user_id, class_id, status, created_at FROM bookings`)
+func (r *BookingRepository) ListAll(ctx context.Context) ([]models.Booking, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, user_id, class_id, status, created_at FROM bookings`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookings []models.Booking
+	for rows.Next() {
+		var b models.Booking
+		if err := rows.Scan(&b.ID, &b.UserID, &b.ClassID, &b.Status, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, b)
+	}
+	return bookings, nil
+}
+
+func (r *BookingRepository) ListByGym(ctx context.Context, gymID int) ([]models.Booking, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT b.id, b.user_id, b.class_id, b.status, b.created_at
+		FROM bookings b JOIN classes c ON c.id = b.class_id
+		WHERE c.gym_id = ?`, gymID)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +97,83 @@ func (r *BookingRepository) ListAll() ([]models.Booking, error) {
 	return bookings, nil
 }
 
-func (r *BookingRepository) Cancel(bookingID, userID int) error {
-	_, err := r.db.Exec(`DELETE FROM bookings WHERE id = ? AND user_id = ?`, bookingID, userID)
+func (r *BookingRepository) Cancel(ctx context.Context, bookingID, userID int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM bookings WHERE id = ? AND user_id = ?`, bookingID, userID)
+	return err
+}
+
+// recipientColumns — поля пользователя u, нужные для отправки ему уведомлений
+const recipientColumns = `u.id, u.name, u.email, u.locale, COALESCE(u.phone, ''), COALESCE(u.push_token, '')`
+
+// recipientFields — куда сканировать recipientColumns
+func recipientFields(u *models.User) []interface{} {
+	return []interface{}{&u.ID, &u.Name, &u.Email, &u.Locale, &u.Phone, &u.PushToken}
+}
+
+// ListAttendees возвращает пользователей, записанных на занятие
+func (r *BookingRepository) ListAttendees(ctx context.Context, classID int) ([]models.User, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+recipientColumns+`
+		FROM bookings b JOIN users u ON u.id = b.user_id
+		WHERE b.class_id = ?`, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(recipientFields(&u)...); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// DeleteByClass снимает все бронирования занятия (при его отмене)
+func (r *BookingRepository) DeleteByClass(ctx context.Context, classID int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM bookings WHERE class_id = ?`, classID)
+	return err
+}
+
+// ListDueReminders возвращает бронирования на занятия, начинающиеся в (from, to],
+// о которых ещё не напоминали (или напоминали до переноса занятия)
+func (r *BookingRepository) ListDueReminders(ctx context.Context, from, to time.Time, limit int) ([]models.BookingReminder, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT b.id, `+recipientColumns+`, c.title, c.start_time, datetime(c.start_time)
+		FROM bookings b
+		JOIN classes c ON c.id = b.class_id
+		JOIN users u ON u.id = b.user_id
+		WHERE datetime(c.start_time) > datetime(?) AND datetime(c.start_time) <= datetime(?)
+		  AND (b.reminded_for IS NULL OR datetime(b.reminded_for) != datetime(c.start_time))
+		ORDER BY c.start_time, b.id
+		LIMIT ?`,
+		from.UTC().Format(sqliteTimeLayout), to.UTC().Format(sqliteTimeLayout), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []models.BookingReminder
+	for rows.Next() {
+		var rem models.BookingReminder
+		var startsAt string
+		dest := append([]interface{}{&rem.BookingID}, recipientFields(&rem.User)...)
+		if err := rows.Scan(append(dest, &rem.ClassTitle, &rem.StartTime, &startsAt)...); err != nil {
+			return nil, err
+		}
+		if rem.StartsAt, err = time.Parse(sqliteTimeLayout, startsAt); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, rem)
+	}
+	return reminders, rows.Err()
+}
+
+// MarkReminded запоминает, о каком времени занятия напомнили
+func (r *BookingRepository) MarkReminded(ctx context.Context, bookingID int, startTime string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE bookings SET reminded_for = ? WHERE id = ?`, startTime, bookingID)
 	return err
 }
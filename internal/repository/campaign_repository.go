@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type CampaignRepository struct {
+	db DBTX
+}
+
+func NewCampaignRepository(db *sql.DB) *CampaignRepository {
+	return &CampaignRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *CampaignRepository) WithTx(tx *sql.Tx) CampaignStore {
+	if tx == nil {
+		return r
+	}
+	return &CampaignRepository{db: tx}
+}
+
+const campaignColumns = `id, name, title, body, segment, status, COALESCE(scheduled_at, ''), rate_per_minute, COALESCE(created_by, 0), created_at, COALESCE(started_at, ''), COALESCE(finished_at, '')`
+
+func scanCampaign(row rowScanner, c *models.Campaign) error {
+	var segment string
+	if err := row.Scan(&c.ID, &c.Name, &c.Title, &c.Body, &segment, &c.Status, &c.ScheduledAt, &c.RatePerMinute, &c.CreatedBy,
+		&c.CreatedAt, &c.StartedAt, &c.FinishedAt); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(segment), &c.Segment)
+}
+
+func scanCampaigns(rows *sql.Rows) ([]models.Campaign, error) {
+	defer rows.Close()
+
+	var campaigns []models.Campaign
+	for rows.Next() {
+		var c models.Campaign
+		if err := scanCampaign(rows, &c); err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, c)
+	}
+	return campaigns, rows.Err()
+}
+
+// inList возвращает "(?, ?, ...)" и аргументы для условия IN
+func inList[T any](values []T) (string, []interface{}) {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ") + ")", args
+}
+
+// segmentWhere строит условие отбора пользователей u по сегменту.
+// Действующий абонемент — активный и не закончившийся к today (YYYY-MM-DD).
+func segmentWhere(seg models.Segment, today string) (string, []interface{}) {
+	conds := []string{"1 = 1"}
+	var args []interface{}
+
+	if len(seg.GymIDs) > 0 {
+		in, gyms := inList(seg.GymIDs)
+		conds = append(conds, `(EXISTS (SELECT 1 FROM bookings b JOIN classes c ON c.id = b.class_id WHERE b.user_id = u.id AND c.gym_id IN `+in+`)
+			OR EXISTS (SELECT 1 FROM payments p WHERE p.user_id = u.id AND p.gym_id IN `+in+`))`)
+		args = append(args, gyms...)
+		args = append(args, gyms...)
+	}
+
+	active := `SELECT 1 FROM user_memberships um WHERE um.user_id = u.id AND um.active = 1 AND date(um.end_date) >= date(?)`
+	activeArgs := []interface{}{today}
+	filtered := false
+	if len(seg.MembershipIDs) > 0 {
+		in, ids := inList(seg.MembershipIDs)
+		active += ` AND um.membership_id IN ` + in
+		activeArgs = append(activeArgs, ids...)
+		filtered = true
+	}
+	if seg.MembershipExpiresFrom != "" {
+		active += ` AND date(um.end_date) >= date(?)`
+		activeArgs = append(activeArgs, seg.MembershipExpiresFrom)
+		filtered = true
+	}
+	if seg.MembershipExpiresTo != "" {
+		active += ` AND date(um.end_date) <= date(?)`
+		activeArgs = append(activeArgs, seg.MembershipExpiresTo)
+		filtered = true
+	}
+	switch {
+	case filtered || (seg.ActiveMembership != nil && *seg.ActiveMembership):
+		conds = append(conds, `EXISTS (`+active+`)`)
+		args = append(args, activeArgs...)
+	case seg.ActiveMembership != nil:
+		conds = append(conds, `NOT EXISTS (`+active+`)`)
+		args = append(args, activeArgs...)
+	}
+
+	if seg.BookedClassTitle != "" || len(seg.BookedClassIDs) > 0 {
+		booked := `SELECT 1 FROM bookings b JOIN classes c ON c.id = b.class_id WHERE b.user_id = u.id`
+		if seg.BookedClassTitle != "" {
+			booked += ` AND c.title LIKE ?`
+			args = append(args, "%"+seg.BookedClassTitle+"%")
+		}
+		if len(seg.BookedClassIDs) > 0 {
+			in, ids := inList(seg.BookedClassIDs)
+			booked += ` AND c.id IN ` + in
+			args = append(args, ids...)
+		}
+		conds = append(conds, `EXISTS (`+booked+`)`)
+	}
+
+	if len(seg.Locales) > 0 {
+		in, locales := inList(seg.Locales)
+		conds = append(conds, `u.locale IN `+in)
+		args = append(args, locales...)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// PreviewSegment возвращает число пользователей в сегменте и первых limit из них
+func (r *CampaignRepository) PreviewSegment(ctx context.Context, seg models.Segment, today string, limit int) (*models.SegmentPreview, error) {
+	where, args := segmentWhere(seg, today)
+
+	preview := &models.SegmentPreview{Sample: []models.User{}}
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users u WHERE `+where, args...).Scan(&preview.Count); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+recipientColumns+` FROM users u WHERE `+where+` ORDER BY u.id LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(recipientFields(&u)...); err != nil {
+			return nil, err
+		}
+		preview.Sample = append(preview.Sample, u)
+	}
+	return preview, rows.Err()
+}
+
+func (r *CampaignRepository) Create(ctx context.Context, c *models.Campaign) (*models.Campaign, error) {
+	segment, err := json.Marshal(c.Segment)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO campaigns (name, title, body, segment, status, scheduled_at, rate_per_minute, created_by)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), ?, NULLIF(?, 0))`,
+		c.Name, c.Title, c.Body, string(segment), c.Status, c.ScheduledAt, c.RatePerMinute, c.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res.LastInsertId()
+	return r.GetByID(ctx, int(id))
+}
+
+func (r *CampaignRepository) GetByID(ctx context.Context, id int) (*models.Campaign, error) {
+	c := &models.Campaign{}
+	if err := scanCampaign(r.db.QueryRowContext(ctx, `SELECT `+campaignColumns+` FROM campaigns WHERE id = ?`, id), c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// List возвращает кампании, новые первыми; status фильтрует по статусу
+func (r *CampaignRepository) List(ctx context.Context, status string) ([]models.Campaign, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+campaignColumns+` FROM campaigns
+		WHERE ? = '' OR status = ?
+		ORDER BY id DESC`, status, status)
+	if err != nil {
+		return nil, err
+	}
+	return scanCampaigns(rows)
+}
+
+// SetStatus переводит кампанию в status, только если она сейчас в одном из from
+func (r *CampaignRepository) SetStatus(ctx context.Context, id int, status, scheduledAt string, from ...string) (bool, error) {
+	in, args := inList(from)
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE campaigns SET status = ?, scheduled_at = COALESCE(NULLIF(?, ''), scheduled_at)
+		WHERE id = ? AND status IN `+in,
+		append([]interface{}{status, scheduledAt, id}, args...)...)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListDue возвращает запланированные кампании, время которых наступило
+func (r *CampaignRepository) ListDue(ctx context.Context, now time.Time) ([]models.Campaign, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+campaignColumns+` FROM campaigns
+		WHERE status = 'scheduled' AND datetime(scheduled_at) <= datetime(?)
+		ORDER BY scheduled_at, id`, now.UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return nil, err
+	}
+	return scanCampaigns(rows)
+}
+
+// Start фиксирует получателей по сегменту и переводит кампанию в sending.
+// Возвращает число получателей; false — кампанию уже запустили или отменили.
+func (r *CampaignRepository) Start(ctx context.Context, c *models.Campaign, today string, now time.Time) (int, bool, error) {
+	var recipients int64
+	started := false
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE campaigns SET status = 'sending', started_at = ?
+			WHERE id = ? AND status = 'scheduled'`, now.UTC().Format(sqliteTimeLayout), c.ID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil
+		}
+
+		where, args := segmentWhere(c.Segment, today)
+		res, err = tx.ExecContext(ctx, `
+			INSERT INTO campaign_recipients (campaign_id, user_id)
+			SELECT ?, u.id FROM users u WHERE `+where, append([]interface{}{c.ID}, args...)...)
+		if err != nil {
+			return err
+		}
+		recipients, _ = res.RowsAffected()
+		started = true
+		return nil
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return int(recipients), started, nil
+}
+
+// PendingRecipients возвращает до limit получателей, которым письмо ещё не поставлено в очередь
+func (r *CampaignRepository) PendingRecipients(ctx context.Context, campaignID, limit int) ([]models.User, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+recipientColumns+`
+		FROM campaign_recipients cr JOIN users u ON u.id = cr.user_id
+		WHERE cr.campaign_id = ? AND cr.status = 'pending'
+		ORDER BY u.id
+		LIMIT ?`, campaignID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(recipientFields(&u)...); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// MarkRecipient отмечает, что письмо получателю поставлено в очередь (queued)
+// или не отправляется (skipped)
+func (r *CampaignRepository) MarkRecipient(ctx context.Context, campaignID, userID int, status string, notificationID int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE campaign_recipients SET status = ?, notification_id = NULLIF(?, 0)
+		WHERE campaign_id = ? AND user_id = ?`, status, notificationID, campaignID, userID)
+	return err
+}
+
+// Finish переводит кампанию в sent, когда не осталось ожидающих получателей
+func (r *CampaignRepository) Finish(ctx context.Context, campaignID int, now time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE campaigns SET status = 'sent', finished_at = ?
+		WHERE id = ? AND status = 'sending'
+		  AND NOT EXISTS (SELECT 1 FROM campaign_recipients WHERE campaign_id = ? AND status = 'pending')`,
+		now.UTC().Format(sqliteTimeLayout), campaignID, campaignID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListSending возвращает кампании, которые сейчас рассылаются
+func (r *CampaignRepository) ListSending(ctx context.Context) ([]models.Campaign, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+campaignColumns+` FROM campaigns WHERE status = 'sending' ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanCampaigns(rows)
+}
+
+// Stats считает получателей кампании по судьбе их писем в очереди уведомлений
+func (r *CampaignRepository) Stats(ctx context.Context, campaignID int) (*models.CampaignStats, error) {
+	s := &models.CampaignStats{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+			COALESCE(SUM(cr.status = 'pending'), 0),
+			COALESCE(SUM(cr.status = 'skipped'), 0),
+			COALESCE(SUM(o.status IN ('pending', 'sending')), 0),
+			COALESCE(SUM(o.status = 'sent'), 0),
+			COALESCE(SUM(o.status = 'dead'), 0),
+			COALESCE(SUM(cr.opened_at IS NOT NULL), 0)
+		FROM campaign_recipients cr
+		LEFT JOIN notification_outbox o ON o.id = cr.notification_id
+		WHERE cr.campaign_id = ?`, campaignID).
+		Scan(&s.Recipients, &s.Pending, &s.Skipped, &s.Queued, &s.Sent, &s.Failed, &s.Opened)
+	return s, err
+}
+
+// MarkOpened запоминает первое открытие письма; false — такого получателя нет
+func (r *CampaignRepository) MarkOpened(ctx context.Context, campaignID, userID int, now time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE campaign_recipients SET opened_at = COALESCE(opened_at, ?)
+		WHERE campaign_id = ? AND user_id = ?`, now.UTC().Format(sqliteTimeLayout), campaignID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
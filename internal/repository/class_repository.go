@@ -2,19 +2,28 @@ package repository
 
 import (
 	"Gym_StrongCode/internal/models"
+	"context"
 	"database/sql"
 )
 
 type ClassRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewClassRepository(db *sql.DB) *ClassRepository {
 	return &ClassRepository{db: db}
 }
 
-func (r *ClassRepository) Create(c *models.Class) (*models.Class, error) {
-	res, err := r.db.Exec(`
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *ClassRepository) WithTx(tx *sql.Tx) ClassStore {
+	if tx == nil {
+		return r
+	}
+	return &ClassRepository{db: tx}
+}
+
+func (r *ClassRepository) Create(ctx context.Context, c *models.Class) (*models.Class, error) {
+	res, err := r.db.ExecContext(ctx, `
 		INSERT INTO classes (title, description, trainer_id, gym_id, start_time, duration_min, capacity)
 		VALUES (?, ?, ?, ?, ?, ?, ?)`,
 		c.Title, c.Description, c.TrainerID, c.GymID, c.StartTime, c.DurationMin, c.Capacity)
@@ -22,20 +31,20 @@ func (r *ClassRepository) Create(c *models.Class) (*models.Class, error) {
 		return nil, err
 	}
 	id, _ := res.LastInsertId()
-	return r.GetByID(int(id))
+	return r.GetByID(ctx, int(id))
 }
 
-func (r *ClassRepository) GetByID(id int) (*models.Class, error) {
+func (r *ClassRepository) GetByID(ctx context.Context, id int) (*models.Class, error) {
 	c := &models.Class{}
-	err := r.db.QueryRow(`
+	err := r.db.QueryRowContext(ctx, `
 		SELECT id, title, description, trainer_id, gym_id, start_time, duration_min, capacity, created_at
 		FROM classes WHERE id = ?`, id).
 		Scan(&c.ID, &c.Title, &c.Description, &c.TrainerID, &c.GymID, &c.StartTime, &c.DurationMin, &c.Capacity, &c.CreatedAt)
 	return c, err
 }
 
-func (r *ClassRepository) List() ([]models.Class, error) {
-	rows, err := r.db.Query(`
+func (r *ClassRepository) List(ctx context.Context) ([]models.Class, error) {
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT id, title, description, trainer_id, gym_id, start_time, duration_min, capacity, created_at
 		FROM classes ORDER BY start_time`)
 	if err != nil {
@@ -54,21 +63,21 @@ func (r *ClassRepository) List() ([]models.Class, error) {
 	return classes, nil
 }
 
-func (r *ClassRepository) Update(id int, c *models.Class) error {
-	_, err := r.db.Exec(`
+func (r *ClassRepository) Update(ctx context.Context, id int, c *models.Class) error {
+	_, err := r.db.ExecContext(ctx, `
 		UPDATE classes SET title = ?, description = ?, trainer_id = ?, gym_id = ?, start_time = ?, duration_min = ?, capacity = ?
 		WHERE id = ?`,
 		c.Title, c.Description, c.TrainerID, c.GymID, c.StartTime, c.DurationMin, c.Capacity, id)
 	return err
 }
 
-func (r *ClassRepository) Delete(id int) error {
-	_, err := r.db.Exec(`DELETE FROM classes WHERE id = ?`, id)
+func (r *ClassRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM classes WHERE id = ?`, id)
 	return err
 }
 
-func (r *ClassRepository) GetBookingCount(classID int) (int, error) {
+func (r *ClassRepository) GetBookingCount(ctx context.Context, classID int) (int, error) {
 	var count int
-	err := r.db.QueryRow(`SELECT COUNT(*) FROM bookings WHERE class_id = ?`, classID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM bookings WHERE class_id = ?`, classID).Scan(&count)
 	return count, err
 }
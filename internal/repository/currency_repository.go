@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+)
+
+type CurrencyRepository struct {
+	db DBTX
+}
+
+func NewCurrencyRepository(db *sql.DB) *CurrencyRepository {
+	return &CurrencyRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *CurrencyRepository) WithTx(tx *sql.Tx) CurrencyStore {
+	if tx == nil {
+		return r
+	}
+	return &CurrencyRepository{db: tx}
+}
+
+func (r *CurrencyRepository) ListRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT currency, rate, updated_at FROM exchange_rates ORDER BY currency`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.ExchangeRate
+	for rows.Next() {
+		var er models.ExchangeRate
+		if err := rows.Scan(&er.Currency, &er.Rate, &er.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, er)
+	}
+	return rates, nil
+}
+
+func (r *CurrencyRepository) GetRate(ctx context.Context, currency string) (*models.ExchangeRate, error) {
+	er := &models.ExchangeRate{}
+	err := r.db.QueryRowContext(ctx, `SELECT currency, rate, updated_at FROM exchange_rates WHERE currency = ?`, currency).
+		Scan(&er.Currency, &er.Rate, &er.UpdatedAt)
+	return er, err
+}
+
+func (r *CurrencyRepository) SetRate(ctx context.Context, currency string, rate float64) (*models.ExchangeRate, error) {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO exchange_rates (currency, rate) VALUES (?, ?)
+		ON CONFLICT(currency) DO UPDATE SET rate = excluded.rate, updated_at = CURRENT_TIMESTAMP`,
+		currency, rate)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetRate(ctx, currency)
+}
+
+func (r *CurrencyRepository) DeleteRate(ctx context.Context, currency string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM exchange_rates WHERE currency = ?`, currency)
+	return err
+}
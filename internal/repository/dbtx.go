@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX — то, что репозиториям нужно от базы: его реализуют и *sql.DB, и *sql.Tx,
+// поэтому один и тот же репозиторий работает и сам по себе, и внутри транзакции (WithTx)
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// inTx выполняет fn в транзакции. Если репозиторий уже привязан к транзакции,
+// fn выполняется в ней, а фиксирует её тот, кто её открыл.
+func inTx(ctx context.Context, db DBTX, fn func(tx DBTX) error) error {
+	conn, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
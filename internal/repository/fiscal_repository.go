@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Формат CURRENT_TIMESTAMP в SQLite (UTC)
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+type FiscalRepository struct {
+	db DBTX
+}
+
+func NewFiscalRepository(db *sql.DB) *FiscalRepository {
+	return &FiscalRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *FiscalRepository) WithTx(tx *sql.Tx) FiscalStore {
+	if tx == nil {
+		return r
+	}
+	return &FiscalRepository{db: tx}
+}
+
+// Enqueue ставит платёж в очередь на регистрацию чека; повторная постановка игнорируется
+func (r *FiscalRepository) Enqueue(ctx context.Context, paymentID int) error {
+	_, err := r.db.ExecContext(ctx, `INSERT OR IGNORE INTO fiscal_outbox (payment_id) VALUES (?)`, paymentID)
+	return err
+}
+
+func (r *FiscalRepository) GetByID(ctx context.Context, id int) (*models.FiscalOutboxEntry, error) {
+	e := &models.FiscalOutboxEntry{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, payment_id, status, attempts, next_attempt_at, COALESCE(last_error, ''), created_at, updated_at
+		FROM fiscal_outbox WHERE id = ?`, id).
+		Scan(&e.ID, &e.PaymentID, &e.Status, &e.Attempts, &e.NextAttemptAt, &e.LastError, &e.CreatedAt, &e.UpdatedAt)
+	return e, err
+}
+
+// ClaimDue забирает до limit чеков, время попытки которых наступило, и помечает их sending до now+lease.
+// Чеки, зависшие в sending дольше lease, забираются повторно.
+func (r *FiscalRepository) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.FiscalOutboxEntry, error) {
+	nowStr := now.UTC().Format(sqliteTimeLayout)
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, payment_id, status, attempts, next_attempt_at, COALESCE(last_error, ''), created_at, updated_at
+		FROM fiscal_outbox
+		WHERE (status = 'pending' AND next_attempt_at <= ?) OR (status = 'sending' AND locked_until <= ?)
+		ORDER BY next_attempt_at, id
+		LIMIT ?`, nowStr, nowStr, limit)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := scanFiscalEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Чек достаётся тому, чей UPDATE его изменил, — так несколько экземпляров не зарегистрируют его дважды
+	lockedUntil := now.Add(lease).UTC().Format(sqliteTimeLayout)
+	var claimed []models.FiscalOutboxEntry
+	for _, e := range candidates {
+		res, err := r.db.ExecContext(ctx, `
+			UPDATE fiscal_outbox SET status = 'sending', locked_until = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND status = ? AND (status = 'pending' OR locked_until <= ?)`,
+			lockedUntil, e.ID, e.Status, nowStr)
+		if err != nil {
+			return claimed, err
+		}
+		if affected, _ := res.RowsAffected(); affected == 1 {
+			e.Status = "sending"
+			claimed = append(claimed, e)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *FiscalRepository) List(ctx context.Context, status string) ([]models.FiscalOutboxEntry, error) {
+	query := `SELECT id, payment_id, status, attempts, next_attempt_at, COALESCE(last_error, ''), created_at, updated_at
+		FROM fiscal_outbox`
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanFiscalEntries(rows)
+}
+
+func scanFiscalEntries(rows *sql.Rows) ([]models.FiscalOutboxEntry, error) {
+	defer rows.Close()
+
+	var entries []models.FiscalOutboxEntry
+	for rows.Next() {
+		var e models.FiscalOutboxEntry
+		if err := rows.Scan(&e.ID, &e.PaymentID, &e.Status, &e.Attempts, &e.NextAttemptAt, &e.LastError, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (r *FiscalRepository) MarkSent(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE fiscal_outbox SET status = 'sent', attempts = attempts + 1, last_error = NULL, locked_until = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, id)
+	return err
+}
+
+// MarkFailed фиксирует неудачную попытку; status = pending для повтора в nextAttempt или failed
+func (r *FiscalRepository) MarkFailed(ctx context.Context, id int, status, lastError string, nextAttempt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE fiscal_outbox
+		SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = ?, locked_until = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, status, lastError, nextAttempt.UTC().Format(sqliteTimeLayout), id)
+	return err
+}
+
+// Retry возвращает запись в очередь для немедленной повторной отправки; взятые воркером не трогает
+func (r *FiscalRepository) Retry(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE fiscal_outbox SET status = 'pending', next_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status IN ('pending', 'failed')`, id)
+	return err
+}
@@ -2,35 +2,44 @@ package repository
 
 import (
 	"Gym_StrongCode/internal/models"
+	"context"
 	"database/sql"
 )
 
 type GymRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewGymRepository(db *sql.DB) *GymRepository {
 	return &GymRepository{db: db}
 }
 
-func (r *GymRepository) Create(name, address string) (*models.Gym, error) {
-	res, err := r.db.Exec(`INSERT INTO gyms (name, address) VALUES (?, ?)`, name, address)
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *GymRepository) WithTx(tx *sql.Tx) GymStore {
+	if tx == nil {
+		return r
+	}
+	return &GymRepository{db: tx}
+}
+
+func (r *GymRepository) Create(ctx context.Context, name, address string) (*models.Gym, error) {
+	res, err := r.db.ExecContext(ctx, `INSERT INTO gyms (name, address) VALUES (?, ?)`, name, address)
 	if err != nil {
 		return nil, err
 	}
 	id, _ := res.LastInsertId()
-	return r.GetByID(int(id))
+	return r.GetByID(ctx, int(id))
 }
 
-func (r *GymRepository) GetByID(id int) (*models.Gym, error) {
+func (r *GymRepository) GetByID(ctx context.Context, id int) (*models.Gym, error) {
 	g := &models.Gym{}
-	err := r.db.QueryRow(`SELECT id, name, address, created_at FROM gyms WHERE id = ?`, id).
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, address, created_at FROM gyms WHERE id = ?`, id).
 		Scan(&g.ID, &g.Name, &g.Address, &g.CreatedAt)
 	return g, err
 }
 
-func (r *GymRepository) List() ([]models.Gym, error) {
-	rows, err := r.db.Query(`SELECT id, name, address, created_at FROM gyms`)
+func (r *GymRepository) List(ctx context.Context) ([]models.Gym, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, address, created_at FROM gyms`)
 	if err != nil {
 		return nil, err
 	}
@@ -47,12 +56,12 @@ func (r *GymRepository) List() ([]models.Gym, error) {
 	return gyms, nil
 }
 
-func (r *GymRepository) Update(id int, name, address string) error {
-	_, err := r.db.Exec(`UPDATE gyms SET name = ?, address = ? WHERE id = ?`, name, address, id)
+func (r *GymRepository) Update(ctx context.Context, id int, name, address string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE gyms SET name = ?, address = ? WHERE id = ?`, name, address, id)
 	return err
 }
 
-func (r *GymRepository) Delete(id int) error {
-	_, err := r.db.Exec(`DELETE FROM gyms WHERE id = ?`, id)
+func (r *GymRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM gyms WHERE id = ?`, id)
 	return err
 }
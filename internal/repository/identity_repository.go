@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// IdentityRepository — привязки внешних аккаунтов OpenID Connect к пользователям
+type IdentityRepository struct {
+	db DBTX
+}
+
+func NewIdentityRepository(db *sql.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *IdentityRepository) WithTx(tx *sql.Tx) IdentityStore {
+	if tx == nil {
+		return r
+	}
+	return &IdentityRepository{db: tx}
+}
+
+// FindUserID возвращает пользователя, к которому привязан аккаунт провайдера; sql.ErrNoRows, если привязки нет
+func (r *IdentityRepository) FindUserID(ctx context.Context, provider, subject string) (int, error) {
+	var userID int
+	err := r.db.QueryRowContext(ctx, `SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?`, provider, subject).Scan(&userID)
+	return userID, err
+}
+
+func (r *IdentityRepository) Create(ctx context.Context, userID int, provider, subject, email string) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO user_identities (user_id, provider, subject, email) VALUES (?, ?, ?, ?)`, userID, provider, subject, email)
+	return err
+}
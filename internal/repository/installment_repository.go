@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+)
+
+const installmentPlanColumns = `id, user_id, membership_id, user_membership_id, total_cents, currency, method, gym_id,
+		installments_count, status, created_at`
+
+const installmentColumns = `id, plan_id, seq, amount_cents, due_date, status, attempts, next_attempt_date,
+		payment_id, COALESCE(last_error, ''), paid_at`
+
+type InstallmentRepository struct {
+	db DBTX
+}
+
+func NewInstallmentRepository(db *sql.DB) *InstallmentRepository {
+	return &InstallmentRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *InstallmentRepository) WithTx(tx *sql.Tx) InstallmentStore {
+	if tx == nil {
+		return r
+	}
+	return &InstallmentRepository{db: tx}
+}
+
+func scanInstallmentPlan(row rowScanner, p *models.InstallmentPlan) error {
+	return row.Scan(&p.ID, &p.UserID, &p.MembershipID, &p.UserMembershipID, &p.TotalCents, &p.Currency, &p.Method, &p.GymID,
+		&p.InstallmentsCount, &p.Status, &p.CreatedAt)
+}
+
+func scanInstallment(row rowScanner, i *models.Installment) error {
+	return row.Scan(&i.ID, &i.PlanID, &i.Seq, &i.AmountCents, &i.DueDate, &i.Status, &i.Attempts, &i.NextAttemptDate,
+		&i.PaymentID, &i.LastError, &i.PaidAt)
+}
+
+// CreatePlan сохраняет план вместе с графиком платежей в одной транзакции
+func (r *InstallmentRepository) CreatePlan(ctx context.Context, plan *models.InstallmentPlan) (int, error) {
+	var id int64
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO installment_plans (user_id, membership_id, total_cents, currency, method, gym_id, installments_count, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			plan.UserID, plan.MembershipID, plan.TotalCents, plan.Currency, plan.Method, plan.GymID, plan.InstallmentsCount, plan.Status)
+		if err != nil {
+			return err
+		}
+		id, _ = res.LastInsertId()
+
+		for _, i := range plan.Installments {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO installments (plan_id, seq, amount_cents, due_date, status, attempts, next_attempt_date, payment_id, paid_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				id, i.Seq, i.AmountCents, i.DueDate, i.Status, i.Attempts, i.NextAttemptDate, i.PaymentID, i.PaidAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return int(id), err
+}
+
+func (r *InstallmentRepository) GetPlan(ctx context.Context, id int) (*models.InstallmentPlan, error) {
+	p := &models.InstallmentPlan{}
+	if err := scanInstallmentPlan(r.db.QueryRowContext(ctx, `SELECT `+installmentPlanColumns+` FROM installment_plans WHERE id = ?`, id), p); err != nil {
+		return nil, err
+	}
+
+	installments, err := r.ListInstallments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	p.Installments = installments
+	return p, nil
+}
+
+// ListPlans возвращает планы без графика; userID = 0 — планы всех пользователей
+func (r *InstallmentRepository) ListPlans(ctx context.Context, userID int, status string) ([]models.InstallmentPlan, error) {
+	query := `SELECT ` + installmentPlanColumns + ` FROM installment_plans WHERE 1 = 1`
+	var args []interface{}
+	if userID != 0 {
+		query += " AND user_id = ?"
+		args = append(args, userID)
+	}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plans []models.InstallmentPlan
+	for rows.Next() {
+		var p models.InstallmentPlan
+		if err := scanInstallmentPlan(rows, &p); err != nil {
+			return nil, err
+		}
+		plans = append(plans, p)
+	}
+	return plans, nil
+}
+
+func (r *InstallmentRepository) ListInstallments(ctx context.Context, planID int) ([]models.Installment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+installmentColumns+` FROM installments WHERE plan_id = ? ORDER BY seq`, planID)
+	if err != nil {
+		return nil, err
+	}
+	return scanInstallments(rows)
+}
+
+// ListDue возвращает неоплаченные платежи, попытка списания которых назначена на today или раньше.
+// Платежи отменённых и завершённых планов не списываются.
+func (r *InstallmentRepository) ListDue(ctx context.Context, today string) ([]models.Installment, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT i.id, i.plan_id, i.seq, i.amount_cents, i.due_date, i.status, i.attempts, i.next_attempt_date,
+			i.payment_id, COALESCE(i.last_error, ''), i.paid_at
+		FROM installments i
+		JOIN installment_plans p ON p.id = i.plan_id
+		WHERE i.status = 'pending' AND i.next_attempt_date <= ? AND p.status IN ('active', 'suspended')
+		ORDER BY i.next_attempt_date, i.id`, today)
+	if err != nil {
+		return nil, err
+	}
+	return scanInstallments(rows)
+}
+
+func scanInstallments(rows *sql.Rows) ([]models.Installment, error) {
+	defer rows.Close()
+
+	var installments []models.Installment
+	for rows.Next() {
+		var i models.Installment
+		if err := scanInstallment(rows, &i); err != nil {
+			return nil, err
+		}
+		installments = append(installments, i)
+	}
+	return installments, nil
+}
+
+// ListOverduePlanIDs возвращает активные планы с неоплаченным платежом, срок которого раньше cutoff
+func (r *InstallmentRepository) ListOverduePlanIDs(ctx context.Context, cutoff string) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT p.id
+		FROM installment_plans p
+		JOIN installments i ON i.plan_id = p.id
+		WHERE p.status = 'active' AND i.status = 'pending' AND i.due_date < ?`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// CountPending считает неоплаченные платежи плана и те из них, срок которых раньше cutoff
+func (r *InstallmentRepository) CountPending(ctx context.Context, planID int, cutoff string) (pending, overdue int, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN due_date < ? THEN 1 ELSE 0 END), 0)
+		FROM installments WHERE plan_id = ? AND status = 'pending'`, cutoff, planID).
+		Scan(&pending, &overdue)
+	return pending, overdue, err
+}
+
+func (r *InstallmentRepository) MarkPaid(ctx context.Context, id, paymentID int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE installments SET status = 'paid', attempts = attempts + 1, payment_id = ?, last_error = NULL, paid_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, paymentID, id)
+	return err
+}
+
+// MarkAttemptFailed фиксирует неудачное списание и переносит попытку на nextAttemptDate
+func (r *InstallmentRepository) MarkAttemptFailed(ctx context.Context, id int, lastError, nextAttemptDate string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE installments SET attempts = attempts + 1, last_error = ?, next_attempt_date = ?
+		WHERE id = ?`, lastError, nextAttemptDate, id)
+	return err
+}
+
+func (r *InstallmentRepository) SetPlanStatus(ctx context.Context, id int, status string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE installment_plans SET status = ? WHERE id = ?`, status, id)
+	return err
+}
+
+func (r *InstallmentRepository) SetUserMembership(ctx context.Context, planID, userMembershipID int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE installment_plans SET user_membership_id = ? WHERE id = ?`, userMembershipID, planID)
+	return err
+}
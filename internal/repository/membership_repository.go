@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -8,15 +9,23 @@ import (
 )
 
 type MembershipRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewMembershipRepository(db *sql.DB) *MembershipRepository {
 	return &MembershipRepository{db: db}
 }
 
-func (r *MembershipRepository) GetAll() ([]models.Membership, error) {
-	rows, err := r.db.Query(`SELECT id, name, duration_days, price_cents, created_at FROM memberships`)
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *MembershipRepository) WithTx(tx *sql.Tx) MembershipStore {
+	if tx == nil {
+		return r
+	}
+	return &MembershipRepository{db: tx}
+}
+
+func (r *MembershipRepository) GetAll(ctx context.Context) ([]models.Membership, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, duration_days, price_cents, created_at FROM memberships`)
 	if err != nil {
 		return nil, err
 	}
@@ -33,49 +42,121 @@ func (r *MembershipRepository) GetAll() ([]models.Membership, error) {
 	return list, nil
 }
 
-func (r *MembershipRepository) GetByID(id int) (*models.Membership, error) {
+func (r *MembershipRepository) GetByID(ctx context.Context, id int) (*models.Membership, error) {
 	m := &models.Membership{}
-	err := r.db.QueryRow(`SELECT id, name, duration_days, price_cents FROM memberships WHERE id = ?`, id).
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, duration_days, price_cents FROM memberships WHERE id = ?`, id).
 		Scan(&m.ID, &m.Name, &m.DurationDays, &m.PriceCents)
 	return m, err
 }
 
-func (r *MembershipRepository) Create(name string, durationDays, priceCents int) (*models.Membership, error) {
-	res, err := r.db.Exec(`INSERT INTO memberships (name, duration_days, price_cents) VALUES (?, ?, ?)`,
+func (r *MembershipRepository) Create(ctx context.Context, name string, durationDays, priceCents int) (*models.Membership, error) {
+	res, err := r.db.ExecContext(ctx, `INSERT INTO memberships (name, duration_days, price_cents) VALUES (?, ?, ?)`,
 		name, durationDays, priceCents)
 	if err != nil {
 		return nil, err
 	}
 	id, _ := res.LastInsertId()
-	return r.GetByID(int(id))
+	return r.GetByID(ctx, int(id))
 }
 
-func (r *MembershipRepository) Update(id int, name string, durationDays, priceCents int) error {
-	_, err := r.db.Exec(`UPDATE memberships SET name = ?, duration_days = ?, price_cents = ? WHERE id = ?`,
+func (r *MembershipRepository) Update(ctx context.Context, id int, name string, durationDays, priceCents int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE memberships SET name = ?, duration_days = ?, price_cents = ? WHERE id = ?`,
 		name, durationDays, priceCents, id)
 	return err
 }
 
-func (r *MembershipRepository) Delete(id int) error {
-	_, err := r.db.Exec(`DELETE FROM memberships WHERE id = ?`, id)
+func (r *MembershipRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM memberships WHERE id = ?`, id)
 	return err
 }
 
-func (r *MembershipRepository) HasActiveMembership(userID int) (bool, error) {
+func (r *MembershipRepository) HasActiveMembership(ctx context.Context, userID int) (bool, error) {
 	var count int
 	current := time.Now().Format("2006-01-02")
-	err := r.db.QueryRow(`
+	err := r.db.QueryRowContext(ctx, `
 		SELECT COUNT(*) FROM user_memberships 
 		WHERE user_id = ? AND active = 1 AND end_date >= ?`, userID, current).
 		Scan(&count)
 	return count > 0, err
 }
 
-func (r *MembershipRepository) Activate(userID, membershipID int, durationDays int) error {
+// Activate активирует подписку с сегодняшнего дня и возвращает её запись с датами действия
+func (r *MembershipRepository) Activate(ctx context.Context, userID, membershipID int, durationDays int) (*models.UserMembership, error) {
 	start := time.Now()
-	end := start.AddDate(0, 0, durationDays)
-	_, err := r.db.Exec(`
+	um := &models.UserMembership{
+		UserID:       userID,
+		MembershipID: membershipID,
+		StartDate:    start.Format("2006-01-02"),
+		EndDate:      start.AddDate(0, 0, durationDays).Format("2006-01-02"),
+		Active:       true,
+	}
+	res, err := r.db.ExecContext(ctx, `
 		INSERT INTO user_memberships (user_id, membership_id, start_date, end_date, active)
-		VALUES (?, ?, ?, ?, 1)`, userID, membershipID, start.Format("2006-01-02"), end.Format("2006-01-02"))
+		VALUES (?, ?, ?, ?, 1)`, userID, membershipID, um.StartDate, um.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res.LastInsertId()
+	um.ID = int(id)
+	return um, nil
+}
+
+// ActivateWithID активирует подписку и возвращает id записи user_memberships
+func (r *MembershipRepository) ActivateWithID(ctx context.Context, userID, membershipID int, durationDays int) (int, error) {
+	um, err := r.Activate(ctx, userID, membershipID, durationDays)
+	if err != nil {
+		return 0, err
+	}
+	return um.ID, nil
+}
+
+// SetActive приостанавливает или возобновляет подписку пользователя
+func (r *MembershipRepository) SetActive(ctx context.Context, userMembershipID int, active bool) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE user_memberships SET active = ? WHERE id = ?`, active, userMembershipID)
+	return err
+}
+
+func (r *MembershipRepository) ListPrices(ctx context.Context, membershipID int) ([]models.MembershipPrice, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, membership_id, currency, price_cents, created_at
+		FROM membership_prices WHERE membership_id = ? ORDER BY currency`, membershipID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []models.MembershipPrice
+	for rows.Next() {
+		var p models.MembershipPrice
+		if err := rows.Scan(&p.ID, &p.MembershipID, &p.Currency, &p.PriceCents, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+	return prices, nil
+}
+
+func (r *MembershipRepository) GetPrice(ctx context.Context, membershipID int, currency string) (*models.MembershipPrice, error) {
+	p := &models.MembershipPrice{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, membership_id, currency, price_cents, created_at
+		FROM membership_prices WHERE membership_id = ? AND currency = ?`, membershipID, currency).
+		Scan(&p.ID, &p.MembershipID, &p.Currency, &p.PriceCents, &p.CreatedAt)
+	return p, err
+}
+
+func (r *MembershipRepository) SetPrice(ctx context.Context, membershipID int, currency string, priceCents int) (*models.MembershipPrice, error) {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO membership_prices (membership_id, currency, price_cents) VALUES (?, ?, ?)
+		ON CONFLICT(membership_id, currency) DO UPDATE SET price_cents = excluded.price_cents`,
+		membershipID, currency, priceCents)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetPrice(ctx, membershipID, currency)
+}
+
+func (r *MembershipRepository) DeletePrice(ctx context.Context, membershipID int, currency string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM membership_prices WHERE membership_id = ? AND currency = ?`, membershipID, currency)
 	return err
 }
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+// Не чаще этого обновлять last_used_at — как в SQLite-репозитории
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *APIKeyRepository) WithTx(tx *sql.Tx) repository.APIKeyStore {
+	return &APIKeyRepository{db: r.db, tx: tx}
+}
+
+// apiKey возвращает копию ключа, чтобы вызывающий не менял хранимые scopes
+func apiKey(key models.APIKey) *models.APIKey {
+	key.Scopes = append([]string(nil), key.Scopes...)
+	return &key
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, name, prefix, keyHash string, scopes []string, gymID *int, createdBy int, expiresAt *time.Time) (*models.APIKey, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	for _, k := range r.db.t.apiKeys.rows {
+		if k.Prefix == prefix {
+			return nil, errUnique("api_keys.prefix")
+		}
+	}
+	var expires *time.Time
+	if expiresAt != nil {
+		t := truncTime(*expiresAt)
+		expires = &t
+	}
+	id := r.db.t.apiKeys.insert(func(id int) models.APIKey {
+		return models.APIKey{
+			ID: id, Name: name, Prefix: prefix, KeyHash: keyHash, Scopes: strings.Fields(strings.Join(scopes, " ")),
+			GymID: copyInt(gymID), CreatedBy: createdBy, ExpiresAt: expires, CreatedAt: truncTime(time.Now()),
+		}
+	})
+	key, _ := r.db.t.apiKeys.get(id)
+	return apiKey(key), nil
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id int) (*models.APIKey, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	key, ok := r.db.t.apiKeys.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return apiKey(key), nil
+}
+
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	for _, key := range r.db.t.apiKeys.rows {
+		if key.Prefix == prefix {
+			return apiKey(key), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *APIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var keys []models.APIKey
+	ids := r.db.t.apiKeys.ids()
+	for i := len(ids) - 1; i >= 0; i-- {
+		keys = append(keys, *apiKey(r.db.t.apiKeys.rows[ids[i]]))
+	}
+	return keys, nil
+}
+
+// Revoke отзывает ключ; false, если ключа нет или он уже отозван
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	key, ok := r.db.t.apiKeys.get(id)
+	if !ok || key.RevokedAt != nil {
+		return false, nil
+	}
+	revoked := truncTime(time.Now())
+	key.RevokedAt = &revoked
+	r.db.t.apiKeys.set(id, key)
+	return true, nil
+}
+
+// TouchLastUsed отмечает использование ключа не чаще раза в apiKeyTouchInterval
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int, now time.Time) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	key, ok := r.db.t.apiKeys.get(id)
+	if ok && (key.LastUsedAt == nil || key.LastUsedAt.Before(truncTime(now.Add(-apiKeyTouchInterval)))) {
+		used := truncTime(now)
+		key.LastUsedAt = &used
+		r.db.t.apiKeys.set(id, key)
+	}
+	return nil
+}
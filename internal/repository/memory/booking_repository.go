@@ -0,0 +1,199 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type bookingRow struct {
+	models.Booking
+	remindedFor string
+}
+
+type BookingRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewBookingRepository(db *DB) *BookingRepository {
+	return &BookingRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *BookingRepository) WithTx(tx *sql.Tx) repository.BookingStore {
+	return &BookingRepository{db: r.db, tx: tx}
+}
+
+func (r *BookingRepository) Create(ctx context.Context, userID, classID int) (int64, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, err
+	}
+	defer r.db.unlock()
+
+	id := r.db.t.bookings.insert(func(id int) bookingRow {
+		return bookingRow{Booking: models.Booking{ID: id, UserID: userID, ClassID: classID, Status: "confirmed", CreatedAt: now()}}
+	})
+	return int64(id), nil
+}
+
+func (r *BookingRepository) Exists(ctx context.Context, userID, classID int) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	for _, b := range r.db.t.bookings.rows {
+		if b.UserID == userID && b.ClassID == classID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// list возвращает бронирования, подходящие под match, в порядке id
+func (r *BookingRepository) list(match func(b bookingRow) bool) []models.Booking {
+	var bookings []models.Booking
+	for _, id := range r.db.t.bookings.ids() {
+		if b := r.db.t.bookings.rows[id]; match(b) {
+			bookings = append(bookings, b.Booking)
+		}
+	}
+	return bookings
+}
+
+func (r *BookingRepository) GetByUser(ctx context.Context, userID int) ([]models.Booking, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	bookings := r.list(func(b bookingRow) bool { return b.UserID == userID })
+	// Новые первыми; при равном времени создания — более поздние id
+	sort.SliceStable(bookings, func(i, j int) bool {
+		if bookings[i].CreatedAt != bookings[j].CreatedAt {
+			return bookings[i].CreatedAt > bookings[j].CreatedAt
+		}
+		return bookings[i].ID > bookings[j].ID
+	})
+	return bookings, nil
+}
+
+func (r *BookingRepository) ListAll(ctx context.Context) ([]models.Booking, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	return r.list(func(bookingRow) bool { return true }), nil
+}
+
+func (r *BookingRepository) ListByGym(ctx context.Context, gymID int) ([]models.Booking, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	return r.list(func(b bookingRow) bool {
+		c, ok := r.db.t.classes.get(b.ClassID)
+		return ok && c.GymID == gymID
+	}), nil
+}
+
+func (r *BookingRepository) Cancel(ctx context.Context, bookingID, userID int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if b, ok := r.db.t.bookings.get(bookingID); ok && b.UserID == userID {
+		r.db.t.bookings.delete(bookingID)
+	}
+	return nil
+}
+
+// recipient оставляет поля пользователя, нужные для отправки ему уведомлений
+func recipient(u userRow) models.User {
+	return models.User{ID: u.ID, Name: u.Name, Email: u.Email, Locale: u.Locale, Phone: u.Phone, PushToken: u.PushToken}
+}
+
+// ListAttendees возвращает пользователей, записанных на занятие
+func (r *BookingRepository) ListAttendees(ctx context.Context, classID int) ([]models.User, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var users []models.User
+	for _, b := range r.list(func(b bookingRow) bool { return b.ClassID == classID }) {
+		if u, ok := r.db.t.users.get(b.UserID); ok {
+			users = append(users, recipient(u))
+		}
+	}
+	return users, nil
+}
+
+// DeleteByClass снимает все бронирования занятия (при его отмене)
+func (r *BookingRepository) DeleteByClass(ctx context.Context, classID int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	for id, b := range r.db.t.bookings.rows {
+		if b.ClassID == classID {
+			r.db.t.bookings.delete(id)
+		}
+	}
+	return nil
+}
+
+// ListDueReminders возвращает бронирования на занятия, начинающиеся в (from, to],
+// о которых ещё не напоминали (или напоминали до переноса занятия)
+func (r *BookingRepository) ListDueReminders(ctx context.Context, from, to time.Time, n int) ([]models.BookingReminder, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	fromTS, toTS := formatTime(from), formatTime(to)
+	var reminders []models.BookingReminder
+	for _, id := range r.db.t.bookings.ids() {
+		b := r.db.t.bookings.rows[id]
+		c, ok := r.db.t.classes.get(b.ClassID)
+		if !ok {
+			continue
+		}
+		u, ok := r.db.t.users.get(b.UserID)
+		if !ok {
+			continue
+		}
+		start := datetime(c.StartTime)
+		if start == "" || start <= fromTS || start > toTS || datetime(b.remindedFor) == start {
+			continue
+		}
+		startsAt, _ := time.Parse(timeLayout, start)
+		reminders = append(reminders, models.BookingReminder{BookingID: b.ID, User: recipient(u), ClassTitle: c.Title, StartTime: c.StartTime, StartsAt: startsAt})
+	}
+	sort.SliceStable(reminders, func(i, j int) bool { return reminders[i].StartTime < reminders[j].StartTime })
+	return limit(reminders, n), nil
+}
+
+// MarkReminded запоминает, о каком времени занятия напомнили; время сравнивается через datetime(),
+// поскольку драйвер может вернуть start_time в другом формате
+func (r *BookingRepository) MarkReminded(ctx context.Context, bookingID int, startTime string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if b, ok := r.db.t.bookings.get(bookingID); ok {
+		b.remindedFor = startTime
+		r.db.t.bookings.set(bookingID, b)
+	}
+	return nil
+}
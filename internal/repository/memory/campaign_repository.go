@@ -0,0 +1,405 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type recipientKey struct {
+	campaignID int
+	userID     int
+}
+
+type recipientRow struct {
+	status         string
+	notificationID int    // 0 — NULL
+	openedAt       string // "" — NULL
+}
+
+type CampaignRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewCampaignRepository(db *DB) *CampaignRepository {
+	return &CampaignRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *CampaignRepository) WithTx(tx *sql.Tx) repository.CampaignStore {
+	return &CampaignRepository{db: r.db, tx: tx}
+}
+
+func contains[T comparable](values []T, v T) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// likeFold приводит к нижнему регистру только латиницу — как LIKE в SQLite
+func likeFold(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+// inSegment повторяет segmentWhere из SQLite-репозитория.
+// Действующий абонемент — активный и не закончившийся к today (YYYY-MM-DD).
+func (t *tables) inSegment(u userRow, seg models.Segment, today string) bool {
+	if len(seg.GymIDs) > 0 {
+		client := false
+		for _, b := range t.bookings.rows {
+			if c, ok := t.classes.get(b.ClassID); ok && b.UserID == u.ID && contains(seg.GymIDs, c.GymID) {
+				client = true
+				break
+			}
+		}
+		for _, p := range t.payments.rows {
+			if !client && p.UserID == u.ID && p.GymID != nil && contains(seg.GymIDs, *p.GymID) {
+				client = true
+			}
+		}
+		if !client {
+			return false
+		}
+	}
+
+	filtered := len(seg.MembershipIDs) > 0 || seg.MembershipExpiresFrom != "" || seg.MembershipExpiresTo != ""
+	if filtered || seg.ActiveMembership != nil {
+		active := false
+		for _, um := range t.userMemberships.rows {
+			end := date(um.EndDate)
+			if um.UserID != u.ID || !um.Active || end == "" || end < date(today) {
+				continue
+			}
+			if len(seg.MembershipIDs) > 0 && !contains(seg.MembershipIDs, um.MembershipID) {
+				continue
+			}
+			if seg.MembershipExpiresFrom != "" && end < date(seg.MembershipExpiresFrom) {
+				continue
+			}
+			if seg.MembershipExpiresTo != "" && end > date(seg.MembershipExpiresTo) {
+				continue
+			}
+			active = true
+			break
+		}
+		if want := filtered || *seg.ActiveMembership; active != want {
+			return false
+		}
+	}
+
+	if seg.BookedClassTitle != "" || len(seg.BookedClassIDs) > 0 {
+		booked := false
+		for _, b := range t.bookings.rows {
+			c, ok := t.classes.get(b.ClassID)
+			if !ok || b.UserID != u.ID {
+				continue
+			}
+			if seg.BookedClassTitle != "" && !strings.Contains(likeFold(c.Title), likeFold(seg.BookedClassTitle)) {
+				continue
+			}
+			if len(seg.BookedClassIDs) > 0 && !contains(seg.BookedClassIDs, c.ID) {
+				continue
+			}
+			booked = true
+			break
+		}
+		if !booked {
+			return false
+		}
+	}
+
+	return len(seg.Locales) == 0 || contains(seg.Locales, u.Locale)
+}
+
+// segment возвращает пользователей сегмента в порядке id
+func (t *tables) segment(seg models.Segment, today string) []models.User {
+	var users []models.User
+	for _, id := range t.users.ids() {
+		if u := t.users.rows[id]; t.inSegment(u, seg, today) {
+			users = append(users, recipient(u))
+		}
+	}
+	return users
+}
+
+// PreviewSegment возвращает число пользователей в сегменте и первых n из них
+func (r *CampaignRepository) PreviewSegment(ctx context.Context, seg models.Segment, today string, n int) (*models.SegmentPreview, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	users := r.db.t.segment(seg, today)
+	return &models.SegmentPreview{Count: len(users), Sample: append([]models.User{}, limit(users, n)...)}, nil
+}
+
+// copySegment копирует сегмент так же, как его сохранение в JSON в SQLite
+func copySegment(seg models.Segment) (models.Segment, error) {
+	data, err := json.Marshal(seg)
+	if err != nil {
+		return models.Segment{}, err
+	}
+	var c models.Segment
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+func (r *CampaignRepository) Create(ctx context.Context, c *models.Campaign) (*models.Campaign, error) {
+	segment, err := copySegment(c.Segment)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	id := r.db.t.campaigns.insert(func(id int) models.Campaign {
+		return models.Campaign{
+			ID: id, Name: c.Name, Title: c.Title, Body: c.Body, Segment: segment, Status: c.Status, ScheduledAt: c.ScheduledAt,
+			RatePerMinute: c.RatePerMinute, CreatedBy: c.CreatedBy, CreatedAt: now(),
+		}
+	})
+	return r.campaign(id)
+}
+
+// campaign возвращает копию кампании, чтобы вызывающий не менял хранимый сегмент
+func (r *CampaignRepository) campaign(id int) (*models.Campaign, error) {
+	c, ok := r.db.t.campaigns.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	segment, err := copySegment(c.Segment)
+	if err != nil {
+		return nil, err
+	}
+	c.Segment = segment
+	return &c, nil
+}
+
+func (r *CampaignRepository) GetByID(ctx context.Context, id int) (*models.Campaign, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	return r.campaign(id)
+}
+
+// list возвращает кампании с подходящими id в заданном порядке
+func (r *CampaignRepository) list(ids []int, match func(c models.Campaign) bool) ([]models.Campaign, error) {
+	var campaigns []models.Campaign
+	for _, id := range ids {
+		if !match(r.db.t.campaigns.rows[id]) {
+			continue
+		}
+		c, err := r.campaign(id)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, *c)
+	}
+	return campaigns, nil
+}
+
+// List возвращает кампании, новые первыми; status фильтрует по статусу
+func (r *CampaignRepository) List(ctx context.Context, status string) ([]models.Campaign, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	ids := r.db.t.campaigns.ids()
+	sort.Sort(sort.Reverse(sort.IntSlice(ids)))
+	return r.list(ids, func(c models.Campaign) bool { return status == "" || c.Status == status })
+}
+
+// SetStatus переводит кампанию в status, только если она сейчас в одном из from
+func (r *CampaignRepository) SetStatus(ctx context.Context, id int, status, scheduledAt string, from ...string) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	c, ok := r.db.t.campaigns.get(id)
+	if !ok || !contains(from, c.Status) {
+		return false, nil
+	}
+	c.Status = status
+	if scheduledAt != "" {
+		c.ScheduledAt = scheduledAt
+	}
+	r.db.t.campaigns.set(id, c)
+	return true, nil
+}
+
+// ListDue возвращает запланированные кампании, время которых наступило
+func (r *CampaignRepository) ListDue(ctx context.Context, now time.Time) ([]models.Campaign, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	ts := formatTime(now)
+	campaigns, err := r.list(r.db.t.campaigns.ids(), func(c models.Campaign) bool {
+		scheduled := datetime(c.ScheduledAt)
+		return c.Status == "scheduled" && scheduled != "" && scheduled <= ts
+	})
+	sort.SliceStable(campaigns, func(i, j int) bool { return campaigns[i].ScheduledAt < campaigns[j].ScheduledAt })
+	return campaigns, err
+}
+
+// Start фиксирует получателей по сегменту и переводит кампанию в sending.
+// Возвращает число получателей; false — кампанию уже запустили или отменили.
+func (r *CampaignRepository) Start(ctx context.Context, c *models.Campaign, today string, now time.Time) (int, bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, false, err
+	}
+	defer r.db.unlock()
+
+	stored, ok := r.db.t.campaigns.get(c.ID)
+	if !ok || stored.Status != "scheduled" {
+		return 0, false, nil
+	}
+	stored.Status, stored.StartedAt = "sending", formatTime(now)
+	r.db.t.campaigns.set(c.ID, stored)
+
+	users := r.db.t.segment(c.Segment, today)
+	for _, u := range users {
+		setKey(&r.db.t.recipients, recipientKey{campaignID: c.ID, userID: u.ID}, recipientRow{status: "pending"})
+	}
+	return len(users), true, nil
+}
+
+// PendingRecipients возвращает до n получателей, которым письмо ещё не поставлено в очередь
+func (r *CampaignRepository) PendingRecipients(ctx context.Context, campaignID, n int) ([]models.User, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var users []models.User
+	for _, id := range r.db.t.users.ids() {
+		if rec, ok := r.db.t.recipients[recipientKey{campaignID, id}]; ok && rec.status == "pending" {
+			users = append(users, recipient(r.db.t.users.rows[id]))
+		}
+	}
+	return limit(users, n), nil
+}
+
+// MarkRecipient отмечает, что письмо получателю поставлено в очередь (queued)
+// или не отправляется (skipped)
+func (r *CampaignRepository) MarkRecipient(ctx context.Context, campaignID, userID int, status string, notificationID int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	k := recipientKey{campaignID, userID}
+	if rec, ok := r.db.t.recipients[k]; ok {
+		rec.status, rec.notificationID = status, notificationID
+		r.db.t.recipients[k] = rec
+	}
+	return nil
+}
+
+// Finish переводит кампанию в sent, когда не осталось ожидающих получателей
+func (r *CampaignRepository) Finish(ctx context.Context, campaignID int, now time.Time) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	c, ok := r.db.t.campaigns.get(campaignID)
+	if !ok || c.Status != "sending" {
+		return false, nil
+	}
+	for k, rec := range r.db.t.recipients {
+		if k.campaignID == campaignID && rec.status == "pending" {
+			return false, nil
+		}
+	}
+	c.Status, c.FinishedAt = "sent", formatTime(now)
+	r.db.t.campaigns.set(campaignID, c)
+	return true, nil
+}
+
+// ListSending возвращает кампании, которые сейчас рассылаются
+func (r *CampaignRepository) ListSending(ctx context.Context) ([]models.Campaign, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	return r.list(r.db.t.campaigns.ids(), func(c models.Campaign) bool { return c.Status == "sending" })
+}
+
+// Stats считает получателей кампании по судьбе их писем в очереди уведомлений
+func (r *CampaignRepository) Stats(ctx context.Context, campaignID int) (*models.CampaignStats, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	s := &models.CampaignStats{}
+	for k, rec := range r.db.t.recipients {
+		if k.campaignID != campaignID {
+			continue
+		}
+		s.Recipients++
+		switch rec.status {
+		case "pending":
+			s.Pending++
+		case "skipped":
+			s.Skipped++
+		}
+		if n, ok := r.db.t.notifications.get(rec.notificationID); ok {
+			switch n.Status {
+			case "pending", "sending":
+				s.Queued++
+			case "sent":
+				s.Sent++
+			case "dead":
+				s.Failed++
+			}
+		}
+		if rec.openedAt != "" {
+			s.Opened++
+		}
+	}
+	return s, nil
+}
+
+// MarkOpened запоминает первое открытие письма; false — такого получателя нет
+func (r *CampaignRepository) MarkOpened(ctx context.Context, campaignID, userID int, now time.Time) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	k := recipientKey{campaignID, userID}
+	rec, ok := r.db.t.recipients[k]
+	if !ok {
+		return false, nil
+	}
+	if rec.openedAt == "" {
+		rec.openedAt = formatTime(now)
+		r.db.t.recipients[k] = rec
+	}
+	return true, nil
+}
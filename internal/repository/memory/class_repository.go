@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type ClassRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewClassRepository(db *DB) *ClassRepository {
+	return &ClassRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *ClassRepository) WithTx(tx *sql.Tx) repository.ClassStore {
+	return &ClassRepository{db: r.db, tx: tx}
+}
+
+func (r *ClassRepository) Create(ctx context.Context, c *models.Class) (*models.Class, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	id := r.db.t.classes.insert(func(id int) models.Class {
+		class := *c
+		class.ID, class.CreatedAt = id, now()
+		return class
+	})
+	class, _ := r.db.t.classes.get(id)
+	return &class, nil
+}
+
+func (r *ClassRepository) GetByID(ctx context.Context, id int) (*models.Class, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	c, ok := r.db.t.classes.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &c, nil
+}
+
+func (r *ClassRepository) List(ctx context.Context) ([]models.Class, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var classes []models.Class
+	for _, id := range r.db.t.classes.ids() {
+		classes = append(classes, r.db.t.classes.rows[id])
+	}
+	sort.SliceStable(classes, func(i, j int) bool { return classes[i].StartTime < classes[j].StartTime })
+	return classes, nil
+}
+
+func (r *ClassRepository) Update(ctx context.Context, id int, c *models.Class) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if old, ok := r.db.t.classes.get(id); ok {
+		class := *c
+		class.ID, class.CreatedAt = id, old.CreatedAt
+		r.db.t.classes.set(id, class)
+	}
+	return nil
+}
+
+func (r *ClassRepository) Delete(ctx context.Context, id int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.db.t.classes.delete(id)
+	return nil
+}
+
+func (r *ClassRepository) GetBookingCount(ctx context.Context, classID int) (int, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, err
+	}
+	defer r.db.unlock()
+
+	count := 0
+	for _, b := range r.db.t.bookings.rows {
+		if b.ClassID == classID {
+			count++
+		}
+	}
+	return count, nil
+}
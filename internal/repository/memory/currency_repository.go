@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type CurrencyRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewCurrencyRepository(db *DB) *CurrencyRepository {
+	return &CurrencyRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *CurrencyRepository) WithTx(tx *sql.Tx) repository.CurrencyStore {
+	return &CurrencyRepository{db: r.db, tx: tx}
+}
+
+func (r *CurrencyRepository) ListRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var rates []models.ExchangeRate
+	for _, er := range r.db.t.exchangeRates {
+		rates = append(rates, er)
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Currency < rates[j].Currency })
+	return rates, nil
+}
+
+func (r *CurrencyRepository) GetRate(ctx context.Context, currency string) (*models.ExchangeRate, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	er, ok := r.db.t.exchangeRates[currency]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &er, nil
+}
+
+func (r *CurrencyRepository) SetRate(ctx context.Context, currency string, rate float64) (*models.ExchangeRate, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	er := models.ExchangeRate{Currency: currency, Rate: rate, UpdatedAt: now()}
+	setKey(&r.db.t.exchangeRates, currency, er)
+	return &er, nil
+}
+
+func (r *CurrencyRepository) DeleteRate(ctx context.Context, currency string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	delete(r.db.t.exchangeRates, currency)
+	return nil
+}
@@ -0,0 +1,286 @@
+// Package memory — хранилища в памяти процесса с тем же поведением, что у SQLite-репозиториев
+// (это проверяют контрактные тесты в tests/contract). Нужны для быстрых тестов сервисов без базы.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+// Формат CURRENT_TIMESTAMP в SQLite (UTC)
+const timeLayout = "2006-01-02 15:04:05"
+
+// DB — общие для всех хранилищ данные, аналог *sql.DB. Реализует repository.Transactor.
+// Хранилища, полученные через WithTx(tx), работают внутри транзакции; остальные
+// ждут её завершения, чтобы откат не затронул их изменения.
+type DB struct {
+	mu   sync.Mutex // защищает t
+	txMu sync.Mutex // открытая транзакция; транзакции выполняются по одной
+	t    tables
+}
+
+func NewDB() *DB {
+	return &DB{}
+}
+
+// Do выполняет fn как транзакцию: если fn вернула ошибку, все изменения откатываются.
+// Внутри fn данные доступны только хранилищам из WithTx(tx), поэтому обращение к данным
+// через хранилище без tx из fn заблокируется навсегда — как и в SQLite, в транзакции
+// нужно работать через tx.
+func (db *DB) Do(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.txMu.Lock()
+	defer db.txMu.Unlock()
+
+	db.mu.Lock()
+	snapshot := db.t.clone()
+	db.mu.Unlock()
+
+	// tx — только метка транзакции для WithTx: запросов через него не выполняется
+	if err := fn(new(sql.Tx)); err != nil {
+		db.mu.Lock()
+		db.t = snapshot
+		db.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// lock захватывает данные; отменённый ctx, как и в SQLite, прерывает запрос.
+// Без tx сначала дожидается завершения открытой транзакции.
+func (db *DB) lock(ctx context.Context, tx *sql.Tx) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if tx == nil {
+		db.txMu.Lock()
+		defer db.txMu.Unlock()
+	}
+	db.mu.Lock()
+	return nil
+}
+
+func (db *DB) unlock() {
+	db.mu.Unlock()
+}
+
+// tables — содержимое базы; clone копирует его для отката транзакции
+type tables struct {
+	users            table[userRow]
+	gyms             table[models.Gym]
+	trainers         table[models.Trainer]
+	classes          table[models.Class]
+	bookings         table[bookingRow]
+	memberships      table[models.Membership]
+	userMemberships  table[models.UserMembership]
+	membershipPrices table[models.MembershipPrice]
+	payments         table[models.Payment]
+	exchangeRates    map[string]models.ExchangeRate
+	taxRates         table[models.TaxRate]
+	fiscalOutbox     table[fiscalRow]
+	notifications    table[notificationRow]
+	settings         map[int]settingsRow
+	preferences      map[preferenceKey]bool
+	installmentPlans table[models.InstallmentPlan]
+	installments     table[models.Installment]
+	refreshTokens    table[models.RefreshToken]
+	revokedAccess    map[string]string // jti -> expires_at
+	userTokens       table[userTokenRow]
+	recoveryCodes    table[recoveryCodeRow]
+	roles            table[models.UserRole]
+	apiKeys          table[models.APIKey]
+	identities       table[identityRow]
+	signingKeys      table[models.SigningKey]
+	campaigns        table[models.Campaign]
+	recipients       map[recipientKey]recipientRow
+}
+
+func (t tables) clone() tables {
+	c := t
+	c.users = t.users.clone()
+	c.gyms = t.gyms.clone()
+	c.trainers = t.trainers.clone()
+	c.classes = t.classes.clone()
+	c.bookings = t.bookings.clone()
+	c.memberships = t.memberships.clone()
+	c.userMemberships = t.userMemberships.clone()
+	c.membershipPrices = t.membershipPrices.clone()
+	c.payments = t.payments.clone()
+	c.exchangeRates = cloneMap(t.exchangeRates)
+	c.taxRates = t.taxRates.clone()
+	c.fiscalOutbox = t.fiscalOutbox.clone()
+	c.notifications = t.notifications.clone()
+	c.settings = cloneMap(t.settings)
+	c.preferences = cloneMap(t.preferences)
+	c.installmentPlans = t.installmentPlans.clone()
+	c.installments = t.installments.clone()
+	c.refreshTokens = t.refreshTokens.clone()
+	c.revokedAccess = cloneMap(t.revokedAccess)
+	c.userTokens = t.userTokens.clone()
+	c.recoveryCodes = t.recoveryCodes.clone()
+	c.roles = t.roles.clone()
+	c.apiKeys = t.apiKeys.clone()
+	c.identities = t.identities.clone()
+	c.signingKeys = t.signingKeys.clone()
+	c.campaigns = t.campaigns.clone()
+	c.recipients = cloneMap(t.recipients)
+	return c
+}
+
+// table — строки с автоинкрементным id. Строки хранятся по значению: изменяемые поля
+// указателей не переиспользуются, поэтому копии map достаточно для снимка.
+type table[T any] struct {
+	rows map[int]T
+	seq  int
+}
+
+func (t *table[T]) insert(row func(id int) T) int {
+	if t.rows == nil {
+		t.rows = make(map[int]T)
+	}
+	t.seq++
+	t.rows[t.seq] = row(t.seq)
+	return t.seq
+}
+
+func (t *table[T]) get(id int) (T, bool) {
+	row, ok := t.rows[id]
+	return row, ok
+}
+
+func (t *table[T]) set(id int, row T) {
+	t.rows[id] = row
+}
+
+func (t *table[T]) delete(id int) {
+	delete(t.rows, id)
+}
+
+// ids возвращает id строк по возрастанию — в порядке rowid, как SQLite без ORDER BY
+func (t *table[T]) ids() []int {
+	ids := make([]int, 0, len(t.rows))
+	for id := range t.rows {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (t table[T]) clone() table[T] {
+	return table[T]{rows: cloneMap(t.rows), seq: t.seq}
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// setKey записывает значение в map, создавая её при первой записи
+func setKey[K comparable, V any](m *map[K]V, k K, v V) {
+	if *m == nil {
+		*m = make(map[K]V)
+	}
+	(*m)[k] = v
+}
+
+// limit обрезает выборку как LIMIT в SQLite: отрицательное значение — без ограничения
+func limit[T any](rows []T, n int) []T {
+	if n >= 0 && len(rows) > n {
+		return rows[:n]
+	}
+	return rows
+}
+
+func errUnique(columns string) error {
+	return fmt.Errorf("UNIQUE constraint failed: %s", columns)
+}
+
+// now — CURRENT_TIMESTAMP
+func now() string {
+	return formatTime(time.Now())
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// truncTime — время в том виде, в каком оно вернётся из SQLite: UTC с точностью до секунды
+func truncTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+var parseLayouts = []string{
+	timeLayout,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// datetime повторяет datetime() из SQLite: приводит время к UTC "YYYY-MM-DD HH:MM:SS",
+// для нераспознанной строки — "" (NULL)
+func datetime(s string) string {
+	for _, layout := range parseLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return formatTime(t)
+		}
+	}
+	return ""
+}
+
+// date повторяет date() из SQLite
+func date(s string) string {
+	if dt := datetime(s); dt != "" {
+		return dt[:len("2006-01-02")]
+	}
+	return ""
+}
+
+func copyInt(p *int) *int {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// Хранилища реализуют те же интерфейсы, что и SQLite-репозитории
+var (
+	_ repository.Transactor        = (*DB)(nil)
+	_ repository.APIKeyStore       = (*APIKeyRepository)(nil)
+	_ repository.BookingStore      = (*BookingRepository)(nil)
+	_ repository.CampaignStore     = (*CampaignRepository)(nil)
+	_ repository.ClassStore        = (*ClassRepository)(nil)
+	_ repository.CurrencyStore     = (*CurrencyRepository)(nil)
+	_ repository.FiscalStore       = (*FiscalRepository)(nil)
+	_ repository.GymStore          = (*GymRepository)(nil)
+	_ repository.IdentityStore     = (*IdentityRepository)(nil)
+	_ repository.InstallmentStore  = (*InstallmentRepository)(nil)
+	_ repository.MembershipStore   = (*MembershipRepository)(nil)
+	_ repository.NotificationStore = (*NotificationRepository)(nil)
+	_ repository.PaymentStore      = (*PaymentRepository)(nil)
+	_ repository.ReportStore       = (*ReportRepository)(nil)
+	_ repository.RoleStore         = (*RoleRepository)(nil)
+	_ repository.SigningKeyStore   = (*SigningKeyRepository)(nil)
+	_ repository.TaxStore          = (*TaxRepository)(nil)
+	_ repository.TokenStore        = (*TokenRepository)(nil)
+	_ repository.TrainerStore      = (*TrainerRepository)(nil)
+	_ repository.UserStore         = (*UserRepository)(nil)
+)
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type fiscalRow struct {
+	models.FiscalOutboxEntry
+	lockedUntil string // "" — NULL
+}
+
+type FiscalRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewFiscalRepository(db *DB) *FiscalRepository {
+	return &FiscalRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *FiscalRepository) WithTx(tx *sql.Tx) repository.FiscalStore {
+	return &FiscalRepository{db: r.db, tx: tx}
+}
+
+// Enqueue ставит платёж в очередь на регистрацию чека; повторная постановка игнорируется
+func (r *FiscalRepository) Enqueue(ctx context.Context, paymentID int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	for _, e := range r.db.t.fiscalOutbox.rows {
+		if e.PaymentID == paymentID {
+			return nil
+		}
+	}
+	ts := now()
+	r.db.t.fiscalOutbox.insert(func(id int) fiscalRow {
+		return fiscalRow{FiscalOutboxEntry: models.FiscalOutboxEntry{ID: id, PaymentID: paymentID, Status: "pending", NextAttemptAt: ts, CreatedAt: ts, UpdatedAt: ts}}
+	})
+	return nil
+}
+
+func (r *FiscalRepository) GetByID(ctx context.Context, id int) (*models.FiscalOutboxEntry, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	row, ok := r.db.t.fiscalOutbox.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &row.FiscalOutboxEntry, nil
+}
+
+// ClaimDue забирает до limit чеков, время попытки которых наступило, и помечает их sending до now+lease.
+// Чеки, зависшие в sending дольше lease, забираются повторно.
+func (r *FiscalRepository) ClaimDue(ctx context.Context, now time.Time, n int, lease time.Duration) ([]models.FiscalOutboxEntry, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	ts, lockedUntil := formatTime(now), formatTime(now.Add(lease))
+	var due []fiscalRow
+	for _, id := range r.db.t.fiscalOutbox.ids() {
+		row := r.db.t.fiscalOutbox.rows[id]
+		if (row.Status == "pending" && row.NextAttemptAt <= ts) ||
+			(row.Status == "sending" && row.lockedUntil != "" && row.lockedUntil <= ts) {
+			due = append(due, row)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextAttemptAt < due[j].NextAttemptAt })
+
+	var claimed []models.FiscalOutboxEntry
+	for _, row := range limit(due, n) {
+		claimed = append(claimed, row.FiscalOutboxEntry)
+		claimed[len(claimed)-1].Status = "sending"
+		row.Status, row.lockedUntil, row.UpdatedAt = "sending", lockedUntil, formatTime(time.Now())
+		r.db.t.fiscalOutbox.set(row.ID, row)
+	}
+	return claimed, nil
+}
+
+func (r *FiscalRepository) List(ctx context.Context, status string) ([]models.FiscalOutboxEntry, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var entries []models.FiscalOutboxEntry
+	ids := r.db.t.fiscalOutbox.ids()
+	for i := len(ids) - 1; i >= 0; i-- {
+		if row := r.db.t.fiscalOutbox.rows[ids[i]]; status == "" || row.Status == status {
+			entries = append(entries, row.FiscalOutboxEntry)
+		}
+	}
+	return entries, nil
+}
+
+func (r *FiscalRepository) update(ctx context.Context, id int, fn func(e *fiscalRow)) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if e, ok := r.db.t.fiscalOutbox.get(id); ok {
+		fn(&e)
+		e.UpdatedAt = now()
+		r.db.t.fiscalOutbox.set(id, e)
+	}
+	return nil
+}
+
+func (r *FiscalRepository) MarkSent(ctx context.Context, id int) error {
+	return r.update(ctx, id, func(e *fiscalRow) {
+		e.Status, e.LastError, e.lockedUntil = "sent", "", ""
+		e.Attempts++
+	})
+}
+
+// MarkFailed фиксирует неудачную попытку; status = pending для повтора в nextAttempt или failed
+func (r *FiscalRepository) MarkFailed(ctx context.Context, id int, status, lastError string, nextAttempt time.Time) error {
+	return r.update(ctx, id, func(e *fiscalRow) {
+		e.Status, e.LastError, e.NextAttemptAt, e.lockedUntil = status, lastError, formatTime(nextAttempt), ""
+		e.Attempts++
+	})
+}
+
+// Retry возвращает запись в очередь для немедленной повторной отправки; взятые воркером не трогает
+func (r *FiscalRepository) Retry(ctx context.Context, id int) error {
+	return r.update(ctx, id, func(e *fiscalRow) {
+		if e.Status == "pending" || e.Status == "failed" {
+			e.Status, e.NextAttemptAt = "pending", now()
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type GymRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewGymRepository(db *DB) *GymRepository {
+	return &GymRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *GymRepository) WithTx(tx *sql.Tx) repository.GymStore {
+	return &GymRepository{db: r.db, tx: tx}
+}
+
+func (r *GymRepository) Create(ctx context.Context, name, address string) (*models.Gym, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	id := r.db.t.gyms.insert(func(id int) models.Gym {
+		return models.Gym{ID: id, Name: name, Address: address, CreatedAt: now()}
+	})
+	g, _ := r.db.t.gyms.get(id)
+	return &g, nil
+}
+
+func (r *GymRepository) GetByID(ctx context.Context, id int) (*models.Gym, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	g, ok := r.db.t.gyms.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &g, nil
+}
+
+func (r *GymRepository) List(ctx context.Context) ([]models.Gym, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var gyms []models.Gym
+	for _, id := range r.db.t.gyms.ids() {
+		gyms = append(gyms, r.db.t.gyms.rows[id])
+	}
+	return gyms, nil
+}
+
+func (r *GymRepository) Update(ctx context.Context, id int, name, address string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if g, ok := r.db.t.gyms.get(id); ok {
+		g.Name, g.Address = name, address
+		r.db.t.gyms.set(id, g)
+	}
+	return nil
+}
+
+func (r *GymRepository) Delete(ctx context.Context, id int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.db.t.gyms.delete(id)
+	return nil
+}
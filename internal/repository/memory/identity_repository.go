@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"Gym_StrongCode/internal/repository"
+)
+
+type identityRow struct {
+	userID   int
+	provider string
+	subject  string
+	email    string
+}
+
+// IdentityRepository — привязки внешних аккаунтов OpenID Connect к пользователям
+type IdentityRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewIdentityRepository(db *DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *IdentityRepository) WithTx(tx *sql.Tx) repository.IdentityStore {
+	return &IdentityRepository{db: r.db, tx: tx}
+}
+
+// FindUserID возвращает пользователя, к которому привязан аккаунт провайдера; sql.ErrNoRows, если привязки нет
+func (r *IdentityRepository) FindUserID(ctx context.Context, provider, subject string) (int, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, err
+	}
+	defer r.db.unlock()
+
+	for _, i := range r.db.t.identities.rows {
+		if i.provider == provider && i.subject == subject {
+			return i.userID, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (r *IdentityRepository) Create(ctx context.Context, userID int, provider, subject, email string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	for _, i := range r.db.t.identities.rows {
+		if i.provider == provider && i.subject == subject {
+			return errUnique("user_identities.provider, user_identities.subject")
+		}
+	}
+	r.db.t.identities.insert(func(int) identityRow {
+		return identityRow{userID: userID, provider: provider, subject: subject, email: email}
+	})
+	return nil
+}
@@ -0,0 +1,224 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type InstallmentRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewInstallmentRepository(db *DB) *InstallmentRepository {
+	return &InstallmentRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *InstallmentRepository) WithTx(tx *sql.Tx) repository.InstallmentStore {
+	return &InstallmentRepository{db: r.db, tx: tx}
+}
+
+// CreatePlan сохраняет план вместе с графиком платежей; при ошибке не сохраняется ничего
+func (r *InstallmentRepository) CreatePlan(ctx context.Context, plan *models.InstallmentPlan) (int, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, err
+	}
+	defer r.db.unlock()
+
+	seqs := make(map[int]bool)
+	for _, i := range plan.Installments {
+		if seqs[i.Seq] {
+			return 0, errUnique("installments.plan_id, installments.seq")
+		}
+		seqs[i.Seq] = true
+	}
+
+	id := r.db.t.installmentPlans.insert(func(id int) models.InstallmentPlan {
+		return models.InstallmentPlan{
+			ID: id, UserID: plan.UserID, MembershipID: plan.MembershipID, TotalCents: plan.TotalCents, Currency: plan.Currency,
+			Method: plan.Method, GymID: copyInt(plan.GymID), InstallmentsCount: plan.InstallmentsCount, Status: plan.Status,
+			CreatedAt: now(),
+		}
+	})
+	for _, i := range plan.Installments {
+		r.db.t.installments.insert(func(iid int) models.Installment {
+			inst := i
+			inst.ID, inst.PlanID, inst.LastError = iid, id, ""
+			inst.PaymentID = copyInt(i.PaymentID)
+			if i.PaidAt != nil {
+				paidAt := *i.PaidAt
+				inst.PaidAt = &paidAt
+			}
+			return inst
+		})
+	}
+	return id, nil
+}
+
+func (r *InstallmentRepository) GetPlan(ctx context.Context, id int) (*models.InstallmentPlan, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	p, ok := r.db.t.installmentPlans.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	p.Installments = r.installments(id)
+	return &p, nil
+}
+
+// ListPlans возвращает планы без графика; userID = 0 — планы всех пользователей
+func (r *InstallmentRepository) ListPlans(ctx context.Context, userID int, status string) ([]models.InstallmentPlan, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var plans []models.InstallmentPlan
+	ids := r.db.t.installmentPlans.ids()
+	for i := len(ids) - 1; i >= 0; i-- {
+		p := r.db.t.installmentPlans.rows[ids[i]]
+		if (userID == 0 || p.UserID == userID) && (status == "" || p.Status == status) {
+			plans = append(plans, p)
+		}
+	}
+	return plans, nil
+}
+
+// installments возвращает график плана в порядке seq
+func (r *InstallmentRepository) installments(planID int) []models.Installment {
+	var list []models.Installment
+	for _, i := range r.db.t.installments.rows {
+		if i.PlanID == planID {
+			list = append(list, i)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Seq < list[b].Seq })
+	return list
+}
+
+func (r *InstallmentRepository) ListInstallments(ctx context.Context, planID int) ([]models.Installment, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	return r.installments(planID), nil
+}
+
+// ListDue возвращает неоплаченные платежи, попытка списания которых назначена на today или раньше.
+// Платежи отменённых и завершённых планов не списываются.
+func (r *InstallmentRepository) ListDue(ctx context.Context, today string) ([]models.Installment, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var due []models.Installment
+	for _, id := range r.db.t.installments.ids() {
+		i := r.db.t.installments.rows[id]
+		p, ok := r.db.t.installmentPlans.get(i.PlanID)
+		if ok && i.Status == "pending" && i.NextAttemptDate <= today && (p.Status == "active" || p.Status == "suspended") {
+			due = append(due, i)
+		}
+	}
+	sort.SliceStable(due, func(a, b int) bool { return due[a].NextAttemptDate < due[b].NextAttemptDate })
+	return due, nil
+}
+
+// ListOverduePlanIDs возвращает активные планы с неоплаченным платежом, срок которого раньше cutoff
+func (r *InstallmentRepository) ListOverduePlanIDs(ctx context.Context, cutoff string) ([]int, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var ids []int
+	for _, id := range r.db.t.installmentPlans.ids() {
+		if r.db.t.installmentPlans.rows[id].Status != "active" {
+			continue
+		}
+		for _, i := range r.db.t.installments.rows {
+			if i.PlanID == id && i.Status == "pending" && i.DueDate < cutoff {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids, nil
+}
+
+// CountPending считает неоплаченные платежи плана и те из них, срок которых раньше cutoff
+func (r *InstallmentRepository) CountPending(ctx context.Context, planID int, cutoff string) (pending, overdue int, err error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, 0, err
+	}
+	defer r.db.unlock()
+
+	for _, i := range r.db.t.installments.rows {
+		if i.PlanID == planID && i.Status == "pending" {
+			pending++
+			if i.DueDate < cutoff {
+				overdue++
+			}
+		}
+	}
+	return pending, overdue, nil
+}
+
+func (r *InstallmentRepository) updateInstallment(ctx context.Context, id int, fn func(i *models.Installment)) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if i, ok := r.db.t.installments.get(id); ok {
+		fn(&i)
+		r.db.t.installments.set(id, i)
+	}
+	return nil
+}
+
+func (r *InstallmentRepository) MarkPaid(ctx context.Context, id, paymentID int) error {
+	return r.updateInstallment(ctx, id, func(i *models.Installment) {
+		paidAt := now()
+		i.Status, i.PaymentID, i.LastError, i.PaidAt = "paid", &paymentID, "", &paidAt
+		i.Attempts++
+	})
+}
+
+// MarkAttemptFailed фиксирует неудачное списание и переносит попытку на nextAttemptDate
+func (r *InstallmentRepository) MarkAttemptFailed(ctx context.Context, id int, lastError, nextAttemptDate string) error {
+	return r.updateInstallment(ctx, id, func(i *models.Installment) {
+		i.LastError, i.NextAttemptDate = lastError, nextAttemptDate
+		i.Attempts++
+	})
+}
+
+func (r *InstallmentRepository) updatePlan(ctx context.Context, id int, fn func(p *models.InstallmentPlan)) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if p, ok := r.db.t.installmentPlans.get(id); ok {
+		fn(&p)
+		r.db.t.installmentPlans.set(id, p)
+	}
+	return nil
+}
+
+func (r *InstallmentRepository) SetPlanStatus(ctx context.Context, id int, status string) error {
+	return r.updatePlan(ctx, id, func(p *models.InstallmentPlan) { p.Status = status })
+}
+
+func (r *InstallmentRepository) SetUserMembership(ctx context.Context, planID, userMembershipID int) error {
+	return r.updatePlan(ctx, planID, func(p *models.InstallmentPlan) { p.UserMembershipID = &userMembershipID })
+}
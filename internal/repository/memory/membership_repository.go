@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type MembershipRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewMembershipRepository(db *DB) *MembershipRepository {
+	return &MembershipRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *MembershipRepository) WithTx(tx *sql.Tx) repository.MembershipStore {
+	return &MembershipRepository{db: r.db, tx: tx}
+}
+
+func (r *MembershipRepository) GetAll(ctx context.Context) ([]models.Membership, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var list []models.Membership
+	for _, id := range r.db.t.memberships.ids() {
+		list = append(list, r.db.t.memberships.rows[id])
+	}
+	return list, nil
+}
+
+func (r *MembershipRepository) GetByID(ctx context.Context, id int) (*models.Membership, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	m, ok := r.db.t.memberships.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &m, nil
+}
+
+func (r *MembershipRepository) Create(ctx context.Context, name string, durationDays, priceCents int) (*models.Membership, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	id := r.db.t.memberships.insert(func(id int) models.Membership {
+		return models.Membership{ID: id, Name: name, DurationDays: durationDays, PriceCents: priceCents, CreatedAt: now()}
+	})
+	m, _ := r.db.t.memberships.get(id)
+	return &m, nil
+}
+
+func (r *MembershipRepository) Update(ctx context.Context, id int, name string, durationDays, priceCents int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if m, ok := r.db.t.memberships.get(id); ok {
+		m.Name, m.DurationDays, m.PriceCents = name, durationDays, priceCents
+		r.db.t.memberships.set(id, m)
+	}
+	return nil
+}
+
+func (r *MembershipRepository) Delete(ctx context.Context, id int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.db.t.memberships.delete(id)
+	return nil
+}
+
+func (r *MembershipRepository) HasActiveMembership(ctx context.Context, userID int) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	current := time.Now().Format("2006-01-02")
+	for _, um := range r.db.t.userMemberships.rows {
+		if um.UserID == userID && um.Active && um.EndDate >= current {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Activate активирует подписку с сегодняшнего дня и возвращает её запись с датами действия
+func (r *MembershipRepository) Activate(ctx context.Context, userID, membershipID int, durationDays int) (*models.UserMembership, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	start := time.Now()
+	um := models.UserMembership{
+		UserID:       userID,
+		MembershipID: membershipID,
+		StartDate:    start.Format("2006-01-02"),
+		EndDate:      start.AddDate(0, 0, durationDays).Format("2006-01-02"),
+		Active:       true,
+	}
+	um.ID = r.db.t.userMemberships.insert(func(id int) models.UserMembership {
+		row := um
+		row.ID, row.CreatedAt = id, now()
+		return row
+	})
+	return &um, nil
+}
+
+// ActivateWithID активирует подписку и возвращает id записи user_memberships
+func (r *MembershipRepository) ActivateWithID(ctx context.Context, userID, membershipID int, durationDays int) (int, error) {
+	um, err := r.Activate(ctx, userID, membershipID, durationDays)
+	if err != nil {
+		return 0, err
+	}
+	return um.ID, nil
+}
+
+// SetActive приостанавливает или возобновляет подписку пользователя
+func (r *MembershipRepository) SetActive(ctx context.Context, userMembershipID int, active bool) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if um, ok := r.db.t.userMemberships.get(userMembershipID); ok {
+		um.Active = active
+		r.db.t.userMemberships.set(userMembershipID, um)
+	}
+	return nil
+}
+
+func (r *MembershipRepository) ListPrices(ctx context.Context, membershipID int) ([]models.MembershipPrice, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var prices []models.MembershipPrice
+	for _, p := range r.db.t.membershipPrices.rows {
+		if p.MembershipID == membershipID {
+			prices = append(prices, p)
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Currency < prices[j].Currency })
+	return prices, nil
+}
+
+// price ищет цену тарифа в валюте; 0 — цены нет
+func (r *MembershipRepository) price(membershipID int, currency string) int {
+	for id, p := range r.db.t.membershipPrices.rows {
+		if p.MembershipID == membershipID && p.Currency == currency {
+			return id
+		}
+	}
+	return 0
+}
+
+func (r *MembershipRepository) GetPrice(ctx context.Context, membershipID int, currency string) (*models.MembershipPrice, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	p, ok := r.db.t.membershipPrices.get(r.price(membershipID, currency))
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &p, nil
+}
+
+func (r *MembershipRepository) SetPrice(ctx context.Context, membershipID int, currency string, priceCents int) (*models.MembershipPrice, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	id := r.price(membershipID, currency)
+	if p, ok := r.db.t.membershipPrices.get(id); ok {
+		p.PriceCents = priceCents
+		r.db.t.membershipPrices.set(id, p)
+	} else {
+		id = r.db.t.membershipPrices.insert(func(id int) models.MembershipPrice {
+			return models.MembershipPrice{ID: id, MembershipID: membershipID, Currency: currency, PriceCents: priceCents, CreatedAt: now()}
+		})
+	}
+	p, _ := r.db.t.membershipPrices.get(id)
+	return &p, nil
+}
+
+func (r *MembershipRepository) DeletePrice(ctx context.Context, membershipID int, currency string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.db.t.membershipPrices.delete(r.price(membershipID, currency))
+	return nil
+}
@@ -0,0 +1,233 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type notificationRow struct {
+	models.NotificationOutboxEntry
+	lockedUntil string // "" — NULL
+}
+
+type settingsRow struct {
+	timezone   string
+	quietStart string
+	quietEnd   string
+}
+
+type preferenceKey struct {
+	userID   int
+	category string
+	channel  string
+}
+
+type NotificationRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewNotificationRepository(db *DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *NotificationRepository) WithTx(tx *sql.Tx) repository.NotificationStore {
+	return &NotificationRepository{db: r.db, tx: tx}
+}
+
+// Enqueue ставит письмо в очередь; без NextAttemptAt письмо отправляется сразу
+func (r *NotificationRepository) Enqueue(ctx context.Context, n *models.NotificationOutboxEntry) (int, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, err
+	}
+	defer r.db.unlock()
+
+	ts := now()
+	return r.db.t.notifications.insert(func(id int) notificationRow {
+		entry := models.NotificationOutboxEntry{
+			ID: id, Channel: n.Channel, Recipient: n.Recipient, Fallback: n.Fallback, Template: n.Template, Locale: n.Locale,
+			Subject: n.Subject, TextBody: n.TextBody, Body: n.Body, UnsubscribeURL: n.UnsubscribeURL,
+			Status: "pending", NextAttemptAt: n.NextAttemptAt, CreatedAt: ts, UpdatedAt: ts,
+		}
+		if entry.NextAttemptAt == "" {
+			entry.NextAttemptAt = ts
+		}
+		return notificationRow{NotificationOutboxEntry: entry}
+	}), nil
+}
+
+func (r *NotificationRepository) GetByID(ctx context.Context, id int) (*models.NotificationOutboxEntry, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	n, ok := r.db.t.notifications.get(id)
+	if !ok {
+		return &models.NotificationOutboxEntry{}, sql.ErrNoRows
+	}
+	return &n.NotificationOutboxEntry, nil
+}
+
+func (r *NotificationRepository) List(ctx context.Context, status string) ([]models.NotificationOutboxEntry, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var entries []models.NotificationOutboxEntry
+	ids := r.db.t.notifications.ids()
+	for i := len(ids) - 1; i >= 0; i-- {
+		if n := r.db.t.notifications.rows[ids[i]]; status == "" || n.Status == status {
+			entries = append(entries, n.NotificationOutboxEntry)
+		}
+	}
+	return entries, nil
+}
+
+// ClaimDue забирает до limit писем, время отправки которых наступило, и помечает их sending до now+lease.
+// Письма, зависшие в sending дольше lease, забираются повторно.
+func (r *NotificationRepository) ClaimDue(ctx context.Context, now time.Time, n int, lease time.Duration) ([]models.NotificationOutboxEntry, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	ts, lockedUntil := formatTime(now), formatTime(now.Add(lease))
+	var due []notificationRow
+	for _, id := range r.db.t.notifications.ids() {
+		row := r.db.t.notifications.rows[id]
+		if (row.Status == "pending" && row.NextAttemptAt <= ts) ||
+			(row.Status == "sending" && row.lockedUntil != "" && row.lockedUntil <= ts) {
+			due = append(due, row)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextAttemptAt < due[j].NextAttemptAt })
+
+	var claimed []models.NotificationOutboxEntry
+	for _, row := range limit(due, n) {
+		claimed = append(claimed, row.NotificationOutboxEntry)
+		claimed[len(claimed)-1].Status = "sending"
+		row.Status, row.lockedUntil, row.UpdatedAt = "sending", lockedUntil, formatTime(time.Now())
+		r.db.t.notifications.set(row.ID, row)
+	}
+	return claimed, nil
+}
+
+func (r *NotificationRepository) update(ctx context.Context, id int, fn func(n *notificationRow)) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if n, ok := r.db.t.notifications.get(id); ok {
+		fn(&n)
+		n.lockedUntil, n.UpdatedAt = "", now()
+		r.db.t.notifications.set(id, n)
+	}
+	return nil
+}
+
+// MarkSent отмечает отправку; текст письма больше не нужен и стирается
+func (r *NotificationRepository) MarkSent(ctx context.Context, id int) error {
+	return r.update(ctx, id, func(n *notificationRow) {
+		sentAt := now()
+		n.Status, n.TextBody, n.Body, n.LastError, n.SentAt = "sent", "", "", "", &sentAt
+		n.Attempts++
+	})
+}
+
+// MarkFailed фиксирует неудачную попытку; status = pending для повтора в nextAttempt или dead
+func (r *NotificationRepository) MarkFailed(ctx context.Context, id int, status, lastError string, nextAttempt time.Time) error {
+	return r.update(ctx, id, func(n *notificationRow) {
+		n.Status, n.LastError, n.NextAttemptAt = status, lastError, formatTime(nextAttempt)
+		n.Attempts++
+	})
+}
+
+// Fallback переключает неотправленное сообщение на запасной канал
+func (r *NotificationRepository) Fallback(ctx context.Context, id int, channel, recipient, fallback, lastError string, nextAttempt time.Time) error {
+	return r.update(ctx, id, func(n *notificationRow) {
+		n.Status, n.Channel, n.Recipient, n.Fallback, n.Attempts = "pending", channel, recipient, fallback, 0
+		n.LastError, n.NextAttemptAt = lastError, formatTime(nextAttempt)
+	})
+}
+
+// Resend возвращает неотправленное письмо в очередь с новым запасом попыток
+func (r *NotificationRepository) Resend(ctx context.Context, id int) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	n, ok := r.db.t.notifications.get(id)
+	if !ok || (n.Status != "pending" && n.Status != "dead") {
+		return false, nil
+	}
+	ts := now()
+	n.Status, n.Attempts, n.NextAttemptAt, n.lockedUntil, n.UpdatedAt = "pending", 0, ts, "", ts
+	r.db.t.notifications.set(id, n)
+	return true, nil
+}
+
+// GetSettings возвращает часовой пояс и тихие часы пользователя; если он их не задавал — пустые настройки
+func (r *NotificationRepository) GetSettings(ctx context.Context, userID int) (*models.NotificationSettings, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	s := r.db.t.settings[userID]
+	return &models.NotificationSettings{Timezone: s.timezone, QuietHoursStart: s.quietStart, QuietHoursEnd: s.quietEnd}, nil
+}
+
+// SaveSettings сохраняет часовой пояс и тихие часы; пустые start/end отключают тихие часы
+func (r *NotificationRepository) SaveSettings(ctx context.Context, userID int, timezone, quietStart, quietEnd string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	setKey(&r.db.t.settings, userID, settingsRow{timezone: timezone, quietStart: quietStart, quietEnd: quietEnd})
+	return nil
+}
+
+// ListPreferences возвращает сохранённые подписки пользователя; для остальных действует значение по умолчанию
+func (r *NotificationRepository) ListPreferences(ctx context.Context, userID int) ([]models.NotificationPreference, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var prefs []models.NotificationPreference
+	for k, enabled := range r.db.t.preferences {
+		if k.userID == userID {
+			prefs = append(prefs, models.NotificationPreference{Category: k.category, Channel: k.channel, Enabled: enabled})
+		}
+	}
+	sort.Slice(prefs, func(i, j int) bool {
+		if prefs[i].Category != prefs[j].Category {
+			return prefs[i].Category < prefs[j].Category
+		}
+		return prefs[i].Channel < prefs[j].Channel
+	})
+	return prefs, nil
+}
+
+func (r *NotificationRepository) SetPreferences(ctx context.Context, userID int, prefs []models.NotificationPreference) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	for _, p := range prefs {
+		setKey(&r.db.t.preferences, preferenceKey{userID: userID, category: p.Category, channel: p.Channel}, p.Enabled)
+	}
+	return nil
+}
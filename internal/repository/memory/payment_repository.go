@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type PaymentRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewPaymentRepository(db *DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *PaymentRepository) WithTx(tx *sql.Tx) repository.PaymentStore {
+	return &PaymentRepository{db: r.db, tx: tx}
+}
+
+// Create сохраняет платёж вместе с налоговой разбивкой.
+// Если разбивка не заполнена, платёж считается безналоговым: net = amount.
+func (r *PaymentRepository) Create(ctx context.Context, p *models.Payment) (*models.Payment, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	if p.ProductType == "" {
+		p.ProductType = models.ProductOther
+	}
+	if p.NetCents == 0 && p.TaxCents == 0 {
+		p.NetCents = p.AmountCents
+	}
+	id := r.db.t.payments.insert(func(id int) models.Payment {
+		return models.Payment{
+			ID: id, UserID: p.UserID, AmountCents: p.AmountCents, Currency: p.Currency, Method: p.Method, Status: p.Status,
+			Description: p.Description, ReferenceID: p.ReferenceID, ProductType: p.ProductType, GymID: copyInt(p.GymID),
+			NetCents: p.NetCents, TaxCents: p.TaxCents, TaxRateBP: p.TaxRateBP, MembershipID: copyInt(p.MembershipID),
+			CreatedAt: now(),
+		}
+	})
+	payment, _ := r.db.t.payments.get(id)
+	return &payment, nil
+}
+
+func (r *PaymentRepository) CreateStandalone(ctx context.Context, userID, amountCents int, currency, method, status, description, referenceID string) (*models.Payment, error) {
+	return r.Create(ctx, &models.Payment{
+		UserID:      userID,
+		AmountCents: amountCents,
+		Currency:    currency,
+		Method:      method,
+		Status:      status,
+		Description: description,
+		ReferenceID: referenceID,
+	})
+}
+
+func (r *PaymentRepository) GetByID(ctx context.Context, id int) (*models.Payment, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	p, ok := r.db.t.payments.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &p, nil
+}
+
+// newestFirst — ORDER BY created_at DESC; при равном времени создания первыми более поздние id
+func (r *PaymentRepository) newestFirst(match func(p models.Payment) bool) []models.Payment {
+	var payments []models.Payment
+	for _, id := range r.db.t.payments.ids() {
+		if p := r.db.t.payments.rows[id]; match(p) {
+			payments = append(payments, p)
+		}
+	}
+	sort.SliceStable(payments, func(i, j int) bool {
+		if payments[i].CreatedAt != payments[j].CreatedAt {
+			return payments[i].CreatedAt > payments[j].CreatedAt
+		}
+		return payments[i].ID > payments[j].ID
+	})
+	return payments
+}
+
+func (r *PaymentRepository) ListByGym(ctx context.Context, gymID int) ([]models.Payment, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	return r.newestFirst(func(p models.Payment) bool { return p.GymID != nil && *p.GymID == gymID }), nil
+}
+
+func (r *PaymentRepository) ListAll(ctx context.Context) ([]models.Payment, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	return r.newestFirst(func(models.Payment) bool { return true }), nil
+}
+
+func (r *PaymentRepository) CreateForMembership(ctx context.Context, userID, amountCents int, currency, method, description, referenceID string) (*models.Payment, error) {
+	return r.CreateStandalone(ctx, userID, amountCents, currency, method, "completed", description, referenceID)
+}
+
+func (r *PaymentRepository) GetByUser(ctx context.Context, userID int, status string) ([]models.Payment, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	return r.newestFirst(func(p models.Payment) bool {
+		return p.UserID == userID && (status == "" || p.Status == status)
+	}), nil
+}
+
+// SetFiscalRegistration сохраняет фискальный признак и ссылку на чек
+func (r *PaymentRepository) SetFiscalRegistration(ctx context.Context, paymentID int, sign, url string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if p, ok := r.db.t.payments.get(paymentID); ok {
+		p.FiscalSign, p.FiscalURL = sign, url
+		r.db.t.payments.set(paymentID, p)
+	}
+	return nil
+}
+
+// Refund добавляет возврат к платежу; при полном возврате платёж получает статус refunded.
+// Возвращает false, если платёж не завершён или сумма возвратов превысила бы сумму платежа.
+func (r *PaymentRepository) Refund(ctx context.Context, id, amountCents int) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	p, ok := r.db.t.payments.get(id)
+	if !ok || p.Status != "completed" || p.RefundedCents+amountCents > p.AmountCents {
+		return false, nil
+	}
+	p.RefundedCents += amountCents
+	if p.RefundedCents >= p.AmountCents {
+		p.Status = "refunded"
+	}
+	ts := now()
+	p.RefundedAt = &ts
+	r.db.t.payments.set(id, p)
+	return true, nil
+}
+
+// RevenueByCurrency суммирует завершённые и возвращённые платежи по валютам за вычетом возвратов
+func (r *PaymentRepository) RevenueByCurrency(ctx context.Context) (map[string]int, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	totals := make(map[string]int)
+	for _, p := range r.db.t.payments.rows {
+		if p.Status == "completed" || p.Status == "refunded" {
+			totals[p.Currency] += p.AmountCents - p.RefundedCents
+		}
+	}
+	return totals, nil
+}
+
+// TaxSummary группирует завершённые и возвращённые платежи за период [from, to) по типу продукта,
+// ставке и валюте. Возвраты вычитаются: налог уменьшается пропорционально доле возврата, нетто — остаток.
+func (r *PaymentRepository) TaxSummary(ctx context.Context, from, to string) ([]models.TaxSummaryRow, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	type key struct {
+		productType string
+		rateBP      int
+		currency    string
+	}
+	groups := make(map[key]*models.TaxSummaryRow)
+	var summary []*models.TaxSummaryRow
+	for _, p := range r.db.t.payments.rows {
+		if (p.Status != "completed" && p.Status != "refunded") || p.CreatedAt < from || p.CreatedAt >= to {
+			continue
+		}
+		k := key{p.ProductType, p.TaxRateBP, p.Currency}
+		row, ok := groups[k]
+		if !ok {
+			row = &models.TaxSummaryRow{ProductType: k.productType, TaxRateBP: k.rateBP, Currency: k.currency}
+			groups[k] = row
+			summary = append(summary, row)
+		}
+		gross, tax := p.AmountCents-p.RefundedCents, p.TaxCents
+		if p.AmountCents > 0 {
+			tax -= p.TaxCents * p.RefundedCents / p.AmountCents
+		}
+		row.PaymentsCount++
+		row.NetCents += gross - tax
+		row.TaxCents += tax
+		row.GrossCents += gross
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		a, b := summary[i], summary[j]
+		if a.ProductType != b.ProductType {
+			return a.ProductType < b.ProductType
+		}
+		if a.TaxRateBP != b.TaxRateBP {
+			return a.TaxRateBP < b.TaxRateBP
+		}
+		return a.Currency < b.Currency
+	})
+
+	var rows []models.TaxSummaryRow
+	for _, row := range summary {
+		rows = append(rows, *row)
+	}
+	return rows, nil
+}
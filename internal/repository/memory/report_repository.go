@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+// revenueKey возвращает ключ и подпись группы платежа p в отчёте по выручке
+type revenueKey func(t *tables, p models.Payment) (key, label string)
+
+// idKey — ключ по необязательной ссылке, "none" — если её нет
+func idKey(id *int) string {
+	if id == nil {
+		return "none"
+	}
+	return strconv.Itoa(*id)
+}
+
+var revenueGroups = map[string]revenueKey{
+	"day":    func(_ *tables, p models.Payment) (string, string) { return date(p.CreatedAt), "" },
+	"week":   func(_ *tables, p models.Payment) (string, string) { return week(p.CreatedAt), "" },
+	"month":  func(_ *tables, p models.Payment) (string, string) { return month(p.CreatedAt), "" },
+	"method": func(_ *tables, p models.Payment) (string, string) { return p.Method, "" },
+	"gym": func(t *tables, p models.Payment) (string, string) {
+		if p.GymID == nil {
+			return idKey(nil), ""
+		}
+		g, _ := t.gyms.get(*p.GymID)
+		return idKey(p.GymID), g.Name
+	},
+	"plan": func(t *tables, p models.Payment) (string, string) {
+		if p.MembershipID == nil {
+			return idKey(nil), ""
+		}
+		m, _ := t.memberships.get(*p.MembershipID)
+		return idKey(p.MembershipID), m.Name
+	},
+	"status": func(_ *tables, p models.Payment) (string, string) { return p.Status, "" },
+}
+
+// month повторяет strftime('%Y-%m', ...)
+func month(s string) string {
+	if d := date(s); d != "" {
+		return d[:len("2006-01")]
+	}
+	return ""
+}
+
+// week повторяет strftime('%Y-W%W', ...): неделя года с понедельника, 00 — дни до первого понедельника
+func week(s string) string {
+	d := date(s)
+	if d == "" {
+		return ""
+	}
+	t, _ := time.Parse("2006-01-02", d)
+	weekday := (int(t.Weekday()) + 6) % 7 // 0 — понедельник
+	return fmt.Sprintf("%d-W%02d", t.Year(), (t.YearDay()-1+7-weekday)/7)
+}
+
+type ReportRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewReportRepository(db *DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *ReportRepository) WithTx(tx *sql.Tx) repository.ReportStore {
+	return &ReportRepository{db: r.db, tx: tx}
+}
+
+// Revenue группирует платежи за период [from, to) по groupBy и валюте.
+// При группировке по статусу учитываются все платежи, в остальных случаях — только
+// завершённые и возвращённые, то есть те, по которым прошли деньги.
+func (r *ReportRepository) Revenue(ctx context.Context, groupBy, from, to string) ([]models.RevenueReportRow, error) {
+	group, ok := revenueGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported grouping: %s", groupBy)
+	}
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	type key struct{ key, currency string }
+	groups := make(map[key]*models.RevenueReportRow)
+	var report []*models.RevenueReportRow
+	for _, id := range r.db.t.payments.ids() {
+		p := r.db.t.payments.rows[id]
+		if groupBy != "status" && p.Status != "completed" && p.Status != "refunded" {
+			continue
+		}
+		if p.CreatedAt < from || p.CreatedAt >= to {
+			continue
+		}
+		k, label := group(&r.db.t, p)
+		row, ok := groups[key{k, p.Currency}]
+		if !ok {
+			row = &models.RevenueReportRow{Key: k, Currency: p.Currency}
+			groups[key{k, p.Currency}] = row
+			report = append(report, row)
+		}
+		// Подпись — MAX по группе, как в SQL
+		if label > row.Label {
+			row.Label = label
+		}
+		row.PaymentsCount++
+		row.GrossCents += p.AmountCents
+		row.RefundedCents += p.RefundedCents
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Key != report[j].Key {
+			return report[i].Key < report[j].Key
+		}
+		return report[i].Currency < report[j].Currency
+	})
+
+	var rows []models.RevenueReportRow
+	for _, row := range report {
+		row.RevenueCents = row.GrossCents - row.RefundedCents
+		rows = append(rows, *row)
+	}
+	return rows, nil
+}
+
+// ListSettled возвращает завершённые и возвращённые платежи за период [from, to); method = "" — все методы
+func (r *ReportRepository) ListSettled(ctx context.Context, from, to, method string) ([]models.Payment, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var payments []models.Payment
+	for _, id := range r.db.t.payments.ids() {
+		p := r.db.t.payments.rows[id]
+		if (p.Status == "completed" || p.Status == "refunded") && p.CreatedAt >= from && p.CreatedAt < to &&
+			(method == "" || p.Method == method) {
+			payments = append(payments, p)
+		}
+	}
+	return payments, nil
+}
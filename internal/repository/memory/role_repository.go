@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type RoleRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewRoleRepository(db *DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *RoleRepository) WithTx(tx *sql.Tx) repository.RoleStore {
+	return &RoleRepository{db: r.db, tx: tx}
+}
+
+func (r *RoleRepository) Assign(ctx context.Context, userID int, role string, gymID *int) (*models.UserRole, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	id := r.db.t.roles.insert(func(id int) models.UserRole {
+		return models.UserRole{ID: id, UserID: userID, Role: role, GymID: copyInt(gymID), CreatedAt: now()}
+	})
+	ur, _ := r.db.t.roles.get(id)
+	return &ur, nil
+}
+
+func (r *RoleRepository) GetByID(ctx context.Context, id int) (*models.UserRole, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	ur, ok := r.db.t.roles.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &ur, nil
+}
+
+// gymOrZero — IFNULL(gym_id, 0)
+func gymOrZero(gymID *int) int {
+	if gymID == nil {
+		return 0
+	}
+	return *gymID
+}
+
+// Exists проверяет, есть ли уже такая роль с той же областью действия
+func (r *RoleRepository) Exists(ctx context.Context, userID int, role string, gymID *int) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	for _, ur := range r.db.t.roles.rows {
+		if ur.UserID == userID && ur.Role == role && gymOrZero(ur.GymID) == gymOrZero(gymID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *RoleRepository) ListForUser(ctx context.Context, userID int) ([]models.UserRole, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var roles []models.UserRole
+	for _, id := range r.db.t.roles.ids() {
+		if ur := r.db.t.roles.rows[id]; ur.UserID == userID {
+			roles = append(roles, ur)
+		}
+	}
+	return roles, nil
+}
+
+func (r *RoleRepository) Delete(ctx context.Context, id int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.db.t.roles.delete(id)
+	return nil
+}
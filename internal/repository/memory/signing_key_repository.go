@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type SigningKeyRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewSigningKeyRepository(db *DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *SigningKeyRepository) WithTx(tx *sql.Tx) repository.SigningKeyStore {
+	return &SigningKeyRepository{db: r.db, tx: tx}
+}
+
+func (r *SigningKeyRepository) Create(ctx context.Context, kid, algorithm, privateKey string) (*models.SigningKey, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	for _, k := range r.db.t.signingKeys.rows {
+		if k.KID == kid {
+			return nil, errUnique("signing_keys.kid")
+		}
+	}
+	id := r.db.t.signingKeys.insert(func(id int) models.SigningKey {
+		return models.SigningKey{ID: id, KID: kid, Algorithm: algorithm, PrivateKey: privateKey, CreatedAt: truncTime(time.Now())}
+	})
+	key, _ := r.db.t.signingKeys.get(id)
+	return &key, nil
+}
+
+// ListPublished возвращает ключи, которые ещё не истекли, — новые первыми
+func (r *SigningKeyRepository) ListPublished(ctx context.Context, now time.Time) ([]models.SigningKey, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var keys []models.SigningKey
+	ids := r.db.t.signingKeys.ids()
+	for i := len(ids) - 1; i >= 0; i-- {
+		if key := r.db.t.signingKeys.rows[ids[i]]; key.ExpiresAt == nil || key.ExpiresAt.After(truncTime(now)) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// RetireOthers снимает с подписи все ключи, кроме keepID; они остаются в JWKS до expiresAt
+func (r *SigningKeyRepository) RetireOthers(ctx context.Context, keepID int, rotatedAt, expiresAt time.Time) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	for id, key := range r.db.t.signingKeys.rows {
+		if key.RotatedAt == nil && id != keepID {
+			rotated, expires := truncTime(rotatedAt), truncTime(expiresAt)
+			key.RotatedAt, key.ExpiresAt = &rotated, &expires
+			r.db.t.signingKeys.set(id, key)
+		}
+	}
+	return nil
+}
+
+// DeleteExpired удаляет ключи, которые больше не публикуются
+func (r *SigningKeyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, err
+	}
+	defer r.db.unlock()
+
+	var n int64
+	for id, key := range r.db.t.signingKeys.rows {
+		if key.ExpiresAt != nil && !key.ExpiresAt.After(truncTime(now)) {
+			r.db.t.signingKeys.delete(id)
+			n++
+		}
+	}
+	return n, nil
+}
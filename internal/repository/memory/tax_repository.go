@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type TaxRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewTaxRepository(db *DB) *TaxRepository {
+	return &TaxRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *TaxRepository) WithTx(tx *sql.Tx) repository.TaxStore {
+	return &TaxRepository{db: r.db, tx: tx}
+}
+
+func (r *TaxRepository) Create(ctx context.Context, t *models.TaxRate) (*models.TaxRate, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	id := r.db.t.taxRates.insert(func(id int) models.TaxRate {
+		rate := *t
+		rate.ID, rate.GymID, rate.CreatedAt = id, copyInt(t.GymID), now()
+		return rate
+	})
+	rate, _ := r.db.t.taxRates.get(id)
+	return &rate, nil
+}
+
+func (r *TaxRepository) GetByID(ctx context.Context, id int) (*models.TaxRate, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	t, ok := r.db.t.taxRates.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &t, nil
+}
+
+// gymLess — порядок gym_id в SQLite: NULL раньше любого зала
+func gymLess(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+	return *a < *b
+}
+
+func (r *TaxRepository) List(ctx context.Context) ([]models.TaxRate, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var rates []models.TaxRate
+	for _, id := range r.db.t.taxRates.ids() {
+		rates = append(rates, r.db.t.taxRates.rows[id])
+	}
+	sort.SliceStable(rates, func(i, j int) bool {
+		if rates[i].ProductType != rates[j].ProductType {
+			return rates[i].ProductType < rates[j].ProductType
+		}
+		return gymLess(rates[i].GymID, rates[j].GymID)
+	})
+	return rates, nil
+}
+
+// FindApplicable возвращает ставку для зала, а если её нет — ставку по умолчанию для типа продукта
+func (r *TaxRepository) FindApplicable(ctx context.Context, productType string, gymID *int) (*models.TaxRate, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var fallback *models.TaxRate
+	for _, id := range r.db.t.taxRates.ids() {
+		t := r.db.t.taxRates.rows[id]
+		if t.ProductType != productType {
+			continue
+		}
+		if t.GymID != nil && gymID != nil && *t.GymID == *gymID {
+			return &t, nil
+		}
+		if t.GymID == nil && fallback == nil {
+			fallback = &t
+		}
+	}
+	if fallback == nil {
+		return nil, sql.ErrNoRows
+	}
+	return fallback, nil
+}
+
+func (r *TaxRepository) Update(ctx context.Context, id int, t *models.TaxRate) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if old, ok := r.db.t.taxRates.get(id); ok {
+		rate := *t
+		rate.ID, rate.GymID, rate.CreatedAt = id, copyInt(t.GymID), old.CreatedAt
+		r.db.t.taxRates.set(id, rate)
+	}
+	return nil
+}
+
+func (r *TaxRepository) Delete(ctx context.Context, id int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.db.t.taxRates.delete(id)
+	return nil
+}
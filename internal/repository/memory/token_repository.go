@@ -0,0 +1,284 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type userTokenRow struct {
+	userID    int
+	purpose   string
+	tokenHash string
+	expiresAt string
+	used      bool
+}
+
+type recoveryCodeRow struct {
+	userID   int
+	codeHash string
+	used     bool
+}
+
+type TokenRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewTokenRepository(db *DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *TokenRepository) WithTx(tx *sql.Tx) repository.TokenStore {
+	return &TokenRepository{db: r.db, tx: tx}
+}
+
+func (r *TokenRepository) CreateRefresh(ctx context.Context, userID int, familyID, tokenHash string, expiresAt time.Time) (int, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, err
+	}
+	defer r.db.unlock()
+
+	for _, t := range r.db.t.refreshTokens.rows {
+		if t.TokenHash == tokenHash {
+			return 0, errUnique("refresh_tokens.token_hash")
+		}
+	}
+	return r.db.t.refreshTokens.insert(func(id int) models.RefreshToken {
+		return models.RefreshToken{
+			ID: id, UserID: userID, FamilyID: familyID, TokenHash: tokenHash, ExpiresAt: formatTime(expiresAt), CreatedAt: now(),
+		}
+	}), nil
+}
+
+func (r *TokenRepository) GetRefreshByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	for _, t := range r.db.t.refreshTokens.rows {
+		if t.TokenHash == tokenHash {
+			t.Expired = t.ExpiresAt <= now()
+			return &t, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// revokeRefresh отзывает ещё не отозванные токены, подходящие под match
+func (r *TokenRepository) revokeRefresh(match func(t models.RefreshToken) bool, replacedBy *int) int {
+	revoked := 0
+	ts := now()
+	for id, t := range r.db.t.refreshTokens.rows {
+		if t.RevokedAt != nil || !match(t) {
+			continue
+		}
+		t.RevokedAt = &ts
+		if replacedBy != nil {
+			t.ReplacedBy = copyInt(replacedBy)
+		}
+		r.db.t.refreshTokens.set(id, t)
+		revoked++
+	}
+	return revoked
+}
+
+// RotateRefresh отзывает токен id, заменяя его на replacedBy.
+// Возвращает false, если токен уже был отозван (например, параллельным запросом).
+func (r *TokenRepository) RotateRefresh(ctx context.Context, id, replacedBy int) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	n := r.revokeRefresh(func(t models.RefreshToken) bool { return t.ID == id }, &replacedBy)
+	return n > 0, nil
+}
+
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.revokeRefresh(func(t models.RefreshToken) bool { return t.FamilyID == familyID }, nil)
+	return nil
+}
+
+func (r *TokenRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.revokeRefresh(func(t models.RefreshToken) bool { return t.UserID == userID }, nil)
+	return nil
+}
+
+// RevokeAccess добавляет jti access-токена в denylist до истечения его срока
+func (r *TokenRepository) RevokeAccess(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if _, ok := r.db.t.revokedAccess[jti]; !ok {
+		setKey(&r.db.t.revokedAccess, jti, formatTime(expiresAt))
+	}
+	return nil
+}
+
+func (r *TokenRepository) IsAccessRevoked(ctx context.Context, jti string) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	_, ok := r.db.t.revokedAccess[jti]
+	return ok, nil
+}
+
+// PurgeExpired удаляет истёкшие записи denylist и refresh-токены
+func (r *TokenRepository) PurgeExpired(ctx context.Context, now time.Time) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	ts := formatTime(now)
+	for jti, expiresAt := range r.db.t.revokedAccess {
+		if expiresAt < ts {
+			delete(r.db.t.revokedAccess, jti)
+		}
+	}
+	for id, t := range r.db.t.refreshTokens.rows {
+		if t.ExpiresAt < ts {
+			r.db.t.refreshTokens.delete(id)
+		}
+	}
+	return nil
+}
+
+func (r *TokenRepository) CreateUserToken(ctx context.Context, userID int, purpose, tokenHash string, expiresAt time.Time) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	for _, t := range r.db.t.userTokens.rows {
+		if t.tokenHash == tokenHash {
+			return errUnique("user_tokens.token_hash")
+		}
+	}
+	r.db.t.userTokens.insert(func(int) userTokenRow {
+		return userTokenRow{userID: userID, purpose: purpose, tokenHash: tokenHash, expiresAt: formatTime(expiresAt)}
+	})
+	return nil
+}
+
+// ConsumeUserToken помечает действующий токен использованным и возвращает его владельца.
+// Просроченный, уже использованный или неизвестный токен даёт sql.ErrNoRows.
+func (r *TokenRepository) ConsumeUserToken(ctx context.Context, purpose, tokenHash string) (int, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, err
+	}
+	defer r.db.unlock()
+
+	ts := now()
+	for id, t := range r.db.t.userTokens.rows {
+		if t.tokenHash == tokenHash && t.purpose == purpose && !t.used && t.expiresAt > ts {
+			t.used = true
+			r.db.t.userTokens.set(id, t)
+			return t.userID, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+// InvalidateUserTokens гасит ранее выданные токены, чтобы действовал только последний
+func (r *TokenRepository) InvalidateUserTokens(ctx context.Context, userID int, purpose string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	for id, t := range r.db.t.userTokens.rows {
+		if t.userID == userID && t.purpose == purpose {
+			t.used = true
+			r.db.t.userTokens.set(id, t)
+		}
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления пользователя новыми
+func (r *TokenRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.deleteRecoveryCodes(userID)
+	for _, h := range codeHashes {
+		r.db.t.recoveryCodes.insert(func(int) recoveryCodeRow {
+			return recoveryCodeRow{userID: userID, codeHash: h}
+		})
+	}
+	return nil
+}
+
+// UseRecoveryCode гасит неиспользованный код; возвращает false, если такого кода нет
+func (r *TokenRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	for _, id := range r.db.t.recoveryCodes.ids() {
+		c := r.db.t.recoveryCodes.rows[id]
+		if c.userID == userID && c.codeHash == codeHash && !c.used {
+			c.used = true
+			r.db.t.recoveryCodes.set(id, c)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *TokenRepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, err
+	}
+	defer r.db.unlock()
+
+	n := 0
+	for _, c := range r.db.t.recoveryCodes.rows {
+		if c.userID == userID && !c.used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *TokenRepository) DeleteRecoveryCodes(ctx context.Context, userID int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.deleteRecoveryCodes(userID)
+	return nil
+}
+
+func (r *TokenRepository) deleteRecoveryCodes(userID int) {
+	for id, c := range r.db.t.recoveryCodes.rows {
+		if c.userID == userID {
+			r.db.t.recoveryCodes.delete(id)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type TrainerRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewTrainerRepository(db *DB) *TrainerRepository {
+	return &TrainerRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *TrainerRepository) WithTx(tx *sql.Tx) repository.TrainerStore {
+	return &TrainerRepository{db: r.db, tx: tx}
+}
+
+func (r *TrainerRepository) Create(ctx context.Context, name, bio string) (*models.Trainer, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	id := r.db.t.trainers.insert(func(id int) models.Trainer {
+		return models.Trainer{ID: id, Name: name, Bio: bio, CreatedAt: now()}
+	})
+	t, _ := r.db.t.trainers.get(id)
+	return &t, nil
+}
+
+func (r *TrainerRepository) GetByID(ctx context.Context, id int) (*models.Trainer, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	t, ok := r.db.t.trainers.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &t, nil
+}
+
+func (r *TrainerRepository) List(ctx context.Context) ([]models.Trainer, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var trainers []models.Trainer
+	for _, id := range r.db.t.trainers.ids() {
+		trainers = append(trainers, r.db.t.trainers.rows[id])
+	}
+	return trainers, nil
+}
+
+func (r *TrainerRepository) Update(ctx context.Context, id int, name, bio string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if t, ok := r.db.t.trainers.get(id); ok {
+		t.Name, t.Bio = name, bio
+		r.db.t.trainers.set(id, t)
+	}
+	return nil
+}
+
+func (r *TrainerRepository) Delete(ctx context.Context, id int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.db.t.trainers.delete(id)
+	return nil
+}
@@ -0,0 +1,232 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+// userRow — пользователь вместе с полями, которых нет в модели
+type userRow struct {
+	models.User
+	tokenVersion int
+	totpSecret   string
+	totpLastStep int64
+}
+
+type UserRepository struct {
+	db *DB
+	tx *sql.Tx
+}
+
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// WithTx возвращает то же хранилище: транзакции в памяти ведёт DB.Do
+func (r *UserRepository) WithTx(tx *sql.Tx) repository.UserStore {
+	return &UserRepository{db: r.db, tx: tx}
+}
+
+// emailTaken проверяет уникальность email; exceptID — сам изменяемый пользователь
+func (r *UserRepository) emailTaken(email string, exceptID int) bool {
+	for id, u := range r.db.t.users.rows {
+		if id != exceptID && u.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *UserRepository) Create(ctx context.Context, name, email, passwordHash string, isAdmin bool) (*models.User, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	if r.emailTaken(email, 0) {
+		return nil, errUnique("users.email")
+	}
+	id := r.db.t.users.insert(func(id int) userRow {
+		return userRow{User: models.User{
+			ID: id, Name: name, Email: email, PasswordHash: passwordHash, IsAdmin: isAdmin, Locale: "ru", CreatedAt: now(),
+		}}
+	})
+	return r.user(id)
+}
+
+// user возвращает пользователя без хеша пароля, как GetByID в SQLite
+func (r *UserRepository) user(id int) (*models.User, error) {
+	row, ok := r.db.t.users.get(id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	u := row.User
+	u.PasswordHash = ""
+	return &u, nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	for _, id := range r.db.t.users.ids() {
+		if row := r.db.t.users.rows[id]; row.Email == email {
+			u := row.User
+			return &u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	return r.user(id)
+}
+
+func (r *UserRepository) List(ctx context.Context) ([]models.User, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return nil, err
+	}
+	defer r.db.unlock()
+
+	var users []models.User
+	for _, id := range r.db.t.users.ids() {
+		u, _ := r.user(id)
+		users = append(users, *u)
+	}
+	return users, nil
+}
+
+// update меняет пользователя id, если он есть; как UPDATE в SQLite, отсутствие строки — не ошибка
+func (r *UserRepository) update(ctx context.Context, id int, fn func(u *userRow)) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	if row, ok := r.db.t.users.get(id); ok {
+		fn(&row)
+		r.db.t.users.set(id, row)
+	}
+	return nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, id int, name, email string) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	row, ok := r.db.t.users.get(id)
+	if !ok {
+		return nil
+	}
+	if r.emailTaken(email, id) {
+		return errUnique("users.email")
+	}
+	row.Name, row.Email = name, email
+	r.db.t.users.set(id, row)
+	return nil
+}
+
+func (r *UserRepository) SetLocale(ctx context.Context, id int, locale string) error {
+	return r.update(ctx, id, func(u *userRow) { u.Locale = locale })
+}
+
+// SetPhone задаёт телефон для SMS; пустая строка удаляет его
+func (r *UserRepository) SetPhone(ctx context.Context, id int, phone string) error {
+	return r.update(ctx, id, func(u *userRow) { u.Phone = phone })
+}
+
+// SetPushToken задаёт токен устройства для push-уведомлений; пустая строка удаляет его
+func (r *UserRepository) SetPushToken(ctx context.Context, id int, token string) error {
+	return r.update(ctx, id, func(u *userRow) { u.PushToken = token })
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id int) error {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return err
+	}
+	defer r.db.unlock()
+
+	r.db.t.users.delete(id)
+	return nil
+}
+
+func (r *UserRepository) GetTokenVersion(ctx context.Context, id int) (int, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return 0, err
+	}
+	defer r.db.unlock()
+
+	row, ok := r.db.t.users.get(id)
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return row.tokenVersion, nil
+}
+
+// IncrementTokenVersion делает недействительными все выданные пользователю access-токены
+func (r *UserRepository) IncrementTokenVersion(ctx context.Context, id int) error {
+	return r.update(ctx, id, func(u *userRow) { u.tokenVersion++ })
+}
+
+func (r *UserRepository) SetPasswordHash(ctx context.Context, id int, passwordHash string) error {
+	return r.update(ctx, id, func(u *userRow) { u.PasswordHash = passwordHash })
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id int) error {
+	return r.update(ctx, id, func(u *userRow) { u.EmailVerified = true })
+}
+
+// GetTOTP возвращает TOTP-секрет пользователя, признак включения 2FA и последний принятый шаг
+func (r *UserRepository) GetTOTP(ctx context.Context, id int) (string, bool, int64, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return "", false, 0, err
+	}
+	defer r.db.unlock()
+
+	row, ok := r.db.t.users.get(id)
+	if !ok {
+		return "", false, 0, sql.ErrNoRows
+	}
+	return row.totpSecret, row.TOTPEnabled, row.totpLastStep, nil
+}
+
+// SetTOTP сохраняет секрет; enabled = false означает, что настройка ещё не подтверждена кодом
+func (r *UserRepository) SetTOTP(ctx context.Context, id int, secret string, enabled bool) error {
+	return r.update(ctx, id, func(u *userRow) {
+		u.totpSecret, u.TOTPEnabled, u.totpLastStep = secret, enabled, 0
+	})
+}
+
+func (r *UserRepository) DisableTOTP(ctx context.Context, id int) error {
+	return r.update(ctx, id, func(u *userRow) {
+		u.totpSecret, u.TOTPEnabled, u.totpLastStep = "", false, 0
+	})
+}
+
+// UseTOTPStep запоминает шаг принятого кода; возвращает false, если код этого или более позднего шага уже использован
+func (r *UserRepository) UseTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	if err := r.db.lock(ctx, r.tx); err != nil {
+		return false, err
+	}
+	defer r.db.unlock()
+
+	row, ok := r.db.t.users.get(id)
+	if !ok || row.totpLastStep >= step {
+		return false, nil
+	}
+	row.totpLastStep = step
+	r.db.t.users.set(id, row)
+	return true, nil
+}
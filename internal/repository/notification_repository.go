@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type NotificationRepository struct {
+	db DBTX
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *NotificationRepository) WithTx(tx *sql.Tx) NotificationStore {
+	if tx == nil {
+		return r
+	}
+	return &NotificationRepository{db: tx}
+}
+
+const notificationColumns = `id, channel, recipient, fallback, template, locale, subject, text_body, body, unsubscribe_url, status, attempts, next_attempt_at, COALESCE(last_error, ''), sent_at, created_at, updated_at`
+
+func scanNotification(row rowScanner, n *models.NotificationOutboxEntry) error {
+	return row.Scan(&n.ID, &n.Channel, &n.Recipient, &n.Fallback, &n.Template, &n.Locale, &n.Subject, &n.TextBody, &n.Body, &n.UnsubscribeURL, &n.Status, &n.Attempts, &n.NextAttemptAt, &n.LastError,
+		&n.SentAt, &n.CreatedAt, &n.UpdatedAt)
+}
+
+func scanNotifications(rows *sql.Rows) ([]models.NotificationOutboxEntry, error) {
+	defer rows.Close()
+
+	var entries []models.NotificationOutboxEntry
+	for rows.Next() {
+		var n models.NotificationOutboxEntry
+		if err := scanNotification(rows, &n); err != nil {
+			return nil, err
+		}
+		entries = append(entries, n)
+	}
+	return entries, rows.Err()
+}
+
+// Enqueue ставит письмо в очередь. Через WithTx запись попадает в ту же транзакцию,
+// что и бизнес-изменение, и не появится, если транзакция будет отменена.
+// Без NextAttemptAt письмо отправляется сразу.
+func (r *NotificationRepository) Enqueue(ctx context.Context, n *models.NotificationOutboxEntry) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO notification_outbox
+			(channel, recipient, fallback, template, locale, subject, text_body, body, unsubscribe_url, next_attempt_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), CURRENT_TIMESTAMP))`,
+		n.Channel, n.Recipient, n.Fallback, n.Template, n.Locale, n.Subject, n.TextBody, n.Body,
+		n.UnsubscribeURL, n.NextAttemptAt)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+	return int(id), nil
+}
+
+func (r *NotificationRepository) GetByID(ctx context.Context, id int) (*models.NotificationOutboxEntry, error) {
+	n := &models.NotificationOutboxEntry{}
+	err := scanNotification(r.db.QueryRowContext(ctx, `SELECT `+notificationColumns+` FROM notification_outbox WHERE id = ?`, id), n)
+	return n, err
+}
+
+func (r *NotificationRepository) List(ctx context.Context, status string) ([]models.NotificationOutboxEntry, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notification_outbox`
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanNotifications(rows)
+}
+
+// ClaimDue забирает до limit писем, время отправки которых наступило, и помечает их sending до now+lease.
+// Письма, зависшие в sending дольше lease (например, после падения процесса), забираются повторно.
+func (r *NotificationRepository) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.NotificationOutboxEntry, error) {
+	nowStr := now.UTC().Format(sqliteTimeLayout)
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+notificationColumns+` FROM notification_outbox
+		WHERE (status = 'pending' AND next_attempt_at <= ?) OR (status = 'sending' AND locked_until <= ?)
+		ORDER BY next_attempt_at, id
+		LIMIT ?`, nowStr, nowStr, limit)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := scanNotifications(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Запись достаётся тому, чей UPDATE её изменил, — так несколько экземпляров не отправят письмо дважды
+	lockedUntil := now.Add(lease).UTC().Format(sqliteTimeLayout)
+	var claimed []models.NotificationOutboxEntry
+	for _, n := range candidates {
+		res, err := r.db.ExecContext(ctx, `
+			UPDATE notification_outbox SET status = 'sending', locked_until = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND status = ? AND (status = 'pending' OR locked_until <= ?)`,
+			lockedUntil, n.ID, n.Status, nowStr)
+		if err != nil {
+			return claimed, err
+		}
+		if affected, _ := res.RowsAffected(); affected == 1 {
+			n.Status = "sending"
+			claimed = append(claimed, n)
+		}
+	}
+	return claimed, nil
+}
+
+// MarkSent отмечает отправку; текст письма больше не нужен и стирается
+func (r *NotificationRepository) MarkSent(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE notification_outbox
+		SET status = 'sent', attempts = attempts + 1, text_body = '', body = '', last_error = NULL, locked_until = NULL,
+			sent_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, id)
+	return err
+}
+
+// MarkFailed фиксирует неудачную попытку; status = pending для повтора в nextAttempt или dead
+func (r *NotificationRepository) MarkFailed(ctx context.Context, id int, status, lastError string, nextAttempt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE notification_outbox
+		SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = ?, locked_until = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, status, lastError, nextAttempt.UTC().Format(sqliteTimeLayout), id)
+	return err
+}
+
+// Fallback переключает неотправленное сообщение на запасной канал: отправка в nextAttempt
+// с новым запасом попыток, fallback — оставшиеся запасные каналы
+func (r *NotificationRepository) Fallback(ctx context.Context, id int, channel, recipient, fallback, lastError string, nextAttempt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE notification_outbox
+		SET status = 'pending', channel = ?, recipient = ?, fallback = ?, attempts = 0, last_error = ?,
+			next_attempt_at = ?, locked_until = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, channel, recipient, fallback, lastError, nextAttempt.UTC().Format(sqliteTimeLayout), id)
+	return err
+}
+
+// Resend возвращает неотправленное письмо в очередь с новым запасом попыток
+func (r *NotificationRepository) Resend(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE notification_outbox
+		SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, locked_until = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status IN ('pending', 'dead')`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// GetSettings возвращает часовой пояс и тихие часы пользователя; если он их не задавал — пустые настройки
+func (r *NotificationRepository) GetSettings(ctx context.Context, userID int) (*models.NotificationSettings, error) {
+	s := &models.NotificationSettings{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT timezone, COALESCE(quiet_hours_start, ''), COALESCE(quiet_hours_end, '')
+		FROM notification_settings WHERE user_id = ?`, userID).
+		Scan(&s.Timezone, &s.QuietHoursStart, &s.QuietHoursEnd)
+	if err == sql.ErrNoRows {
+		return s, nil
+	}
+	return s, err
+}
+
+// SaveSettings сохраняет часовой пояс и тихие часы; пустые start/end отключают тихие часы
+func (r *NotificationRepository) SaveSettings(ctx context.Context, userID int, timezone, quietStart, quietEnd string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO notification_settings (user_id, timezone, quiet_hours_start, quiet_hours_end)
+		VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''))
+		ON CONFLICT(user_id) DO UPDATE SET timezone = excluded.timezone,
+			quiet_hours_start = excluded.quiet_hours_start, quiet_hours_end = excluded.quiet_hours_end,
+			updated_at = CURRENT_TIMESTAMP`,
+		userID, timezone, quietStart, quietEnd)
+	return err
+}
+
+// ListPreferences возвращает сохранённые подписки пользователя; для остальных действует значение по умолчанию
+func (r *NotificationRepository) ListPreferences(ctx context.Context, userID int) ([]models.NotificationPreference, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT category, channel, enabled FROM notification_preferences WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prefs []models.NotificationPreference
+	for rows.Next() {
+		var p models.NotificationPreference
+		if err := rows.Scan(&p.Category, &p.Channel, &p.Enabled); err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, p)
+	}
+	return prefs, rows.Err()
+}
+
+// SetPreferences сохраняет подписки одной транзакцией
+func (r *NotificationRepository) SetPreferences(ctx context.Context, userID int, prefs []models.NotificationPreference) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		for _, p := range prefs {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO notification_preferences (user_id, category, channel, enabled) VALUES (?, ?, ?, ?)
+				ON CONFLICT(user_id, category, channel) DO UPDATE SET enabled = excluded.enabled, updated_at = CURRENT_TIMESTAMP`,
+				userID, p.Category, p.Channel, p.Enabled); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -2,64 +2,117 @@ package repository
 
 import (
 	"Gym_StrongCode/internal/models"
+	"context"
 	"database/sql"
 )
 
+const paymentColumns = `id, user_id, amount_cents, currency, method, status, description, reference_id,
+		COALESCE(product_type, 'other'), gym_id, COALESCE(net_cents, amount_cents), COALESCE(tax_cents, 0), COALESCE(tax_rate_bp, 0),
+		COALESCE(fiscal_sign, ''), COALESCE(fiscal_url, ''), membership_id, COALESCE(refunded_cents, 0), refunded_at, created_at`
+
 type PaymentRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewPaymentRepository(db *sql.DB) *PaymentRepository {
 	return &PaymentRepository{db: db}
 }
 
-func (r *PaymentRepository) CreateStandalone(userID, amountCents int, currency, method, status, description, referenceID string) (*models.Payment, error) {
-	res, err := r.db.Exec(`
-        INSERT INTO payments (user_id, amount_cents, currency, method, status, description, reference_id)
-        VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		userID, amountCents, currency, method, status, description, referenceID)
-	if err != nil {
-		return nil, err
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *PaymentRepository) WithTx(tx *sql.Tx) PaymentStore {
+	if tx == nil {
+		return r
 	}
-	id, _ := res.LastInsertId()
-	return r.GetByID(int(id))
+	return &PaymentRepository{db: tx}
 }
 
-func (r *PaymentRepository) GetByID(id int) (*models.Payment, error) {
-	p := &models.Payment{}
-	err := r.db.QueryRow(`
-		SELECT id, user_id, amount_cents, currency, method, status, description, reference_id, created_at
-		FROM payments WHERE id = ?`, id).
-		Scan(&p.ID, &p.UserID, &p.AmountCents, &p.Currency, &p.Method, &p.Status, &p.Description, &p.ReferenceID, &p.CreatedAt)
-	return p, err
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (r *PaymentRepository) ListAll() ([]models.Payment, error) {
-	rows, err := r.db.Query(`
-		SELECT id, user_id, amount_cents, currency, method, status, description, reference_id, created_at
-		FROM payments ORDER BY created_at DESC`)
-	if err != nil {
-		return nil, err
-	}
+func scanPayment(row rowScanner, p *models.Payment) error {
+	return row.Scan(&p.ID, &p.UserID, &p.AmountCents, &p.Currency, &p.Method, &p.Status, &p.Description, &p.ReferenceID,
+		&p.ProductType, &p.GymID, &p.NetCents, &p.TaxCents, &p.TaxRateBP, &p.FiscalSign, &p.FiscalURL,
+		&p.MembershipID, &p.RefundedCents, &p.RefundedAt, &p.CreatedAt)
+}
+
+func scanPayments(rows *sql.Rows) ([]models.Payment, error) {
 	defer rows.Close()
 
 	var payments []models.Payment
 	for rows.Next() {
 		var p models.Payment
-		if err := rows.Scan(&p.ID, &p.UserID, &p.AmountCents, &p.Currency, &p.Method, &p.Status, &p.Description, &p.ReferenceID, &p.CreatedAt); err != nil {
+		if err := scanPayment(rows, &p); err != nil {
 			return nil, err
 		}
 		payments = append(payments, p)
 	}
 	return payments, nil
 }
-func (r *PaymentRepository) CreateForMembership(userID, amountCents int, currency, method, description, referenceID string) (*models.Payment, error) {
-	return r.CreateStandalone(userID, amountCents, currency, method, "completed", description, referenceID)
+
+// Create сохраняет платёж вместе с налоговой разбивкой.
+// Если разбивка не заполнена, платёж считается безналоговым: net = amount.
+func (r *PaymentRepository) Create(ctx context.Context, p *models.Payment) (*models.Payment, error) {
+	if p.ProductType == "" {
+		p.ProductType = models.ProductOther
+	}
+	if p.NetCents == 0 && p.TaxCents == 0 {
+		p.NetCents = p.AmountCents
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+        INSERT INTO payments (user_id, amount_cents, currency, method, status, description, reference_id,
+            product_type, gym_id, net_cents, tax_cents, tax_rate_bp, membership_id)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.UserID, p.AmountCents, p.Currency, p.Method, p.Status, p.Description, p.ReferenceID,
+		p.ProductType, p.GymID, p.NetCents, p.TaxCents, p.TaxRateBP, p.MembershipID)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res.LastInsertId()
+	return r.GetByID(ctx, int(id))
+}
+
+func (r *PaymentRepository) CreateStandalone(ctx context.Context, userID, amountCents int, currency, method, status, description, referenceID string) (*models.Payment, error) {
+	return r.Create(ctx, &models.Payment{
+		UserID:      userID,
+		AmountCents: amountCents,
+		Currency:    currency,
+		Method:      method,
+		Status:      status,
+		Description: description,
+		ReferenceID: referenceID,
+	})
+}
+
+func (r *PaymentRepository) GetByID(ctx context.Context, id int) (*models.Payment, error) {
+	p := &models.Payment{}
+	err := scanPayment(r.db.QueryRowContext(ctx, `SELECT `+paymentColumns+` FROM payments WHERE id = ?`, id), p)
+	return p, err
+}
+
+func (r *PaymentRepository) ListByGym(ctx context.Context, gymID int) ([]models.Payment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+paymentColumns+` FROM payments WHERE gym_id = ? ORDER BY created_at DESC`, gymID)
+	if err != nil {
+		return nil, err
+	}
+	return scanPayments(rows)
 }
 
-func (r *PaymentRepository) GetByUser(userID int, status string) ([]models.Payment, error) {
-	query := `SELECT id, user_id, amount_cents, currency, method, status, description, reference_id, created_at
-		FROM payments WHERE user_id = ?`
+func (r *PaymentRepository) ListAll(ctx context.Context) ([]models.Payment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+paymentColumns+` FROM payments ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	return scanPayments(rows)
+}
+
+func (r *PaymentRepository) CreateForMembership(ctx context.Context, userID, amountCents int, currency, method, description, referenceID string) (*models.Payment, error) {
+	return r.CreateStandalone(ctx, userID, amountCents, currency, method, "completed", description, referenceID)
+}
+
+func (r *PaymentRepository) GetByUser(ctx context.Context, userID int, status string) ([]models.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE user_id = ?`
 	args := []interface{}{userID}
 
 	if status != "" {
@@ -68,19 +121,86 @@ func (r *PaymentRepository) GetByUser(userID int, status string) ([]models.Payme
 	}
 	query += " ORDER BY created_at DESC"
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanPayments(rows)
+}
+
+// SetFiscalRegistration сохраняет фискальный признак и ссылку на чек
+func (r *PaymentRepository) SetFiscalRegistration(ctx context.Context, paymentID int, sign, url string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE payments SET fiscal_sign = ?, fiscal_url = ? WHERE id = ?`, sign, url, paymentID)
+	return err
+}
+
+// Refund добавляет возврат к платежу; при полном возврате платёж получает статус refunded.
+// Возвращает false, если платёж не завершён или сумма возвратов превысила бы сумму платежа.
+func (r *PaymentRepository) Refund(ctx context.Context, id, amountCents int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE payments
+		SET refunded_cents = COALESCE(refunded_cents, 0) + ?,
+			status = CASE WHEN COALESCE(refunded_cents, 0) + ? >= amount_cents THEN 'refunded' ELSE status END,
+			refunded_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'completed' AND COALESCE(refunded_cents, 0) + ? <= amount_cents`,
+		amountCents, amountCents, id, amountCents)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RevenueByCurrency суммирует завершённые и возвращённые платежи по валютам за вычетом возвратов
+func (r *PaymentRepository) RevenueByCurrency(ctx context.Context) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT currency, COALESCE(SUM(amount_cents - COALESCE(refunded_cents, 0)), 0)
+		FROM payments WHERE status IN ('completed', 'refunded') GROUP BY currency`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var payments []models.Payment
+	totals := make(map[string]int)
 	for rows.Next() {
-		var p models.Payment
-		if err := rows.Scan(&p.ID, &p.UserID, &p.AmountCents, &p.Currency, &p.Method, &p.Status, &p.Description, &p.ReferenceID, &p.CreatedAt); err != nil {
+		var currency string
+		var total int
+		if err := rows.Scan(&currency, &total); err != nil {
 			return nil, err
 		}
-		payments = append(payments, p)
+		totals[currency] = total
 	}
-	return payments, nil
+	return totals, nil
+}
+
+// TaxSummary группирует завершённые и возвращённые платежи за период [from, to) по типу продукта,
+// ставке и валюте. Возвраты вычитаются: налог уменьшается пропорционально доле возврата, нетто — остаток.
+func (r *PaymentRepository) TaxSummary(ctx context.Context, from, to string) ([]models.TaxSummaryRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT product_type, tax_rate_bp, currency, COUNT(*),
+			COALESCE(SUM(gross - tax), 0), COALESCE(SUM(tax), 0), COALESCE(SUM(gross), 0)
+		FROM (
+			SELECT COALESCE(product_type, 'other') AS product_type, COALESCE(tax_rate_bp, 0) AS tax_rate_bp, currency,
+				amount_cents - COALESCE(refunded_cents, 0) AS gross,
+				COALESCE(tax_cents, 0) - COALESCE(COALESCE(tax_cents, 0) * COALESCE(refunded_cents, 0) / NULLIF(amount_cents, 0), 0) AS tax
+			FROM payments
+			WHERE status IN ('completed', 'refunded') AND created_at >= ? AND created_at < ?
+		)
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summary []models.TaxSummaryRow
+	for rows.Next() {
+		var row models.TaxSummaryRow
+		if err := rows.Scan(&row.ProductType, &row.TaxRateBP, &row.Currency, &row.PaymentsCount,
+			&row.NetCents, &row.TaxCents, &row.GrossCents); err != nil {
+			return nil, err
+		}
+		summary = append(summary, row)
+	}
+	return summary, nil
 }
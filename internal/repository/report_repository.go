@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// revenueGroup — выражения ключа и подписи группировки отчёта по выручке
+type revenueGroup struct {
+	key   string
+	label string
+}
+
+var revenueGroups = map[string]revenueGroup{
+	"day":    {key: "date(p.created_at)", label: "''"},
+	"week":   {key: "strftime('%Y-W%W', p.created_at)", label: "''"},
+	"month":  {key: "strftime('%Y-%m', p.created_at)", label: "''"},
+	"method": {key: "p.method", label: "''"},
+	"gym":    {key: "COALESCE(CAST(p.gym_id AS TEXT), 'none')", label: "COALESCE(MAX(g.name), '')"},
+	"plan":   {key: "COALESCE(CAST(p.membership_id AS TEXT), 'none')", label: "COALESCE(MAX(m.name), '')"},
+	"status": {key: "p.status", label: "''"},
+}
+
+type ReportRepository struct {
+	db DBTX
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *ReportRepository) WithTx(tx *sql.Tx) ReportStore {
+	if tx == nil {
+		return r
+	}
+	return &ReportRepository{db: tx}
+}
+
+// Revenue группирует платежи за период [from, to) по groupBy и валюте.
+// При группировке по статусу учитываются все платежи, в остальных случаях — только
+// завершённые и возвращённые, то есть те, по которым прошли деньги.
+func (r *ReportRepository) Revenue(ctx context.Context, groupBy, from, to string) ([]models.RevenueReportRow, error) {
+	group, ok := revenueGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported grouping: %s", groupBy)
+	}
+
+	statusFilter := "p.status IN ('completed', 'refunded')"
+	if groupBy == "status" {
+		statusFilter = "1 = 1"
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+group.key+`, `+group.label+`, p.currency, COUNT(*),
+			COALESCE(SUM(p.amount_cents), 0), COALESCE(SUM(COALESCE(p.refunded_cents, 0)), 0)
+		FROM payments p
+		LEFT JOIN gyms g ON g.id = p.gym_id
+		LEFT JOIN memberships m ON m.id = p.membership_id
+		WHERE `+statusFilter+` AND p.created_at >= ? AND p.created_at < ?
+		GROUP BY 1, 3
+		ORDER BY 1, 3`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var report []models.RevenueReportRow
+	for rows.Next() {
+		var row models.RevenueReportRow
+		if err := rows.Scan(&row.Key, &row.Label, &row.Currency, &row.PaymentsCount, &row.GrossCents, &row.RefundedCents); err != nil {
+			return nil, err
+		}
+		row.RevenueCents = row.GrossCents - row.RefundedCents
+		report = append(report, row)
+	}
+	return report, nil
+}
+
+// ListSettled возвращает завершённые и возвращённые платежи за период [from, to); method = "" — все методы
+func (r *ReportRepository) ListSettled(ctx context.Context, from, to, method string) ([]models.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments
+		WHERE status IN ('completed', 'refunded') AND created_at >= ? AND created_at < ?`
+	args := []interface{}{from, to}
+	if method != "" {
+		query += " AND method = ?"
+		args = append(args, method)
+	}
+	query += " ORDER BY id"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanPayments(rows)
+}
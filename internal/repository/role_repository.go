@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+)
+
+type RoleRepository struct {
+	db DBTX
+}
+
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *RoleRepository) WithTx(tx *sql.Tx) RoleStore {
+	if tx == nil {
+		return r
+	}
+	return &RoleRepository{db: tx}
+}
+
+func (r *RoleRepository) Assign(ctx context.Context, userID int, role string, gymID *int) (*models.UserRole, error) {
+	res, err := r.db.ExecContext(ctx, `INSERT INTO user_roles (user_id, role, gym_id) VALUES (?, ?, ?)`, userID, role, gymID)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res.LastInsertId()
+	return r.GetByID(ctx, int(id))
+}
+
+func (r *RoleRepository) GetByID(ctx context.Context, id int) (*models.UserRole, error) {
+	ur := &models.UserRole{}
+	err := r.db.QueryRowContext(ctx, `SELECT id, user_id, role, gym_id, created_at FROM user_roles WHERE id = ?`, id).
+		Scan(&ur.ID, &ur.UserID, &ur.Role, &ur.GymID, &ur.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return ur, nil
+}
+
+// Exists проверяет, есть ли уже такая роль с той же областью действия
+func (r *RoleRepository) Exists(ctx context.Context, userID int, role string, gymID *int) (bool, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM user_roles
+		WHERE user_id = ? AND role = ? AND IFNULL(gym_id, 0) = IFNULL(?, 0)`, userID, role, gymID).Scan(&n)
+	return n > 0, err
+}
+
+func (r *RoleRepository) ListForUser(ctx context.Context, userID int) ([]models.UserRole, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, role, gym_id, created_at FROM user_roles
+		WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []models.UserRole
+	for rows.Next() {
+		var ur models.UserRole
+		if err := rows.Scan(&ur.ID, &ur.UserID, &ur.Role, &ur.GymID, &ur.CreatedAt); err != nil {
+			return nil, err
+		}
+		roles = append(roles, ur)
+	}
+	return roles, rows.Err()
+}
+
+func (r *RoleRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM user_roles WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type SigningKeyRepository struct {
+	db DBTX
+}
+
+func NewSigningKeyRepository(db *sql.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *SigningKeyRepository) WithTx(tx *sql.Tx) SigningKeyStore {
+	if tx == nil {
+		return r
+	}
+	return &SigningKeyRepository{db: tx}
+}
+
+func (r *SigningKeyRepository) Create(ctx context.Context, kid, algorithm, privateKey string) (*models.SigningKey, error) {
+	res, err := r.db.ExecContext(ctx, `INSERT INTO signing_keys (kid, algorithm, private_key) VALUES (?, ?, ?)`, kid, algorithm, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res.LastInsertId()
+
+	key := &models.SigningKey{}
+	var rotatedAt, expiresAt sql.NullTime
+	err = r.db.QueryRowContext(ctx, `SELECT id, kid, algorithm, private_key, created_at, rotated_at, expires_at FROM signing_keys WHERE id = ?`, id).
+		Scan(&key.ID, &key.KID, &key.Algorithm, &key.PrivateKey, &key.CreatedAt, &rotatedAt, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	setNullTimes(key, rotatedAt, expiresAt)
+	return key, nil
+}
+
+// ListPublished возвращает ключи, которые ещё не истекли, — новые первыми
+func (r *SigningKeyRepository) ListPublished(ctx context.Context, now time.Time) ([]models.SigningKey, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, kid, algorithm, private_key, created_at, rotated_at, expires_at FROM signing_keys
+		WHERE expires_at IS NULL OR expires_at > ?
+		ORDER BY id DESC`, now.UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.SigningKey
+	for rows.Next() {
+		var key models.SigningKey
+		var rotatedAt, expiresAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.KID, &key.Algorithm, &key.PrivateKey, &key.CreatedAt, &rotatedAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		setNullTimes(&key, rotatedAt, expiresAt)
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RetireOthers снимает с подписи все ключи, кроме keepID; они остаются в JWKS до expiresAt
+func (r *SigningKeyRepository) RetireOthers(ctx context.Context, keepID int, rotatedAt, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE signing_keys SET rotated_at = ?, expires_at = ?
+		WHERE rotated_at IS NULL AND id != ?`,
+		rotatedAt.UTC().Format(sqliteTimeLayout), expiresAt.UTC().Format(sqliteTimeLayout), keepID)
+	return err
+}
+
+// DeleteExpired удаляет ключи, которые больше не публикуются
+func (r *SigningKeyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM signing_keys WHERE expires_at IS NOT NULL AND expires_at <= ?`, now.UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func setNullTimes(key *models.SigningKey, rotatedAt, expiresAt sql.NullTime) {
+	if rotatedAt.Valid {
+		key.RotatedAt = &rotatedAt.Time
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+}
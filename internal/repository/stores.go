@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Интерфейсы хранилищ, от которых зависят сервисы. Реализации: *XRepository поверх SQLite
+// в этом пакете и хранилища в памяти из пакета memory — для быстрых тестов.
+// WithTx(nil) возвращает то же хранилище вне транзакции.
+
+// APIKeyStore — ключи интеграций
+type APIKeyStore interface {
+	WithTx(tx *sql.Tx) APIKeyStore
+	Create(ctx context.Context, name, prefix, keyHash string, scopes []string, gymID *int, createdBy int, expiresAt *time.Time) (*models.APIKey, error)
+	GetByID(ctx context.Context, id int) (*models.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id int) (bool, error)
+	TouchLastUsed(ctx context.Context, id int, now time.Time) error
+}
+
+// BookingStore — бронирования занятий
+type BookingStore interface {
+	WithTx(tx *sql.Tx) BookingStore
+	Create(ctx context.Context, userID, classID int) (int64, error)
+	Exists(ctx context.Context, userID, classID int) (bool, error)
+	GetByUser(ctx context.Context, userID int) ([]models.Booking, error)
+	ListAll(ctx context.Context) ([]models.Booking, error)
+	ListByGym(ctx context.Context, gymID int) ([]models.Booking, error)
+	Cancel(ctx context.Context, bookingID, userID int) error
+	ListAttendees(ctx context.Context, classID int) ([]models.User, error)
+	DeleteByClass(ctx context.Context, classID int) error
+	ListDueReminders(ctx context.Context, from, to time.Time, limit int) ([]models.BookingReminder, error)
+	MarkReminded(ctx context.Context, bookingID int, startTime string) error
+}
+
+// CampaignStore — рассылки по сегментам и их получатели
+type CampaignStore interface {
+	WithTx(tx *sql.Tx) CampaignStore
+	PreviewSegment(ctx context.Context, seg models.Segment, today string, limit int) (*models.SegmentPreview, error)
+	Create(ctx context.Context, c *models.Campaign) (*models.Campaign, error)
+	GetByID(ctx context.Context, id int) (*models.Campaign, error)
+	List(ctx context.Context, status string) ([]models.Campaign, error)
+	SetStatus(ctx context.Context, id int, status, scheduledAt string, from ...string) (bool, error)
+	ListDue(ctx context.Context, now time.Time) ([]models.Campaign, error)
+	Start(ctx context.Context, c *models.Campaign, today string, now time.Time) (int, bool, error)
+	PendingRecipients(ctx context.Context, campaignID, limit int) ([]models.User, error)
+	MarkRecipient(ctx context.Context, campaignID, userID int, status string, notificationID int) error
+	Finish(ctx context.Context, campaignID int, now time.Time) (bool, error)
+	ListSending(ctx context.Context) ([]models.Campaign, error)
+	Stats(ctx context.Context, campaignID int) (*models.CampaignStats, error)
+	MarkOpened(ctx context.Context, campaignID, userID int, now time.Time) (bool, error)
+}
+
+// ClassStore — расписание занятий
+type ClassStore interface {
+	WithTx(tx *sql.Tx) ClassStore
+	Create(ctx context.Context, c *models.Class) (*models.Class, error)
+	GetByID(ctx context.Context, id int) (*models.Class, error)
+	List(ctx context.Context) ([]models.Class, error)
+	Update(ctx context.Context, id int, c *models.Class) error
+	Delete(ctx context.Context, id int) error
+	GetBookingCount(ctx context.Context, classID int) (int, error)
+}
+
+// CurrencyStore — курсы валют
+type CurrencyStore interface {
+	WithTx(tx *sql.Tx) CurrencyStore
+	ListRates(ctx context.Context) ([]models.ExchangeRate, error)
+	GetRate(ctx context.Context, currency string) (*models.ExchangeRate, error)
+	SetRate(ctx context.Context, currency string, rate float64) (*models.ExchangeRate, error)
+	DeleteRate(ctx context.Context, currency string) error
+}
+
+// FiscalStore — очередь регистрации чеков
+type FiscalStore interface {
+	WithTx(tx *sql.Tx) FiscalStore
+	Enqueue(ctx context.Context, paymentID int) error
+	GetByID(ctx context.Context, id int) (*models.FiscalOutboxEntry, error)
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.FiscalOutboxEntry, error)
+	List(ctx context.Context, status string) ([]models.FiscalOutboxEntry, error)
+	MarkSent(ctx context.Context, id int) error
+	MarkFailed(ctx context.Context, id int, status, lastError string, nextAttempt time.Time) error
+	Retry(ctx context.Context, id int) error
+}
+
+// GymStore — залы
+type GymStore interface {
+	WithTx(tx *sql.Tx) GymStore
+	Create(ctx context.Context, name, address string) (*models.Gym, error)
+	GetByID(ctx context.Context, id int) (*models.Gym, error)
+	List(ctx context.Context) ([]models.Gym, error)
+	Update(ctx context.Context, id int, name, address string) error
+	Delete(ctx context.Context, id int) error
+}
+
+// IdentityStore — привязки аккаунтов OpenID Connect
+type IdentityStore interface {
+	WithTx(tx *sql.Tx) IdentityStore
+	FindUserID(ctx context.Context, provider, subject string) (int, error)
+	Create(ctx context.Context, userID int, provider, subject, email string) error
+}
+
+// InstallmentStore — планы рассрочки и графики платежей
+type InstallmentStore interface {
+	WithTx(tx *sql.Tx) InstallmentStore
+	CreatePlan(ctx context.Context, plan *models.InstallmentPlan) (int, error)
+	GetPlan(ctx context.Context, id int) (*models.InstallmentPlan, error)
+	ListPlans(ctx context.Context, userID int, status string) ([]models.InstallmentPlan, error)
+	ListInstallments(ctx context.Context, planID int) ([]models.Installment, error)
+	ListDue(ctx context.Context, today string) ([]models.Installment, error)
+	ListOverduePlanIDs(ctx context.Context, cutoff string) ([]int, error)
+	CountPending(ctx context.Context, planID int, cutoff string) (pending, overdue int, err error)
+	MarkPaid(ctx context.Context, id, paymentID int) error
+	MarkAttemptFailed(ctx context.Context, id int, lastError, nextAttemptDate string) error
+	SetPlanStatus(ctx context.Context, id int, status string) error
+	SetUserMembership(ctx context.Context, planID, userMembershipID int) error
+}
+
+// MembershipStore — тарифы, их цены и подписки пользователей
+type MembershipStore interface {
+	WithTx(tx *sql.Tx) MembershipStore
+	GetAll(ctx context.Context) ([]models.Membership, error)
+	GetByID(ctx context.Context, id int) (*models.Membership, error)
+	Create(ctx context.Context, name string, durationDays, priceCents int) (*models.Membership, error)
+	Update(ctx context.Context, id int, name string, durationDays, priceCents int) error
+	Delete(ctx context.Context, id int) error
+	HasActiveMembership(ctx context.Context, userID int) (bool, error)
+	Activate(ctx context.Context, userID, membershipID int, durationDays int) (*models.UserMembership, error)
+	ActivateWithID(ctx context.Context, userID, membershipID int, durationDays int) (int, error)
+	SetActive(ctx context.Context, userMembershipID int, active bool) error
+	ListPrices(ctx context.Context, membershipID int) ([]models.MembershipPrice, error)
+	GetPrice(ctx context.Context, membershipID int, currency string) (*models.MembershipPrice, error)
+	SetPrice(ctx context.Context, membershipID int, currency string, priceCents int) (*models.MembershipPrice, error)
+	DeletePrice(ctx context.Context, membershipID int, currency string) error
+}
+
+// NotificationStore — очередь уведомлений и настройки пользователей
+type NotificationStore interface {
+	WithTx(tx *sql.Tx) NotificationStore
+	Enqueue(ctx context.Context, n *models.NotificationOutboxEntry) (int, error)
+	GetByID(ctx context.Context, id int) (*models.NotificationOutboxEntry, error)
+	List(ctx context.Context, status string) ([]models.NotificationOutboxEntry, error)
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.NotificationOutboxEntry, error)
+	MarkSent(ctx context.Context, id int) error
+	MarkFailed(ctx context.Context, id int, status, lastError string, nextAttempt time.Time) error
+	Fallback(ctx context.Context, id int, channel, recipient, fallback, lastError string, nextAttempt time.Time) error
+	Resend(ctx context.Context, id int) (bool, error)
+	GetSettings(ctx context.Context, userID int) (*models.NotificationSettings, error)
+	SaveSettings(ctx context.Context, userID int, timezone, quietStart, quietEnd string) error
+	ListPreferences(ctx context.Context, userID int) ([]models.NotificationPreference, error)
+	SetPreferences(ctx context.Context, userID int, prefs []models.NotificationPreference) error
+}
+
+// PaymentStore — платежи
+type PaymentStore interface {
+	WithTx(tx *sql.Tx) PaymentStore
+	Create(ctx context.Context, p *models.Payment) (*models.Payment, error)
+	CreateStandalone(ctx context.Context, userID, amountCents int, currency, method, status, description, referenceID string) (*models.Payment, error)
+	GetByID(ctx context.Context, id int) (*models.Payment, error)
+	ListByGym(ctx context.Context, gymID int) ([]models.Payment, error)
+	ListAll(ctx context.Context) ([]models.Payment, error)
+	CreateForMembership(ctx context.Context, userID, amountCents int, currency, method, description, referenceID string) (*models.Payment, error)
+	GetByUser(ctx context.Context, userID int, status string) ([]models.Payment, error)
+	SetFiscalRegistration(ctx context.Context, paymentID int, sign, url string) error
+	Refund(ctx context.Context, id, amountCents int) (bool, error)
+	RevenueByCurrency(ctx context.Context) (map[string]int, error)
+	TaxSummary(ctx context.Context, from, to string) ([]models.TaxSummaryRow, error)
+}
+
+// ReportStore — выборки для финансовых отчётов
+type ReportStore interface {
+	WithTx(tx *sql.Tx) ReportStore
+	Revenue(ctx context.Context, groupBy, from, to string) ([]models.RevenueReportRow, error)
+	ListSettled(ctx context.Context, from, to, method string) ([]models.Payment, error)
+}
+
+// RoleStore — роли пользователей
+type RoleStore interface {
+	WithTx(tx *sql.Tx) RoleStore
+	Assign(ctx context.Context, userID int, role string, gymID *int) (*models.UserRole, error)
+	GetByID(ctx context.Context, id int) (*models.UserRole, error)
+	Exists(ctx context.Context, userID int, role string, gymID *int) (bool, error)
+	ListForUser(ctx context.Context, userID int) ([]models.UserRole, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// SigningKeyStore — ключи подписи JWT
+type SigningKeyStore interface {
+	WithTx(tx *sql.Tx) SigningKeyStore
+	Create(ctx context.Context, kid, algorithm, privateKey string) (*models.SigningKey, error)
+	ListPublished(ctx context.Context, now time.Time) ([]models.SigningKey, error)
+	RetireOthers(ctx context.Context, keepID int, rotatedAt, expiresAt time.Time) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// TaxStore — налоговые ставки
+type TaxStore interface {
+	WithTx(tx *sql.Tx) TaxStore
+	Create(ctx context.Context, t *models.TaxRate) (*models.TaxRate, error)
+	GetByID(ctx context.Context, id int) (*models.TaxRate, error)
+	List(ctx context.Context) ([]models.TaxRate, error)
+	FindApplicable(ctx context.Context, productType string, gymID *int) (*models.TaxRate, error)
+	Update(ctx context.Context, id int, t *models.TaxRate) error
+	Delete(ctx context.Context, id int) error
+}
+
+// TokenStore — refresh-токены, denylist access-токенов, одноразовые токены и коды восстановления
+type TokenStore interface {
+	WithTx(tx *sql.Tx) TokenStore
+	CreateRefresh(ctx context.Context, userID int, familyID, tokenHash string, expiresAt time.Time) (int, error)
+	GetRefreshByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	RotateRefresh(ctx context.Context, id, replacedBy int) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID int) error
+	RevokeAccess(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessRevoked(ctx context.Context, jti string) (bool, error)
+	PurgeExpired(ctx context.Context, now time.Time) error
+	CreateUserToken(ctx context.Context, userID int, purpose, tokenHash string, expiresAt time.Time) error
+	ConsumeUserToken(ctx context.Context, purpose, tokenHash string) (int, error)
+	InvalidateUserTokens(ctx context.Context, userID int, purpose string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID int) (int, error)
+	DeleteRecoveryCodes(ctx context.Context, userID int) error
+}
+
+// TrainerStore — тренеры
+type TrainerStore interface {
+	WithTx(tx *sql.Tx) TrainerStore
+	Create(ctx context.Context, name, bio string) (*models.Trainer, error)
+	GetByID(ctx context.Context, id int) (*models.Trainer, error)
+	List(ctx context.Context) ([]models.Trainer, error)
+	Update(ctx context.Context, id int, name, bio string) error
+	Delete(ctx context.Context, id int) error
+}
+
+// UserStore — пользователи
+type UserStore interface {
+	WithTx(tx *sql.Tx) UserStore
+	Create(ctx context.Context, name, email, passwordHash string, isAdmin bool) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	List(ctx context.Context) ([]models.User, error)
+	Update(ctx context.Context, id int, name, email string) error
+	SetLocale(ctx context.Context, id int, locale string) error
+	SetPhone(ctx context.Context, id int, phone string) error
+	SetPushToken(ctx context.Context, id int, token string) error
+	Delete(ctx context.Context, id int) error
+	GetTokenVersion(ctx context.Context, id int) (int, error)
+	IncrementTokenVersion(ctx context.Context, id int) error
+	SetPasswordHash(ctx context.Context, id int, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, id int) error
+	GetTOTP(ctx context.Context, id int) (string, bool, int64, error)
+	SetTOTP(ctx context.Context, id int, secret string, enabled bool) error
+	DisableTOTP(ctx context.Context, id int) error
+	UseTOTPStep(ctx context.Context, id int, step int64) (bool, error)
+}
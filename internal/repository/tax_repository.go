@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+)
+
+type TaxRepository struct {
+	db DBTX
+}
+
+func NewTaxRepository(db *sql.DB) *TaxRepository {
+	return &TaxRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *TaxRepository) WithTx(tx *sql.Tx) TaxStore {
+	if tx == nil {
+		return r
+	}
+	return &TaxRepository{db: tx}
+}
+
+func (r *TaxRepository) Create(ctx context.Context, t *models.TaxRate) (*models.TaxRate, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO tax_rates (name, product_type, gym_id, rate_bp, inclusive)
+		VALUES (?, ?, ?, ?, ?)`,
+		t.Name, t.ProductType, t.GymID, t.RateBP, t.Inclusive)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res.LastInsertId()
+	return r.GetByID(ctx, int(id))
+}
+
+func (r *TaxRepository) GetByID(ctx context.Context, id int) (*models.TaxRate, error) {
+	t := &models.TaxRate{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, name, product_type, gym_id, rate_bp, inclusive, created_at
+		FROM tax_rates WHERE id = ?`, id).
+		Scan(&t.ID, &t.Name, &t.ProductType, &t.GymID, &t.RateBP, &t.Inclusive, &t.CreatedAt)
+	return t, err
+}
+
+func (r *TaxRepository) List(ctx context.Context) ([]models.TaxRate, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, product_type, gym_id, rate_bp, inclusive, created_at
+		FROM tax_rates ORDER BY product_type, gym_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.TaxRate
+	for rows.Next() {
+		var t models.TaxRate
+		if err := rows.Scan(&t.ID, &t.Name, &t.ProductType, &t.GymID, &t.RateBP, &t.Inclusive, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, t)
+	}
+	return rates, nil
+}
+
+// FindApplicable возвращает ставку для зала, а если её нет — ставку по умолчанию для типа продукта
+func (r *TaxRepository) FindApplicable(ctx context.Context, productType string, gymID *int) (*models.TaxRate, error) {
+	t := &models.TaxRate{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, name, product_type, gym_id, rate_bp, inclusive, created_at
+		FROM tax_rates
+		WHERE product_type = ? AND (gym_id = ? OR gym_id IS NULL)
+		ORDER BY gym_id IS NULL
+		LIMIT 1`, productType, gymID).
+		Scan(&t.ID, &t.Name, &t.ProductType, &t.GymID, &t.RateBP, &t.Inclusive, &t.CreatedAt)
+	return t, err
+}
+
+func (r *TaxRepository) Update(ctx context.Context, id int, t *models.TaxRate) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE tax_rates SET name = ?, product_type = ?, gym_id = ?, rate_bp = ?, inclusive = ?
+		WHERE id = ?`,
+		t.Name, t.ProductType, t.GymID, t.RateBP, t.Inclusive, id)
+	return err
+}
+
+func (r *TaxRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM tax_rates WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"Gym_StrongCode/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type TokenRepository struct {
+	db DBTX
+}
+
+func NewTokenRepository(db *sql.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *TokenRepository) WithTx(tx *sql.Tx) TokenStore {
+	if tx == nil {
+		return r
+	}
+	return &TokenRepository{db: tx}
+}
+
+func (r *TokenRepository) CreateRefresh(ctx context.Context, userID int, familyID, tokenHash string, expiresAt time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) VALUES (?, ?, ?, ?)`,
+		userID, familyID, tokenHash, expiresAt.UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+	return int(id), nil
+}
+
+func (r *TokenRepository) GetRefreshByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	t := &models.RefreshToken{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at,
+			expires_at <= CURRENT_TIMESTAMP
+		FROM refresh_tokens WHERE token_hash = ?`, tokenHash).
+		Scan(&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &t.RevokedAt, &t.ReplacedBy, &t.CreatedAt, &t.Expired)
+	return t, err
+}
+
+// RotateRefresh отзывает токен id, заменяя его на replacedBy.
+// Возвращает false, если токен уже был отозван (например, параллельным запросом).
+func (r *TokenRepository) RotateRefresh(ctx context.Context, id, replacedBy int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP, replaced_by = ?
+		WHERE id = ? AND revoked_at IS NULL`, replacedBy, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+		WHERE family_id = ? AND revoked_at IS NULL`, familyID)
+	return err
+}
+
+func (r *TokenRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND revoked_at IS NULL`, userID)
+	return err
+}
+
+// RevokeAccess добавляет jti access-токена в denylist до истечения его срока
+func (r *TokenRepository) RevokeAccess(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `INSERT OR IGNORE INTO revoked_access_tokens (jti, expires_at) VALUES (?, ?)`,
+		jti, expiresAt.UTC().Format(sqliteTimeLayout))
+	return err
+}
+
+func (r *TokenRepository) IsAccessRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM revoked_access_tokens WHERE jti = ?`, jti).Scan(&count)
+	return count > 0, err
+}
+
+// PurgeExpired удаляет истёкшие записи denylist и refresh-токены
+func (r *TokenRepository) PurgeExpired(ctx context.Context, now time.Time) error {
+	ts := now.UTC().Format(sqliteTimeLayout)
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM revoked_access_tokens WHERE expires_at < ?`, ts); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at < ?`, ts)
+	return err
+}
+
+func (r *TokenRepository) CreateUserToken(ctx context.Context, userID int, purpose, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at) VALUES (?, ?, ?, ?)`,
+		userID, purpose, tokenHash, expiresAt.UTC().Format(sqliteTimeLayout))
+	return err
+}
+
+// ConsumeUserToken помечает действующий токен использованным и возвращает его владельца.
+// Просроченный, уже использованный или неизвестный токен даёт sql.ErrNoRows.
+func (r *TokenRepository) ConsumeUserToken(ctx context.Context, purpose, tokenHash string) (int, error) {
+	var id, userID int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id FROM user_tokens
+		WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP`,
+		tokenHash, purpose).Scan(&id, &userID)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := r.db.ExecContext(ctx, `UPDATE user_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = ? AND used_at IS NULL`, id)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return userID, nil
+}
+
+// InvalidateUserTokens гасит ранее выданные токены, чтобы действовал только последний
+func (r *TokenRepository) InvalidateUserTokens(ctx context.Context, userID int, purpose string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE user_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND purpose = ? AND used_at IS NULL`, userID, purpose)
+	return err
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления пользователя новыми
+func (r *TokenRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+			return err
+		}
+		for _, h := range codeHashes {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID, h); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UseRecoveryCode гасит неиспользованный код; возвращает false, если такого кода нет
+func (r *TokenRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE id = (SELECT id FROM recovery_codes WHERE user_id = ? AND code_hash = ? AND used_at IS NULL LIMIT 1)`,
+		userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *TokenRepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL`, userID).Scan(&n)
+	return n, err
+}
+
+func (r *TokenRepository) DeleteRecoveryCodes(ctx context.Context, userID int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userID)
+	return err
+}
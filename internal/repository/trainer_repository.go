@@ -2,35 +2,44 @@ package repository
 
 import (
 	"Gym_StrongCode/internal/models"
+	"context"
 	"database/sql"
 )
 
 type TrainerRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewTrainerRepository(db *sql.DB) *TrainerRepository {
 	return &TrainerRepository{db: db}
 }
 
-func (r *TrainerRepository) Create(name, bio string) (*models.Trainer, error) {
-	res, err := r.db.Exec(`INSERT INTO trainers (name, bio) VALUES (?, ?)`, name, bio)
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *TrainerRepository) WithTx(tx *sql.Tx) TrainerStore {
+	if tx == nil {
+		return r
+	}
+	return &TrainerRepository{db: tx}
+}
+
+func (r *TrainerRepository) Create(ctx context.Context, name, bio string) (*models.Trainer, error) {
+	res, err := r.db.ExecContext(ctx, `INSERT INTO trainers (name, bio) VALUES (?, ?)`, name, bio)
 	if err != nil {
 		return nil, err
 	}
 	id, _ := res.LastInsertId()
-	return r.GetByID(int(id))
+	return r.GetByID(ctx, int(id))
 }
 
-func (r *TrainerRepository) GetByID(id int) (*models.Trainer, error) {
+func (r *TrainerRepository) GetByID(ctx context.Context, id int) (*models.Trainer, error) {
 	t := &models.Trainer{}
-	err := r.db.QueryRow(`SELECT id, name, bio, created_at FROM trainers WHERE id = ?`, id).
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, bio, created_at FROM trainers WHERE id = ?`, id).
 		Scan(&t.ID, &t.Name, &t.Bio, &t.CreatedAt)
 	return t, err
 }
 
-func (r *TrainerRepository) List() ([]models.Trainer, error) {
-	rows, err := r.db.Query(`SELECT id, name, bio, created_at FROM trainers`)
+func (r *TrainerRepository) List(ctx context.Context) ([]models.Trainer, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, bio, created_at FROM trainers`)
 	if err != nil {
 		return nil, err
 	}
@@ -47,12 +56,12 @@ func (r *TrainerRepository) List() ([]models.Trainer, error) {
 	return trainers, nil
 }
 
-func (r *TrainerRepository) Update(id int, name, bio string) error {
-	_, err := r.db.Exec(`UPDATE trainers SET name = ?, bio = ? WHERE id = ?`, name, bio, id)
+func (r *TrainerRepository) Update(ctx context.Context, id int, name, bio string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE trainers SET name = ?, bio = ? WHERE id = ?`, name, bio, id)
 	return err
 }
 
-func (r *TrainerRepository) Delete(id int) error {
-	_, err := r.db.Exec(`DELETE FROM trainers WHERE id = ?`, id)
+func (r *TrainerRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM trainers WHERE id = ?`, id)
 	return err
 }
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTxRetries = 5
+	txRetryBackoff   = 20 * time.Millisecond
+)
+
+// Transactor выполняет fn как одну транзакцию; его реализуют UnitOfWork и memory.DB
+type Transactor interface {
+	Do(ctx context.Context, fn func(tx *sql.Tx) error) error
+}
+
+// UnitOfWork выполняет несколько операций с репозиториями как одну транзакцию.
+// SQLite допускает одного писателя: если база занята (SQLITE_BUSY), транзакция
+// повторяется целиком с нарастающей паузой.
+type UnitOfWork struct {
+	db      *sql.DB
+	retries int
+	backoff time.Duration
+}
+
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db, retries: defaultTxRetries, backoff: txRetryBackoff}
+}
+
+// Do выполняет fn в транзакции: фиксирует её, если fn вернула nil, иначе откатывает.
+// При повторе fn вызывается заново, поэтому побочные эффекты вне БД (будить воркеры,
+// отправлять запросы наружу) выполняются после Do. Отмена ctx прерывает и запросы, и ожидание повтора.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	for attempt := 0; ; attempt++ {
+		err := u.do(ctx, fn)
+		if err == nil || !IsBusy(err) || attempt >= u.retries {
+			return err
+		}
+		utils.GetLogger().Warn("Database is busy, retrying transaction", zap.Int("attempt", attempt+1), zap.Error(err))
+
+		timer := time.NewTimer(u.backoff << attempt)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (u *UnitOfWork) do(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// IsBusy сообщает, что SQLite отказал из-за блокировки базы другим соединением.
+// Драйверы (mattn/go-sqlite3 в приложении, modernc.org/sqlite в тестах) возвращают
+// разные типы ошибок, поэтому проверяется текст.
+func IsBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked") ||
+		strings.Contains(msg, "SQLITE_BUSY")
+}
@@ -2,19 +2,28 @@ package repository
 
 import (
 	"Gym_StrongCode/internal/models"
+	"context"
 	"database/sql"
 )
 
 type UserRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) Create(name, email, passwordHash string, isAdmin bool) (*models.User, error) {
-	res, err := r.db.Exec(`
+// WithTx возвращает репозиторий, работающий внутри транзакции tx; nil — вне транзакции
+func (r *UserRepository) WithTx(tx *sql.Tx) UserStore {
+	if tx == nil {
+		return r
+	}
+	return &UserRepository{db: tx}
+}
+
+func (r *UserRepository) Create(ctx context.Context, name, email, passwordHash string, isAdmin bool) (*models.User, error) {
+	res, err := r.db.ExecContext(ctx, `
 		INSERT INTO users (name, email, password_hash, is_admin) 
 		VALUES (?, ?, ?, ?)`, name, email, passwordHash, isAdmin)
 	if err != nil {
@@ -22,33 +31,33 @@ func (r *UserRepository) Create(name, email, passwordHash string, isAdmin bool)
 	}
 
 	id, _ := res.LastInsertId()
-	return r.GetByID(int(id))
+	return r.GetByID(ctx, int(id))
 }
 
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	user := &models.User{}
-	err := r.db.QueryRow(`SELECT id, name, email, password_hash, is_admin, created_at FROM users WHERE email = ?`, email).
-		Scan(&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.IsAdmin, &user.CreatedAt)
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, email, password_hash, is_admin, email_verified, totp_enabled, locale, COALESCE(phone, ''), COALESCE(push_token, ''), created_at FROM users WHERE email = ?`, email).
+		Scan(&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.IsAdmin, &user.EmailVerified, &user.TOTPEnabled, &user.Locale, &user.Phone, &user.PushToken, &user.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-func (r *UserRepository) GetByID(id int) (*models.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
 	user := &models.User{}
-	err := r.db.QueryRow(`
-		SELECT id, name, email, is_admin, created_at 
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, name, email, is_admin, email_verified, totp_enabled, locale, COALESCE(phone, ''), COALESCE(push_token, ''), created_at 
 		FROM users WHERE id = ?`, id).
-		Scan(&user.ID, &user.Name, &user.Email, &user.IsAdmin, &user.CreatedAt)
+		Scan(&user.ID, &user.Name, &user.Email, &user.IsAdmin, &user.EmailVerified, &user.TOTPEnabled, &user.Locale, &user.Phone, &user.PushToken, &user.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-func (r *UserRepository) List() ([]models.User, error) {
-	rows, err := r.db.Query(`SELECT id, name, email, is_admin, created_at FROM users`)
+func (r *UserRepository) List(ctx context.Context) ([]models.User, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, email, is_admin, email_verified, totp_enabled, locale, COALESCE(phone, ''), COALESCE(push_token, ''), created_at FROM users`)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +66,7 @@ func (r *UserRepository) List() ([]models.User, error) {
 	var users []models.User
 	for rows.Next() {
 		var u models.User
-		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.IsAdmin, &u.CreatedAt); err != nil {
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.IsAdmin, &u.EmailVerified, &u.TOTPEnabled, &u.Locale, &u.Phone, &u.PushToken, &u.CreatedAt); err != nil {
 			return nil, err
 		}
 		users = append(users, u)
@@ -65,15 +74,85 @@ func (r *UserRepository) List() ([]models.User, error) {
 	return users, nil
 }
 
-func (r *UserRepository) Update(id int, name, email string) error {
-	_, err := r.db.Exec(`
+func (r *UserRepository) Update(ctx context.Context, id int, name, email string) error {
+	_, err := r.db.ExecContext(ctx, `
 		UPDATE users 
 		SET name = ?, email = ? 
 		WHERE id = ?`, name, email, id)
 	return err
 }
 
-func (r *UserRepository) Delete(id int) error {
-	_, err := r.db.Exec(`DELETE FROM users WHERE id = ?`, id)
+func (r *UserRepository) SetLocale(ctx context.Context, id int, locale string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET locale = ? WHERE id = ?`, locale, id)
+	return err
+}
+
+// SetPhone задаёт телефон для SMS; пустая строка удаляет его
+func (r *UserRepository) SetPhone(ctx context.Context, id int, phone string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET phone = NULLIF(?, '') WHERE id = ?`, phone, id)
+	return err
+}
+
+// SetPushToken задаёт токен устройства для push-уведомлений; пустая строка удаляет его
+func (r *UserRepository) SetPushToken(ctx context.Context, id int, token string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET push_token = NULLIF(?, '') WHERE id = ?`, token, id)
 	return err
 }
+
+func (r *UserRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
+	return err
+}
+
+func (r *UserRepository) GetTokenVersion(ctx context.Context, id int) (int, error) {
+	var version int
+	err := r.db.QueryRowContext(ctx, `SELECT token_version FROM users WHERE id = ?`, id).Scan(&version)
+	return version, err
+}
+
+// IncrementTokenVersion делает недействительными все выданные пользователю access-токены
+func (r *UserRepository) IncrementTokenVersion(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET token_version = token_version + 1 WHERE id = ?`, id)
+	return err
+}
+
+func (r *UserRepository) SetPasswordHash(ctx context.Context, id int, passwordHash string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET password_hash = ? WHERE id = ?`, passwordHash, id)
+	return err
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET email_verified = 1 WHERE id = ?`, id)
+	return err
+}
+
+// GetTOTP возвращает TOTP-секрет пользователя, признак включения 2FA и последний принятый шаг
+func (r *UserRepository) GetTOTP(ctx context.Context, id int) (string, bool, int64, error) {
+	var secret sql.NullString
+	var enabled bool
+	var lastStep int64
+	err := r.db.QueryRowContext(ctx, `SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE id = ?`, id).
+		Scan(&secret, &enabled, &lastStep)
+	return secret.String, enabled, lastStep, err
+}
+
+// SetTOTP сохраняет секрет; enabled = false означает, что настройка ещё не подтверждена кодом
+func (r *UserRepository) SetTOTP(ctx context.Context, id int, secret string, enabled bool) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET totp_secret = ?, totp_enabled = ?, totp_last_step = 0 WHERE id = ?`, secret, enabled, id)
+	return err
+}
+
+func (r *UserRepository) DisableTOTP(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET totp_secret = NULL, totp_enabled = 0, totp_last_step = 0 WHERE id = ?`, id)
+	return err
+}
+
+// UseTOTPStep запоминает шаг принятого кода; возвращает false, если код этого или более позднего шага уже использован
+func (r *UserRepository) UseTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?`, step, id, step)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"Gym_StrongCode/internal/cache"
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPasswordResetTTL     = time.Hour
+	defaultEmailVerificationTTL = 48 * time.Hour
+)
+
+var (
+	ErrInvalidUserToken     = errors.New("invalid or expired token")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+)
+
+// Notifier отправляет пользователю письмо по шаблону на его языке; реализуется NotificationService
+type Notifier interface {
+	NotifyUser(ctx context.Context, user *models.User, msg notification.Message)
+}
+
+// AccountService — сброс пароля и подтверждение email через одноразовые токены из письма
+type AccountService struct {
+	userRepo  repository.UserStore
+	tokenRepo repository.TokenStore
+	uow       repository.Transactor
+	authSvc   *AuthService
+	sessions  cache.SessionStore
+	notifier  Notifier
+	appURL    string
+	resetTTL  time.Duration
+	verifyTTL time.Duration
+}
+
+// NewAccountService создаёт сервис; sessions — серверные сессии (nil в режиме JWT),
+// appURL — адрес фронтенда для ссылок в письмах, нулевые TTL заменяются значениями по умолчанию
+func NewAccountService(userRepo repository.UserStore, tokenRepo repository.TokenStore, uow repository.Transactor, authSvc *AuthService, sessions cache.SessionStore, notifier Notifier, appURL string, resetTTL, verifyTTL time.Duration) *AccountService {
+	if resetTTL <= 0 {
+		resetTTL = defaultPasswordResetTTL
+	}
+	if verifyTTL <= 0 {
+		verifyTTL = defaultEmailVerificationTTL
+	}
+	return &AccountService{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		uow:       uow,
+		authSvc:   authSvc,
+		sessions:  sessions,
+		notifier:  notifier,
+		appURL:    strings.TrimRight(appURL, "/"),
+		resetTTL:  resetTTL,
+		verifyTTL: verifyTTL,
+	}
+}
+
+// issueToken гасит прежние токены того же назначения и выдаёт новый
+func (s *AccountService) issueToken(ctx context.Context, userID int, purpose string, ttl time.Duration) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		tokenRepo := s.tokenRepo.WithTx(tx)
+		if err := tokenRepo.InvalidateUserTokens(ctx, userID, purpose); err != nil {
+			return err
+		}
+		return tokenRepo.CreateUserToken(ctx, userID, purpose, hashToken(token), time.Now().Add(ttl))
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *AccountService) consumeToken(ctx context.Context, tx *sql.Tx, purpose, token string) (int, error) {
+	userID, err := s.tokenRepo.WithTx(tx).ConsumeUserToken(ctx, purpose, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidUserToken
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+// RequestPasswordReset отправляет письмо со ссылкой для сброса пароля.
+// Для неизвестного email ничего не делает и не возвращает ошибку, чтобы не раскрывать, какие адреса зарегистрированы.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := s.issueToken(ctx, user.ID, models.TokenPurposePasswordReset, s.resetTTL)
+	if err != nil {
+		return err
+	}
+
+	s.notifier.NotifyUser(ctx, user, notification.PasswordReset{
+		Name:     user.Name,
+		Link:     fmt.Sprintf("%s/reset-password?token=%s", s.appURL, token),
+		ValidFor: s.resetTTL,
+	})
+	return nil
+}
+
+// ResetPassword задаёт новый пароль по токену из письма и завершает все сессии пользователя
+func (s *AccountService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	// Общие правила проверяются до того, как ссылка будет израсходована
+	if err := s.authSvc.passwords.Validate(newPassword, "", ""); err != nil {
+		return err
+	}
+	hash, err := s.authSvc.passwords.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	// Ссылка расходуется, только если пароль действительно сменился
+	var userID int
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		userRepo := s.userRepo.WithTx(tx)
+
+		var err error
+		userID, err = s.consumeToken(ctx, tx, models.TokenPurposePasswordReset, token)
+		if err != nil {
+			return err
+		}
+		user, err := userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if err := s.authSvc.passwords.Validate(newPassword, user.Email, user.Name); err != nil {
+			return err
+		}
+
+		if err := userRepo.SetPasswordHash(ctx, userID, hash); err != nil {
+			return err
+		}
+		// Письмо со ссылкой пришло на этот email — значит, адрес подтверждён
+		if err := userRepo.MarkEmailVerified(ctx, userID); err != nil {
+			return err
+		}
+		return s.authSvc.logoutAll(ctx, tx, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Серверные сессии удаляются после фиксации: пароль уже сменён, поэтому ошибка только логируется —
+	// сессии со старой версией токенов и так отклоняются при проверке
+	if s.sessions != nil {
+		if err := s.sessions.DeleteUserSessions(ctx, userID); err != nil {
+			utils.GetLogger().Error("Failed to delete sessions after password reset", zap.Int("user_id", userID), zap.Error(err))
+		}
+	}
+	utils.GetLogger().Info("Password reset", zap.Int("user_id", userID))
+	return nil
+}
+
+// SendVerification отправляет письмо для подтверждения email
+func (s *AccountService) SendVerification(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueToken(ctx, user.ID, models.TokenPurposeEmailVerification, s.verifyTTL)
+	if err != nil {
+		return err
+	}
+
+	s.notifier.NotifyUser(ctx, user, notification.EmailVerification{
+		Name:     user.Name,
+		Link:     fmt.Sprintf("%s/verify-email?token=%s", s.appURL, token),
+		ValidFor: s.verifyTTL,
+	})
+	return nil
+}
+
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	return s.uow.Do(ctx, func(tx *sql.Tx) error {
+		userID, err := s.consumeToken(ctx, tx, models.TokenPurposeEmailVerification, token)
+		if err != nil {
+			return err
+		}
+		return s.userRepo.WithTx(tx).MarkEmailVerified(ctx, userID)
+	})
+}
+
+// IsEmailVerified используется middleware, запрещающим покупки и бронирования без подтверждённого email
+func (s *AccountService) IsEmailVerified(ctx context.Context, userID int) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerified, nil
+}
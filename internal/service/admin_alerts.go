@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// AdminAlerts — оповещения администраторам о крупных платежах, возвратах, неудачных списаниях
+// по рассрочке и заполненных занятиях. Адреса берутся из правил; nil — оповещения выключены.
+type AdminAlerts struct {
+	notificationSvc   *NotificationService
+	currencySvc       *CurrencyService
+	rules             notification.AlertRules
+	largePaymentCents int
+}
+
+// NewAdminAlerts создаёт оповещения; largePaymentCents — порог крупного платежа в базовой валюте
+func NewAdminAlerts(notificationSvc *NotificationService, currencySvc *CurrencyService, rules notification.AlertRules, largePaymentCents int) *AdminAlerts {
+	return &AdminAlerts{
+		notificationSvc:   notificationSvc,
+		currencySvc:       currencySvc,
+		rules:             rules,
+		largePaymentCents: largePaymentCents,
+	}
+}
+
+// PaymentCompleted оповещает о платеже, если он не меньше порога
+func (a *AdminAlerts) PaymentCompleted(ctx context.Context, tx *sql.Tx, p *models.Payment) {
+	if a == nil || a.largePaymentCents <= 0 {
+		return
+	}
+	amount, err := a.currencySvc.ConvertToBase(ctx, p.AmountCents, p.Currency)
+	if err != nil {
+		utils.GetLogger().Warn("Cannot check payment against alert threshold", zap.Int("payment_id", p.ID), zap.Error(err))
+		return
+	}
+	if amount < a.largePaymentCents {
+		return
+	}
+	a.send(ctx, tx, notification.AlertLargePayment, notification.LargePaymentAlert{
+		PaymentID:   p.ID,
+		UserID:      p.UserID,
+		Amount:      formatMoney(p.AmountCents, p.Currency),
+		Description: p.Description,
+	})
+}
+
+// Refunded оповещает о возврате refundedCents по платежу p (p — уже после возврата)
+func (a *AdminAlerts) Refunded(ctx context.Context, p *models.Payment, refundedCents int) {
+	if a == nil {
+		return
+	}
+	a.send(ctx, nil, notification.AlertRefund, notification.RefundAlert{
+		PaymentID: p.ID,
+		UserID:    p.UserID,
+		Refunded:  formatMoney(refundedCents, p.Currency),
+		Remaining: formatMoney(p.AmountCents-p.RefundedCents, p.Currency),
+	})
+}
+
+// RenewalFailed оповещает о неудачном списании платежа по рассрочке
+func (a *AdminAlerts) RenewalFailed(ctx context.Context, plan *models.InstallmentPlan, installment *models.Installment, chargeErr error) {
+	if a == nil {
+		return
+	}
+	a.send(ctx, nil, notification.AlertFailedRenewal, notification.RenewalFailedAlert{
+		PlanID:  plan.ID,
+		UserID:  plan.UserID,
+		Seq:     installment.Seq,
+		Amount:  formatMoney(installment.AmountCents, plan.Currency),
+		DueDate: installment.DueDate,
+		Error:   chargeErr.Error(),
+	})
+}
+
+// ClassFull оповещает, что на занятие записались все
+func (a *AdminAlerts) ClassFull(ctx context.Context, tx *sql.Tx, class *models.Class) {
+	if a == nil {
+		return
+	}
+	a.send(ctx, tx, notification.AlertCapacityReached, notification.ClassFullAlert{
+		ClassID:    class.ID,
+		ClassTitle: class.Title,
+		StartTime:  class.StartTime,
+		Capacity:   class.Capacity,
+	})
+}
+
+// send ставит оповещение в очередь на все адреса правила; оповещение не должно срывать
+// основную операцию, поэтому ошибки только логируются
+func (a *AdminAlerts) send(ctx context.Context, tx *sql.Tx, kind string, msg notification.Message) {
+	for _, to := range a.rules[kind] {
+		if err := a.notificationSvc.Notify(ctx, tx, notification.Recipient{Email: to, Locale: notification.DefaultLocale}, msg); err != nil {
+			utils.GetLogger().Error("Failed to enqueue admin alert",
+				zap.String("alert", kind), zap.String("to", to), zap.Error(err))
+		}
+	}
+}
+
+// formatMoney выводит сумму в копейках как "25 000.00 KZT"
+func formatMoney(cents int, currency string) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	units := strconv.Itoa(cents / 100)
+	grouped := ""
+	for len(units) > 3 {
+		grouped = " " + units[len(units)-3:] + grouped
+		units = units[:len(units)-3]
+	}
+	return fmt.Sprintf("%s%s%s.%02d %s", sign, units, grouped, cents%100, currency)
+}
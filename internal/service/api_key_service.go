@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// Ключ имеет вид gsk_<prefix>_<secret>: prefix открыт и служит для поиска, secret хранится только в виде хеша
+const apiKeyTag = "gsk"
+
+var (
+	ErrInvalidAPIKey       = errors.New("invalid api key")
+	ErrAPIKeyNotFound      = errors.New("api key not found")
+	ErrInvalidAPIKeyScope  = errors.New("invalid api key scope")
+	ErrInvalidAPIKeyExpiry = errors.New("expires_at must be in the future")
+)
+
+type APIKeyService struct {
+	repo repository.APIKeyStore
+}
+
+func NewAPIKeyService(repo repository.APIKeyStore) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// Create выпускает ключ и возвращает его открытое значение — показать его можно только сейчас
+func (s *APIKeyService) Create(ctx context.Context, createdBy int, name string, scopes []string, gymID *int, expiresAt *time.Time) (*models.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidAPIKeyScope
+	}
+	for _, scope := range scopes {
+		if !isAPIKeyScope(scope) {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidAPIKeyScope, scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidAPIKeyExpiry
+	}
+
+	prefixBytes := make([]byte, 8)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, "", err
+	}
+	prefix := hex.EncodeToString(prefixBytes)
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key, err := s.repo.Create(ctx, name, prefix, hashToken(secret), scopes, gymID, createdBy, expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, apiKeyTag + "_" + prefix + "_" + secret, nil
+}
+
+// AuthenticateAPIKey проверяет ключ и отмечает время его использования
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	parts := strings.SplitN(rawKey, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyTag || parts[1] == "" || parts[2] == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetByPrefix(ctx, parts[1])
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(parts[2])), []byte(key.KeyHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := s.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+		utils.GetLogger().Warn("Failed to update api key last use", zap.Int("api_key_id", key.ID), zap.Error(err))
+	}
+	return key, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context) ([]models.APIKey, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id int) error {
+	ok, err := s.repo.Revoke(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func isAPIKeyScope(scope string) bool {
+	for _, s := range models.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
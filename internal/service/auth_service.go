@@ -1,48 +1,411 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"Gym_StrongCode/internal/models"
 	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	// Сколько действует mfa_token между вводом пароля и кода 2FA
+	mfaChallengeTTL = 5 * time.Minute
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrTokenRevoked        = errors.New("token revoked")
+	ErrInvalidMFAToken     = errors.New("invalid or expired mfa token")
+	ErrInvalidPassword     = errors.New("invalid password")
+)
+
 type AuthService struct {
-	userRepo  *repository.UserRepository
-	jwtSecret string
+	userRepo   repository.UserStore
+	tokenRepo  repository.TokenStore
+	uow        repository.Transactor
+	keys       *KeyService
+	passwords  *PasswordPolicy
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
-func NewAuthService(userRepo *repository.UserRepository, jwtSecret string) *AuthService {
-	return &AuthService{userRepo: userRepo, jwtSecret: jwtSecret}
+// NewAuthService создаёт сервис аутентификации; без passwords действует политика паролей по умолчанию,
+// нулевые TTL заменяются значениями по умолчанию
+func NewAuthService(userRepo repository.UserStore, tokenRepo repository.TokenStore, uow repository.Transactor, keys *KeyService, passwords *PasswordPolicy, accessTTL, refreshTTL time.Duration) *AuthService {
+	if passwords == nil {
+		passwords = NewPasswordPolicy(0, 0, 0)
+	}
+	if accessTTL <= 0 {
+		accessTTL = defaultAccessTokenTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTokenTTL
+	}
+	return &AuthService{
+		userRepo:   userRepo,
+		tokenRepo:  tokenRepo,
+		uow:        uow,
+		keys:       keys,
+		passwords:  passwords,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
 }
 
-func (s *AuthService) Register(name, email, password string) (*models.User, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (s *AuthService) Register(ctx context.Context, name, email, password string) (*models.User, error) {
+	if err := s.passwords.Validate(password, email, name); err != nil {
+		return nil, err
+	}
+	hash, err := s.passwords.Hash(password)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.userRepo.Create(name, email, string(hash), false)
+	return s.userRepo.Create(ctx, name, email, hash, false)
 }
 
-func (s *AuthService) Login(email, password string) (string, error) {
-	user, err := s.userRepo.GetByEmail(email)
+// ChangePassword меняет пароль после проверки текущего и завершает все сеансы пользователя
+func (s *AuthService) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return "", err
+		return err
+	}
+	if _, err := s.Authenticate(ctx, user.Email, currentPassword); err != nil {
+		return ErrInvalidPassword
+	}
+	if err := s.passwords.Validate(newPassword, user.Email, user.Name); err != nil {
+		return err
+	}
+
+	hash, err := s.passwords.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		if err := s.userRepo.WithTx(tx).SetPasswordHash(ctx, userID, hash); err != nil {
+			return err
+		}
+		return s.logoutAll(ctx, tx, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	utils.GetLogger().Info("Password changed", zap.Int("user_id", userID))
+	return nil
+}
+
+// Authenticate проверяет email и пароль и возвращает пользователя
+func (s *AuthService) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Login проверяет пароль и выдаёт токены. Если у пользователя включена 2FA,
+// вместо токенов возвращается mfa_token для второго шага (LoginMFA).
+func (s *AuthService) Login(ctx context.Context, email, password string) (*models.TokenPair, error) {
+	user, err := s.Authenticate(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+	return s.LoginUser(ctx, user)
+}
+
+// LoginUser завершает вход пользователя, личность которого уже подтверждена (паролем или внешним провайдером)
+func (s *AuthService) LoginUser(ctx context.Context, user *models.User) (*models.TokenPair, error) {
+	if user.TOTPEnabled {
+		challenge, err := s.issueMFAChallenge(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &models.TokenPair{MFARequired: true, MFAToken: challenge}, nil
+	}
+	return s.startSession(ctx, user)
+}
+
+// MFAUser возвращает пользователя, для которого выдан mfa_token
+func (s *AuthService) MFAUser(ctx context.Context, mfaToken string) (*models.User, error) {
+	claims, err := s.keys.ParseToken(ctx, mfaToken)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	userID, ok := claims["mfa_user_id"].(float64)
+	if !ok {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, int(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// LoginMFA — второй шаг входа: mfa_token из Login и TOTP-код или код восстановления
+func (s *AuthService) LoginMFA(ctx context.Context, mfaToken, code string) (*models.TokenPair, error) {
+	user, err := s.MFAUser(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySecondFactor(ctx, s.userRepo, s.tokenRepo, user.ID, code); err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, user)
+}
+
+// VerifySecondFactor проверяет код 2FA пользователя (используется входом через сессии)
+func (s *AuthService) VerifySecondFactor(ctx context.Context, userID int, code string) error {
+	return verifySecondFactor(ctx, s.userRepo, s.tokenRepo, userID, code)
+}
+
+// issueMFAChallenge выдаёт короткоживущий токен, подтверждающий, что пароль уже проверен.
+// В нём нет user_id, поэтому AuthMiddleware не примет его как access-токен.
+func (s *AuthService) issueMFAChallenge(ctx context.Context, userID int) (string, error) {
+	now := time.Now()
+	return s.keys.Sign(ctx, jwt.MapClaims{
+		"mfa_user_id": userID,
+		"iat":         now.Unix(),
+		"exp":         now.Add(mfaChallengeTTL).Unix(),
+	})
+}
+
+// startSession открывает новое семейство refresh-токенов
+func (s *AuthService) startSession(ctx context.Context, user *models.User) (*models.TokenPair, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	pair, _, err := s.issueTokens(ctx, nil, user, familyID)
+	return pair, err
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens выдаёт access-токен и новый refresh-токен в семействе familyID; tx = nil — вне транзакции
+func (s *AuthService) issueTokens(ctx context.Context, tx *sql.Tx, user *models.User, familyID string) (*models.TokenPair, int, error) {
+	version, err := s.userRepo.WithTx(tx).GetTokenVersion(ctx, user.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	jti, err := randomToken(16)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	now := time.Now()
+	expiresAt := now.Add(s.accessTTL)
+	accessToken, err := s.keys.Sign(ctx, jwt.MapClaims{
 		"user_id":  user.ID,
 		"is_admin": user.IsAdmin,
-		"exp":      time.Now().Add(time.Hour * 24 * 7).Unix(),
+		"ver":      version,
+		"jti":      jti,
+		"iat":      now.Unix(),
+		"exp":      expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, 0, err
+	}
+	refreshID, err := s.tokenRepo.WithTx(tx).CreateRefresh(ctx, user.ID, familyID, hashToken(refreshToken), now.Add(s.refreshTTL))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresAt:    expiresAt.UTC().Format(time.RFC3339),
+	}, refreshID, nil
+}
+
+// Refresh обменивает refresh-токен на новую пару. Каждый refresh-токен одноразовый:
+// повторное предъявление уже использованного токена означает его утечку, поэтому
+// отзывается всё семейство токенов этого входа.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	stored, err := s.tokenRepo.GetRefreshByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+	if stored.Expired {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	// Новый refresh-токен сохраняется, только если удалось погасить предъявленный
+	var pair *models.TokenPair
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		var newID int
+		pair, newID, err = s.issueTokens(ctx, tx, user, stored.FamilyID)
+		if err != nil {
+			return err
+		}
+		rotated, err := s.tokenRepo.WithTx(tx).RotateRefresh(ctx, stored.ID, newID)
+		if err != nil {
+			return err
+		}
+		if !rotated {
+			return ErrRefreshTokenReused
+		}
+		return nil
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// Токен успели использовать параллельно — считаем это повторным использованием
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+func (s *AuthService) revokeReusedFamily(ctx context.Context, stored *models.RefreshToken) error {
+	utils.GetLogger().Warn("Refresh token reuse detected",
+		zap.Int("user_id", stored.UserID),
+		zap.String("family_id", stored.FamilyID),
+	)
+	if err := s.tokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// Logout отзывает текущий access-токен и, если передан, refresh-токен этого входа
+func (s *AuthService) Logout(ctx context.Context, userID int, jti string, accessExpiresAt time.Time, refreshToken string) error {
+	if jti != "" {
+		if err := s.tokenRepo.RevokeAccess(ctx, jti, accessExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken != "" {
+		stored, err := s.tokenRepo.GetRefreshByHash(ctx, hashToken(refreshToken))
+		if err == nil && stored.UserID == userID {
+			if err := s.tokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+				return err
+			}
+		} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	return s.tokenRepo.PurgeExpired(ctx, time.Now())
+}
+
+// LogoutAll завершает все сессии пользователя: отзывает refresh-токены и повышает версию токенов
+func (s *AuthService) LogoutAll(ctx context.Context, userID int) error {
+	return s.uow.Do(ctx, func(tx *sql.Tx) error {
+		return s.logoutAll(ctx, tx, userID)
 	})
+}
 
-	return token.SignedString([]byte(s.jwtSecret))
+// logoutAll — LogoutAll внутри транзакции вызывающего
+func (s *AuthService) logoutAll(ctx context.Context, tx *sql.Tx, userID int) error {
+	if err := s.tokenRepo.WithTx(tx).RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.userRepo.WithTx(tx).IncrementTokenVersion(ctx, userID)
+}
+
+// CheckSession сверяет серверную сессию с пользователем: он существует и версия токенов не менялась
+// (выход везде, смена или сброс пароля). Возвращает актуальный признак администратора.
+func (s *AuthService) CheckSession(ctx context.Context, userID, version int) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrTokenRevoked
+		}
+		return false, err
+	}
+	current, err := s.userRepo.GetTokenVersion(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrTokenRevoked
+		}
+		return false, err
+	}
+	if current != version {
+		return false, ErrTokenRevoked
+	}
+	return user.IsAdmin, nil
+}
+
+// CheckAccessToken проверяет, что access-токен не отозван: его нет в denylist,
+// пользователь существует и версия токенов не менялась
+func (s *AuthService) CheckAccessToken(ctx context.Context, userID int, jti string, version int) error {
+	if jti != "" {
+		revoked, err := s.tokenRepo.IsAccessRevoked(ctx, jti)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrTokenRevoked
+		}
+	}
+
+	current, err := s.userRepo.GetTokenVersion(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTokenRevoked
+		}
+		return err
+	}
+	if current != version {
+		return ErrTokenRevoked
+	}
+	return nil
 }
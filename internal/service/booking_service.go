@@ -1,68 +1,89 @@
 package service
 
 import (
-	"fmt"
+	"context"
+	"database/sql"
 
 	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
 	"Gym_StrongCode/internal/repository"
 )
 
 type BookingService struct {
-	bookingRepo     *repository.BookingRepository
-	classRepo       *repository.ClassRepository
-	membershipRepo  *repository.MembershipRepository
+	bookingRepo     repository.BookingStore
+	classRepo       repository.ClassStore
+	membershipRepo  repository.MembershipStore
+	uow             repository.Transactor
 	notificationSvc *NotificationService
+	alerts          *AdminAlerts
 }
 
 func NewBookingService(
-	bookingRepo *repository.BookingRepository,
-	classRepo *repository.ClassRepository,
-	membershipRepo *repository.MembershipRepository,
+	bookingRepo repository.BookingStore,
+	classRepo repository.ClassStore,
+	membershipRepo repository.MembershipStore,
+	uow repository.Transactor,
 	notificationSvc *NotificationService,
+	alerts *AdminAlerts,
 ) *BookingService {
 	return &BookingService{
 		bookingRepo:     bookingRepo,
 		classRepo:       classRepo,
 		membershipRepo:  membershipRepo,
+		uow:             uow,
 		notificationSvc: notificationSvc,
+		alerts:          alerts,
 	}
 }
 
-func (s *BookingService) Create(userID, classID int, userEmail string) error {
+func (s *BookingService) Create(ctx context.Context, user *models.User, classID int) error {
 	// ... проверки (класс существует, есть места, активная подписка и т.д.)
-
-	// Создаём бронирование
-	_, err := s.bookingRepo.Create(userID, classID)
+	class, err := s.classRepo.GetByID(ctx, classID)
 	if err != nil {
 		return err
 	}
 
-	// Уведомление по email
-	class, err := s.classRepo.GetByID(classID)
+	// Бронирование и письмо о нём сохраняются в одной транзакции
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		if _, err := s.bookingRepo.WithTx(tx).Create(ctx, user.ID, classID); err != nil {
+			return err
+		}
+		msg := notification.BookingConfirmed{Name: user.Name, ClassTitle: class.Title, StartTime: class.StartTime}
+		if err := s.notificationSvc.Notify(ctx, tx, recipientOf(user), msg); err != nil {
+			return err
+		}
+		// Оповещаем администраторов один раз — когда занято последнее место
+		if s.alerts != nil && class.Capacity > 0 {
+			booked, err := s.classRepo.WithTx(tx).GetBookingCount(ctx, classID)
+			if err != nil {
+				return err
+			}
+			if booked == class.Capacity {
+				s.alerts.ClassFull(ctx, tx, class)
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	body := fmt.Sprintf(`
-		<h2>Бронирование подтверждено!</h2>
-		<p>Вы успешно забронировали занятие: <strong>%s</strong></p>
-		<p>Дата и время: %s</p>
-		<p>Спасибо за выбор StrongCode!</p>
-	`, class.Title, class.StartTime)
-
-	s.notificationSvc.SendNotification(userEmail, "Бронирование занятия", body)
-
+	s.notificationSvc.Wake()
 	return nil
 }
 
-func (s *BookingService) ListUser(userID int) ([]models.Booking, error) {
-	return s.bookingRepo.GetByUser(userID)
+func (s *BookingService) ListUser(ctx context.Context, userID int) ([]models.Booking, error) {
+	return s.bookingRepo.GetByUser(ctx, userID)
+}
+
+func (s *BookingService) ListAll(ctx context.Context) ([]models.Booking, error) {
+	return s.bookingRepo.ListAll(ctx)
 }
 
-func (s *BookingService) ListAll() ([]models.Booking, error) {
-	return s.bookingRepo.ListAll()
+func (s *BookingService) ListByGym(ctx context.Context, gymID int) ([]models.Booking, error) {
+	return s.bookingRepo.ListByGym(ctx, gymID)
 }
 
-func (s *BookingService) Cancel(bookingID, userID int) error {
-	return s.bookingRepo.Cancel(bookingID, userID)
+func (s *BookingService) Cancel(ctx context.Context, bookingID, userID int) error {
+	return s.bookingRepo.Cancel(ctx, bookingID, userID)
 }
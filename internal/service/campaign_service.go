@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// Кампании рассылаются пачками раз в минуту, поэтому rate_per_minute — размер пачки
+	campaignPollInterval = time.Minute
+	defaultCampaignRate  = 60
+	maxCampaignRate      = 1000
+	segmentPreviewSize   = 20
+)
+
+var (
+	ErrCampaignNotFound = errors.New("campaign not found")
+	ErrInvalidCampaign  = errors.New("invalid campaign")
+	ErrCampaignState    = errors.New("campaign cannot be changed in its current status")
+)
+
+// CampaignService — рассылки по сегментам пользователей. Запланированная кампания в своё время
+// фиксирует получателей и отправляет им письма через очередь уведомлений не быстрее rate_per_minute;
+// подписки и тихие часы получателей учитываются, как у любых маркетинговых сообщений.
+type CampaignService struct {
+	repo            repository.CampaignStore
+	notificationSvc *NotificationService
+	tracker         *notification.OpenTracker
+	uow             repository.Transactor
+	now             func() time.Time
+	stop            context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewCampaignService создаёт сервис рассылок; tracker — пиксель открытий (nil — открытия не считаются)
+func NewCampaignService(repo repository.CampaignStore, uow repository.Transactor, notificationSvc *NotificationService, tracker *notification.OpenTracker) *CampaignService {
+	return &CampaignService{
+		repo:            repo,
+		notificationSvc: notificationSvc,
+		tracker:         tracker,
+		uow:             uow,
+		now:             time.Now,
+	}
+}
+
+func validateSegment(seg *models.Segment) error {
+	for _, date := range []string{seg.MembershipExpiresFrom, seg.MembershipExpiresTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("%w: membership expiry dates must be YYYY-MM-DD", ErrInvalidCampaign)
+		}
+	}
+	filtered := len(seg.MembershipIDs) > 0 || seg.MembershipExpiresFrom != "" || seg.MembershipExpiresTo != ""
+	if filtered && seg.ActiveMembership != nil && !*seg.ActiveMembership {
+		return fmt.Errorf("%w: membership filters require an active membership", ErrInvalidCampaign)
+	}
+	for _, locale := range seg.Locales {
+		if !notification.IsSupportedLocale(locale) {
+			return fmt.Errorf("%w: %s: %s", ErrInvalidCampaign, notification.ErrUnsupportedLocale, locale)
+		}
+	}
+	return nil
+}
+
+// parseSchedule переводит время из RFC 3339 в формат колонки
+func parseSchedule(at string) (string, error) {
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return "", fmt.Errorf("%w: scheduled_at must be RFC 3339", ErrInvalidCampaign)
+	}
+	return t.UTC().Format("2006-01-02 15:04:05"), nil
+}
+
+// PreviewSegment показывает, сколько пользователей попадёт в сегмент, и первых из них
+func (s *CampaignService) PreviewSegment(ctx context.Context, seg *models.Segment) (*models.SegmentPreview, error) {
+	if err := validateSegment(seg); err != nil {
+		return nil, err
+	}
+	return s.repo.PreviewSegment(ctx, *seg, s.now().Format("2006-01-02"), segmentPreviewSize)
+}
+
+// Create сохраняет кампанию: с scheduled_at — запланированной, без него — черновиком
+func (s *CampaignService) Create(ctx context.Context, c *models.Campaign, createdBy int) (*models.Campaign, error) {
+	c.Name, c.Title, c.Body = strings.TrimSpace(c.Name), strings.TrimSpace(c.Title), strings.TrimSpace(c.Body)
+	if c.Name == "" || c.Title == "" || c.Body == "" {
+		return nil, fmt.Errorf("%w: name, title and body are required", ErrInvalidCampaign)
+	}
+	if err := validateSegment(&c.Segment); err != nil {
+		return nil, err
+	}
+	if c.RatePerMinute == 0 {
+		c.RatePerMinute = defaultCampaignRate
+	}
+	if c.RatePerMinute < 0 || c.RatePerMinute > maxCampaignRate {
+		return nil, fmt.Errorf("%w: rate_per_minute must be between 1 and %d", ErrInvalidCampaign, maxCampaignRate)
+	}
+
+	c.Status = "draft"
+	if c.ScheduledAt != "" {
+		at, err := parseSchedule(c.ScheduledAt)
+		if err != nil {
+			return nil, err
+		}
+		c.ScheduledAt, c.Status = at, "scheduled"
+	}
+	c.CreatedBy = createdBy
+	return s.repo.Create(ctx, c)
+}
+
+func (s *CampaignService) List(ctx context.Context, status string) ([]models.Campaign, error) {
+	return s.repo.List(ctx, status)
+}
+
+// Get возвращает кампанию со статистикой доставки и открытий
+func (s *CampaignService) Get(ctx context.Context, id int) (*models.Campaign, error) {
+	c, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, err
+	}
+	if c.Stats, err = s.repo.Stats(ctx, id); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Schedule планирует черновик (или переносит запланированную кампанию) на at; пустое at — сейчас
+func (s *CampaignService) Schedule(ctx context.Context, id int, at string) error {
+	scheduledAt := s.now().UTC().Format("2006-01-02 15:04:05")
+	if at != "" {
+		var err error
+		if scheduledAt, err = parseSchedule(at); err != nil {
+			return err
+		}
+	}
+	return s.transition(ctx, id, "scheduled", scheduledAt, "draft", "scheduled")
+}
+
+// Cancel останавливает кампанию; уже поставленные в очередь письма уйдут
+func (s *CampaignService) Cancel(ctx context.Context, id int) error {
+	return s.transition(ctx, id, "cancelled", "", "draft", "scheduled", "sending")
+}
+
+func (s *CampaignService) transition(ctx context.Context, id int, status, scheduledAt string, from ...string) error {
+	ok, err := s.repo.SetStatus(ctx, id, status, scheduledAt, from...)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if _, err := s.Get(ctx, id); err != nil {
+			return err
+		}
+		return ErrCampaignState
+	}
+	return nil
+}
+
+// ProcessDue запускает наступившие кампании и ставит в очередь очередную пачку писем
+// каждой рассылаемой; возвращает число поставленных писем
+func (s *CampaignService) ProcessDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.ListDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	for i := range due {
+		n, started, err := s.repo.Start(ctx, &due[i], now.Format("2006-01-02"), now)
+		if err != nil {
+			return 0, err
+		}
+		if started {
+			utils.GetLogger().Info("Campaign started", zap.Int("campaign_id", due[i].ID), zap.Int("recipients", n))
+		}
+	}
+
+	sending, err := s.repo.ListSending(ctx)
+	if err != nil {
+		return 0, err
+	}
+	queued := 0
+	for i := range sending {
+		n, err := s.sendBatch(ctx, &sending[i], now)
+		queued += n
+		if err != nil {
+			return queued, err
+		}
+	}
+	if queued > 0 {
+		s.notificationSvc.Wake()
+	}
+	return queued, nil
+}
+
+// sendBatch ставит в очередь письма следующим rate_per_minute получателям кампании
+func (s *CampaignService) sendBatch(ctx context.Context, c *models.Campaign, now time.Time) (int, error) {
+	users, err := s.repo.PendingRecipients(ctx, c.ID, c.RatePerMinute)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for i := range users {
+		ok, err := s.sendOne(ctx, c, &users[i])
+		if err != nil {
+			return queued, err
+		}
+		if ok {
+			queued++
+		}
+	}
+
+	finished, err := s.repo.Finish(ctx, c.ID, now)
+	if err != nil {
+		return queued, err
+	}
+	if finished {
+		utils.GetLogger().Info("Campaign sent", zap.Int("campaign_id", c.ID))
+	}
+	return queued, nil
+}
+
+// sendOne ставит письмо получателю в очередь вместе с отметкой в campaign_recipients
+func (s *CampaignService) sendOne(ctx context.Context, c *models.Campaign, user *models.User) (bool, error) {
+	msg := notification.Campaign{Name: user.Name, Title: c.Title, Body: c.Body}
+	if s.tracker != nil {
+		msg.OpenURL = s.tracker.URL(c.ID, user.ID)
+	}
+
+	queued := false
+	err := s.uow.Do(ctx, func(tx *sql.Tx) error {
+		id, err := s.notificationSvc.Enqueue(ctx, tx, recipientOf(user), msg)
+		if err != nil && !errors.Is(err, ErrNoRecipient) {
+			return err
+		}
+
+		status := "queued"
+		if id == 0 {
+			status = "skipped"
+		}
+		queued = id != 0
+		return s.repo.WithTx(tx).MarkRecipient(ctx, c.ID, user.ID, status, id)
+	})
+	return queued, err
+}
+
+// TrackOpen отмечает открытие письма по токену из пикселя
+func (s *CampaignService) TrackOpen(ctx context.Context, token string) error {
+	if s.tracker == nil {
+		return notification.ErrInvalidTrackingLink
+	}
+	campaignID, userID, err := s.tracker.Parse(token)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.MarkOpened(ctx, campaignID, userID, s.now())
+	return err
+}
+
+func (s *CampaignService) StartWorker() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(campaignPollInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.ProcessDue(ctx, time.Now()); err != nil {
+				utils.GetLogger().Error("Campaign processing failed", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *CampaignService) StopWorker() {
+	// Отмена прерывает и запросы, которые воркер выполняет в этот момент
+	if s.stop != nil {
+		s.stop()
+	}
+	s.wg.Wait()
+}
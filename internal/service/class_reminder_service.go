@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	classReminderPollInterval = 5 * time.Minute
+	classReminderBatchSize    = 200
+)
+
+// ClassReminderService напоминает записавшимся о занятии за lead до его начала.
+// Напоминание помечается временем занятия, поэтому после переноса оно уходит снова.
+type ClassReminderService struct {
+	bookingRepo     repository.BookingStore
+	uow             repository.Transactor
+	notificationSvc *NotificationService
+	lead            time.Duration
+	stop            context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewClassReminderService создаёт сервис напоминаний; lead <= 0 — напоминания выключены
+func NewClassReminderService(bookingRepo repository.BookingStore, uow repository.Transactor, notificationSvc *NotificationService, lead time.Duration) *ClassReminderService {
+	return &ClassReminderService{
+		bookingRepo:     bookingRepo,
+		uow:             uow,
+		notificationSvc: notificationSvc,
+		lead:            lead,
+	}
+}
+
+// SendDue ставит в очередь напоминания о занятиях, начинающихся в ближайшие lead,
+// и возвращает их число
+func (s *ClassReminderService) SendDue(ctx context.Context, now time.Time) (int, error) {
+	if s.lead <= 0 {
+		return 0, nil
+	}
+
+	sent := 0
+	for {
+		due, err := s.bookingRepo.ListDueReminders(ctx, now, now.Add(s.lead), classReminderBatchSize)
+		if err != nil {
+			return sent, err
+		}
+		for i := range due {
+			if err := s.remind(ctx, &due[i]); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		if len(due) < classReminderBatchSize {
+			break
+		}
+	}
+
+	if sent > 0 {
+		s.notificationSvc.Wake()
+	}
+	return sent, nil
+}
+
+// remind ставит напоминание в очередь и помечает бронирование в одной транзакции
+func (s *ClassReminderService) remind(ctx context.Context, r *models.BookingReminder) error {
+	return s.uow.Do(ctx, func(tx *sql.Tx) error {
+		msg := notification.ClassReminder{Name: r.User.Name, ClassTitle: r.ClassTitle, StartTime: r.StartTime, StartsAt: r.StartsAt}
+		if err := s.notificationSvc.Notify(ctx, tx, recipientOf(&r.User), msg); err != nil && !errors.Is(err, ErrNoRecipient) {
+			return err
+		}
+		return s.bookingRepo.WithTx(tx).MarkReminded(ctx, r.BookingID, r.StartTime)
+	})
+}
+
+func (s *ClassReminderService) StartWorker() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(classReminderPollInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.SendDue(ctx, time.Now()); err != nil {
+				utils.GetLogger().Error("Class reminders processing failed", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *ClassReminderService) StopWorker() {
+	// Отмена прерывает и запросы, которые воркер выполняет в этот момент
+	if s.stop != nil {
+		s.stop()
+	}
+	s.wg.Wait()
+}
@@ -1,42 +1,183 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+
 	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
 	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
 )
 
+var ErrClassNotFound = errors.New("class not found")
+
 type ClassService struct {
-	classRepo   *repository.ClassRepository
-	trainerRepo *repository.TrainerRepository
-	gymRepo     *repository.GymRepository
+	classRepo       repository.ClassStore
+	trainerRepo     repository.TrainerStore
+	gymRepo         repository.GymStore
+	bookingRepo     repository.BookingStore
+	uow             repository.Transactor
+	notificationSvc *NotificationService
+}
+
+func NewClassService(
+	classRepo repository.ClassStore,
+	trainerRepo repository.TrainerStore,
+	gymRepo repository.GymStore,
+	bookingRepo repository.BookingStore,
+	uow repository.Transactor,
+	notificationSvc *NotificationService,
+) *ClassService {
+	return &ClassService{
+		classRepo:       classRepo,
+		trainerRepo:     trainerRepo,
+		gymRepo:         gymRepo,
+		bookingRepo:     bookingRepo,
+		uow:             uow,
+		notificationSvc: notificationSvc,
+	}
 }
 
-func NewClassService(classRepo *repository.ClassRepository, trainerRepo *repository.TrainerRepository, gymRepo *repository.GymRepository) *ClassService {
-	return &ClassService{classRepo: classRepo, trainerRepo: trainerRepo, gymRepo: gymRepo}
+func (s *ClassService) Create(ctx context.Context, c *models.Class) (*models.Class, error) {
+	if err := s.validate(ctx, c); err != nil {
+		return nil, err
+	}
+	return s.classRepo.Create(ctx, c)
 }
 
-func (s *ClassService) Create(c *models.Class) (*models.Class, error) {
-	// Валидация trainer_id и gym_id
+// validate проверяет trainer_id и gym_id
+func (s *ClassService) validate(ctx context.Context, c *models.Class) error {
 	if c.TrainerID != 0 {
-		if _, err := s.trainerRepo.GetByID(c.TrainerID); err != nil {
-			return nil, err
+		if _, err := s.trainerRepo.GetByID(ctx, c.TrainerID); err != nil {
+			return err
 		}
 	}
-	if _, err := s.gymRepo.GetByID(c.GymID); err != nil {
-		return nil, err
+	if _, err := s.gymRepo.GetByID(ctx, c.GymID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *ClassService) List(ctx context.Context) ([]models.Class, error) {
+	return s.classRepo.List(ctx)
+}
+
+func (s *ClassService) get(ctx context.Context, id int) (*models.Class, error) {
+	class, err := s.classRepo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrClassNotFound
+	}
+	return class, err
+}
+
+// Update сохраняет занятие; если сменились время или тренер, записавшиеся получают
+// уведомление в той же транзакции
+func (s *ClassService) Update(ctx context.Context, id int, c *models.Class) error {
+	old, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.validate(ctx, c); err != nil {
+		return err
+	}
+
+	msg := notification.ClassChanged{ClassTitle: c.Title, StartTime: c.StartTime}
+	if c.StartTime != old.StartTime {
+		msg.OldStartTime = old.StartTime
+	}
+	if c.TrainerID != old.TrainerID {
+		msg.TrainerChanged = true
+		if c.TrainerID != 0 {
+			trainer, err := s.trainerRepo.GetByID(ctx, c.TrainerID)
+			if err != nil {
+				return err
+			}
+			msg.Trainer = trainer.Name
+		}
+	}
+	changed := msg.OldStartTime != "" || msg.TrainerChanged
+
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		if err := s.classRepo.WithTx(tx).Update(ctx, id, c); err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+		return s.notifyAttendees(ctx, tx, id, func(user *models.User) notification.Message {
+			m := msg
+			m.Name = user.Name
+			return m
+		})
+	})
+	if err != nil {
+		return err
 	}
 
-	return s.classRepo.Create(c)
+	if changed {
+		s.notificationSvc.Wake()
+	}
+	return nil
 }
 
-func (s *ClassService) List() ([]models.Class, error) {
-	return s.classRepo.List()
+// GymOf возвращает зал, в котором проходит занятие
+func (s *ClassService) GymOf(ctx context.Context, classID int) (int, error) {
+	class, err := s.classRepo.GetByID(ctx, classID)
+	if err != nil {
+		return 0, err
+	}
+	return class.GymID, nil
 }
 
-func (s *ClassService) Update(id int, c *models.Class) error {
-	return s.classRepo.Update(id, c)
+// Delete отменяет занятие: записавшиеся получают уведомление, их бронирования снимаются
+func (s *ClassService) Delete(ctx context.Context, id int) error {
+	class, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		if err := s.notifyAttendees(ctx, tx, id, func(user *models.User) notification.Message {
+			return notification.ClassCancelled{Name: user.Name, ClassTitle: class.Title, StartTime: class.StartTime}
+		}); err != nil {
+			return err
+		}
+		if err := s.bookingRepo.WithTx(tx).DeleteByClass(ctx, id); err != nil {
+			return err
+		}
+		return s.classRepo.WithTx(tx).Delete(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notificationSvc.Wake()
+	return nil
 }
 
-func (s *ClassService) Delete(id int) error {
-	return s.classRepo.Delete(id)
+// notifyAttendees ставит в очередь сообщение каждому записавшемуся на занятие
+func (s *ClassService) notifyAttendees(ctx context.Context, tx *sql.Tx, classID int, message func(user *models.User) notification.Message) error {
+	attendees, err := s.bookingRepo.WithTx(tx).ListAttendees(ctx, classID)
+	if err != nil {
+		return err
+	}
+	for i := range attendees {
+		user := &attendees[i]
+		msg := message(user)
+		if err := s.notificationSvc.Notify(ctx, tx, recipientOf(user), msg); err != nil {
+			if errors.Is(err, ErrNoRecipient) {
+				continue
+			}
+			return err
+		}
+	}
+	if len(attendees) > 0 {
+		utils.GetLogger().Info("Class attendees notified",
+			zap.Int("class_id", classID), zap.Int("attendees", len(attendees)))
+	}
+	return nil
 }
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+type CurrencyService struct {
+	currencyRepo repository.CurrencyStore
+	baseCurrency string
+}
+
+func NewCurrencyService(currencyRepo repository.CurrencyStore, baseCurrency string) *CurrencyService {
+	return &CurrencyService{currencyRepo: currencyRepo, baseCurrency: NormalizeCurrency(baseCurrency)}
+}
+
+// NormalizeCurrency приводит код валюты к виду ISO 4217 (USD, EUR, KZT)
+func NormalizeCurrency(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func validCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, ch := range code {
+		if ch < 'A' || ch > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *CurrencyService) BaseCurrency() string {
+	return s.baseCurrency
+}
+
+// Resolve возвращает нормализованный код валюты; пустая строка означает базовую валюту.
+// Валюта поддерживается, если это базовая валюта или для неё задан курс.
+func (s *CurrencyService) Resolve(ctx context.Context, currency string) (string, error) {
+	code := NormalizeCurrency(currency)
+	if code == "" || code == s.baseCurrency {
+		return s.baseCurrency, nil
+	}
+
+	if _, err := s.currencyRepo.GetRate(ctx, code); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, code)
+		}
+		return "", err
+	}
+	return code, nil
+}
+
+func (s *CurrencyService) ListRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	return s.currencyRepo.ListRates(ctx)
+}
+
+func (s *CurrencyService) SetRate(ctx context.Context, currency string, rate float64) (*models.ExchangeRate, error) {
+	code := NormalizeCurrency(currency)
+	if !validCurrencyCode(code) {
+		return nil, fmt.Errorf("invalid currency code: %s", currency)
+	}
+	if code == s.baseCurrency {
+		return nil, fmt.Errorf("rate for base currency %s is always 1", code)
+	}
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return nil, fmt.Errorf("rate must be positive")
+	}
+
+	return s.currencyRepo.SetRate(ctx, code, rate)
+}
+
+func (s *CurrencyService) DeleteRate(ctx context.Context, currency string) error {
+	return s.currencyRepo.DeleteRate(ctx, NormalizeCurrency(currency))
+}
+
+// ConvertToBase переводит сумму в минимальных единицах валюты (центы, тиыны)
+// в минимальные единицы базовой валюты. Обе валюты считаются двухзнаковыми.
+func (s *CurrencyService) ConvertToBase(ctx context.Context, amountCents int, currency string) (int, error) {
+	code := NormalizeCurrency(currency)
+	if code == "" || code == s.baseCurrency {
+		return amountCents, nil
+	}
+
+	rate, err := s.currencyRepo.GetRate(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, code)
+		}
+		return 0, err
+	}
+
+	return int(math.Round(float64(amountCents) * rate.Rate)), nil
+}
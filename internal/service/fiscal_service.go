@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	fiscalBatchSize    = 20
+	fiscalPollInterval = 30 * time.Second
+	fiscalSendTimeout  = 15 * time.Second
+	fiscalMaxAttempts  = 10
+	fiscalBaseBackoff  = 30 * time.Second
+	fiscalMaxBackoff   = 6 * time.Hour
+	// Чек числится за воркером на время пачки: до fiscalBatchSize отправок по fiscalSendTimeout
+	fiscalLease = 10 * time.Minute
+)
+
+// FiscalReceiptSender регистрирует чек у оператора фискальных данных (ОФД).
+// Реальный клиент оператора подключается через этот интерфейс.
+type FiscalReceiptSender interface {
+	SendReceipt(ctx context.Context, receipt *models.FiscalReceipt) (*models.FiscalRegistration, error)
+}
+
+type FiscalService struct {
+	fiscalRepo  repository.FiscalStore
+	paymentRepo repository.PaymentStore
+	sender      FiscalReceiptSender
+	wake        chan struct{}
+	stop        context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewFiscalService создаёт сервис фискализации. Если sender == nil, чеки
+// только копятся в очереди и будут отправлены, когда оператор будет подключён.
+func NewFiscalService(fiscalRepo repository.FiscalStore, paymentRepo repository.PaymentStore, sender FiscalReceiptSender) *FiscalService {
+	return &FiscalService{
+		fiscalRepo:  fiscalRepo,
+		paymentRepo: paymentRepo,
+		sender:      sender,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Enqueue ставит завершённый платёж в очередь регистрации чека; с tx — в той же транзакции,
+// что и платёж. Воркер будится только после фиксации транзакции вызывающим (Wake).
+func (s *FiscalService) Enqueue(ctx context.Context, tx *sql.Tx, payment *models.Payment) error {
+	if payment.Status != "completed" {
+		return nil
+	}
+	if err := s.fiscalRepo.WithTx(tx).Enqueue(ctx, payment.ID); err != nil {
+		return err
+	}
+	if tx == nil {
+		s.Wake()
+	}
+	return nil
+}
+
+// Wake запускает регистрацию чеков, не дожидаясь таймера
+func (s *FiscalService) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// fiscalBackoff — экспоненциальная задержка перед следующей попыткой
+func fiscalBackoff(attempts int) time.Duration {
+	d := fiscalBaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= fiscalMaxBackoff {
+			return fiscalMaxBackoff
+		}
+	}
+	return d
+}
+
+// ProcessPending отправляет чеки, время попытки которых наступило, и возвращает число успешных
+func (s *FiscalService) ProcessPending(ctx context.Context, now time.Time) (int, error) {
+	if s.sender == nil {
+		return 0, nil
+	}
+
+	entries, err := s.fiscalRepo.ClaimDue(ctx, now, fiscalBatchSize, fiscalLease)
+	if err != nil {
+		return 0, err
+	}
+
+	// Результат сохраняется без отмены: если StopWorker отменит ctx посреди отправки,
+	// неотмеченный чек был бы зарегистрирован повторно
+	saveCtx := context.WithoutCancel(ctx)
+	sent := 0
+	for _, entry := range entries {
+		if err := s.send(ctx, entry); err != nil {
+			attempts := entry.Attempts + 1
+			status := "pending"
+			if attempts >= fiscalMaxAttempts {
+				status = "failed"
+			}
+			utils.GetLogger().Warn("Fiscal receipt registration failed",
+				zap.Int("payment_id", entry.PaymentID),
+				zap.Int("attempts", attempts),
+				zap.Error(err),
+			)
+			if err := s.fiscalRepo.MarkFailed(saveCtx, entry.ID, status, err.Error(), now.Add(fiscalBackoff(attempts))); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if err := s.fiscalRepo.MarkSent(saveCtx, entry.ID); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *FiscalService) send(ctx context.Context, entry models.FiscalOutboxEntry) error {
+	payment, err := s.paymentRepo.GetByID(ctx, entry.PaymentID)
+	if err != nil {
+		return fmt.Errorf("load payment: %w", err)
+	}
+
+	receipt := &models.FiscalReceipt{
+		PaymentID:   payment.ID,
+		UserID:      payment.UserID,
+		AmountCents: payment.AmountCents,
+		NetCents:    payment.NetCents,
+		TaxCents:    payment.TaxCents,
+		TaxRateBP:   payment.TaxRateBP,
+		Currency:    payment.Currency,
+		Method:      payment.Method,
+		ProductType: payment.ProductType,
+		Description: payment.Description,
+		CreatedAt:   payment.CreatedAt,
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, fiscalSendTimeout)
+	defer cancel()
+
+	reg, err := s.sender.SendReceipt(sendCtx, receipt)
+	if err != nil {
+		return err
+	}
+	return s.paymentRepo.SetFiscalRegistration(context.WithoutCancel(ctx), payment.ID, reg.FiscalSign, reg.URL)
+}
+
+func (s *FiscalService) List(ctx context.Context, status string) ([]models.FiscalOutboxEntry, error) {
+	return s.fiscalRepo.List(ctx, status)
+}
+
+// Retry сбрасывает задержку и возвращает запись в очередь (в том числе из failed)
+func (s *FiscalService) Retry(ctx context.Context, id int) error {
+	entry, err := s.fiscalRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("outbox entry %d not found", id)
+		}
+		return err
+	}
+	if entry.Status == "sent" {
+		return fmt.Errorf("receipt for payment %d is already registered", entry.PaymentID)
+	}
+	if entry.Status == "sending" {
+		return fmt.Errorf("receipt for payment %d is being registered", entry.PaymentID)
+	}
+	if err := s.fiscalRepo.Retry(ctx, id); err != nil {
+		return err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *FiscalService) StartWorker() {
+	if s.sender == nil {
+		utils.GetLogger().Warn("Fiscal operator not configured - receipts are queued but not sent")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(fiscalPollInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.ProcessPending(ctx, time.Now()); err != nil {
+				utils.GetLogger().Error("Fiscal outbox processing failed", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+func (s *FiscalService) StopWorker() {
+	if s.sender == nil {
+		return
+	}
+	// Отмена прерывает и запросы, которые воркер выполняет в этот момент
+	if s.stop != nil {
+		s.stop()
+	}
+	s.wg.Wait()
+}
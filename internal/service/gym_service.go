@@ -3,28 +3,29 @@ package service
 import (
 	"Gym_StrongCode/internal/models"
 	"Gym_StrongCode/internal/repository"
+	"context"
 )
 
 type GymService struct {
-	gymRepo *repository.GymRepository
+	gymRepo repository.GymStore
 }
 
-func NewGymService(gymRepo *repository.GymRepository) *GymService {
+func NewGymService(gymRepo repository.GymStore) *GymService {
 	return &GymService{gymRepo: gymRepo}
 }
 
-func (s *GymService) Create(name, address string) (*models.Gym, error) {
-	return s.gymRepo.Create(name, address)
+func (s *GymService) Create(ctx context.Context, name, address string) (*models.Gym, error) {
+	return s.gymRepo.Create(ctx, name, address)
 }
 
-func (s *GymService) List() ([]models.Gym, error) {
-	return s.gymRepo.List()
+func (s *GymService) List(ctx context.Context) ([]models.Gym, error) {
+	return s.gymRepo.List(ctx)
 }
 
-func (s *GymService) Update(id int, name, address string) error {
-	return s.gymRepo.Update(id, name, address)
+func (s *GymService) Update(ctx context.Context, id int, name, address string) error {
+	return s.gymRepo.Update(ctx, id, name, address)
 }
 
-func (s *GymService) Delete(id int) error {
-	return s.gymRepo.Delete(id)
+func (s *GymService) Delete(ctx context.Context, id int) error {
+	return s.gymRepo.Delete(ctx, id)
 }
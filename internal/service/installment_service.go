@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	minInstallments             = 2
+	maxInstallments             = 12
+	minInstallmentIntervalDays  = 7
+	installmentPollInterval     = time.Hour
+	defaultInstallmentGraceDays = 3
+)
+
+var ErrInstallmentPlanNotFound = errors.New("installment plan not found")
+
+// PaymentCharger списывает платёж; реализуется PaymentService.
+// Prepare выполняется до транзакции, ChargeTx — внутри неё.
+type PaymentCharger interface {
+	Prepare(ctx context.Context, p *models.Payment) error
+	ChargeTx(ctx context.Context, tx *sql.Tx, p *models.Payment) (*models.Payment, error)
+}
+
+type InstallmentService struct {
+	installmentRepo repository.InstallmentStore
+	membershipRepo  repository.MembershipStore
+	uow             repository.Transactor
+	membershipSvc   *MembershipService
+	charger         PaymentCharger
+	alerts          *AdminAlerts
+	graceDays       int
+	stop            context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewInstallmentService создаёт сервис рассрочки. graceDays — сколько дней после срока
+// платёж может оставаться неоплаченным, прежде чем подписка будет приостановлена.
+// alerts — оповещения администраторам о неудачных списаниях (nil — без них).
+func NewInstallmentService(installmentRepo repository.InstallmentStore, membershipRepo repository.MembershipStore, uow repository.Transactor, membershipSvc *MembershipService, charger PaymentCharger, alerts *AdminAlerts, graceDays int) *InstallmentService {
+	if graceDays < 0 {
+		graceDays = defaultInstallmentGraceDays
+	}
+	return &InstallmentService{
+		installmentRepo: installmentRepo,
+		membershipRepo:  membershipRepo,
+		uow:             uow,
+		membershipSvc:   membershipSvc,
+		charger:         charger,
+		alerts:          alerts,
+		graceDays:       graceDays,
+	}
+}
+
+// splitInstallments делит сумму на count частей; остаток от деления добавляется к первому платежу
+func splitInstallments(totalCents, count int) []int {
+	amounts := make([]int, count)
+	for i := range amounts {
+		amounts[i] = totalCents / count
+	}
+	amounts[0] += totalCents % count
+	return amounts
+}
+
+// CreatePlan оформляет покупку тарифа в рассрочку. Первый платёж списывается сразу,
+// подписка активируется после его успешной оплаты, остальные платежи идут по графику
+// с равным шагом в пределах срока действия тарифа.
+func (s *InstallmentService) CreatePlan(ctx context.Context, userID, membershipID, count int, method, currency string, gymID *int) (*models.InstallmentPlan, error) {
+	if count < minInstallments || count > maxInstallments {
+		return nil, fmt.Errorf("installments count must be between %d and %d", minInstallments, maxInstallments)
+	}
+	if !validPaymentMethod(method) {
+		return nil, fmt.Errorf("invalid payment method: %s", method)
+	}
+
+	membership, err := s.membershipRepo.GetByID(ctx, membershipID)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := membership.DurationDays / count
+	if interval < minInstallmentIntervalDays {
+		return nil, fmt.Errorf("membership is too short for %d installments", count)
+	}
+
+	currency, totalCents, err := s.membershipSvc.PriceIn(ctx, membership, currency)
+	if err != nil {
+		return nil, err
+	}
+	if totalCents < count {
+		return nil, fmt.Errorf("price is too low for %d installments", count)
+	}
+
+	plan := &models.InstallmentPlan{
+		UserID:            userID,
+		MembershipID:      membershipID,
+		TotalCents:        totalCents,
+		Currency:          currency,
+		Method:            method,
+		GymID:             gymID,
+		InstallmentsCount: count,
+		Status:            "active",
+	}
+
+	today := time.Now()
+	for i, amount := range splitInstallments(totalCents, count) {
+		due := today.AddDate(0, 0, i*interval).Format("2006-01-02")
+		plan.Installments = append(plan.Installments, models.Installment{
+			Seq:             i + 1,
+			AmountCents:     amount,
+			DueDate:         due,
+			Status:          "pending",
+			NextAttemptDate: due,
+		})
+	}
+
+	first := &plan.Installments[0]
+	payment := s.installmentPayment(plan, first)
+	if err := s.charger.Prepare(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	// Первый платёж, план и подписка сохраняются одной транзакцией:
+	// если платёж не прошёл, рассрочка не оформляется
+	var planID int
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		installmentRepo := s.installmentRepo.WithTx(tx)
+
+		saved, err := s.charger.ChargeTx(ctx, tx, payment)
+		if err != nil {
+			return err
+		}
+		paidAt := saved.CreatedAt
+		first.Status = "paid"
+		first.Attempts = 1
+		first.PaymentID = &saved.ID
+		first.PaidAt = &paidAt
+
+		planID, err = installmentRepo.CreatePlan(ctx, plan)
+		if err != nil {
+			return err
+		}
+		userMembershipID, err := s.membershipRepo.WithTx(tx).ActivateWithID(ctx, userID, membershipID, membership.DurationDays)
+		if err != nil {
+			return err
+		}
+		return installmentRepo.SetUserMembership(ctx, planID, userMembershipID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.installmentRepo.GetPlan(ctx, planID)
+}
+
+func (s *InstallmentService) installmentPayment(plan *models.InstallmentPlan, installment *models.Installment) *models.Payment {
+	return &models.Payment{
+		UserID:       plan.UserID,
+		AmountCents:  installment.AmountCents,
+		Currency:     plan.Currency,
+		Method:       plan.Method,
+		Description:  fmt.Sprintf("membership installment %d/%d", installment.Seq, plan.InstallmentsCount),
+		ReferenceID:  fmt.Sprintf("membership_%d", plan.MembershipID),
+		ProductType:  models.ProductMembership,
+		GymID:        plan.GymID,
+		MembershipID: &plan.MembershipID,
+	}
+}
+
+// ProcessDue списывает платежи, срок которых наступил, и возвращает число успешных.
+// Неудачное списание повторяется на следующий день; если платёж не оплачен дольше
+// льготного периода, подписка приостанавливается до погашения долга.
+func (s *InstallmentService) ProcessDue(ctx context.Context, now time.Time) (int, error) {
+	today := now.Format("2006-01-02")
+	cutoff := now.AddDate(0, 0, -s.graceDays).Format("2006-01-02")
+
+	due, err := s.installmentRepo.ListDue(ctx, today)
+	if err != nil {
+		return 0, err
+	}
+
+	paid := 0
+	touched := make(map[int]bool)
+	plans := make(map[int]*models.InstallmentPlan)
+	for i := range due {
+		installment := &due[i]
+		plan, ok := plans[installment.PlanID]
+		if !ok {
+			plan, err = s.installmentRepo.GetPlan(ctx, installment.PlanID)
+			if err != nil {
+				return paid, err
+			}
+			plans[plan.ID] = plan
+		}
+		touched[plan.ID] = true
+
+		// Платёж и отметка об оплате взноса сохраняются вместе
+		payment := s.installmentPayment(plan, installment)
+		chargeErr := s.charger.Prepare(ctx, payment)
+		if chargeErr == nil {
+			err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+				chargeErr = nil
+				saved, err := s.charger.ChargeTx(ctx, tx, payment)
+				if err != nil {
+					chargeErr = err
+					return err
+				}
+				return s.installmentRepo.WithTx(tx).MarkPaid(ctx, installment.ID, saved.ID)
+			})
+		}
+		if chargeErr != nil {
+			utils.GetLogger().Warn("Installment charge failed",
+				zap.Int("plan_id", plan.ID),
+				zap.Int("seq", installment.Seq),
+				zap.Error(chargeErr),
+			)
+			s.alerts.RenewalFailed(ctx, plan, installment, chargeErr)
+			if err := s.installmentRepo.MarkAttemptFailed(ctx, installment.ID, chargeErr.Error(), now.AddDate(0, 0, 1).Format("2006-01-02")); err != nil {
+				return paid, err
+			}
+			continue
+		}
+		if err != nil {
+			return paid, err
+		}
+		paid++
+	}
+
+	overdue, err := s.installmentRepo.ListOverduePlanIDs(ctx, cutoff)
+	if err != nil {
+		return paid, err
+	}
+	for _, id := range overdue {
+		touched[id] = true
+	}
+
+	for id := range touched {
+		if err := s.refreshPlan(ctx, id, cutoff); err != nil {
+			return paid, err
+		}
+	}
+	return paid, nil
+}
+
+// refreshPlan пересчитывает статус плана по оставшимся платежам
+func (s *InstallmentService) refreshPlan(ctx context.Context, planID int, cutoff string) error {
+	plan, err := s.installmentRepo.GetPlan(ctx, planID)
+	if err != nil {
+		return err
+	}
+	pending, overdue, err := s.installmentRepo.CountPending(ctx, planID, cutoff)
+	if err != nil {
+		return err
+	}
+
+	var status string
+	switch {
+	case pending == 0:
+		status = "completed"
+	case overdue > 0:
+		status = "suspended"
+	default:
+		status = "active"
+	}
+	if status == plan.Status {
+		return nil
+	}
+
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		if err := s.installmentRepo.WithTx(tx).SetPlanStatus(ctx, planID, status); err != nil {
+			return err
+		}
+		if plan.UserMembershipID == nil {
+			return nil
+		}
+		return s.membershipRepo.WithTx(tx).SetActive(ctx, *plan.UserMembershipID, status != "suspended")
+	})
+	if err != nil {
+		return err
+	}
+
+	utils.GetLogger().Info("Installment plan status changed",
+		zap.Int("plan_id", planID),
+		zap.String("from", plan.Status),
+		zap.String("to", status),
+	)
+	return nil
+}
+
+func (s *InstallmentService) ListForUser(ctx context.Context, userID int) ([]models.InstallmentPlan, error) {
+	return s.installmentRepo.ListPlans(ctx, userID, "")
+}
+
+// GetForUser возвращает план с графиком, только если он принадлежит пользователю
+func (s *InstallmentService) GetForUser(ctx context.Context, id, userID int) (*models.InstallmentPlan, error) {
+	plan, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if plan.UserID != userID {
+		return nil, ErrInstallmentPlanNotFound
+	}
+	return plan, nil
+}
+
+func (s *InstallmentService) ListAll(ctx context.Context, status string) ([]models.InstallmentPlan, error) {
+	return s.installmentRepo.ListPlans(ctx, 0, status)
+}
+
+func (s *InstallmentService) Get(ctx context.Context, id int) (*models.InstallmentPlan, error) {
+	plan, err := s.installmentRepo.GetPlan(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInstallmentPlanNotFound
+		}
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *InstallmentService) StartWorker() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(installmentPollInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.ProcessDue(ctx, time.Now()); err != nil {
+				utils.GetLogger().Error("Installment processing failed", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *InstallmentService) StopWorker() {
+	// Отмена прерывает и запросы, которые воркер выполняет в этот момент
+	if s.stop != nil {
+		s.stop()
+	}
+	s.wg.Wait()
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultKeyRotationInterval = 30 * 24 * time.Hour
+	defaultKeyOverlap          = 24 * time.Hour
+	keyCheckInterval           = 10 * time.Minute
+	// JWKSMaxAge — сколько клиенты могут кэшировать JWKS
+	JWKSMaxAge = 5 * time.Minute
+	// Новый ключ подписывает только после того, как его увидели все инстансы и истекли кэши JWKS
+	keyPublishDelay = keyCheckInterval + JWKSMaxAge
+	// Не чаще этого перечитывать ключи из БД из-за незнакомого kid (защита от подбора kid)
+	keyReloadCooldown = 10 * time.Second
+)
+
+var ErrUnknownSigningKey = errors.New("unknown signing key")
+
+type signingKey struct {
+	id        int
+	kid       string
+	alg       string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	createdAt time.Time
+}
+
+// KeyService подписывает и проверяет JWT асимметричными ключами (RS256 или EdDSA).
+// Ключи хранятся в БД, поэтому общие для всех инстансов. По расписанию создаётся новый ключ,
+// Новый ключ сначала только публикуется в JWKS (next) и начинает подписывать через keyPublishDelay,
+// а предыдущий после этого ещё overlap публикуется в JWKS и принимается при проверке.
+type KeyService struct {
+	repo             repository.SigningKeyStore
+	algorithm        string
+	rotationInterval time.Duration
+	overlap          time.Duration
+
+	rotateMu   sync.Mutex
+	mu         sync.RWMutex
+	current    *signingKey
+	next       *signingKey
+	keys       []*signingKey
+	lastMissAt time.Time
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// NewKeyService создаёт сервис ключей; пустой алгоритм означает RS256, нулевые интервалы — значения по умолчанию.
+// overlap должен быть не меньше времени жизни выдаваемых токенов.
+func NewKeyService(repo repository.SigningKeyStore, algorithm string, rotationInterval, overlap time.Duration) *KeyService {
+	if algorithm == "" {
+		algorithm = utils.JWTAlgorithmRS256
+	}
+	if rotationInterval <= 0 {
+		rotationInterval = defaultKeyRotationInterval
+	}
+	if overlap <= 0 {
+		overlap = defaultKeyOverlap
+	}
+	return &KeyService{
+		repo:             repo,
+		algorithm:        algorithm,
+		rotationInterval: rotationInterval,
+		overlap:          overlap,
+	}
+}
+
+// Init загружает ключи и создаёт первый, если подходящего ещё нет
+func (s *KeyService) Init(ctx context.Context) error {
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+
+	if err := s.Reload(ctx); err != nil {
+		return err
+	}
+	current, next := s.currentKey(), s.nextKey()
+	if current == nil {
+		return s.rotateLocked(ctx)
+	}
+	if current.alg != s.algorithm && (next == nil || next.alg != s.algorithm) {
+		_, err := s.publishLocked(ctx)
+		return err
+	}
+	return nil
+}
+
+// Reload перечитывает опубликованные ключи из БД (их мог создать другой инстанс)
+func (s *KeyService) Reload(ctx context.Context) error {
+	stored, err := s.repo.ListPublished(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var current, newest *signingKey
+	keys := make([]*signingKey, 0, len(stored))
+	for _, k := range stored {
+		private, err := utils.DecodePrivateKey(k.PrivateKey)
+		if err != nil {
+			utils.GetLogger().Error("Failed to decode signing key", zap.String("kid", k.KID), zap.Error(err))
+			continue
+		}
+		method, err := utils.SigningMethod(k.Algorithm)
+		if err != nil {
+			utils.GetLogger().Error("Unsupported signing key algorithm", zap.String("kid", k.KID), zap.Error(err))
+			continue
+		}
+		key := &signingKey{id: k.ID, kid: k.KID, alg: k.Algorithm, method: method, private: private, createdAt: k.CreatedAt}
+		keys = append(keys, key)
+		// Ключи отсортированы от новых к старым. Подписывает самый старый действующий,
+		// а более новый действующий только опубликован и ждёт своей очереди.
+		if k.RotatedAt == nil {
+			if newest == nil {
+				newest = key
+			}
+			current = key
+		}
+	}
+	var next *signingKey
+	if newest != current {
+		next = newest
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.current = current
+	s.next = next
+	s.mu.Unlock()
+	return nil
+}
+
+// Rotate создаёт новый ключ и сразу подписывает им; прежние остаются в JWKS ещё overlap.
+// Клиенты с закэшированным JWKS не примут новые токены до обновления кэша,
+// поэтому плановая ротация идёт через RotateIfDue.
+func (s *KeyService) Rotate(ctx context.Context) error {
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+	return s.rotateLocked(ctx)
+}
+
+func (s *KeyService) rotateLocked(ctx context.Context) error {
+	key, err := s.publishLocked(ctx)
+	if err != nil {
+		return err
+	}
+	return s.activateLocked(ctx, key.ID, key.KID)
+}
+
+// publishLocked создаёт ключ, который попадает в JWKS, но ещё не подписывает
+func (s *KeyService) publishLocked(ctx context.Context) (*models.SigningKey, error) {
+	private, err := utils.GenerateSigningKey(s.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := utils.EncodePrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := randomToken(12)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.repo.Create(ctx, kid, s.algorithm, encoded)
+	if err != nil {
+		return nil, err
+	}
+	utils.GetLogger().Info("JWT signing key published", zap.String("kid", kid), zap.String("algorithm", s.algorithm))
+	return key, s.Reload(ctx)
+}
+
+// activateLocked начинает подписывать ключом и выводит остальные из оборота
+func (s *KeyService) activateLocked(ctx context.Context, id int, kid string) error {
+	// Другие инстансы подписывают прежним ключом до своей следующей перезагрузки
+	now := time.Now()
+	if err := s.repo.RetireOthers(ctx, id, now, now.Add(s.overlap+keyCheckInterval)); err != nil {
+		return err
+	}
+
+	utils.GetLogger().Info("JWT signing key rotated", zap.String("kid", kid))
+	return s.Reload(ctx)
+}
+
+// RotateIfDue публикует новый ключ, если текущему больше rotationInterval, переключает подпись
+// на опубликованный ключ спустя keyPublishDelay и удаляет истёкшие
+func (s *KeyService) RotateIfDue(ctx context.Context, now time.Time) (bool, error) {
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+
+	if _, err := s.repo.DeleteExpired(ctx, now); err != nil {
+		return false, err
+	}
+	if err := s.Reload(ctx); err != nil {
+		return false, err
+	}
+
+	if next := s.nextKey(); next != nil {
+		if now.Sub(next.createdAt) < keyPublishDelay {
+			return false, nil
+		}
+		if err := s.activateLocked(ctx, next.id, next.kid); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	current := s.currentKey()
+	if current == nil {
+		if err := s.rotateLocked(ctx); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if current.alg == s.algorithm && now.Sub(current.createdAt) < s.rotationInterval {
+		return false, nil
+	}
+	if _, err := s.publishLocked(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *KeyService) currentKey() *signingKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+func (s *KeyService) nextKey() *signingKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.next
+}
+
+func (s *KeyService) findKey(kid string) *signingKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.keys {
+		if key.kid == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+// Sign подписывает claims текущим ключом и указывает его kid в заголовке
+func (s *KeyService) Sign(ctx context.Context, claims jwt.MapClaims) (string, error) {
+	current := s.currentKey()
+	if current == nil {
+		if err := s.Init(ctx); err != nil {
+			return "", err
+		}
+		current = s.currentKey()
+	}
+
+	token := jwt.NewWithClaims(current.method, claims)
+	token.Header["kid"] = current.kid
+	return token.SignedString(current.private)
+}
+
+// ParseToken проверяет подпись и срок действия токена. Принимаются только RS256 и EdDSA,
+// причём алгоритм в заголовке должен совпадать с алгоритмом ключа kid.
+func (s *KeyService) ParseToken(ctx context.Context, tokenStr string) (jwt.MapClaims, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		return s.verificationKey(ctx, token)
+	}
+	token, err := jwt.Parse(tokenStr, keyFunc, jwt.WithValidMethods(utils.JWTAlgorithms))
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+func (s *KeyService) verificationKey(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownSigningKey
+	}
+
+	key := s.findKey(kid)
+	if key == nil {
+		s.mu.Lock()
+		stale := time.Since(s.lastMissAt) > keyReloadCooldown
+		if stale {
+			s.lastMissAt = time.Now()
+		}
+		s.mu.Unlock()
+		if stale {
+			if err := s.Reload(ctx); err != nil {
+				return nil, err
+			}
+			key = s.findKey(kid)
+		}
+	}
+	if key == nil {
+		return nil, ErrUnknownSigningKey
+	}
+	if token.Method.Alg() != key.alg {
+		return nil, fmt.Errorf("unexpected signing method %q for key %s", token.Method.Alg(), kid)
+	}
+	return key.private.Public(), nil
+}
+
+// JWKS возвращает открытые части всех опубликованных ключей
+func (s *KeyService) JWKS() models.JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := models.JWKS{Keys: make([]models.JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwk := models.JWK{Use: "sig", Alg: key.alg, Kid: key.kid}
+		switch pub := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// StartWorker периодически проверяет, не пора ли ротировать ключ
+func (s *KeyService) StartWorker() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(keyCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if _, err := s.RotateIfDue(ctx, time.Now()); err != nil {
+				utils.GetLogger().Error("Signing key rotation failed", zap.Error(err))
+			}
+		}
+	}()
+}
+
+func (s *KeyService) StopWorker() {
+	// Отмена прерывает и запросы, которые воркер выполняет в этот момент
+	if s.stop != nil {
+		s.stop()
+	}
+	s.wg.Wait()
+}
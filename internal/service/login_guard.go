@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"Gym_StrongCode/internal/cache"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMaxLoginAttempts      = 5
+	defaultMaxLoginAttemptsPerIP = 20
+	defaultLoginLockout          = time.Minute
+	defaultMaxLoginLockout       = time.Hour
+	// Сколько помнить неудачные попытки после последней из них
+	loginAttemptWindow = 15 * time.Minute
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// LoginLockedError — вход временно заблокирован после серии неудачных попыток
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return "too many failed login attempts"
+}
+
+// LoginGuard считает неудачные входы по аккаунту и по IP. После maxAttempts неудач вход
+// блокируется, и каждая следующая неудача удваивает блокировку вплоть до maxLockout.
+type LoginGuard struct {
+	store         cache.LoginAttemptStore
+	userRepo      repository.UserStore
+	notifier      Notifier
+	maxAttempts   int
+	maxAttemptsIP int
+	lockout       time.Duration
+	maxLockout    time.Duration
+}
+
+// NewLoginGuard создаёт защиту от перебора; нулевые параметры заменяются значениями по умолчанию
+func NewLoginGuard(store cache.LoginAttemptStore, userRepo repository.UserStore, notifier Notifier, maxAttempts, maxAttemptsIP int, lockout, maxLockout time.Duration) *LoginGuard {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxLoginAttempts
+	}
+	if maxAttemptsIP <= 0 {
+		maxAttemptsIP = defaultMaxLoginAttemptsPerIP
+	}
+	if lockout <= 0 {
+		lockout = defaultLoginLockout
+	}
+	if maxLockout < lockout {
+		maxLockout = defaultMaxLoginLockout
+	}
+	return &LoginGuard{
+		store:         store,
+		userRepo:      userRepo,
+		notifier:      notifier,
+		maxAttempts:   maxAttempts,
+		maxAttemptsIP: maxAttemptsIP,
+		lockout:       lockout,
+		maxLockout:    maxLockout,
+	}
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// lockoutFor возвращает длительность блокировки после extra неудач сверх порога
+func (g *LoginGuard) lockoutFor(extra int) time.Duration {
+	d := g.lockout
+	for i := 0; i < extra && d < g.maxLockout; i++ {
+		d *= 2
+	}
+	if d > g.maxLockout {
+		d = g.maxLockout
+	}
+	return d
+}
+
+// Check возвращает *LoginLockedError, если аккаунт или IP сейчас заблокированы.
+// При недоступности хранилища вход не блокируется.
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, key := range []string{accountKey(email), ipKey(ip)} {
+		attempts, err := g.store.GetAttempts(ctx, key)
+		if err != nil {
+			utils.GetLogger().Error("Failed to read login attempts", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		if attempts != nil && attempts.LockedUntil.After(now) {
+			if wait := attempts.LockedUntil.Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Failure учитывает неудачную попытку входа
+func (g *LoginGuard) Failure(ctx context.Context, email, ip string) error {
+	locked, err := g.registerFailure(ctx, accountKey(email), g.maxAttempts)
+	if err != nil {
+		return err
+	}
+	if locked > 0 {
+		utils.GetLogger().Warn("Account login locked", zap.String("email", email), zap.Duration("lockout", locked))
+		g.notifyLocked(ctx, email, locked)
+	}
+
+	locked, err = g.registerFailure(ctx, ipKey(ip), g.maxAttemptsIP)
+	if err != nil {
+		return err
+	}
+	if locked > 0 {
+		utils.GetLogger().Warn("IP login locked", zap.String("ip", ip), zap.Duration("lockout", locked))
+	}
+	return nil
+}
+
+// registerFailure атомарно увеличивает счётчик и возвращает длительность блокировки,
+// если эта неудача привела к первой блокировке серии
+func (g *LoginGuard) registerFailure(ctx context.Context, key string, maxAttempts int) (time.Duration, error) {
+	failures, err := g.store.Increment(ctx, key, loginAttemptWindow)
+	if err != nil {
+		return 0, err
+	}
+	if failures < maxAttempts {
+		return 0, nil
+	}
+
+	lockout := g.lockoutFor(failures - maxAttempts)
+	if err := g.store.Lock(ctx, key, time.Now().Add(lockout), lockout+loginAttemptWindow); err != nil {
+		return 0, err
+	}
+	if failures == maxAttempts {
+		return lockout, nil
+	}
+	return 0, nil
+}
+
+func (g *LoginGuard) notifyLocked(ctx context.Context, email string, lockout time.Duration) {
+	user, err := g.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return
+	}
+	g.notifier.NotifyUser(ctx, user, notification.LoginLocked{Name: user.Name, LockedFor: lockout})
+}
+
+// Success сбрасывает счётчик аккаунта после успешного входа; счётчик IP затухает сам
+func (g *LoginGuard) Success(ctx context.Context, email string) error {
+	return g.store.ResetAttempts(ctx, accountKey(email))
+}
+
+// Unlock снимает блокировку аккаунта (для администратора)
+func (g *LoginGuard) Unlock(ctx context.Context, userID int) error {
+	user, err := g.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	utils.GetLogger().Info("Account login unlocked", zap.Int("user_id", userID))
+	return g.store.ResetAttempts(ctx, accountKey(user.Email))
+}
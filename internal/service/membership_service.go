@@ -1,60 +1,131 @@
 package service
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
 	"Gym_StrongCode/internal/repository"
 )
 
 type MembershipService struct {
-	membershipRepo  *repository.MembershipRepository
-	paymentRepo     *repository.PaymentRepository
-	db              *sql.DB
+	membershipRepo  repository.MembershipStore
+	paymentRepo     repository.PaymentStore
+	uow             repository.Transactor
 	notificationSvc *NotificationService
+	currencySvc     *CurrencyService
+	taxSvc          *TaxService
+	fiscalSvc       *FiscalService
+	alerts          *AdminAlerts
 }
 
-func NewMembershipService(membershipRepo *repository.MembershipRepository, paymentRepo *repository.PaymentRepository, db *sql.DB, notificationSvc *NotificationService) *MembershipService {
+func NewMembershipService(membershipRepo repository.MembershipStore, paymentRepo repository.PaymentStore, uow repository.Transactor, notificationSvc *NotificationService, currencySvc *CurrencyService, taxSvc *TaxService, fiscalSvc *FiscalService, alerts *AdminAlerts) *MembershipService {
 	return &MembershipService{
 		membershipRepo:  membershipRepo,
 		paymentRepo:     paymentRepo,
-		db:              db,
+		uow:             uow,
 		notificationSvc: notificationSvc,
+		currencySvc:     currencySvc,
+		taxSvc:          taxSvc,
+		fiscalSvc:       fiscalSvc,
+		alerts:          alerts,
 	}
 }
 
-func (s *MembershipService) List() ([]models.Membership, error) {
-	return s.membershipRepo.GetAll()
+func (s *MembershipService) List(ctx context.Context) ([]models.Membership, error) {
+	return s.membershipRepo.GetAll(ctx)
 }
 
-func (s *MembershipService) Buy(userID, membershipID int, method string) (map[string]interface{}, error) {
-	membership, err := s.membershipRepo.GetByID(membershipID)
+// PriceIn возвращает цену тарифа в указанной валюте.
+// Для базовой валюты берётся memberships.price_cents, для остальных — прайс-лист тарифа.
+func (s *MembershipService) PriceIn(ctx context.Context, membership *models.Membership, currency string) (string, int, error) {
+	code, err := s.currencySvc.Resolve(ctx, currency)
 	if err != nil {
-		return nil, err
+		return "", 0, err
+	}
+	if code == s.currencySvc.BaseCurrency() {
+		return code, membership.PriceCents, nil
+	}
+
+	price, err := s.membershipRepo.GetPrice(ctx, membership.ID, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", 0, fmt.Errorf("membership %d has no price in %s", membership.ID, code)
+		}
+		return "", 0, err
+	}
+	return code, price.PriceCents, nil
+}
+
+// Buy покупает тариф и отправляет покупателю чек с тарифом, сроком и суммой;
+// gymID — зал продажи (nil, если не указан), от него зависит ставка налога
+func (s *MembershipService) Buy(ctx context.Context, user *models.User, membershipID int, method, currency string, gymID *int) (map[string]interface{}, error) {
+	if !validPaymentMethod(method) {
+		return nil, fmt.Errorf("invalid payment method: %s", method)
 	}
 
-	tx, err := s.db.Begin()
+	membership, err := s.membershipRepo.GetByID(ctx, membershipID)
 	if err != nil {
 		return nil, err
 	}
 
-	payment, err := s.paymentRepo.CreateForMembership(userID, membership.PriceCents, "KZT", method, "membership purchase", fmt.Sprintf("membership_%d", membershipID))
+	currency, amountCents, err := s.PriceIn(ctx, membership, currency)
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
-	// Активируем подписку
-	if err := s.membershipRepo.Activate(userID, membershipID, membership.DurationDays); err != nil {
-		tx.Rollback()
+	payment := &models.Payment{
+		UserID:       user.ID,
+		AmountCents:  amountCents,
+		Currency:     currency,
+		Method:       method,
+		Status:       "completed",
+		Description:  "membership purchase",
+		ReferenceID:  fmt.Sprintf("membership_%d", membershipID),
+		ProductType:  models.ProductMembership,
+		GymID:        gymID,
+		MembershipID: &membershipID,
+	}
+	if err := s.taxSvc.Apply(ctx, payment); err != nil {
 		return nil, err
 	}
 
-	tx.Commit()
+	// Платёж, подписка, чек для ОФД и письмо покупателю сохраняются вместе или не сохраняются вовсе
+	var saved *models.Payment
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		var err error
+		saved, err = s.paymentRepo.WithTx(tx).Create(ctx, payment)
+		if err != nil {
+			return err
+		}
+		activated, err := s.membershipRepo.WithTx(tx).Activate(ctx, user.ID, membershipID, membership.DurationDays)
+		if err != nil {
+			return err
+		}
+		if err := s.fiscalSvc.Enqueue(ctx, tx, saved); err != nil {
+			return err
+		}
+		s.alerts.PaymentCompleted(ctx, tx, saved)
 
-	// Уведомление
-	s.notificationSvc.SendNotification("", "Подписка активирована", "Ваша подписка успешно куплена!")
+		return s.notificationSvc.Notify(ctx, tx, recipientOf(user), notification.MembershipActivated{
+			Name:         user.Name,
+			PlanName:     membership.Name,
+			DurationDays: membership.DurationDays,
+			ValidFrom:    activated.StartDate,
+			ValidUntil:   activated.EndDate,
+			Amount:       formatMoney(saved.AmountCents, saved.Currency),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	payment = saved
+
+	s.fiscalSvc.Wake()
+	s.notificationSvc.Wake()
 
 	return map[string]interface{}{
 		"payment":    payment,
@@ -63,14 +134,45 @@ func (s *MembershipService) Buy(userID, membershipID int, method string) (map[st
 	}, nil
 }
 
-func (s *MembershipService) Create(name string, durationDays, priceCents int) (*models.Membership, error) {
-	return s.membershipRepo.Create(name, durationDays, priceCents)
+func (s *MembershipService) Create(ctx context.Context, name string, durationDays, priceCents int) (*models.Membership, error) {
+	return s.membershipRepo.Create(ctx, name, durationDays, priceCents)
+}
+
+func (s *MembershipService) Update(ctx context.Context, id int, name string, durationDays, priceCents int) error {
+	return s.membershipRepo.Update(ctx, id, name, durationDays, priceCents)
 }
 
-func (s *MembershipService) Update(id int, name string, durationDays, priceCents int) error {
-	return s.membershipRepo.Update(id, name, durationDays, priceCents)
+func (s *MembershipService) Delete(ctx context.Context, id int) error {
+	return s.membershipRepo.Delete(ctx, id)
+}
+
+func (s *MembershipService) ListPrices(ctx context.Context, membershipID int) ([]models.MembershipPrice, error) {
+	if _, err := s.membershipRepo.GetByID(ctx, membershipID); err != nil {
+		return nil, err
+	}
+	return s.membershipRepo.ListPrices(ctx, membershipID)
+}
+
+func (s *MembershipService) SetPrice(ctx context.Context, membershipID int, currency string, priceCents int) (*models.MembershipPrice, error) {
+	if priceCents <= 0 {
+		return nil, fmt.Errorf("price must be positive")
+	}
+	if _, err := s.membershipRepo.GetByID(ctx, membershipID); err != nil {
+		return nil, err
+	}
+
+	// Цену можно задать только в валюте с курсом, иначе её не свести в отчётах
+	code, err := s.currencySvc.Resolve(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+	if code == s.currencySvc.BaseCurrency() {
+		return nil, fmt.Errorf("base currency price is set on the membership itself")
+	}
+
+	return s.membershipRepo.SetPrice(ctx, membershipID, code, priceCents)
 }
 
-func (s *MembershipService) Delete(id int) error {
-	return s.membershipRepo.Delete(id)
+func (s *MembershipService) DeletePrice(ctx context.Context, membershipID int, currency string) error {
+	return s.membershipRepo.DeletePrice(ctx, membershipID, NormalizeCurrency(currency))
 }
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidNotificationPreference = errors.New("invalid notification preferences")
+	ErrTransactionalOptOut           = errors.New("transactional notifications cannot be disabled")
+)
+
+// applyPreferences убирает каналы, от которых пользователь отписался в этой категории,
+// и переносит отправку на конец его тихих часов (нулевое время — отправлять сразу).
+// Сообщение, которое после переноса пришло бы позже своего Deadline, не отправляется.
+func (ns *NotificationService) applyPreferences(ctx context.Context, tx *sql.Tx, userID int, msg notification.Message, deliveries []notification.Delivery) ([]notification.Delivery, time.Time, error) {
+	category := msg.Category()
+	prefs, err := ns.repo.WithTx(tx).ListPreferences(ctx, userID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	disabled := map[string]bool{}
+	for _, p := range prefs {
+		if p.Category == category && !p.Enabled {
+			disabled[p.Channel] = true
+		}
+	}
+	var allowed []notification.Delivery
+	for _, d := range deliveries {
+		if !disabled[d.Channel] {
+			allowed = append(allowed, d)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, time.Time{}, nil
+	}
+
+	settings, err := ns.repo.WithTx(tx).GetSettings(ctx, userID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	quiet, err := notification.NewQuietHours(settings.QuietHoursStart, settings.QuietHoursEnd, timezoneOrDefault(settings.Timezone))
+	if err != nil {
+		// Настройки проверяются при сохранении; испорченные не должны блокировать отправку
+		utils.GetLogger().Warn("Invalid quiet hours, ignoring", zap.Int("user_id", userID), zap.Error(err))
+		return allowed, time.Time{}, nil
+	}
+
+	now := ns.now()
+	if sendAt := quiet.Defer(now); sendAt.After(now) {
+		if expiring, ok := msg.(notification.Expiring); ok {
+			if deadline := expiring.Deadline(); !deadline.IsZero() && !sendAt.Before(deadline) {
+				utils.GetLogger().Debug("Notification dropped: quiet hours end after its deadline",
+					zap.String("template", msg.TemplateID()), zap.Int("user_id", userID))
+				return nil, time.Time{}, nil
+			}
+		}
+		return allowed, sendAt, nil
+	}
+	return allowed, time.Time{}, nil
+}
+
+func timezoneOrDefault(tz string) string {
+	if tz == "" {
+		return notification.DefaultTimezone
+	}
+	return tz
+}
+
+// formatSendAt переводит время отправки в формат колонки; нулевое — "" (сразу)
+func formatSendAt(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// Preferences возвращает настройки уведомлений пользователя: все отключаемые категории во всех каналах
+func (ns *NotificationService) Preferences(ctx context.Context, userID int) (*models.NotificationSettings, error) {
+	settings, err := ns.repo.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.Timezone = timezoneOrDefault(settings.Timezone)
+
+	stored, err := ns.repo.ListPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := map[[2]string]bool{}
+	for _, p := range stored {
+		enabled[[2]string{p.Category, p.Channel}] = p.Enabled
+	}
+
+	settings.Preferences = []models.NotificationPreference{}
+	for _, category := range notification.Categories {
+		if category == notification.CategoryTransactional {
+			continue
+		}
+		for _, channel := range notification.Channels {
+			on, ok := enabled[[2]string{category, channel}]
+			settings.Preferences = append(settings.Preferences, models.NotificationPreference{
+				Category: category,
+				Channel:  channel,
+				Enabled:  on || !ok,
+			})
+		}
+	}
+	return settings, nil
+}
+
+// UpdatePreferences сохраняет часовой пояс, тихие часы и перечисленные подписки;
+// не перечисленные подписки не меняются
+func (ns *NotificationService) UpdatePreferences(ctx context.Context, userID int, update *models.NotificationSettings) error {
+	timezone := timezoneOrDefault(update.Timezone)
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidNotificationPreference, update.Timezone)
+	}
+	if (update.QuietHoursStart == "") != (update.QuietHoursEnd == "") {
+		return fmt.Errorf("%w: quiet_hours_start and quiet_hours_end must be set together", ErrInvalidNotificationPreference)
+	}
+	if _, err := notification.NewQuietHours(update.QuietHoursStart, update.QuietHoursEnd, timezone); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidNotificationPreference, err)
+	}
+	for _, p := range update.Preferences {
+		if err := validatePreference(p); err != nil {
+			return err
+		}
+	}
+
+	// Настройки и подписки сохраняются вместе
+	return ns.uow.Do(ctx, func(tx *sql.Tx) error {
+		repo := ns.repo.WithTx(tx)
+		if err := repo.SaveSettings(ctx, userID, timezone, update.QuietHoursStart, update.QuietHoursEnd); err != nil {
+			return err
+		}
+		return repo.SetPreferences(ctx, userID, update.Preferences)
+	})
+}
+
+func validatePreference(p models.NotificationPreference) error {
+	if p.Category == notification.CategoryTransactional {
+		return ErrTransactionalOptOut
+	}
+	if !contains(notification.Categories, p.Category) {
+		return fmt.Errorf("%w: unknown category %q", ErrInvalidNotificationPreference, p.Category)
+	}
+	if !contains(notification.Channels, p.Channel) {
+		return fmt.Errorf("%w: unknown channel %q", ErrInvalidNotificationPreference, p.Channel)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseUnsubscribe проверяет ссылку отписки и возвращает пользователя и категорию
+func (ns *NotificationService) parseUnsubscribe(token string) (int, string, error) {
+	if ns.links == nil {
+		return 0, "", notification.ErrInvalidUnsubscribeLink
+	}
+	userID, category, err := ns.links.Parse(token)
+	if err != nil {
+		return 0, "", err
+	}
+	if category == notification.CategoryTransactional || !contains(notification.Categories, category) {
+		return 0, "", notification.ErrInvalidUnsubscribeLink
+	}
+	return userID, category, nil
+}
+
+// UnsubscribeCategory проверяет ссылку отписки и возвращает её категорию, ничего не меняя
+func (ns *NotificationService) UnsubscribeCategory(token string) (string, error) {
+	_, category, err := ns.parseUnsubscribe(token)
+	return category, err
+}
+
+// Unsubscribe по ссылке из письма отключает категорию во всех каналах
+func (ns *NotificationService) Unsubscribe(ctx context.Context, token string) (string, error) {
+	userID, category, err := ns.parseUnsubscribe(token)
+	if err != nil {
+		return "", err
+	}
+
+	prefs := make([]models.NotificationPreference, 0, len(notification.Channels))
+	for _, channel := range notification.Channels {
+		prefs = append(prefs, models.NotificationPreference{Category: category, Channel: channel, Enabled: false})
+	}
+	if err := ns.repo.SetPreferences(ctx, userID, prefs); err != nil {
+		return "", err
+	}
+	utils.GetLogger().Info("User unsubscribed", zap.Int("user_id", userID), zap.String("category", category))
+	return category, nil
+}
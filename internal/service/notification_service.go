@@ -1,98 +1,362 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"net/smtp"
 	"sync"
+	"time"
 
-	"Gym_StrongCode/config"
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/internal/utils"
 
-	"github.com/jordan-wright/email"
 	"go.uber.org/zap"
 )
 
+const (
+	notificationBatchSize    = 50
+	notificationPollInterval = 15 * time.Second
+	notificationSendTimeout  = 30 * time.Second
+	// Сколько письмо числится за воркером; после этого его может забрать другой
+	notificationLease          = 2 * time.Minute
+	notificationMaxAttempts    = 8
+	notificationBaseBackoff    = time.Minute
+	notificationMaxBackoff     = 6 * time.Hour
+	defaultNotificationWorkers = 4
+)
+
+var (
+	ErrNoRecipient           = errors.New("notification recipient is empty")
+	ErrNotificationNotFound  = errors.New("notification not found")
+	ErrNotificationDelivered = errors.New("notification is already being sent or delivered")
+)
+
+// NotificationService — очередь уведомлений в БД (notification_outbox) и пул воркеров,
+// которые отправляют сообщения с повторами и переводят безнадёжные в dead.
+// Сообщения собираются из шаблонов на языке получателя в момент постановки в очередь;
+// канал выбирается по маршруту шаблона, при отказе сообщение уходит в следующий канал маршрута.
+// Для нетранзакционных сообщений учитываются подписки и тихие часы пользователя.
 type NotificationService struct {
-	cfg       *config.Config
-	emailPool chan *email.Email
+	repo      repository.NotificationStore
+	uow       repository.Transactor
+	router    *notification.Router
+	links     *notification.UnsubscribeLinks
+	templates *notification.Templates
+	now       func() time.Time
+	workers   int
+	wake      chan struct{}
+	stop      context.CancelFunc
 	wg        sync.WaitGroup
-	stop      chan struct{}
 }
 
-type Notification struct {
-	To      string
-	Subject string
-	Body    string
+// NewNotificationService создаёт сервис; links — ссылки отписки (nil — письма без них),
+// workers — сколько сообщений отправляется параллельно (0 — по умолчанию)
+func NewNotificationService(repo repository.NotificationStore, uow repository.Transactor, router *notification.Router, links *notification.UnsubscribeLinks, workers int) *NotificationService {
+	if workers <= 0 {
+		workers = defaultNotificationWorkers
+	}
+	return &NotificationService{
+		repo:      repo,
+		uow:       uow,
+		router:    router,
+		links:     links,
+		templates: notification.DefaultTemplates(),
+		now:       time.Now,
+		workers:   workers,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Notify собирает сообщение по шаблону msg на языке получателя и ставит его в очередь;
+// с tx — в той же транзакции, что и бизнес-изменение.
+// Воркер будится только после фиксации транзакции вызывающим (или по таймеру).
+func (ns *NotificationService) Notify(ctx context.Context, tx *sql.Tx, rcpt notification.Recipient, msg notification.Message) error {
+	_, err := ns.Enqueue(ctx, tx, rcpt, msg)
+	return err
 }
 
-func NewNotificationService(cfg *config.Config) *NotificationService {
-	if cfg.SMTPHost == "" || cfg.FromEmail == "" {
-		utils.GetLogger().Warn("SMTP not configured - notifications will be logged only")
-		return &NotificationService{cfg: cfg} // Добавили cfg для использования в Send
+// Enqueue — как Notify, но возвращает ID сообщения в очереди; 0 — сообщение не поставлено,
+// потому что получатель отписался от категории во всех его каналах
+func (ns *NotificationService) Enqueue(ctx context.Context, tx *sql.Tx, rcpt notification.Recipient, msg notification.Message) (int, error) {
+	deliveries := ns.router.Plan(msg.TemplateID(), rcpt)
+	if len(deliveries) == 0 {
+		return 0, ErrNoRecipient
+	}
+
+	var sendAt time.Time
+	var unsubscribeURL string
+	if msg.Category() != notification.CategoryTransactional && rcpt.UserID != 0 {
+		var err error
+		deliveries, sendAt, err = ns.applyPreferences(ctx, tx, rcpt.UserID, msg, deliveries)
+		if err != nil {
+			return 0, err
+		}
+		if len(deliveries) == 0 {
+			utils.GetLogger().Debug("Notification suppressed by user preferences",
+				zap.String("template", msg.TemplateID()), zap.Int("user_id", rcpt.UserID))
+			return 0, nil
+		}
+		if ns.links != nil {
+			unsubscribeURL = ns.links.URL(rcpt.UserID, msg.Category())
+		}
 	}
 
-	ns := &NotificationService{
-		cfg:       cfg,
-		emailPool: make(chan *email.Email, 100),
-		stop:      make(chan struct{}),
+	rendered, err := ns.templates.Render(rcpt.Locale, msg, unsubscribeURL)
+	if err != nil {
+		return 0, err
+	}
+	fallback, err := encodeFallback(deliveries[1:])
+	if err != nil {
+		return 0, err
 	}
 
-	return ns
+	id, err := ns.repo.WithTx(tx).Enqueue(ctx, &models.NotificationOutboxEntry{
+		Channel:        deliveries[0].Channel,
+		Recipient:      deliveries[0].To,
+		Fallback:       fallback,
+		Template:       rendered.Template,
+		Locale:         rendered.Locale,
+		Subject:        rendered.Subject,
+		TextBody:       rendered.Text,
+		Body:           rendered.HTML,
+		UnsubscribeURL: unsubscribeURL,
+		NextAttemptAt:  formatSendAt(sendAt),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if tx == nil {
+		ns.Wake()
+	}
+	return id, nil
 }
 
-func (ns *NotificationService) SendNotification(to, subject, body string) {
-	if ns.cfg.SMTPHost == "" {
-		utils.GetLogger().Info("Notification logged (no SMTP):",
-			zap.String("to", to),
-			zap.String("subject", subject),
-			zap.String("body", body),
-		)
-		return
+// NotifyUser ставит сообщение пользователю в очередь вне транзакции; ошибка только логируется
+func (ns *NotificationService) NotifyUser(ctx context.Context, user *models.User, msg notification.Message) {
+	if err := ns.Notify(ctx, nil, recipientOf(user), msg); err != nil {
+		utils.GetLogger().Error("Failed to enqueue notification",
+			zap.String("template", msg.TemplateID()), zap.Int("user_id", user.ID), zap.Error(err))
 	}
+}
 
-	e := email.NewEmail()
-	e.From = ns.cfg.FromEmail
-	e.To = []string{to}
-	e.Subject = subject
-	e.HTML = []byte(body)
+// recipientOf собирает адреса пользователя во всех каналах
+func recipientOf(user *models.User) notification.Recipient {
+	return notification.Recipient{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Phone:     user.Phone,
+		PushToken: user.PushToken,
+		Locale:    user.Locale,
+	}
+}
+
+func encodeFallback(deliveries []notification.Delivery) (string, error) {
+	if len(deliveries) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(deliveries)
+	return string(b), err
+}
+
+// Templates возвращает шаблоны писем с языками перевода
+func (ns *NotificationService) Templates() []notification.TemplateInfo {
+	return ns.templates.List()
+}
+
+// PreviewTemplate рендерит шаблон на примерных данных, дополненных data (JSON)
+func (ns *NotificationService) PreviewTemplate(id, locale string, data []byte) (*notification.Rendered, error) {
+	return ns.templates.Preview(id, locale, data)
+}
 
+// Wake запускает обработку очереди, не дожидаясь таймера
+func (ns *NotificationService) Wake() {
 	select {
-	case ns.emailPool <- e:
+	case ns.wake <- struct{}{}:
 	default:
-		utils.GetLogger().Warn("Email queue full, dropping notification")
 	}
 }
 
-func (ns *NotificationService) StartWorker() {
-	if ns.cfg.SMTPHost == "" {
-		return
+// notificationBackoff — экспоненциальная задержка перед следующей попыткой
+func notificationBackoff(attempts int) time.Duration {
+	d := notificationBaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= notificationMaxBackoff {
+			return notificationMaxBackoff
+		}
+	}
+	return d
+}
+
+// ProcessPending отправляет письма, время которых наступило, силами пула воркеров
+// и возвращает число отправленных
+func (ns *NotificationService) ProcessPending(ctx context.Context, now time.Time) (int, error) {
+	entries, err := ns.repo.ClaimDue(ctx, now, notificationBatchSize, notificationLease)
+	if err != nil {
+		return 0, err
+	}
+
+	jobs := make(chan models.NotificationOutboxEntry)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sent := 0
+	var firstErr error
+
+	for i := 0; i < ns.workers && i < len(entries); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range jobs {
+				ok, err := ns.deliver(ctx, entry, now)
+				mu.Lock()
+				if ok {
+					sent++
+				}
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, entry := range entries {
+		jobs <- entry
+	}
+	close(jobs)
+	wg.Wait()
+
+	return sent, firstErr
+}
+
+// deliver отправляет одно сообщение и сохраняет результат; ошибка — только ошибка записи в БД
+func (ns *NotificationService) deliver(ctx context.Context, entry models.NotificationOutboxEntry, now time.Time) (bool, error) {
+	// Таймаут только на отправку. Результат сохраняется без отмены: если StopWorker
+	// отменит ctx посреди отправки, неотмеченное сообщение ушло бы повторно
+	sendCtx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+	defer cancel()
+	saveCtx := context.WithoutCancel(ctx)
+
+	err := ns.router.Send(sendCtx, entry.Channel, notification.Envelope{
+		To:       entry.Recipient,
+		Template: entry.Template,
+		Locale:   entry.Locale,
+		Subject:  entry.Subject,
+		Text:     entry.TextBody,
+		HTML:     entry.Body,
+
+		UnsubscribeURL: entry.UnsubscribeURL,
+	})
+	if err != nil && entry.Fallback != "" {
+		return false, ns.fallback(saveCtx, entry, err, now)
+	}
+	if err != nil {
+		attempts := entry.Attempts + 1
+		status := "pending"
+		if attempts >= notificationMaxAttempts {
+			status = "dead"
+		}
+		utils.GetLogger().Warn("Notification delivery failed",
+			zap.Int("notification_id", entry.ID),
+			zap.String("channel", entry.Channel),
+			zap.Int("attempts", attempts),
+			zap.String("status", status),
+			zap.Error(err),
+		)
+		return false, ns.repo.MarkFailed(saveCtx, entry.ID, status, err.Error(), now.Add(notificationBackoff(attempts)))
 	}
 
+	if err := ns.repo.MarkSent(saveCtx, entry.ID); err != nil {
+		return false, err
+	}
+	utils.GetLogger().Info("Notification sent",
+		zap.Int("notification_id", entry.ID),
+		zap.String("channel", entry.Channel),
+		zap.String("template", entry.Template),
+	)
+	return true, nil
+}
+
+// fallback сразу переключает сообщение на следующий канал маршрута; ctx не должен отменяться остановкой воркера
+func (ns *NotificationService) fallback(ctx context.Context, entry models.NotificationOutboxEntry, sendErr error, now time.Time) error {
+	var deliveries []notification.Delivery
+	if err := json.Unmarshal([]byte(entry.Fallback), &deliveries); err != nil || len(deliveries) == 0 {
+		return ns.repo.MarkFailed(ctx, entry.ID, "dead", fmt.Sprintf("invalid fallback: %v", err), now)
+	}
+	rest, err := encodeFallback(deliveries[1:])
+	if err != nil {
+		return err
+	}
+
+	utils.GetLogger().Warn("Notification delivery failed, falling back to another channel",
+		zap.Int("notification_id", entry.ID),
+		zap.String("channel", entry.Channel),
+		zap.String("fallback_channel", deliveries[0].Channel),
+		zap.Error(sendErr),
+	)
+	lastError := fmt.Sprintf("%s: %v", entry.Channel, sendErr)
+	if err := ns.repo.Fallback(ctx, entry.ID, deliveries[0].Channel, deliveries[0].To, rest, lastError, now); err != nil {
+		return err
+	}
+	ns.Wake()
+	return nil
+}
+
+func (ns *NotificationService) List(ctx context.Context, status string) ([]models.NotificationOutboxEntry, error) {
+	return ns.repo.List(ctx, status)
+}
+
+// Resend возвращает письмо из dead (или ожидающее повтора) в очередь для немедленной отправки
+func (ns *NotificationService) Resend(ctx context.Context, id int) error {
+	if _, err := ns.repo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %d", ErrNotificationNotFound, id)
+		}
+		return err
+	}
+	ok, err := ns.repo.Resend(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotificationDelivered
+	}
+	ns.Wake()
+	return nil
+}
+
+func (ns *NotificationService) StartWorker() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ns.stop = cancel
+
 	ns.wg.Add(1)
 	go func() {
 		defer ns.wg.Done()
-		auth := smtp.PlainAuth("", ns.cfg.SMTPUser, ns.cfg.SMTPPass, ns.cfg.SMTPHost)
+		ticker := time.NewTicker(notificationPollInterval)
+		defer ticker.Stop()
 
 		for {
+			if _, err := ns.ProcessPending(ctx, time.Now()); err != nil {
+				utils.GetLogger().Error("Notification outbox processing failed", zap.Error(err))
+			}
+
 			select {
-			case <-ns.stop:
+			case <-ctx.Done():
 				return
-			case e := <-ns.emailPool:
-				addr := fmt.Sprintf("%s:%s", ns.cfg.SMTPHost, ns.cfg.SMTPPort)
-				if err := e.Send(addr, auth); err != nil {
-					utils.GetLogger().Error("Failed to send email", zap.Error(err))
-				} else {
-					utils.GetLogger().Info("Email sent", zap.String("to", e.To[0]), zap.String("subject", e.Subject))
-				}
+			case <-ticker.C:
+			case <-ns.wake:
 			}
 		}
 	}()
 }
 
 func (ns *NotificationService) StopWorker() {
-	if ns.cfg.SMTPHost == "" {
-		return
+	// Отмена прерывает и запросы, которые воркер выполняет в этот момент
+	if ns.stop != nil {
+		ns.stop()
 	}
-	close(ns.stop)
 	ns.wg.Wait()
 }
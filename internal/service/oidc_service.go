@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"Gym_StrongCode/config"
+	"Gym_StrongCode/internal/cache"
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// Сколько ждём возврата пользователя от провайдера
+	oidcStateTTL       = 10 * time.Minute
+	oidcHTTPTimeout    = 10 * time.Second
+	oidcKeysRefreshMin = time.Minute
+	oidcClockSkew      = time.Minute
+)
+
+var (
+	ErrUnknownOIDCProvider    = errors.New("unknown identity provider")
+	ErrInvalidOIDCState       = errors.New("invalid or expired oidc state")
+	ErrOIDCEmailNotVerified   = errors.New("identity provider did not return a verified email")
+	ErrOIDCAccountNotVerified = errors.New("an account with this email exists but its email is not verified; sign in with password and verify it first")
+	ErrInvalidIDToken         = errors.New("invalid id token")
+	ErrOIDCCodeExchange       = errors.New("failed to exchange authorization code")
+)
+
+// Алгоритмы подписи id_token, которые используют распространённые провайдеры
+var oidcAlgorithms = []string{"RS256", "ES256", "EdDSA"}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcProvider struct {
+	cfg config.OIDCProvider
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// OIDCService — вход через внешних провайдеров OpenID Connect (authorization code + PKCE).
+// Аккаунт провайдера привязывается к пользователю по подтверждённому email или создаёт нового.
+type OIDCService struct {
+	providers    map[string]*oidcProvider
+	names        []string
+	states       cache.OIDCStateStore
+	userRepo     repository.UserStore
+	identityRepo repository.IdentityStore
+	uow          repository.Transactor
+	httpClient   *http.Client
+}
+
+func NewOIDCService(providers []config.OIDCProvider, states cache.OIDCStateStore, userRepo repository.UserStore, identityRepo repository.IdentityStore, uow repository.Transactor) *OIDCService {
+	s := &OIDCService{
+		providers:    make(map[string]*oidcProvider, len(providers)),
+		states:       states,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		uow:          uow,
+		httpClient:   &http.Client{Timeout: oidcHTTPTimeout},
+	}
+	for _, p := range providers {
+		s.providers[p.Name] = &oidcProvider{cfg: p}
+		s.names = append(s.names, p.Name)
+	}
+	return s
+}
+
+// Providers возвращает имена настроенных провайдеров
+func (s *OIDCService) Providers() []string {
+	return s.names
+}
+
+// AuthURL готовит вход: сохраняет state, nonce и code_verifier и возвращает адрес страницы провайдера
+func (s *OIDCService) AuthURL(providerName string) (string, error) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		return "", ErrUnknownOIDCProvider
+	}
+	discovery, err := s.discover(p)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := s.states.SaveOIDCState(state, &cache.OIDCState{Provider: providerName, Nonce: nonce, CodeVerifier: verifier}, oidcStateTTL); err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if p.cfg.ResponseMode != "" {
+		params.Set("response_mode", p.cfg.ResponseMode)
+	}
+
+	sep := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return discovery.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Callback обменивает code на id_token, проверяет его и возвращает привязанного пользователя
+func (s *OIDCService) Callback(ctx context.Context, providerName, code, state string) (*models.User, error) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownOIDCProvider
+	}
+	saved, err := s.states.TakeOIDCState(state)
+	if err != nil {
+		return nil, err
+	}
+	if saved == nil || saved.Provider != providerName || code == "" {
+		return nil, ErrInvalidOIDCState
+	}
+
+	discovery, err := s.discover(p)
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, err := s.exchangeCode(p, discovery, code, saved.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.verifyIDToken(p, discovery, rawIDToken, saved.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	return s.linkUser(ctx, providerName, claims)
+}
+
+func (s *OIDCService) discover(p *oidcProvider) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := s.getJSON(p.cfg.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("oidc discovery for %s: %w", p.cfg.Name, err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery for %s: issuer mismatch %q", p.cfg.Name, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery for %s: incomplete provider metadata", p.cfg.Name)
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+func (s *OIDCService) getJSON(endpoint string, dest interface{}) error {
+	resp, err := s.httpClient.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+func (s *OIDCService) exchangeCode(p *oidcProvider, discovery *oidcDiscovery, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	resp, err := s.httpClient.PostForm(discovery.TokenEndpoint, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+		utils.GetLogger().Warn("OIDC token exchange failed",
+			zap.String("provider", p.cfg.Name),
+			zap.Int("status", resp.StatusCode),
+			zap.String("error", body.Error),
+			zap.String("description", body.ErrorDescription),
+		)
+		return "", ErrOIDCCodeExchange
+	}
+	return body.IDToken, nil
+}
+
+func (s *OIDCService) verifyIDToken(p *oidcProvider, discovery *oidcDiscovery, rawIDToken, nonce string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.providerKey(p, discovery, kid)
+	},
+		jwt.WithValidMethods(oidcAlgorithms),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(oidcClockSkew),
+	)
+	if err != nil {
+		utils.GetLogger().Warn("OIDC id token rejected", zap.String("provider", p.cfg.Name), zap.Error(err))
+		return nil, ErrInvalidIDToken
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["nonce"] != nonce {
+		return nil, ErrInvalidIDToken
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, ErrInvalidIDToken
+	}
+	return claims, nil
+}
+
+// providerKey возвращает открытый ключ провайдера; при незнакомом kid ключи перечитываются (провайдер их ротирует)
+func (s *OIDCService) providerKey(p *oidcProvider, discovery *oidcDiscovery, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < oidcKeysRefreshMin {
+		return nil, ErrUnknownSigningKey
+	}
+
+	var set models.JWKS
+	if err := s.getJSON(discovery.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwkPublicKey(jwk)
+		if err != nil {
+			utils.GetLogger().Warn("Skipping provider key", zap.String("provider", p.cfg.Name), zap.String("kid", jwk.Kid), zap.Error(err))
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownSigningKey
+}
+
+func jwkPublicKey(jwk models.JWK) (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, err := decode(jwk.X)
+		if err != nil || jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("unsupported OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+// emailVerified учитывает, что некоторые провайдеры (Apple) присылают email_verified строкой
+func emailVerified(claims jwt.MapClaims) bool {
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// linkUser находит пользователя по привязке, иначе привязывает по подтверждённому email или создаёт нового
+func (s *OIDCService) linkUser(ctx context.Context, provider string, claims jwt.MapClaims) (*models.User, error) {
+	subject, _ := claims["sub"].(string)
+	userID, err := s.identityRepo.FindUserID(ctx, provider, subject)
+	if err == nil {
+		return s.userRepo.GetByID(ctx, userID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	email, _ := claims["email"].(string)
+	if email == "" || !emailVerified(claims) {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	existing, err := s.userRepo.GetByEmail(ctx, email)
+	switch {
+	case err == nil:
+		// Иначе пароль от аккаунта, заранее зарегистрированного на чужой email, остался бы у того, кто его создал
+		if !existing.EmailVerified {
+			return nil, ErrOIDCAccountNotVerified
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		existing = nil
+	default:
+		return nil, err
+	}
+
+	// Новый пользователь сохраняется только вместе с привязкой
+	var user *models.User
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		user = existing
+		if user == nil {
+			var err error
+			if user, err = s.createUser(ctx, tx, email, claims); err != nil {
+				return err
+			}
+		}
+		return s.identityRepo.WithTx(tx).Create(ctx, user.ID, provider, subject, email)
+	})
+	if err != nil {
+		return nil, err
+	}
+	utils.GetLogger().Info("External identity linked", zap.Int("user_id", user.ID), zap.String("provider", provider))
+	return user, nil
+}
+
+// createUser создаёт пользователя без пароля: войти по паролю он сможет после сброса пароля
+func (s *OIDCService) createUser(ctx context.Context, tx *sql.Tx, email string, claims jwt.MapClaims) (*models.User, error) {
+	name, _ := claims["name"].(string)
+	if name == "" {
+		name = strings.SplitN(email, "@", 2)[0]
+	}
+	unusable, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(unusable), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	userRepo := s.userRepo.WithTx(tx)
+	user, err := userRepo.Create(ctx, name, email, string(hash), false)
+	if err != nil {
+		return nil, err
+	}
+	if err := userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	user.EmailVerified = true
+	return user, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultPasswordMinLength      = 10
+	defaultPasswordMinCharClasses = 3
+	// bcrypt учитывает только первые 72 байта пароля
+	maxPasswordBytes = 72
+)
+
+var ErrWeakPassword = errors.New("password does not meet the policy")
+
+// Частые и утёкшие пароли; сравнение без учёта регистра
+//
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+// PasswordPolicy проверяет новые пароли и хеширует их с заданной стоимостью bcrypt
+type PasswordPolicy struct {
+	minLength      int
+	minCharClasses int
+	bcryptCost     int
+	common         map[string]struct{}
+}
+
+// NewPasswordPolicy создаёт политику; нулевые значения заменяются значениями по умолчанию.
+// minCharClasses — сколько из классов символов (строчные, заглавные, цифры, прочие) должно встречаться в пароле.
+func NewPasswordPolicy(minLength, minCharClasses, bcryptCost int) *PasswordPolicy {
+	if minLength <= 0 {
+		minLength = defaultPasswordMinLength
+	}
+	if minCharClasses <= 0 {
+		minCharClasses = defaultPasswordMinCharClasses
+	}
+	if minCharClasses > 4 {
+		minCharClasses = 4
+	}
+	if bcryptCost <= 0 {
+		bcryptCost = bcrypt.DefaultCost
+	}
+
+	common := make(map[string]struct{})
+	for _, line := range strings.Split(commonPasswordsList, "\n") {
+		if p := strings.TrimSpace(line); p != "" && !strings.HasPrefix(p, "#") {
+			common[strings.ToLower(p)] = struct{}{}
+		}
+	}
+	return &PasswordPolicy{
+		minLength:      minLength,
+		minCharClasses: minCharClasses,
+		bcryptCost:     bcryptCost,
+		common:         common,
+	}
+}
+
+// Validate проверяет пароль; email и name — данные владельца, которые не должны входить в пароль
+func (p *PasswordPolicy) Validate(password, email, name string) error {
+	if len([]rune(password)) < p.minLength {
+		return fmt.Errorf("%w: must be at least %d characters long", ErrWeakPassword, p.minLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("%w: must be at most %d bytes long", ErrWeakPassword, maxPasswordBytes)
+	}
+	if charClasses(password) < p.minCharClasses {
+		return fmt.Errorf("%w: must contain at least %d of: lowercase letters, uppercase letters, digits, symbols", ErrWeakPassword, p.minCharClasses)
+	}
+
+	lower := strings.ToLower(password)
+	if _, ok := p.common[lower]; ok {
+		return fmt.Errorf("%w: this password is too common", ErrWeakPassword)
+	}
+	for _, part := range personalParts(email, name) {
+		if strings.Contains(lower, part) {
+			return fmt.Errorf("%w: must not contain your name or email", ErrWeakPassword)
+		}
+	}
+	return nil
+}
+
+// Hash хеширует пароль с настроенной стоимостью bcrypt
+func (p *PasswordPolicy) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), p.bcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func charClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, has := range []bool{lower, upper, digit, other} {
+		if has {
+			n++
+		}
+	}
+	return n
+}
+
+// personalParts — слова имени и локальная часть email длиной от 3 символов в нижнем регистре
+func personalParts(email, name string) []string {
+	var parts []string
+	local, _, _ := strings.Cut(strings.ToLower(email), "@")
+	candidates := append(strings.Fields(strings.ToLower(name)), local)
+	for _, c := range candidates {
+		if len([]rune(c)) >= 3 {
+			parts = append(parts, c)
+		}
+	}
+	return parts
+}
@@ -3,46 +3,201 @@ package service
 import (
 	"Gym_StrongCode/internal/models"
 	"Gym_StrongCode/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
 )
 
 type PaymentService struct {
-	paymentRepo *repository.PaymentRepository
+	paymentRepo repository.PaymentStore
+	uow         repository.Transactor
+	currencySvc *CurrencyService
+	taxSvc      *TaxService
+	fiscalSvc   *FiscalService
+	alerts      *AdminAlerts
 }
 
-func NewPaymentService(paymentRepo *repository.PaymentRepository) *PaymentService {
-	return &PaymentService{paymentRepo: paymentRepo}
+// NewPaymentService создаёт платёжный сервис; alerts — оповещения администраторам (nil — без них)
+func NewPaymentService(paymentRepo repository.PaymentStore, uow repository.Transactor, currencySvc *CurrencyService, taxSvc *TaxService, fiscalSvc *FiscalService, alerts *AdminAlerts) *PaymentService {
+	return &PaymentService{paymentRepo: paymentRepo, uow: uow, currencySvc: currencySvc, taxSvc: taxSvc, fiscalSvc: fiscalSvc, alerts: alerts}
 }
 
-func (s *PaymentService) Create(userID, amountCents int, method, description, referenceID string) (*models.Payment, error) {
-	if amountCents <= 0 {
-		return nil, fmt.Errorf("amount must be positive")
-	}
-
-	// Валидация метода оплаты
+func validPaymentMethod(method string) bool {
 	validMethods := []string{"card", "cash", "bank_transfer", "qr_code"}
-	valid := false
 	for _, m := range validMethods {
 		if method == m {
-			valid = true
-			break
+			return true
 		}
 	}
-	if !valid {
-		return nil, fmt.Errorf("invalid payment method: %s", method)
+	return false
+}
+
+func (s *PaymentService) Create(ctx context.Context, userID, amountCents int, currency, method, description, referenceID string) (*models.Payment, error) {
+	return s.Charge(ctx, &models.Payment{
+		UserID:      userID,
+		AmountCents: amountCents,
+		Currency:    currency,
+		Method:      method,
+		Description: description,
+		ReferenceID: referenceID,
+		ProductType: models.ProductOther,
+	})
+}
+
+// Charge проверяет платёж, начисляет налог по типу продукта и залу и сохраняет его как завершённый.
+// p.AmountCents — цена из прайса; итоговая сумма к оплате может включать налог сверху.
+func (s *PaymentService) Charge(ctx context.Context, p *models.Payment) (*models.Payment, error) {
+	if err := s.Prepare(ctx, p); err != nil {
+		return nil, err
+	}
+
+	var payment *models.Payment
+	err := s.uow.Do(ctx, func(tx *sql.Tx) error {
+		var err error
+		payment, err = s.ChargeTx(ctx, tx, p)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.fiscalSvc.Wake()
+	return payment, nil
+}
+
+// Prepare проверяет платёж, определяет валюту и начисляет налог; в БД ничего не пишет
+func (s *PaymentService) Prepare(ctx context.Context, p *models.Payment) error {
+	if p.AmountCents <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+
+	// Валидация метода оплаты
+	if !validPaymentMethod(p.Method) {
+		return fmt.Errorf("invalid payment method: %s", p.Method)
+	}
+
+	if p.ProductType == "" {
+		p.ProductType = models.ProductOther
+	}
+	if !validProductType(p.ProductType) {
+		return fmt.Errorf("invalid product type: %s", p.ProductType)
+	}
+
+	currency, err := s.currencySvc.Resolve(ctx, p.Currency)
+	if err != nil {
+		return err
+	}
+	p.Currency = currency
+	p.Status = "completed"
+
+	return s.taxSvc.Apply(ctx, p)
+}
+
+// ChargeTx сохраняет подготовленный через Prepare платёж и ставит чек в очередь ОФД внутри tx.
+// Регистрация чека начнётся по таймеру или после FiscalService.Wake.
+func (s *PaymentService) ChargeTx(ctx context.Context, tx *sql.Tx, p *models.Payment) (*models.Payment, error) {
+	payment, err := s.paymentRepo.WithTx(tx).Create(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.fiscalSvc.Enqueue(ctx, tx, payment); err != nil {
+		return nil, err
+	}
+	s.alerts.PaymentCompleted(ctx, tx, payment)
+
+	return payment, nil
+}
+
+// Refund оформляет возврат по завершённому платежу; amountCents = 0 — возврат оставшейся суммы.
+// Чек возврата в ОФД не регистрируется: fiscal_outbox ведёт только чеки прихода (один на платёж),
+// возврат пока оформляется вручную в кабинете оператора.
+func (s *PaymentService) Refund(ctx context.Context, id, amountCents int) (*models.Payment, error) {
+	payment, err := s.paymentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != "completed" {
+		return nil, fmt.Errorf("only completed payments can be refunded")
+	}
+
+	remaining := payment.AmountCents - payment.RefundedCents
+	if amountCents == 0 {
+		amountCents = remaining
+	}
+	if amountCents < 0 || amountCents > remaining {
+		return nil, fmt.Errorf("refund amount must be between 1 and %d", remaining)
+	}
+
+	ok, err := s.paymentRepo.Refund(ctx, id, amountCents)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("payment %d was changed concurrently, try again", id)
+	}
+
+	refunded, err := s.paymentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
+	s.alerts.Refunded(ctx, refunded, amountCents)
+	return refunded, nil
+}
+
+func (s *PaymentService) GetByUser(ctx context.Context, userID int, status string) ([]models.Payment, error) {
+	return s.paymentRepo.GetByUser(ctx, userID, status)
+}
 
-	return s.paymentRepo.CreateStandalone(userID, amountCents, "KZT", method, "completed", description, referenceID)
+func (s *PaymentService) ListAll(ctx context.Context) ([]models.Payment, error) {
+	return s.paymentRepo.ListAll(ctx)
 }
 
-func (s *PaymentService) GetByUser(userID int, status string) ([]models.Payment, error) {
-	return s.paymentRepo.GetByUser(userID, status)
+func (s *PaymentService) ListByGym(ctx context.Context, gymID int) ([]models.Payment, error) {
+	return s.paymentRepo.ListByGym(ctx, gymID)
+}
+
+// GymOf возвращает зал платежа; платёж без зала даёт ошибку
+func (s *PaymentService) GymOf(ctx context.Context, paymentID int) (int, error) {
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		return 0, err
+	}
+	if payment.GymID == nil {
+		return 0, fmt.Errorf("payment has no gym")
+	}
+	return *payment.GymID, nil
 }
 
-func (s *PaymentService) ListAll() ([]models.Payment, error) {
-	return s.paymentRepo.ListAll()
+func (s *PaymentService) CreateStandalone(ctx context.Context, userID, amountCents int, currency, method string) (*models.Payment, error) {
+	return s.Create(ctx, userID, amountCents, currency, method, "", "")
 }
 
-func (s *PaymentService) CreateStandalone(userID, amountCents int, method string) (*models.Payment, error) {
-	return s.Create(userID, amountCents, method, "", "")
+// Summary сводит выручку (завершённые платежи за вычетом возвратов) к базовой валюте по текущим курсам.
+// Валюты без курса не попадают в общий итог и перечисляются в MissingRates.
+func (s *PaymentService) Summary(ctx context.Context) (*models.PaymentSummary, error) {
+	totals, err := s.paymentRepo.RevenueByCurrency(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.PaymentSummary{
+		BaseCurrency: s.currencySvc.BaseCurrency(),
+		ByCurrency:   totals,
+	}
+	for currency, amount := range totals {
+		converted, err := s.currencySvc.ConvertToBase(ctx, amount, currency)
+		if err != nil {
+			if errors.Is(err, ErrUnsupportedCurrency) {
+				summary.MissingRates = append(summary.MissingRates, currency)
+				continue
+			}
+			return nil, err
+		}
+		summary.TotalBaseCents += converted
+	}
+	sort.Strings(summary.MissingRates)
+
+	return summary, nil
 }
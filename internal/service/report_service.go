@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+type ReportService struct {
+	reportRepo  repository.ReportStore
+	paymentRepo repository.PaymentStore
+}
+
+func NewReportService(reportRepo repository.ReportStore, paymentRepo repository.PaymentStore) *ReportService {
+	return &ReportService{reportRepo: reportRepo, paymentRepo: paymentRepo}
+}
+
+// parsePeriod разбирает период из дат YYYY-MM-DD включительно и возвращает границы [from, to)
+func parsePeriod(from, to string) (string, string, error) {
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid from date: %s", from)
+	}
+	toDate, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid to date: %s", to)
+	}
+	if toDate.Before(fromDate) {
+		return "", "", fmt.Errorf("to date is before from date")
+	}
+	return fromDate.Format("2006-01-02"), toDate.AddDate(0, 0, 1).Format("2006-01-02"), nil
+}
+
+// Revenue возвращает отчёт по выручке за период; groupBy — day, week, month, method, gym, plan или status
+func (s *ReportService) Revenue(ctx context.Context, groupBy, from, to string) ([]models.RevenueReportRow, error) {
+	if groupBy == "" {
+		groupBy = "day"
+	}
+	start, end, err := parsePeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return s.reportRepo.Revenue(ctx, groupBy, start, end)
+}
+
+// ParseStatement читает CSV-выписку с заголовком; обязательны колонки payment_id и amount_cents,
+// колонка currency необязательна
+func ParseStatement(r io.Reader) ([]models.StatementRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("statement is empty")
+		}
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	idCol, ok := columns["payment_id"]
+	if !ok {
+		return nil, fmt.Errorf("statement has no payment_id column")
+	}
+	amountCol, ok := columns["amount_cents"]
+	if !ok {
+		return nil, fmt.Errorf("statement has no amount_cents column")
+	}
+	currencyCol, hasCurrency := columns["currency"]
+
+	var rows []models.StatementRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		paymentID, err := strconv.Atoi(strings.TrimSpace(record[idCol]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid payment_id %q", line, record[idCol])
+		}
+		amount, err := strconv.Atoi(strings.TrimSpace(record[amountCol]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount_cents %q", line, record[amountCol])
+		}
+
+		row := models.StatementRow{Line: line, PaymentID: paymentID, AmountCents: amount}
+		if hasCurrency {
+			row.Currency = NormalizeCurrency(record[currencyCol])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Reconcile сверяет выписку с платежами за период. Ожидаемая сумма платежа — за вычетом возвратов.
+// method ограничивает сверку одним методом оплаты; если он пуст, наличные не сверяются,
+// так как в выписку банка они не попадают.
+func (s *ReportService) Reconcile(ctx context.Context, statement io.Reader, from, to, method string) (*models.ReconciliationResult, error) {
+	start, end, err := parsePeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if method != "" && !validPaymentMethod(method) {
+		return nil, fmt.Errorf("invalid payment method: %s", method)
+	}
+
+	rows, err := ParseStatement(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.reportRepo.ListSettled(ctx, start, end, method)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[int]models.Payment)
+	for _, p := range payments {
+		if method == "" && p.Method == "cash" {
+			continue
+		}
+		// Полностью возвращённый платёж в выписке может и не появиться
+		if p.AmountCents == p.RefundedCents {
+			continue
+		}
+		expected[p.ID] = p
+	}
+
+	result := &models.ReconciliationResult{
+		From:          from,
+		To:            to,
+		StatementRows: len(rows),
+		Mismatches:    []models.ReconciliationMismatch{},
+	}
+
+	seen := make(map[int]bool)
+	for _, row := range rows {
+		if seen[row.PaymentID] {
+			result.Mismatches = append(result.Mismatches, models.ReconciliationMismatch{
+				Kind: models.MismatchDuplicate, PaymentID: row.PaymentID, Line: row.Line, StatementAmountCents: row.AmountCents,
+			})
+			continue
+		}
+		seen[row.PaymentID] = true
+
+		payment, ok := expected[row.PaymentID]
+		if !ok {
+			// Провайдер мог провести платёж на границе периода — ищем его среди всех платежей
+			p, err := s.paymentRepo.GetByID(ctx, row.PaymentID)
+			if err != nil || (p.Status != "completed" && p.Status != "refunded") {
+				result.Mismatches = append(result.Mismatches, models.ReconciliationMismatch{
+					Kind: models.MismatchMissingInRecords, PaymentID: row.PaymentID, Line: row.Line, StatementAmountCents: row.AmountCents,
+				})
+				continue
+			}
+			payment = *p
+		}
+		delete(expected, row.PaymentID)
+
+		recorded := payment.AmountCents - payment.RefundedCents
+		switch {
+		case row.Currency != "" && row.Currency != payment.Currency:
+			result.Mismatches = append(result.Mismatches, models.ReconciliationMismatch{
+				Kind: models.MismatchCurrency, PaymentID: payment.ID, Line: row.Line,
+				RecordedCurrency: payment.Currency, StatementCurrency: row.Currency,
+			})
+		case row.AmountCents != recorded:
+			result.Mismatches = append(result.Mismatches, models.ReconciliationMismatch{
+				Kind: models.MismatchAmount, PaymentID: payment.ID, Line: row.Line,
+				RecordedAmountCents: recorded, StatementAmountCents: row.AmountCents,
+			})
+		default:
+			result.Matched++
+		}
+	}
+
+	// Оставшиеся платежи есть у нас, но отсутствуют в выписке
+	for _, p := range payments {
+		if _, ok := expected[p.ID]; !ok {
+			continue
+		}
+		result.Mismatches = append(result.Mismatches, models.ReconciliationMismatch{
+			Kind: models.MismatchMissingInStatement, PaymentID: p.ID,
+			RecordedAmountCents: p.AmountCents - p.RefundedCents, RecordedCurrency: p.Currency,
+		})
+	}
+
+	return result, nil
+}
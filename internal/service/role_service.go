@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleAlreadyExists = errors.New("role already assigned")
+	ErrRoleGrantDenied   = errors.New("only owners can grant or revoke manager and owner roles")
+)
+
+// rolePermissions — права каждой роли. Если роль выдана на конкретный зал,
+// права действуют только в его пределах.
+var rolePermissions = map[string][]string{
+	models.RoleMember:  {},
+	models.RoleTrainer: {models.PermBookingsView},
+	models.RoleFrontDesk: {
+		models.PermUsersView, models.PermBookingsView, models.PermPaymentsView, models.PermInstallmentsView,
+	},
+	models.RoleManager: {
+		models.PermUsersView, models.PermRolesManage, models.PermGymsManage, models.PermCatalogManage,
+		models.PermTrainersManage, models.PermClassesManage, models.PermBookingsView, models.PermPaymentsView,
+		models.PermPaymentsRefund, models.PermReportsView, models.PermInstallmentsView, models.PermCampaignsManage,
+	},
+	models.RoleOwner: {
+		models.PermUsersView, models.PermUsersManage, models.PermRolesManage, models.PermGymsManage,
+		models.PermCatalogManage, models.PermTrainersManage, models.PermClassesManage, models.PermBookingsView,
+		models.PermPaymentsView, models.PermPaymentsRefund, models.PermReportsView, models.PermInstallmentsView,
+		models.PermFiscalManage, models.PermAPIKeysManage, models.PermNotificationsManage, models.PermCampaignsManage,
+	},
+}
+
+func validRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func roleHasPermission(role, perm string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+type RoleService struct {
+	roleRepo repository.RoleStore
+	userRepo repository.UserStore
+}
+
+func NewRoleService(roleRepo repository.RoleStore, userRepo repository.UserStore) *RoleService {
+	return &RoleService{roleRepo: roleRepo, userRepo: userRepo}
+}
+
+// PermissionScope возвращает, где у пользователя есть право perm: global — во всех залах,
+// иначе gymIDs — список залов. Флаг is_admin равнозначен роли owner без ограничения по залам.
+func (s *RoleService) PermissionScope(ctx context.Context, userID int, isAdmin bool, perm string) (bool, []int, error) {
+	if isAdmin && roleHasPermission(models.RoleOwner, perm) {
+		return true, nil, nil
+	}
+
+	roles, err := s.roleRepo.ListForUser(ctx, userID)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var gymIDs []int
+	for _, r := range roles {
+		if !roleHasPermission(r.Role, perm) {
+			continue
+		}
+		if r.GymID == nil {
+			return true, nil, nil
+		}
+		gymIDs = append(gymIDs, *r.GymID)
+	}
+	return false, gymIDs, nil
+}
+
+// isOwner — владелец сети: is_admin или роль owner без привязки к залу
+func (s *RoleService) isOwner(ctx context.Context, userID int, isAdmin bool) (bool, error) {
+	if isAdmin {
+		return true, nil
+	}
+	roles, err := s.roleRepo.ListForUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if r.Role == models.RoleOwner && r.GymID == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkGrant запрещает не-владельцам выдавать и отзывать роли manager и owner.
+// Доступ к залу роли проверяется на уровне маршрута.
+func (s *RoleService) checkGrant(ctx context.Context, grantorID int, grantorIsAdmin bool, role string) error {
+	if role != models.RoleManager && role != models.RoleOwner {
+		return nil
+	}
+	owner, err := s.isOwner(ctx, grantorID, grantorIsAdmin)
+	if err != nil {
+		return err
+	}
+	if !owner {
+		return ErrRoleGrantDenied
+	}
+	return nil
+}
+
+func (s *RoleService) ListForUser(ctx context.Context, userID int) ([]models.UserRole, error) {
+	return s.roleRepo.ListForUser(ctx, userID)
+}
+
+func (s *RoleService) Assign(ctx context.Context, grantorID int, grantorIsAdmin bool, userID int, role string, gymID *int) (*models.UserRole, error) {
+	if !validRole(role) {
+		return nil, fmt.Errorf("invalid role: %s", role)
+	}
+	if err := s.checkGrant(ctx, grantorID, grantorIsAdmin, role); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, err
+	}
+
+	exists, err := s.roleRepo.Exists(ctx, userID, role, gymID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrRoleAlreadyExists
+	}
+	return s.roleRepo.Assign(ctx, userID, role, gymID)
+}
+
+// Revoke снимает роль roleID с пользователя userID
+func (s *RoleService) Revoke(ctx context.Context, grantorID int, grantorIsAdmin bool, userID, roleID int) error {
+	role, err := s.roleRepo.GetByID(ctx, roleID)
+	if err != nil || role.UserID != userID {
+		return ErrRoleNotFound
+	}
+	if err := s.checkGrant(ctx, grantorID, grantorIsAdmin, role.Role); err != nil {
+		return err
+	}
+	return s.roleRepo.Delete(ctx, roleID)
+}
+
+// GymOf возвращает зал, к которому привязана роль; для ролей на всю сеть — ErrRoleNotFound,
+// чтобы управлять ими могли только пользователи с правом во всех залах
+func (s *RoleService) GymOf(ctx context.Context, roleID int) (int, error) {
+	role, err := s.roleRepo.GetByID(ctx, roleID)
+	if err != nil || role.GymID == nil {
+		return 0, ErrRoleNotFound
+	}
+	return *role.GymID, nil
+}
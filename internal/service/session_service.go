@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"Gym_StrongCode/internal/cache"
+	"Gym_StrongCode/internal/models"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionService — вход через серверные сессии (AUTH_MODE=session)
+type SessionService struct {
+	authSvc *AuthService
+	store   cache.SessionStore
+}
+
+func NewSessionService(authSvc *AuthService, store cache.SessionStore) *SessionService {
+	return &SessionService{authSvc: authSvc, store: store}
+}
+
+// Login создаёт сессию; если у пользователя включена 2FA, code обязателен
+func (s *SessionService) Login(ctx context.Context, email, password, code, userAgent, ip string) (*models.Session, error) {
+	user, err := s.authSvc.Authenticate(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		if err := s.authSvc.VerifySecondFactor(ctx, user.ID, code); err != nil {
+			return nil, err
+		}
+	}
+	return s.StartSession(ctx, user, userAgent, ip)
+}
+
+// MFAUser возвращает пользователя, для которого выдан mfa_token
+func (s *SessionService) MFAUser(ctx context.Context, mfaToken string) (*models.User, error) {
+	return s.authSvc.MFAUser(ctx, mfaToken)
+}
+
+// LoginMFA создаёт сессию по mfa_token (выданному, например, после входа через внешнего провайдера) и коду 2FA
+func (s *SessionService) LoginMFA(ctx context.Context, mfaToken, code, userAgent, ip string) (*models.Session, error) {
+	user, err := s.authSvc.MFAUser(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authSvc.VerifySecondFactor(ctx, user.ID, code); err != nil {
+		return nil, err
+	}
+	return s.StartSession(ctx, user, userAgent, ip)
+}
+
+// StartSession создаёт сессию для пользователя, личность которого уже подтверждена
+func (s *SessionService) StartSession(ctx context.Context, user *models.User, userAgent, ip string) (*models.Session, error) {
+	version, err := s.authSvc.userRepo.GetTokenVersion(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.CreateSession(ctx, user.ID, user.Email, user.IsAdmin, version, userAgent, ip)
+}
+
+func (s *SessionService) Logout(ctx context.Context, sessionID string) error {
+	return s.store.DeleteSession(ctx, sessionID)
+}
+
+func (s *SessionService) LogoutAll(ctx context.Context, userID int) error {
+	return s.store.DeleteUserSessions(ctx, userID)
+}
+
+// ChangePassword меняет пароль и завершает все сессии пользователя
+func (s *SessionService) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) error {
+	if err := s.authSvc.ChangePassword(ctx, userID, currentPassword, newPassword); err != nil {
+		return err
+	}
+	return s.store.DeleteUserSessions(ctx, userID)
+}
+
+func (s *SessionService) List(ctx context.Context, userID int) ([]models.Session, error) {
+	return s.store.ListUserSessions(ctx, userID)
+}
+
+// Revoke завершает одну из сессий пользователя; чужие сессии не видны
+func (s *SessionService) Revoke(ctx context.Context, userID int, sessionID string) error {
+	session, err := s.store.GetSession(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.store.DeleteSession(ctx, sessionID)
+}
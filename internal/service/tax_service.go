@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+)
+
+var validProductTypes = []string{
+	models.ProductMembership,
+	models.ProductPersonalTraining,
+	models.ProductMerchandise,
+	models.ProductOther,
+}
+
+type TaxService struct {
+	taxRepo     repository.TaxStore
+	paymentRepo repository.PaymentStore
+}
+
+func NewTaxService(taxRepo repository.TaxStore, paymentRepo repository.PaymentStore) *TaxService {
+	return &TaxService{taxRepo: taxRepo, paymentRepo: paymentRepo}
+}
+
+func validProductType(productType string) bool {
+	for _, pt := range validProductTypes {
+		if pt == productType {
+			return true
+		}
+	}
+	return false
+}
+
+// CalculateTax раскладывает сумму по ставке.
+// Для inclusive сумма уже содержит налог (брутто), иначе налог начисляется сверху.
+func CalculateTax(amountCents int, rate *models.TaxRate) models.TaxBreakdown {
+	if rate == nil || rate.RateBP == 0 {
+		return models.TaxBreakdown{NetCents: amountCents, GrossCents: amountCents, Inclusive: true}
+	}
+
+	b := models.TaxBreakdown{RateBP: rate.RateBP, Inclusive: rate.Inclusive}
+	if rate.Inclusive {
+		b.GrossCents = amountCents
+		b.TaxCents = int(math.Round(float64(amountCents) * float64(rate.RateBP) / float64(10000+rate.RateBP)))
+		b.NetCents = b.GrossCents - b.TaxCents
+	} else {
+		b.NetCents = amountCents
+		b.TaxCents = int(math.Round(float64(amountCents) * float64(rate.RateBP) / 10000))
+		b.GrossCents = b.NetCents + b.TaxCents
+	}
+	return b
+}
+
+// Apply рассчитывает налог для платежа по его типу продукта и залу.
+// p.AmountCents на входе — цена из прайса, на выходе — брутто к оплате.
+func (s *TaxService) Apply(ctx context.Context, p *models.Payment) error {
+	if p.ProductType == "" {
+		p.ProductType = models.ProductOther
+	}
+
+	rate, err := s.taxRepo.FindApplicable(ctx, p.ProductType, p.GymID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		rate = nil
+	}
+
+	b := CalculateTax(p.AmountCents, rate)
+	p.AmountCents = b.GrossCents
+	p.NetCents = b.NetCents
+	p.TaxCents = b.TaxCents
+	p.TaxRateBP = b.RateBP
+	return nil
+}
+
+func (s *TaxService) validate(t *models.TaxRate) error {
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !validProductType(t.ProductType) {
+		return fmt.Errorf("invalid product type: %s", t.ProductType)
+	}
+	if t.RateBP < 0 || t.RateBP > 10000 {
+		return fmt.Errorf("rate_bp must be between 0 and 10000")
+	}
+	return nil
+}
+
+func (s *TaxService) ListRates(ctx context.Context) ([]models.TaxRate, error) {
+	return s.taxRepo.List(ctx)
+}
+
+func (s *TaxService) CreateRate(ctx context.Context, t *models.TaxRate) (*models.TaxRate, error) {
+	if err := s.validate(t); err != nil {
+		return nil, err
+	}
+	return s.taxRepo.Create(ctx, t)
+}
+
+func (s *TaxService) UpdateRate(ctx context.Context, id int, t *models.TaxRate) error {
+	if err := s.validate(t); err != nil {
+		return err
+	}
+	return s.taxRepo.Update(ctx, id, t)
+}
+
+func (s *TaxService) DeleteRate(ctx context.Context, id int) error {
+	return s.taxRepo.Delete(ctx, id)
+}
+
+// Summary возвращает налоговый отчёт за период; from и to — даты YYYY-MM-DD включительно
+func (s *TaxService) Summary(ctx context.Context, from, to string) ([]models.TaxSummaryRow, error) {
+	start, end, err := parsePeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return s.paymentRepo.TaxSummary(ctx, start, end)
+}
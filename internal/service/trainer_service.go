@@ -3,28 +3,29 @@ package service
 import (
 	"Gym_StrongCode/internal/models"
 	"Gym_StrongCode/internal/repository"
+	"context"
 )
 
 type TrainerService struct {
-	trainerRepo *repository.TrainerRepository
+	trainerRepo repository.TrainerStore
 }
 
-func NewTrainerService(trainerRepo *repository.TrainerRepository) *TrainerService {
+func NewTrainerService(trainerRepo repository.TrainerStore) *TrainerService {
 	return &TrainerService{trainerRepo: trainerRepo}
 }
 
-func (s *TrainerService) Create(name, bio string) (*models.Trainer, error) {
-	return s.trainerRepo.Create(name, bio)
+func (s *TrainerService) Create(ctx context.Context, name, bio string) (*models.Trainer, error) {
+	return s.trainerRepo.Create(ctx, name, bio)
 }
 
-func (s *TrainerService) List() ([]models.Trainer, error) {
-	return s.trainerRepo.List()
+func (s *TrainerService) List(ctx context.Context) ([]models.Trainer, error) {
+	return s.trainerRepo.List(ctx)
 }
 
-func (s *TrainerService) Update(id int, name, bio string) error {
-	return s.trainerRepo.Update(id, name, bio)
+func (s *TrainerService) Update(ctx context.Context, id int, name, bio string) error {
+	return s.trainerRepo.Update(ctx, id, name, bio)
 }
 
-func (s *TrainerService) Delete(id int) error {
-	return s.trainerRepo.Delete(id)
+func (s *TrainerService) Delete(ctx context.Context, id int) error {
+	return s.trainerRepo.Delete(ctx, id)
 }
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/utils"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	totpIssuer        = "Gym StrongCode"
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTwoFactorRequired       = errors.New("two-factor code required")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotSetup       = errors.New("two-factor setup not started")
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorService — подключение и отключение TOTP и коды восстановления
+type TwoFactorService struct {
+	userRepo  repository.UserStore
+	tokenRepo repository.TokenStore
+	uow       repository.Transactor
+}
+
+func NewTwoFactorService(userRepo repository.UserStore, tokenRepo repository.TokenStore, uow repository.Transactor) *TwoFactorService {
+	return &TwoFactorService{userRepo: userRepo, tokenRepo: tokenRepo, uow: uow}
+}
+
+// normalizeRecoveryCode приводит код восстановления к виду, в котором он хешируется
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// verifySecondFactor принимает TOTP-код или неиспользованный код восстановления.
+// Каждый TOTP-код принимается только один раз.
+func verifySecondFactor(ctx context.Context, userRepo repository.UserStore, tokenRepo repository.TokenStore, userID int, code string) error {
+	secret, enabled, _, err := userRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if code == "" {
+		return ErrTwoFactorRequired
+	}
+
+	if step, ok := utils.ValidateTOTP(secret, code, time.Now()); ok {
+		fresh, err := userRepo.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := tokenRepo.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	utils.GetLogger().Info("Recovery code used", zap.Int("user_id", userID))
+	return nil
+}
+
+// Setup создаёт новый секрет; 2FA включается только после подтверждения кодом в Enable
+func (s *TwoFactorService) Setup(ctx context.Context, userID int) (*models.TwoFactorSetup, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetTOTP(ctx, userID, secret, false); err != nil {
+		return nil, err
+	}
+	return &models.TwoFactorSetup{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// Enable проверяет код из приложения, включает 2FA и возвращает коды восстановления
+func (s *TwoFactorService) Enable(ctx context.Context, userID int, code string) ([]string, error) {
+	secret, enabled, _, err := s.userRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if secret == "" {
+		return nil, ErrTwoFactorNotSetup
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	// 2FA включается только вместе с кодами восстановления
+	var codes []string
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		userRepo := s.userRepo.WithTx(tx)
+		if err := userRepo.SetTOTP(ctx, userID, secret, true); err != nil {
+			return err
+		}
+		if _, err := userRepo.UseTOTPStep(ctx, userID, step); err != nil {
+			return err
+		}
+		codes, err = s.generateRecoveryCodes(ctx, tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.GetLogger().Info("Two-factor authentication enabled", zap.Int("user_id", userID))
+	return codes, nil
+}
+
+// Disable отключает 2FA; требуются пароль и действующий код
+func (s *TwoFactorService) Disable(ctx context.Context, userID int, password, code string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	withHash, err := s.userRepo.GetByEmail(ctx, user.Email)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(withHash.PasswordHash), []byte(password)); err != nil {
+		return ErrInvalidPassword
+	}
+	if err := verifySecondFactor(ctx, s.userRepo, s.tokenRepo, userID, code); err != nil {
+		return err
+	}
+
+	err = s.uow.Do(ctx, func(tx *sql.Tx) error {
+		if err := s.userRepo.WithTx(tx).DisableTOTP(ctx, userID); err != nil {
+			return err
+		}
+		return s.tokenRepo.WithTx(tx).DeleteRecoveryCodes(ctx, userID)
+	})
+	if err != nil {
+		return err
+	}
+	utils.GetLogger().Info("Two-factor authentication disabled", zap.Int("user_id", userID))
+	return nil
+}
+
+// RegenerateRecoveryCodes выдаёт новый набор кодов восстановления, старые перестают действовать
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	if err := verifySecondFactor(ctx, s.userRepo, s.tokenRepo, userID, code); err != nil {
+		return nil, err
+	}
+	return s.generateRecoveryCodes(ctx, nil, userID)
+}
+
+// generateRecoveryCodes заменяет коды восстановления новыми; tx = nil — вне транзакции
+func (s *TwoFactorService) generateRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		codes[i] = raw[:4] + "-" + raw[4:]
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	if err := s.tokenRepo.WithTx(tx).ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// IsTwoFactorEnabled используется middleware, требующим 2FA для админских маршрутов
+func (s *TwoFactorService) IsTwoFactorEnabled(ctx context.Context, userID int) (bool, error) {
+	_, enabled, _, err := s.userRepo.GetTOTP(ctx, userID)
+	return enabled, err
+}
@@ -3,24 +3,25 @@ package service
 import (
 	"Gym_StrongCode/internal/models"
 	"Gym_StrongCode/internal/repository"
+	"context"
 )
 
 type UserService struct {
-	userRepo *repository.UserRepository
+	userRepo repository.UserStore
 }
 
-func NewUserService(userRepo *repository.UserRepository) *UserService {
+func NewUserService(userRepo repository.UserStore) *UserService {
 	return &UserService{userRepo: userRepo}
 }
 
-func (s *UserService) GetByID(id int) (*models.User, error) {
-	return s.userRepo.GetByID(id)
+func (s *UserService) GetByID(ctx context.Context, id int) (*models.User, error) {
+	return s.userRepo.GetByID(ctx, id)
 }
 
-func (s *UserService) List() ([]models.User, error) {
-	return s.userRepo.List()
+func (s *UserService) List(ctx context.Context) ([]models.User, error) {
+	return s.userRepo.List(ctx)
 }
 
-func (s *UserService) Delete(id int) error {
-	return s.userRepo.Delete(id)
+func (s *UserService) Delete(ctx context.Context, id int) error {
+	return s.userRepo.Delete(ctx, id)
 }
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Поддерживаемые алгоритмы подписи JWT (значения заголовка alg)
+const (
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+const rsaKeyBits = 2048
+
+// JWTAlgorithms — единственные алгоритмы, которые принимаются при проверке токенов
+var JWTAlgorithms = []string{JWTAlgorithmRS256, JWTAlgorithmEdDSA}
+
+// GenerateSigningKey создаёт новый закрытый ключ для алгоритма alg
+func GenerateSigningKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case JWTAlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case JWTAlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", alg)
+	}
+}
+
+// SigningMethod возвращает метод подписи jwt для алгоритма alg
+func SigningMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case JWTAlgorithmRS256:
+		return jwt.SigningMethodRS256, nil
+	case JWTAlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", alg)
+	}
+}
+
+// EncodePrivateKey сериализует ключ в PEM (PKCS #8)
+func EncodePrivateKey(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// DecodePrivateKey разбирает ключ, сохранённый EncodePrivateKey
+func DecodePrivateKey(data string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("invalid private key PEM")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+	return signer, nil
+}
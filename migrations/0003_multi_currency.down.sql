@@ -0,0 +1,4 @@
+-- +goose Down
+DROP INDEX IF EXISTS idx_payments_currency;
+DROP TABLE IF EXISTS exchange_rates;
+DROP TABLE IF EXISTS membership_prices;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Цены тарифов в дополнительных валютах (базовая цена остаётся в memberships.price_cents)
+CREATE TABLE membership_prices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    membership_id INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    price_cents INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(membership_id) REFERENCES memberships(id) ON DELETE CASCADE,
+    UNIQUE(membership_id, currency)
+);
+
+-- Курсы валют: сколько единиц базовой валюты стоит 1 единица currency
+CREATE TABLE exchange_rates (
+    currency TEXT PRIMARY KEY,
+    rate REAL NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_payments_currency ON payments(currency);
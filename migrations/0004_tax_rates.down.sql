@@ -0,0 +1,9 @@
+-- +goose Down
+DROP INDEX IF EXISTS idx_payments_created;
+ALTER TABLE payments DROP COLUMN tax_rate_bp;
+ALTER TABLE payments DROP COLUMN tax_cents;
+ALTER TABLE payments DROP COLUMN net_cents;
+ALTER TABLE payments DROP COLUMN gym_id;
+ALTER TABLE payments DROP COLUMN product_type;
+DROP INDEX IF EXISTS idx_tax_rates_product_gym;
+DROP TABLE IF EXISTS tax_rates;
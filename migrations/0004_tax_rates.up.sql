@@ -0,0 +1,27 @@
+-- +goose Up
+-- Ставки налога по типу продукта; gym_id = NULL — ставка по умолчанию для всех залов
+CREATE TABLE tax_rates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    product_type TEXT NOT NULL,
+    gym_id INTEGER,
+    rate_bp INTEGER NOT NULL,
+    inclusive INTEGER DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(gym_id) REFERENCES gyms(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_tax_rates_product_gym ON tax_rates(product_type, IFNULL(gym_id, 0));
+
+-- Налоговая разбивка платежа: amount_cents = брутто, net_cents + tax_cents = amount_cents.
+-- gym_id без FK: платёж должен сохранить зал для отчётов и после удаления зала
+ALTER TABLE payments ADD COLUMN product_type TEXT DEFAULT 'other';
+ALTER TABLE payments ADD COLUMN gym_id INTEGER;
+ALTER TABLE payments ADD COLUMN net_cents INTEGER;
+ALTER TABLE payments ADD COLUMN tax_cents INTEGER DEFAULT 0;
+ALTER TABLE payments ADD COLUMN tax_rate_bp INTEGER DEFAULT 0;
+
+UPDATE payments SET net_cents = amount_cents WHERE net_cents IS NULL;
+UPDATE payments SET product_type = 'membership' WHERE reference_id LIKE 'membership_%';
+
+CREATE INDEX idx_payments_created ON payments(created_at);
@@ -0,0 +1,5 @@
+-- +goose Down
+ALTER TABLE payments DROP COLUMN fiscal_url;
+ALTER TABLE payments DROP COLUMN fiscal_sign;
+DROP INDEX IF EXISTS idx_fiscal_outbox_due;
+DROP TABLE IF EXISTS fiscal_outbox;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Очередь регистрации чеков в ОФД: запись создаётся для каждого завершённого платежа
+CREATE TABLE fiscal_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    payment_id INTEGER NOT NULL UNIQUE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(payment_id) REFERENCES payments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_fiscal_outbox_due ON fiscal_outbox(status, next_attempt_at);
+
+-- Фискальный признак и ссылка на чек, которые вернул оператор
+ALTER TABLE payments ADD COLUMN fiscal_sign TEXT;
+ALTER TABLE payments ADD COLUMN fiscal_url TEXT;
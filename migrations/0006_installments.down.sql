@@ -0,0 +1,5 @@
+-- +goose Down
+DROP INDEX IF EXISTS idx_installments_due;
+DROP INDEX IF EXISTS idx_installment_plans_user;
+DROP TABLE IF EXISTS installments;
+DROP TABLE IF EXISTS installment_plans;
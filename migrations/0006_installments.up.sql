@@ -0,0 +1,38 @@
+-- +goose Up
+-- Рассрочка: цена тарифа делится на платежи по графику
+CREATE TABLE installment_plans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    membership_id INTEGER NOT NULL,
+    user_membership_id INTEGER,
+    total_cents INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    method TEXT NOT NULL,
+    gym_id INTEGER,
+    installments_count INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'active',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY(membership_id) REFERENCES memberships(id),
+    FOREIGN KEY(user_membership_id) REFERENCES user_memberships(id) ON DELETE SET NULL
+);
+
+CREATE TABLE installments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    plan_id INTEGER NOT NULL,
+    seq INTEGER NOT NULL,
+    amount_cents INTEGER NOT NULL,
+    due_date DATE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_date DATE NOT NULL,
+    payment_id INTEGER,
+    last_error TEXT,
+    paid_at DATETIME,
+    FOREIGN KEY(plan_id) REFERENCES installment_plans(id) ON DELETE CASCADE,
+    FOREIGN KEY(payment_id) REFERENCES payments(id),
+    UNIQUE(plan_id, seq)
+);
+
+CREATE INDEX idx_installment_plans_user ON installment_plans(user_id);
+CREATE INDEX idx_installments_due ON installments(status, next_attempt_date);
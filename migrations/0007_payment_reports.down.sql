@@ -0,0 +1,5 @@
+-- +goose Down
+DROP INDEX IF EXISTS idx_payments_membership;
+ALTER TABLE payments DROP COLUMN refunded_at;
+ALTER TABLE payments DROP COLUMN refunded_cents;
+ALTER TABLE payments DROP COLUMN membership_id;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Тариф, к которому относится платёж, и сумма возвратов
+ALTER TABLE payments ADD COLUMN membership_id INTEGER;
+ALTER TABLE payments ADD COLUMN refunded_cents INTEGER DEFAULT 0;
+ALTER TABLE payments ADD COLUMN refunded_at DATETIME;
+
+-- До этой миграции тариф хранился только в reference_id вида membership_<id>
+UPDATE payments
+SET membership_id = CAST(SUBSTR(reference_id, 12) AS INTEGER)
+WHERE reference_id LIKE 'membership\_%' ESCAPE '\';
+
+UPDATE payments SET refunded_cents = 0 WHERE refunded_cents IS NULL;
+
+CREATE INDEX idx_payments_membership ON payments(membership_id);
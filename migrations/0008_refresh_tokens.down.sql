@@ -0,0 +1,6 @@
+-- +goose Down
+ALTER TABLE users DROP COLUMN token_version;
+DROP TABLE IF EXISTS revoked_access_tokens;
+DROP INDEX IF EXISTS idx_refresh_tokens_family;
+DROP INDEX IF EXISTS idx_refresh_tokens_user;
+DROP TABLE IF EXISTS refresh_tokens;
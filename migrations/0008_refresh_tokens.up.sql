@@ -0,0 +1,25 @@
+-- +goose Up
+-- Refresh-токены хранятся только в виде SHA-256; family_id объединяет цепочку ротаций одного входа
+CREATE TABLE refresh_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    family_id TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    replaced_by INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_refresh_tokens_user ON refresh_tokens(user_id);
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens(family_id);
+
+-- Отозванные access-токены (по jti) до истечения их срока
+CREATE TABLE revoked_access_tokens (
+    jti TEXT PRIMARY KEY,
+    expires_at DATETIME NOT NULL
+);
+
+-- Увеличение версии отзывает все выданные пользователю access-токены
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- +goose Down
+ALTER TABLE users DROP COLUMN email_verified;
+DROP INDEX IF EXISTS idx_user_tokens_user;
+DROP TABLE IF EXISTS user_tokens;
@@ -0,0 +1,3 @@
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_roles_unique;
+DROP TABLE IF EXISTS user_roles;
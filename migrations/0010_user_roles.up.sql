@@ -0,0 +1,16 @@
+-- +goose Up
+-- Роли пользователей; gym_id = NULL — роль действует во всех залах
+CREATE TABLE user_roles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK(role IN ('member', 'trainer', 'front_desk', 'manager', 'owner')),
+    gym_id INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY(gym_id) REFERENCES gyms(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_user_roles_unique ON user_roles(user_id, role, IFNULL(gym_id, 0));
+
+-- Существующие администраторы становятся владельцами
+INSERT INTO user_roles (user_id, role) SELECT id, 'owner' FROM users WHERE is_admin = 1;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Ключи подписи JWT. Ключ с rotated_at IS NULL подписывает новые токены;
+-- после ротации ключ публикуется в JWKS до expires_at, затем удаляется
+CREATE TABLE signing_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kid TEXT NOT NULL UNIQUE,
+    algorithm TEXT NOT NULL CHECK (algorithm IN ('RS256', 'EdDSA')),
+    private_key TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    rotated_at DATETIME,
+    expires_at DATETIME
+);
@@ -0,0 +1,3 @@
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_identities_user;
+DROP TABLE IF EXISTS user_identities;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Привязка аккаунтов внешних провайдеров OpenID Connect (sub в рамках провайдера) к пользователям
+CREATE TABLE user_identities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user ON user_identities(user_id);
@@ -0,0 +1,18 @@
+-- +goose Up
+-- API-ключи для интеграций (турникеты, BI). Хранится только SHA-256 ключа; prefix — открытая часть для поиска.
+-- scopes — права через пробел, gym_id ограничивает ключ одним залом (NULL — все залы)
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL UNIQUE,
+    key_hash TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    gym_id INTEGER,
+    created_by INTEGER NOT NULL,
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    revoked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(gym_id) REFERENCES gyms(id) ON DELETE CASCADE,
+    FOREIGN KEY(created_by) REFERENCES users(id)
+);
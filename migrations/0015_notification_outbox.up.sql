@@ -0,0 +1,19 @@
+-- +goose Up
+-- Очередь уведомлений: запись создаётся вместе с бизнес-изменением и отправляется воркерами.
+-- sending — запись взята воркером до locked_until; dead — исчерпаны попытки, нужна ручная переотправка
+CREATE TABLE notification_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    recipient TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'sending', 'sent', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    locked_until DATETIME,
+    last_error TEXT,
+    sent_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notification_outbox_due ON notification_outbox(status, next_attempt_at);
@@ -0,0 +1,5 @@
+-- +goose Down
+ALTER TABLE notification_outbox DROP COLUMN text_body;
+ALTER TABLE notification_outbox DROP COLUMN locale;
+ALTER TABLE notification_outbox DROP COLUMN template;
+ALTER TABLE users DROP COLUMN locale;
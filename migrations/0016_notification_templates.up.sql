@@ -0,0 +1,6 @@
+-- +goose Up
+-- Язык писем пользователя; шаблон и текстовая версия письма в очереди
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT 'ru';
+ALTER TABLE notification_outbox ADD COLUMN template TEXT NOT NULL DEFAULT '';
+ALTER TABLE notification_outbox ADD COLUMN locale TEXT NOT NULL DEFAULT '';
+ALTER TABLE notification_outbox ADD COLUMN text_body TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- +goose Down
+ALTER TABLE notification_outbox DROP COLUMN fallback;
+ALTER TABLE notification_outbox DROP COLUMN channel;
+ALTER TABLE users DROP COLUMN push_token;
+ALTER TABLE users DROP COLUMN phone;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Адреса пользователя для SMS и push; канал письма в очереди и запасные каналы (JSON) на случай отказа
+ALTER TABLE users ADD COLUMN phone TEXT;
+ALTER TABLE users ADD COLUMN push_token TEXT;
+ALTER TABLE notification_outbox ADD COLUMN channel TEXT NOT NULL DEFAULT 'email';
+ALTER TABLE notification_outbox ADD COLUMN fallback TEXT NOT NULL DEFAULT '';
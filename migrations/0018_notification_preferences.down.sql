@@ -0,0 +1,4 @@
+-- +goose Down
+ALTER TABLE notification_outbox DROP COLUMN unsubscribe_url;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS notification_settings;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Настройки уведомлений пользователя: часовой пояс и тихие часы (HH:MM по его времени)
+CREATE TABLE notification_settings (
+    user_id INTEGER PRIMARY KEY,
+    timezone TEXT NOT NULL DEFAULT 'Asia/Almaty',
+    quiet_hours_start TEXT,
+    quiet_hours_end TEXT,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Подписки по категориям и каналам; нет строки — уведомления включены
+CREATE TABLE notification_preferences (
+    user_id INTEGER NOT NULL,
+    category TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, category, channel),
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Ссылка отписки для заголовка List-Unsubscribe
+ALTER TABLE notification_outbox ADD COLUMN unsubscribe_url TEXT NOT NULL DEFAULT '';
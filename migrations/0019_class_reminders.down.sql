@@ -0,0 +1,2 @@
+-- +goose Down
+ALTER TABLE bookings DROP COLUMN reminded_for;
@@ -0,0 +1,3 @@
+-- +goose Up
+-- Время занятия, о котором уже напомнили; после переноса занятия напоминание уходит снова
+ALTER TABLE bookings ADD COLUMN reminded_for DATETIME;
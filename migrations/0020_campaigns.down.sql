@@ -0,0 +1,3 @@
+-- +goose Down
+DROP TABLE campaign_recipients;
+DROP TABLE campaigns;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- Рассылки по сегментам; segment — условия отбора в JSON
+CREATE TABLE campaigns (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    segment TEXT NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'draft',
+    scheduled_at DATETIME,
+    rate_per_minute INTEGER NOT NULL DEFAULT 60,
+    created_by INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    started_at DATETIME,
+    finished_at DATETIME,
+    FOREIGN KEY(created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_campaigns_status ON campaigns(status, scheduled_at);
+
+-- Получатели фиксируются при запуске кампании; notification_id — письмо в notification_outbox
+CREATE TABLE campaign_recipients (
+    campaign_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    notification_id INTEGER,
+    opened_at DATETIME,
+    PRIMARY KEY(campaign_id, user_id),
+    FOREIGN KEY(campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_campaign_recipients_status ON campaign_recipients(campaign_id, status);
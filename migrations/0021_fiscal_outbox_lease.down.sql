@@ -0,0 +1,2 @@
+-- +goose Down
+ALTER TABLE fiscal_outbox DROP COLUMN locked_until;
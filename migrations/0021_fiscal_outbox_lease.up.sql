@@ -0,0 +1,3 @@
+-- +goose Up
+-- Чек забирается воркером в sending до locked_until, чтобы несколько экземпляров не отправили его дважды
+ALTER TABLE fiscal_outbox ADD COLUMN locked_until DATETIME;
@@ -0,0 +1,368 @@
+package contract
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+
+		u, err := s.Users.Create(ctx, "Ivan", "ivan@example.com", "hash", false)
+		require.NoError(t, err)
+		assert.Equal(t, "ru", u.Locale)
+
+		_, err = s.Users.Create(ctx, "Other", "ivan@example.com", "hash", false)
+		assert.Error(t, err, "email уникален")
+
+		byEmail, err := s.Users.GetByEmail(ctx, "ivan@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "hash", byEmail.PasswordHash)
+		_, err = s.Users.GetByEmail(ctx, "nobody@example.com")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		require.NoError(t, s.Users.Update(ctx, u.ID, "Ivan P.", "ivan.p@example.com"))
+		require.NoError(t, s.Users.SetLocale(ctx, u.ID, "en"))
+		require.NoError(t, s.Users.SetPhone(ctx, u.ID, "+77001234567"))
+		require.NoError(t, s.Users.SetPushToken(ctx, u.ID, "device"))
+		require.NoError(t, s.Users.SetPasswordHash(ctx, u.ID, "new-hash"))
+		require.NoError(t, s.Users.MarkEmailVerified(ctx, u.ID))
+
+		got, err := s.Users.GetByID(ctx, u.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Ivan P.", got.Name)
+		assert.Equal(t, "ivan.p@example.com", got.Email)
+		assert.Equal(t, "en", got.Locale)
+		assert.Equal(t, "+77001234567", got.Phone)
+		assert.Equal(t, "device", got.PushToken)
+		assert.True(t, got.EmailVerified)
+		byEmail, err = s.Users.GetByEmail(ctx, "ivan.p@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "new-hash", byEmail.PasswordHash)
+
+		require.NoError(t, s.Users.SetPhone(ctx, u.ID, ""))
+		got, err = s.Users.GetByID(ctx, u.ID)
+		require.NoError(t, err)
+		assert.Empty(t, got.Phone)
+
+		version, err := s.Users.GetTokenVersion(ctx, u.ID)
+		require.NoError(t, err)
+		require.NoError(t, s.Users.IncrementTokenVersion(ctx, u.ID))
+		next, err := s.Users.GetTokenVersion(ctx, u.ID)
+		require.NoError(t, err)
+		assert.Equal(t, version+1, next)
+
+		require.NoError(t, s.Users.Delete(ctx, u.ID))
+		_, err = s.Users.GetByID(ctx, u.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = s.Users.GetTokenVersion(ctx, u.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		users, err := s.Users.List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	})
+}
+
+func TestUserStore_TOTP(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "ivan@example.com")
+
+		secret, enabled, step, err := s.Users.GetTOTP(ctx, userID)
+		require.NoError(t, err)
+		assert.Empty(t, secret)
+		assert.False(t, enabled)
+		assert.Zero(t, step)
+
+		require.NoError(t, s.Users.SetTOTP(ctx, userID, "SECRET", true))
+		ok, err := s.Users.UseTOTPStep(ctx, userID, 100)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		// Тот же или более ранний шаг повторно не принимается
+		ok, err = s.Users.UseTOTPStep(ctx, userID, 100)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		secret, enabled, step, err = s.Users.GetTOTP(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, "SECRET", secret)
+		assert.True(t, enabled)
+		assert.Equal(t, int64(100), step)
+
+		require.NoError(t, s.Users.DisableTOTP(ctx, userID))
+		secret, enabled, step, err = s.Users.GetTOTP(ctx, userID)
+		require.NoError(t, err)
+		assert.Empty(t, secret)
+		assert.False(t, enabled)
+		assert.Zero(t, step)
+	})
+}
+
+func TestTokenStore_Refresh(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "ivan@example.com")
+		expires := time.Now().Add(time.Hour)
+
+		first, err := s.Tokens.CreateRefresh(ctx, userID, "family", "hash-1", expires)
+		require.NoError(t, err)
+		second, err := s.Tokens.CreateRefresh(ctx, userID, "family", "hash-2", expires)
+		require.NoError(t, err)
+		_, err = s.Tokens.CreateRefresh(ctx, userID, "family", "hash-2", expires)
+		assert.Error(t, err, "хэш токена уникален")
+
+		ok, err := s.Tokens.RotateRefresh(ctx, first, second)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = s.Tokens.RotateRefresh(ctx, first, second)
+		require.NoError(t, err)
+		assert.False(t, ok, "отозванный токен повторно не ротируется")
+
+		rt, err := s.Tokens.GetRefreshByHash(ctx, "hash-1")
+		require.NoError(t, err)
+		assert.NotNil(t, rt.RevokedAt)
+		require.NotNil(t, rt.ReplacedBy)
+		assert.Equal(t, second, *rt.ReplacedBy)
+		assert.False(t, rt.Expired)
+
+		require.NoError(t, s.Tokens.RevokeFamily(ctx, "family"))
+		rt, err = s.Tokens.GetRefreshByHash(ctx, "hash-2")
+		require.NoError(t, err)
+		assert.NotNil(t, rt.RevokedAt)
+
+		expired, err := s.Tokens.CreateRefresh(ctx, userID, "old", "hash-old", time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.NotZero(t, expired)
+		rt, err = s.Tokens.GetRefreshByHash(ctx, "hash-old")
+		require.NoError(t, err)
+		assert.True(t, rt.Expired)
+
+		require.NoError(t, s.Tokens.PurgeExpired(ctx, time.Now()))
+		_, err = s.Tokens.GetRefreshByHash(ctx, "hash-old")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = s.Tokens.GetRefreshByHash(ctx, "hash-1")
+		assert.NoError(t, err)
+	})
+}
+
+func TestTokenStore_AccessDenylist(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+
+		require.NoError(t, s.Tokens.RevokeAccess(ctx, "jti-1", time.Now().Add(time.Hour)))
+		require.NoError(t, s.Tokens.RevokeAccess(ctx, "jti-1", time.Now().Add(time.Hour)))
+		require.NoError(t, s.Tokens.RevokeAccess(ctx, "jti-2", time.Now().Add(-time.Hour)))
+
+		revoked, err := s.Tokens.IsAccessRevoked(ctx, "jti-1")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		require.NoError(t, s.Tokens.PurgeExpired(ctx, time.Now()))
+		revoked, err = s.Tokens.IsAccessRevoked(ctx, "jti-2")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+		revoked, err = s.Tokens.IsAccessRevoked(ctx, "jti-1")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	})
+}
+
+func TestTokenStore_UserTokens(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "ivan@example.com")
+		purpose := models.TokenPurposePasswordReset
+
+		require.NoError(t, s.Tokens.CreateUserToken(ctx, userID, purpose, "old", time.Now().Add(time.Hour)))
+		require.NoError(t, s.Tokens.InvalidateUserTokens(ctx, userID, purpose))
+		require.NoError(t, s.Tokens.CreateUserToken(ctx, userID, purpose, "new", time.Now().Add(time.Hour)))
+		require.NoError(t, s.Tokens.CreateUserToken(ctx, userID, purpose, "expired", time.Now().Add(-time.Hour)))
+
+		_, err := s.Tokens.ConsumeUserToken(ctx, purpose, "old")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = s.Tokens.ConsumeUserToken(ctx, purpose, "expired")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = s.Tokens.ConsumeUserToken(ctx, models.TokenPurposeEmailVerification, "new")
+		assert.ErrorIs(t, err, sql.ErrNoRows, "токен другого назначения не подходит")
+
+		owner, err := s.Tokens.ConsumeUserToken(ctx, purpose, "new")
+		require.NoError(t, err)
+		assert.Equal(t, userID, owner)
+		_, err = s.Tokens.ConsumeUserToken(ctx, purpose, "new")
+		assert.ErrorIs(t, err, sql.ErrNoRows, "токен одноразовый")
+	})
+}
+
+func TestTokenStore_RecoveryCodes(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "ivan@example.com")
+
+		require.NoError(t, s.Tokens.ReplaceRecoveryCodes(ctx, userID, []string{"a", "b"}))
+		require.NoError(t, s.Tokens.ReplaceRecoveryCodes(ctx, userID, []string{"c", "d", "e"}))
+		n, err := s.Tokens.CountRecoveryCodes(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+
+		ok, err := s.Tokens.UseRecoveryCode(ctx, userID, "a")
+		require.NoError(t, err)
+		assert.False(t, ok, "старые коды заменены")
+		ok, err = s.Tokens.UseRecoveryCode(ctx, userID, "c")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = s.Tokens.UseRecoveryCode(ctx, userID, "c")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		n, err = s.Tokens.CountRecoveryCodes(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		require.NoError(t, s.Tokens.DeleteRecoveryCodes(ctx, userID))
+		n, err = s.Tokens.CountRecoveryCodes(ctx, userID)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
+
+func TestRoleStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "manager@example.com")
+		gymID := createGym(t, s, "Central")
+
+		global, err := s.Roles.Assign(ctx, userID, "support", nil)
+		require.NoError(t, err)
+		scoped, err := s.Roles.Assign(ctx, userID, "gym_manager", &gymID)
+		require.NoError(t, err)
+		require.NotNil(t, scoped.GymID)
+		assert.Equal(t, gymID, *scoped.GymID)
+
+		exists, err := s.Roles.Exists(ctx, userID, "gym_manager", &gymID)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = s.Roles.Exists(ctx, userID, "gym_manager", nil)
+		require.NoError(t, err)
+		assert.False(t, exists)
+		exists, err = s.Roles.Exists(ctx, userID, "support", nil)
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		roles, err := s.Roles.ListForUser(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, roles, 2)
+		assert.Equal(t, global.ID, roles[0].ID)
+
+		require.NoError(t, s.Roles.Delete(ctx, global.ID))
+		_, err = s.Roles.GetByID(ctx, global.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestIdentityStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "ivan@example.com")
+
+		_, err := s.Identities.FindUserID(ctx, "google", "sub-1")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		require.NoError(t, s.Identities.Create(ctx, userID, "google", "sub-1", "ivan@example.com"))
+		assert.Error(t, s.Identities.Create(ctx, userID, "google", "sub-1", "ivan@example.com"), "привязка уникальна")
+
+		found, err := s.Identities.FindUserID(ctx, "google", "sub-1")
+		require.NoError(t, err)
+		assert.Equal(t, userID, found)
+		_, err = s.Identities.FindUserID(ctx, "github", "sub-1")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestAPIKeyStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		adminID := createUser(t, s, "admin@example.com")
+		expires := time.Now().Add(24 * time.Hour)
+
+		key, err := s.APIKeys.Create(ctx, "Reports", "gsk_abc", "hash", []string{"reports:read", "payments:read"}, nil, adminID, &expires)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"reports:read", "payments:read"}, key.Scopes)
+		require.NotNil(t, key.ExpiresAt)
+		assert.WithinDuration(t, expires, *key.ExpiresAt, time.Second)
+		assert.Nil(t, key.LastUsedAt)
+
+		_, err = s.APIKeys.Create(ctx, "Dup", "gsk_abc", "hash", nil, nil, adminID, nil)
+		assert.Error(t, err, "префикс уникален")
+
+		byPrefix, err := s.APIKeys.GetByPrefix(ctx, "gsk_abc")
+		require.NoError(t, err)
+		assert.Equal(t, key.ID, byPrefix.ID)
+		_, err = s.APIKeys.GetByPrefix(ctx, "gsk_none")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		now := time.Now()
+		require.NoError(t, s.APIKeys.TouchLastUsed(ctx, key.ID, now))
+		// Повторное использование в пределах минуты не пишется
+		require.NoError(t, s.APIKeys.TouchLastUsed(ctx, key.ID, now.Add(30*time.Second)))
+		got, err := s.APIKeys.GetByID(ctx, key.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got.LastUsedAt)
+		assert.WithinDuration(t, now, *got.LastUsedAt, time.Second)
+
+		ok, err := s.APIKeys.Revoke(ctx, key.ID)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = s.APIKeys.Revoke(ctx, key.ID)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		second, err := s.APIKeys.Create(ctx, "Bot", "gsk_def", "hash", []string{"bookings:read"}, nil, adminID, nil)
+		require.NoError(t, err)
+		keys, err := s.APIKeys.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, second.ID, keys[0].ID, "новые ключи первыми")
+		assert.NotNil(t, keys[1].RevokedAt)
+	})
+}
+
+func TestSigningKeyStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		now := time.Now()
+
+		old, err := s.SigningKeys.Create(ctx, "kid-1", "RS256", "pem-1")
+		require.NoError(t, err)
+		_, err = s.SigningKeys.Create(ctx, "kid-1", "RS256", "pem-1")
+		assert.Error(t, err, "kid уникален")
+		current, err := s.SigningKeys.Create(ctx, "kid-2", "RS256", "pem-2")
+		require.NoError(t, err)
+
+		require.NoError(t, s.SigningKeys.RetireOthers(ctx, current.ID, now, now.Add(time.Hour)))
+		keys, err := s.SigningKeys.ListPublished(ctx, now)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, current.ID, keys[0].ID)
+		assert.Nil(t, keys[0].RotatedAt)
+		require.NotNil(t, keys[1].ExpiresAt)
+		assert.Equal(t, old.ID, keys[1].ID)
+
+		// После истечения старый ключ не публикуется и удаляется
+		later := now.Add(2 * time.Hour)
+		keys, err = s.SigningKeys.ListPublished(ctx, later)
+		require.NoError(t, err)
+		assert.Len(t, keys, 1)
+		n, err := s.SigningKeys.DeleteExpired(ctx, later)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+	})
+}
@@ -0,0 +1,371 @@
+package contract
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Период, в который попадают все платежи теста
+const reportFrom, reportTo = "2000-01-01", "2100-01-01"
+
+func TestPaymentStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "buyer@example.com")
+		gymID := createGym(t, s, "Central")
+
+		p, err := s.Payments.Create(ctx, &models.Payment{UserID: userID, AmountCents: 1120, Currency: "KZT", Method: "card", Status: "completed",
+			ProductType: models.ProductMembership, GymID: &gymID, NetCents: 1000, TaxCents: 120, TaxRateBP: 1200})
+		require.NoError(t, err)
+		// Без налоговой разбивки нетто равно сумме платежа
+		plain, err := s.Payments.CreateStandalone(ctx, userID, 500, "KZT", "cash", "pending", "Water", "ref-1")
+		require.NoError(t, err)
+		assert.Equal(t, models.ProductOther, plain.ProductType)
+		assert.Equal(t, 500, plain.NetCents)
+
+		got, err := s.Payments.GetByID(ctx, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 120, got.TaxCents)
+		require.NotNil(t, got.GymID)
+		_, err = s.Payments.GetByID(ctx, 999)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		byGym, err := s.Payments.ListByGym(ctx, gymID)
+		require.NoError(t, err)
+		assert.Len(t, byGym, 1)
+		pending, err := s.Payments.GetByUser(ctx, userID, "pending")
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, plain.ID, pending[0].ID)
+		all, err := s.Payments.GetByUser(ctx, userID, "")
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+
+		require.NoError(t, s.Payments.SetFiscalRegistration(ctx, p.ID, "sign", "https://receipt"))
+		got, err = s.Payments.GetByID(ctx, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "sign", got.FiscalSign)
+
+		// Возврат частями; сверх суммы и для незавершённого платежа — отказ
+		ok, err := s.Payments.Refund(ctx, p.ID, 1000)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		revenue, err := s.Payments.RevenueByCurrency(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"KZT": 120}, revenue, "частичный возврат вычитается, ожидающий платёж не учитывается")
+		ok, err = s.Payments.Refund(ctx, p.ID, 500)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = s.Payments.Refund(ctx, plain.ID, 100)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = s.Payments.Refund(ctx, p.ID, 120)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		got, err = s.Payments.GetByID(ctx, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "refunded", got.Status)
+		assert.NotNil(t, got.RefundedAt)
+
+		revenue, err = s.Payments.RevenueByCurrency(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"KZT": 0}, revenue, "полностью возвращённый платёж не даёт выручки")
+	})
+}
+
+func TestPaymentStore_TaxSummary(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "buyer@example.com")
+
+		var ids []int
+		for _, p := range []models.Payment{
+			{AmountCents: 1120, NetCents: 1000, TaxCents: 120, TaxRateBP: 1200, ProductType: models.ProductMembership, Status: "completed"},
+			{AmountCents: 2240, NetCents: 2000, TaxCents: 240, TaxRateBP: 1200, ProductType: models.ProductMembership, Status: "completed"},
+			{AmountCents: 300, ProductType: models.ProductMerchandise, Status: "completed"},
+			{AmountCents: 999, ProductType: models.ProductMerchandise, Status: "pending"},
+			{AmountCents: 2240, NetCents: 2000, TaxCents: 240, TaxRateBP: 1200, ProductType: models.ProductMembership, Status: "completed"},
+			{AmountCents: 1120, NetCents: 1000, TaxCents: 120, TaxRateBP: 1200, ProductType: models.ProductMembership, Status: "completed"},
+		} {
+			p.UserID, p.Currency, p.Method = userID, "KZT", "card"
+			created, err := s.Payments.Create(ctx, &p)
+			require.NoError(t, err)
+			ids = append(ids, created.ID)
+		}
+		// Частичный возврат уменьшает налог пропорционально, полный — обнуляет вклад платежа
+		for id, amount := range map[int]int{ids[4]: 560, ids[5]: 1120} {
+			ok, err := s.Payments.Refund(ctx, id, amount)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+
+		summary, err := s.Payments.TaxSummary(ctx, reportFrom, reportTo)
+		require.NoError(t, err)
+		require.Len(t, summary, 2)
+		assert.Equal(t, models.TaxSummaryRow{ProductType: models.ProductMembership, TaxRateBP: 1200, Currency: "KZT",
+			PaymentsCount: 4, NetCents: 3000 + 1500, TaxCents: 360 + 180, GrossCents: 3360 + 1680}, summary[0])
+		assert.Equal(t, models.ProductMerchandise, summary[1].ProductType)
+		assert.Equal(t, 300, summary[1].NetCents)
+	})
+}
+
+func TestReportStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "buyer@example.com")
+		gymID := createGym(t, s, "Central")
+
+		create := func(amount int, currency, method, status string, gym *int) int {
+			p, err := s.Payments.Create(ctx, &models.Payment{UserID: userID, AmountCents: amount, Currency: currency, Method: method, Status: status, GymID: gym})
+			require.NoError(t, err)
+			return p.ID
+		}
+		refundedID := create(1000, "KZT", "card", "completed", &gymID)
+		create(500, "KZT", "cash", "completed", nil)
+		create(700, "USD", "card", "completed", &gymID)
+		create(300, "KZT", "card", "failed", &gymID)
+		ok, err := s.Payments.Refund(ctx, refundedID, 400)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		rows, err := s.Reports.Revenue(ctx, "method", reportFrom, reportTo)
+		require.NoError(t, err)
+		assert.Equal(t, []models.RevenueReportRow{
+			{Key: "card", Currency: "KZT", PaymentsCount: 1, GrossCents: 1000, RefundedCents: 400, RevenueCents: 600},
+			{Key: "card", Currency: "USD", PaymentsCount: 1, GrossCents: 700, RevenueCents: 700},
+			{Key: "cash", Currency: "KZT", PaymentsCount: 1, GrossCents: 500, RevenueCents: 500},
+		}, rows)
+
+		rows, err = s.Reports.Revenue(ctx, "gym", reportFrom, reportTo)
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+		assert.Equal(t, "Central", rows[0].Label)
+
+		// По статусу учитываются и неуспешные платежи
+		rows, err = s.Reports.Revenue(ctx, "status", reportFrom, reportTo)
+		require.NoError(t, err)
+		var keys []string
+		for _, row := range rows {
+			keys = append(keys, row.Key+"/"+row.Currency)
+		}
+		assert.Equal(t, []string{"completed/KZT", "completed/USD", "failed/KZT"}, keys)
+
+		rows, err = s.Reports.Revenue(ctx, "day", reportFrom, reportTo)
+		require.NoError(t, err)
+		require.NotEmpty(t, rows)
+		assert.Equal(t, time.Now().UTC().Format("2006-01-02"), rows[0].Key)
+
+		_, err = s.Reports.Revenue(ctx, "nope", reportFrom, reportTo)
+		assert.Error(t, err)
+
+		settled, err := s.Reports.ListSettled(ctx, reportFrom, reportTo, "card")
+		require.NoError(t, err)
+		assert.Len(t, settled, 2)
+		settled, err = s.Reports.ListSettled(ctx, reportFrom, "2001-01-01", "")
+		require.NoError(t, err)
+		assert.Empty(t, settled)
+	})
+}
+
+func TestTaxStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		gymID := createGym(t, s, "Central")
+		otherGymID := createGym(t, s, "North")
+
+		def, err := s.Taxes.Create(ctx, &models.TaxRate{Name: "VAT", ProductType: models.ProductMembership, RateBP: 1200, Inclusive: true})
+		require.NoError(t, err)
+		local, err := s.Taxes.Create(ctx, &models.TaxRate{Name: "Local VAT", ProductType: models.ProductMembership, GymID: &gymID, RateBP: 1000})
+		require.NoError(t, err)
+
+		rate, err := s.Taxes.FindApplicable(ctx, models.ProductMembership, &gymID)
+		require.NoError(t, err)
+		assert.Equal(t, local.ID, rate.ID, "ставка зала важнее общей")
+		rate, err = s.Taxes.FindApplicable(ctx, models.ProductMembership, &otherGymID)
+		require.NoError(t, err)
+		assert.Equal(t, def.ID, rate.ID)
+		rate, err = s.Taxes.FindApplicable(ctx, models.ProductMembership, nil)
+		require.NoError(t, err)
+		assert.Equal(t, def.ID, rate.ID)
+		_, err = s.Taxes.FindApplicable(ctx, models.ProductMerchandise, &gymID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		rates, err := s.Taxes.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, rates, 2)
+		assert.Nil(t, rates[0].GymID, "общая ставка первой")
+
+		update := *local
+		update.RateBP = 800
+		require.NoError(t, s.Taxes.Update(ctx, local.ID, &update))
+		got, err := s.Taxes.GetByID(ctx, local.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 800, got.RateBP)
+
+		require.NoError(t, s.Taxes.Delete(ctx, local.ID))
+		_, err = s.Taxes.GetByID(ctx, local.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestCurrencyStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+
+		_, err := s.Currencies.SetRate(ctx, "USD", 450)
+		require.NoError(t, err)
+		rate, err := s.Currencies.SetRate(ctx, "USD", 470.5)
+		require.NoError(t, err)
+		assert.Equal(t, 470.5, rate.Rate)
+		_, err = s.Currencies.SetRate(ctx, "EUR", 510)
+		require.NoError(t, err)
+
+		rates, err := s.Currencies.ListRates(ctx)
+		require.NoError(t, err)
+		require.Len(t, rates, 2)
+		assert.Equal(t, "EUR", rates[0].Currency)
+
+		require.NoError(t, s.Currencies.DeleteRate(ctx, "USD"))
+		_, err = s.Currencies.GetRate(ctx, "USD")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestFiscalStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "buyer@example.com")
+		p, err := s.Payments.CreateStandalone(ctx, userID, 1000, "KZT", "card", "completed", "", "")
+		require.NoError(t, err)
+
+		require.NoError(t, s.Fiscal.Enqueue(ctx, p.ID))
+		require.NoError(t, s.Fiscal.Enqueue(ctx, p.ID), "повторная постановка игнорируется")
+
+		due, err := s.Fiscal.ClaimDue(ctx, time.Now(), 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		entry := due[0]
+		assert.Equal(t, p.ID, entry.PaymentID)
+		assert.Equal(t, "sending", entry.Status)
+
+		// Взятый чек не достаётся другому воркеру до истечения аренды
+		due, err = s.Fiscal.ClaimDue(ctx, time.Now(), 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, due)
+		due, err = s.Fiscal.ClaimDue(ctx, time.Now().Add(2*time.Minute), 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+
+		require.NoError(t, s.Fiscal.MarkFailed(ctx, entry.ID, "pending", "timeout", time.Now().Add(time.Hour)))
+		due, err = s.Fiscal.ClaimDue(ctx, time.Now(), 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, due, "повтор отложен")
+
+		require.NoError(t, s.Fiscal.Retry(ctx, entry.ID))
+		due, err = s.Fiscal.ClaimDue(ctx, time.Now().Add(time.Second), 10, time.Minute)
+		require.NoError(t, err)
+		assert.Len(t, due, 1)
+
+		require.NoError(t, s.Fiscal.MarkSent(ctx, entry.ID))
+		got, err := s.Fiscal.GetByID(ctx, entry.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "sent", got.Status)
+		assert.Equal(t, 2, got.Attempts)
+		assert.Empty(t, got.LastError)
+
+		// Отправленный чек в очередь не возвращается
+		require.NoError(t, s.Fiscal.Retry(ctx, entry.ID))
+		sent, err := s.Fiscal.List(ctx, "sent")
+		require.NoError(t, err)
+		assert.Len(t, sent, 1)
+		_, err = s.Fiscal.GetByID(ctx, 999)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestInstallmentStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "buyer@example.com")
+		m, err := s.Memberships.Create(ctx, "Year", 365, 30000)
+		require.NoError(t, err)
+
+		plan := &models.InstallmentPlan{UserID: userID, MembershipID: m.ID, TotalCents: 30000, Currency: "KZT", Method: "card",
+			InstallmentsCount: 3, Status: "active"}
+		for i, due := range []string{"2030-01-01", "2030-02-01", "2030-03-01"} {
+			plan.Installments = append(plan.Installments, models.Installment{Seq: i + 1, AmountCents: 10000, DueDate: due,
+				Status: "pending", NextAttemptDate: due})
+		}
+
+		// Дубликат номера платежа отменяет создание плана целиком
+		bad := *plan
+		bad.Installments = append(append([]models.Installment(nil), plan.Installments...), plan.Installments[0])
+		_, err = s.Installments.CreatePlan(ctx, &bad)
+		assert.Error(t, err)
+		plans, err := s.Installments.ListPlans(ctx, 0, "")
+		require.NoError(t, err)
+		assert.Empty(t, plans)
+
+		planID, err := s.Installments.CreatePlan(ctx, plan)
+		require.NoError(t, err)
+		got, err := s.Installments.GetPlan(ctx, planID)
+		require.NoError(t, err)
+		require.Len(t, got.Installments, 3)
+		assert.Equal(t, "2030-01-01", got.Installments[0].DueDate[:len("2006-01-02")], "драйвер SQLite может добавить время")
+		_, err = s.Installments.GetPlan(ctx, 999)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		due, err := s.Installments.ListDue(ctx, "2030-02-15")
+		require.NoError(t, err)
+		require.Len(t, due, 2)
+		first := due[0]
+
+		payment, err := s.Payments.CreateStandalone(ctx, userID, 10000, "KZT", "card", "completed", "", "")
+		require.NoError(t, err)
+		require.NoError(t, s.Installments.MarkPaid(ctx, first.ID, payment.ID))
+		require.NoError(t, s.Installments.MarkAttemptFailed(ctx, due[1].ID, "declined", "2030-02-20"))
+
+		list, err := s.Installments.ListInstallments(ctx, planID)
+		require.NoError(t, err)
+		assert.Equal(t, "paid", list[0].Status)
+		require.NotNil(t, list[0].PaymentID)
+		assert.Equal(t, payment.ID, *list[0].PaymentID)
+		assert.NotNil(t, list[0].PaidAt)
+		assert.Equal(t, 1, list[1].Attempts)
+		assert.Equal(t, "declined", list[1].LastError)
+		assert.Equal(t, "2030-02-20", list[1].NextAttemptDate[:len("2006-01-02")])
+
+		pending, overdue, err := s.Installments.CountPending(ctx, planID, "2030-02-15")
+		require.NoError(t, err)
+		assert.Equal(t, 2, pending)
+		assert.Equal(t, 1, overdue)
+
+		ids, err := s.Installments.ListOverduePlanIDs(ctx, "2030-02-15")
+		require.NoError(t, err)
+		assert.Equal(t, []int{planID}, ids)
+
+		// Платежи отменённого плана не списываются
+		require.NoError(t, s.Installments.SetPlanStatus(ctx, planID, "cancelled"))
+		due, err = s.Installments.ListDue(ctx, "2030-12-31")
+		require.NoError(t, err)
+		assert.Empty(t, due)
+
+		umID, err := s.Memberships.ActivateWithID(ctx, userID, m.ID, 365)
+		require.NoError(t, err)
+		require.NoError(t, s.Installments.SetUserMembership(ctx, planID, umID))
+		plans, err = s.Installments.ListPlans(ctx, userID, "cancelled")
+		require.NoError(t, err)
+		require.Len(t, plans, 1)
+		require.NotNil(t, plans[0].UserMembershipID)
+		assert.Equal(t, umID, *plans[0].UserMembershipID)
+		assert.Empty(t, plans[0].Installments)
+	})
+}
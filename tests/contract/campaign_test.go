@@ -0,0 +1,167 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// campaignAudience — пользователи для проверки сегментов: клиент зала с абонементом,
+// клиент другого зала без абонемента и пользователь без активности на английском
+type campaignAudience struct {
+	gymID, otherGymID, membershipID, classID int
+	member, visitor, idle                    int
+}
+
+func seedAudience(t *testing.T, s *testutils.Stores) campaignAudience {
+	ctx := context.Background()
+	a := campaignAudience{
+		gymID:      createGym(t, s, "Central"),
+		otherGymID: createGym(t, s, "North"),
+		member:     createUser(t, s, "member@example.com"),
+		visitor:    createUser(t, s, "visitor@example.com"),
+		idle:       createUser(t, s, "idle@example.com"),
+	}
+	require.NoError(t, s.Users.SetLocale(ctx, a.idle, "en"))
+
+	m, err := s.Memberships.Create(ctx, "Monthly", 30, 5000)
+	require.NoError(t, err)
+	a.membershipID = m.ID
+	_, err = s.Memberships.Activate(ctx, a.member, m.ID, 30)
+	require.NoError(t, err)
+
+	c, err := s.Classes.Create(ctx, &models.Class{Title: "Morning Yoga", GymID: a.gymID, StartTime: "2030-01-02 08:00:00", DurationMin: 60, Capacity: 10})
+	require.NoError(t, err)
+	a.classID = c.ID
+	_, err = s.Bookings.Create(ctx, a.member, c.ID)
+	require.NoError(t, err)
+
+	_, err = s.Payments.Create(ctx, &models.Payment{UserID: a.visitor, AmountCents: 500, Currency: "KZT", Method: "cash", Status: "completed", GymID: &a.otherGymID})
+	require.NoError(t, err)
+	return a
+}
+
+func previewIDs(t *testing.T, s *testutils.Stores, seg models.Segment) []int {
+	today := time.Now().Format("2006-01-02")
+	preview, err := s.Campaigns.PreviewSegment(context.Background(), seg, today, 10)
+	require.NoError(t, err)
+	ids := []int{}
+	for _, u := range preview.Sample {
+		ids = append(ids, u.ID)
+	}
+	assert.Equal(t, preview.Count, len(ids))
+	return ids
+}
+
+func TestCampaignStore_Segments(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		a := seedAudience(t, s)
+		yes, no := true, false
+		in30days := time.Now().AddDate(0, 0, 31).Format("2006-01-02")
+
+		assert.Equal(t, []int{a.member, a.visitor, a.idle}, previewIDs(t, s, models.Segment{}))
+		assert.Equal(t, []int{a.member}, previewIDs(t, s, models.Segment{GymIDs: []int{a.gymID}}))
+		assert.Equal(t, []int{a.visitor}, previewIDs(t, s, models.Segment{GymIDs: []int{a.otherGymID}}), "платёж в зале делает клиентом")
+		assert.Equal(t, []int{a.member}, previewIDs(t, s, models.Segment{ActiveMembership: &yes}))
+		assert.Equal(t, []int{a.visitor, a.idle}, previewIDs(t, s, models.Segment{ActiveMembership: &no}))
+		assert.Equal(t, []int{a.member}, previewIDs(t, s, models.Segment{MembershipIDs: []int{a.membershipID}, MembershipExpiresTo: in30days}))
+		assert.Equal(t, []int{}, previewIDs(t, s, models.Segment{MembershipExpiresFrom: in30days}))
+		assert.Equal(t, []int{a.member}, previewIDs(t, s, models.Segment{BookedClassTitle: "yoga"}), "поиск по названию без учёта регистра")
+		assert.Equal(t, []int{}, previewIDs(t, s, models.Segment{BookedClassTitle: "Boxing"}))
+		assert.Equal(t, []int{a.member}, previewIDs(t, s, models.Segment{BookedClassIDs: []int{a.classID}}))
+		assert.Equal(t, []int{a.idle}, previewIDs(t, s, models.Segment{Locales: []string{"en"}}))
+		assert.Equal(t, []int{a.visitor}, previewIDs(t, s, models.Segment{ActiveMembership: &no, Locales: []string{"ru"}}))
+
+		preview, err := s.Campaigns.PreviewSegment(context.Background(), models.Segment{}, time.Now().Format("2006-01-02"), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 3, preview.Count)
+		assert.Len(t, preview.Sample, 1)
+	})
+}
+
+func TestCampaignStore_Lifecycle(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		a := seedAudience(t, s)
+		now := time.Now()
+		today := now.Format("2006-01-02")
+
+		draft, err := s.Campaigns.Create(ctx, &models.Campaign{Name: "Draft", Title: "Hi", Body: "Body", Status: "draft", RatePerMinute: 60})
+		require.NoError(t, err)
+		assert.Empty(t, draft.ScheduledAt)
+		c, err := s.Campaigns.Create(ctx, &models.Campaign{Name: "Promo", Title: "Hi", Body: "Body", Status: "draft", RatePerMinute: 60,
+			Segment: models.Segment{Locales: []string{"ru"}}, CreatedBy: a.member})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ru"}, c.Segment.Locales)
+
+		// Планировать можно только черновик
+		past := now.Add(-time.Minute).UTC().Format("2006-01-02 15:04:05")
+		ok, err := s.Campaigns.SetStatus(ctx, c.ID, "scheduled", past, "draft")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = s.Campaigns.SetStatus(ctx, c.ID, "scheduled", past, "draft")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		due, err := s.Campaigns.ListDue(ctx, now)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, c.ID, due[0].ID)
+
+		recipients, started, err := s.Campaigns.Start(ctx, &due[0], today, now)
+		require.NoError(t, err)
+		assert.True(t, started)
+		assert.Equal(t, 2, recipients)
+		_, started, err = s.Campaigns.Start(ctx, &due[0], today, now)
+		require.NoError(t, err)
+		assert.False(t, started, "кампания запускается один раз")
+
+		sending, err := s.Campaigns.ListSending(ctx)
+		require.NoError(t, err)
+		require.Len(t, sending, 1)
+		assert.NotEmpty(t, sending[0].StartedAt)
+
+		pending, err := s.Campaigns.PendingRecipients(ctx, c.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 2)
+		assert.Equal(t, a.member, pending[0].ID)
+
+		notificationID := enqueue(t, s, pending[0].Email)
+		require.NoError(t, s.Campaigns.MarkRecipient(ctx, c.ID, a.member, "queued", notificationID))
+		finished, err := s.Campaigns.Finish(ctx, c.ID, now)
+		require.NoError(t, err)
+		assert.False(t, finished, "остались ожидающие получатели")
+		require.NoError(t, s.Campaigns.MarkRecipient(ctx, c.ID, a.visitor, "skipped", 0))
+
+		require.NoError(t, s.Notifications.MarkSent(ctx, notificationID))
+		opened, err := s.Campaigns.MarkOpened(ctx, c.ID, a.member, now)
+		require.NoError(t, err)
+		assert.True(t, opened)
+		opened, err = s.Campaigns.MarkOpened(ctx, c.ID, a.idle, now)
+		require.NoError(t, err)
+		assert.False(t, opened)
+
+		finished, err = s.Campaigns.Finish(ctx, c.ID, now)
+		require.NoError(t, err)
+		assert.True(t, finished)
+
+		stats, err := s.Campaigns.Stats(ctx, c.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.CampaignStats{Recipients: 2, Skipped: 1, Sent: 1, Opened: 1}, *stats)
+
+		sent, err := s.Campaigns.List(ctx, "sent")
+		require.NoError(t, err)
+		require.Len(t, sent, 1)
+		assert.NotEmpty(t, sent[0].FinishedAt)
+		all, err := s.Campaigns.List(ctx, "")
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, c.ID, all[0].ID, "новые первыми")
+	})
+}
@@ -0,0 +1,198 @@
+package contract
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGymStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+
+		g, err := s.Gyms.Create(ctx, "Central", "Main st. 1")
+		require.NoError(t, err)
+		assert.NotZero(t, g.ID)
+		assert.NotEmpty(t, g.CreatedAt)
+
+		require.NoError(t, s.Gyms.Update(ctx, g.ID, "Central 2", "Main st. 2"))
+		got, err := s.Gyms.GetByID(ctx, g.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Central 2", got.Name)
+		assert.Equal(t, "Main st. 2", got.Address)
+
+		createGym(t, s, "North")
+		gyms, err := s.Gyms.List(ctx)
+		require.NoError(t, err)
+		assert.Len(t, gyms, 2)
+
+		require.NoError(t, s.Gyms.Delete(ctx, g.ID))
+		_, err = s.Gyms.GetByID(ctx, g.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		// Обновление и удаление несуществующей записи — не ошибка
+		assert.NoError(t, s.Gyms.Update(ctx, g.ID, "Gone", "Gone"))
+		assert.NoError(t, s.Gyms.Delete(ctx, g.ID))
+	})
+}
+
+func TestTrainerStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+
+		tr, err := s.Trainers.Create(ctx, "Anna", "Yoga")
+		require.NoError(t, err)
+		require.NoError(t, s.Trainers.Update(ctx, tr.ID, "Anna K.", "Pilates"))
+
+		got, err := s.Trainers.GetByID(ctx, tr.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Anna K.", got.Name)
+		assert.Equal(t, "Pilates", got.Bio)
+
+		require.NoError(t, s.Trainers.Delete(ctx, tr.ID))
+		trainers, err := s.Trainers.List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, trainers)
+		_, err = s.Trainers.GetByID(ctx, tr.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestClassStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		gymID := createGym(t, s, "Central")
+		tr, err := s.Trainers.Create(ctx, "Anna", "Yoga")
+		require.NoError(t, err)
+
+		late, err := s.Classes.Create(ctx, &models.Class{Title: "Late", TrainerID: tr.ID, GymID: gymID, StartTime: "2030-01-02 18:00:00", DurationMin: 60, Capacity: 10})
+		require.NoError(t, err)
+		early, err := s.Classes.Create(ctx, &models.Class{Title: "Early", TrainerID: tr.ID, GymID: gymID, StartTime: "2030-01-02 08:00:00", DurationMin: 45, Capacity: 5})
+		require.NoError(t, err)
+
+		classes, err := s.Classes.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, classes, 2)
+		assert.Equal(t, early.ID, classes[0].ID, "занятия упорядочены по времени начала")
+
+		update := *late
+		update.Title, update.Capacity = "Later", 20
+		require.NoError(t, s.Classes.Update(ctx, late.ID, &update))
+		got, err := s.Classes.GetByID(ctx, late.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Later", got.Title)
+		assert.Equal(t, 20, got.Capacity)
+
+		userID := createUser(t, s, "member@example.com")
+		_, err = s.Bookings.Create(ctx, userID, late.ID)
+		require.NoError(t, err)
+		count, err := s.Classes.GetBookingCount(ctx, late.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		require.NoError(t, s.Classes.Delete(ctx, early.ID))
+		_, err = s.Classes.GetByID(ctx, early.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestMembershipStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "member@example.com")
+
+		m, err := s.Memberships.Create(ctx, "Monthly", 30, 5000)
+		require.NoError(t, err)
+		require.NoError(t, s.Memberships.Update(ctx, m.ID, "Monthly+", 31, 5500))
+		got, err := s.Memberships.GetByID(ctx, m.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Monthly+", got.Name)
+		assert.Equal(t, 31, got.DurationDays)
+		assert.Equal(t, 5500, got.PriceCents)
+
+		active, err := s.Memberships.HasActiveMembership(ctx, userID)
+		require.NoError(t, err)
+		assert.False(t, active)
+
+		um, err := s.Memberships.Activate(ctx, userID, m.ID, 30)
+		require.NoError(t, err)
+		assert.True(t, um.Active)
+		assert.NotEqual(t, um.StartDate, um.EndDate)
+		active, err = s.Memberships.HasActiveMembership(ctx, userID)
+		require.NoError(t, err)
+		assert.True(t, active)
+
+		require.NoError(t, s.Memberships.SetActive(ctx, um.ID, false))
+		active, err = s.Memberships.HasActiveMembership(ctx, userID)
+		require.NoError(t, err)
+		assert.False(t, active)
+
+		// Цены в валютах: повторная установка заменяет цену
+		_, err = s.Memberships.SetPrice(ctx, m.ID, "USD", 1000)
+		require.NoError(t, err)
+		price, err := s.Memberships.SetPrice(ctx, m.ID, "USD", 1200)
+		require.NoError(t, err)
+		assert.Equal(t, 1200, price.PriceCents)
+		_, err = s.Memberships.SetPrice(ctx, m.ID, "EUR", 1100)
+		require.NoError(t, err)
+
+		prices, err := s.Memberships.ListPrices(ctx, m.ID)
+		require.NoError(t, err)
+		require.Len(t, prices, 2)
+		assert.Equal(t, "EUR", prices[0].Currency)
+
+		require.NoError(t, s.Memberships.DeletePrice(ctx, m.ID, "USD"))
+		_, err = s.Memberships.GetPrice(ctx, m.ID, "USD")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		require.NoError(t, s.Memberships.Delete(ctx, m.ID))
+		_, err = s.Memberships.GetByID(ctx, m.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestBookingStore(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		gymID := createGym(t, s, "Central")
+		otherGymID := createGym(t, s, "North")
+		userID := createUser(t, s, "member@example.com")
+		c, err := s.Classes.Create(ctx, &models.Class{Title: "Yoga", GymID: gymID, StartTime: "2030-01-02 18:00:00", DurationMin: 60, Capacity: 10})
+		require.NoError(t, err)
+
+		id, err := s.Bookings.Create(ctx, userID, c.ID)
+		require.NoError(t, err)
+		exists, err := s.Bookings.Exists(ctx, userID, c.ID)
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		byGym, err := s.Bookings.ListByGym(ctx, gymID)
+		require.NoError(t, err)
+		assert.Len(t, byGym, 1)
+		byGym, err = s.Bookings.ListByGym(ctx, otherGymID)
+		require.NoError(t, err)
+		assert.Empty(t, byGym)
+
+		attendees, err := s.Bookings.ListAttendees(ctx, c.ID)
+		require.NoError(t, err)
+		require.Len(t, attendees, 1)
+		assert.Equal(t, "member@example.com", attendees[0].Email)
+		assert.Empty(t, attendees[0].PasswordHash)
+
+		// Чужое бронирование не отменяется
+		require.NoError(t, s.Bookings.Cancel(ctx, int(id), userID+1))
+		mine, err := s.Bookings.GetByUser(ctx, userID)
+		require.NoError(t, err)
+		assert.Len(t, mine, 1)
+
+		require.NoError(t, s.Bookings.Cancel(ctx, int(id), userID))
+		mine, err = s.Bookings.GetByUser(ctx, userID)
+		require.NoError(t, err)
+		assert.Empty(t, mine)
+	})
+}
@@ -0,0 +1,34 @@
+// Package contract проверяет, что SQLite-репозитории и хранилища в памяти ведут себя одинаково:
+// каждый тест выполняется для обеих реализаций.
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/require"
+)
+
+// run выполняет тест для каждой реализации хранилищ на чистых данных
+func run(t *testing.T, test func(t *testing.T, s *testutils.Stores)) {
+	t.Run("sqlite", func(t *testing.T) {
+		test(t, testutils.NewSQLiteStores(t))
+	})
+	t.Run("memory", func(t *testing.T) {
+		test(t, testutils.NewMemoryStores())
+	})
+}
+
+func createUser(t *testing.T, s *testutils.Stores, email string) int {
+	u, err := s.Users.Create(context.Background(), "User "+email, email, "hash", false)
+	require.NoError(t, err)
+	return u.ID
+}
+
+func createGym(t *testing.T, s *testutils.Stores, name string) int {
+	g, err := s.Gyms.Create(context.Background(), name, "Address")
+	require.NoError(t, err)
+	return g.ID
+}
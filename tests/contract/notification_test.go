@@ -0,0 +1,181 @@
+package contract
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func enqueue(t *testing.T, s *testutils.Stores, recipient string) int {
+	id, err := s.Notifications.Enqueue(context.Background(), &models.NotificationOutboxEntry{
+		Channel: "email", Recipient: recipient, Template: "welcome", Locale: "ru", Subject: "Hi", TextBody: "text", Body: "<p>html</p>",
+	})
+	require.NoError(t, err)
+	return id
+}
+
+func TestNotificationStore_Outbox(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		now := time.Now()
+
+		first := enqueue(t, s, "a@example.com")
+		second := enqueue(t, s, "b@example.com")
+		later, err := s.Notifications.Enqueue(ctx, &models.NotificationOutboxEntry{Channel: "email", Recipient: "c@example.com",
+			NextAttemptAt: now.Add(time.Hour).UTC().Format("2006-01-02 15:04:05")})
+		require.NoError(t, err)
+
+		claimed, err := s.Notifications.ClaimDue(ctx, now.Add(time.Second), 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 2)
+		assert.Equal(t, first, claimed[0].ID)
+		assert.Equal(t, "sending", claimed[0].Status)
+		assert.Equal(t, "text", claimed[0].TextBody)
+
+		// Забранные письма не выдаются повторно, пока не истечёт аренда
+		claimed, err = s.Notifications.ClaimDue(ctx, now.Add(time.Second), 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+
+		require.NoError(t, s.Notifications.MarkSent(ctx, first))
+		sent, err := s.Notifications.GetByID(ctx, first)
+		require.NoError(t, err)
+		assert.Equal(t, "sent", sent.Status)
+		assert.Equal(t, 1, sent.Attempts)
+		assert.Empty(t, sent.Body, "текст отправленного письма стирается")
+		assert.NotNil(t, sent.SentAt)
+
+		// Зависшее письмо забирается после истечения аренды
+		claimed, err = s.Notifications.ClaimDue(ctx, now.Add(2*time.Minute), 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, second, claimed[0].ID)
+
+		require.NoError(t, s.Notifications.MarkFailed(ctx, second, "dead", "bounced", now))
+		dead, err := s.Notifications.List(ctx, "dead")
+		require.NoError(t, err)
+		require.Len(t, dead, 1)
+		assert.Equal(t, "bounced", dead[0].LastError)
+
+		ok, err := s.Notifications.Resend(ctx, second)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = s.Notifications.Resend(ctx, first)
+		require.NoError(t, err)
+		assert.False(t, ok, "отправленное письмо не переотправляется")
+		resent, err := s.Notifications.GetByID(ctx, second)
+		require.NoError(t, err)
+		assert.Equal(t, "pending", resent.Status)
+		assert.Zero(t, resent.Attempts)
+
+		require.NoError(t, s.Notifications.Fallback(ctx, later, "sms", "+77001234567", "", "no email", now))
+		switched, err := s.Notifications.GetByID(ctx, later)
+		require.NoError(t, err)
+		assert.Equal(t, "sms", switched.Channel)
+		assert.Equal(t, "+77001234567", switched.Recipient)
+		assert.Equal(t, "pending", switched.Status)
+
+		all, err := s.Notifications.List(ctx, "")
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+		assert.Equal(t, later, all[0].ID, "новые первыми")
+		_, err = s.Notifications.GetByID(ctx, 999)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestNotificationStore_ClaimLimit(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		for i := 0; i < 3; i++ {
+			enqueue(t, s, "a@example.com")
+		}
+		claimed, err := s.Notifications.ClaimDue(context.Background(), time.Now().Add(time.Second), 2, time.Minute)
+		require.NoError(t, err)
+		assert.Len(t, claimed, 2)
+	})
+}
+
+func TestNotificationStore_Settings(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		userID := createUser(t, s, "ivan@example.com")
+
+		settings, err := s.Notifications.GetSettings(ctx, userID)
+		require.NoError(t, err)
+		assert.Empty(t, settings.Timezone)
+
+		require.NoError(t, s.Notifications.SaveSettings(ctx, userID, "Asia/Almaty", "22:00", "08:00"))
+		require.NoError(t, s.Notifications.SaveSettings(ctx, userID, "Asia/Almaty", "", ""))
+		settings, err = s.Notifications.GetSettings(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, "Asia/Almaty", settings.Timezone)
+		assert.Empty(t, settings.QuietHoursStart)
+
+		require.NoError(t, s.Notifications.SetPreferences(ctx, userID, []models.NotificationPreference{
+			{Category: "marketing", Channel: "email", Enabled: true},
+			{Category: "marketing", Channel: "sms", Enabled: false},
+		}))
+		require.NoError(t, s.Notifications.SetPreferences(ctx, userID, []models.NotificationPreference{
+			{Category: "marketing", Channel: "email", Enabled: false},
+		}))
+		prefs, err := s.Notifications.ListPreferences(ctx, userID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.NotificationPreference{
+			{Category: "marketing", Channel: "email", Enabled: false},
+			{Category: "marketing", Channel: "sms", Enabled: false},
+		}, prefs)
+	})
+}
+
+func TestBookingStore_Reminders(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+		now := time.Now()
+		gymID := createGym(t, s, "Central")
+		userID := createUser(t, s, "member@example.com")
+
+		start := now.Add(time.Hour).UTC().Format("2006-01-02 15:04:05")
+		soon, err := s.Classes.Create(ctx, &models.Class{Title: "Yoga", GymID: gymID, StartTime: start, DurationMin: 60, Capacity: 10})
+		require.NoError(t, err)
+		farStart := now.Add(48 * time.Hour).UTC().Format("2006-01-02 15:04:05")
+		far, err := s.Classes.Create(ctx, &models.Class{Title: "Boxing", GymID: gymID, StartTime: farStart, DurationMin: 60, Capacity: 10})
+		require.NoError(t, err)
+		bookingID, err := s.Bookings.Create(ctx, userID, soon.ID)
+		require.NoError(t, err)
+		_, err = s.Bookings.Create(ctx, userID, far.ID)
+		require.NoError(t, err)
+
+		due, err := s.Bookings.ListDueReminders(ctx, now, now.Add(2*time.Hour), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, int(bookingID), due[0].BookingID)
+		assert.WithinDuration(t, now.Add(time.Hour), due[0].StartsAt, time.Second)
+		assert.Equal(t, "Yoga", due[0].ClassTitle)
+		assert.Equal(t, "member@example.com", due[0].User.Email)
+
+		require.NoError(t, s.Bookings.MarkReminded(ctx, int(bookingID), due[0].StartTime))
+		due, err = s.Bookings.ListDueReminders(ctx, now, now.Add(2*time.Hour), 10)
+		require.NoError(t, err)
+		assert.Empty(t, due)
+
+		// После переноса занятия напоминание отправляется снова
+		moved := *soon
+		moved.StartTime = now.Add(90 * time.Minute).UTC().Format("2006-01-02 15:04:05")
+		require.NoError(t, s.Classes.Update(ctx, soon.ID, &moved))
+		due, err = s.Bookings.ListDueReminders(ctx, now, now.Add(2*time.Hour), 10)
+		require.NoError(t, err)
+		assert.Len(t, due, 1)
+
+		require.NoError(t, s.Bookings.DeleteByClass(ctx, soon.ID))
+		count, err := s.Classes.GetBookingCount(ctx, soon.ID)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,75 @@
+package contract
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactor(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx := context.Background()
+
+		// Ошибка откатывает все изменения транзакции
+		failed := errors.New("second step failed")
+		err := s.UoW.Do(ctx, func(tx *sql.Tx) error {
+			g, err := s.Gyms.WithTx(tx).Create(ctx, "First", "Address")
+			if err != nil {
+				return err
+			}
+			if _, err := s.Payments.WithTx(tx).CreateStandalone(ctx, 1, 100, "KZT", "card", "completed", "", ""); err != nil {
+				return err
+			}
+			if err := s.Gyms.WithTx(tx).Update(ctx, g.ID, "Renamed", "Address"); err != nil {
+				return err
+			}
+			return failed
+		})
+		assert.ErrorIs(t, err, failed)
+
+		gyms, err := s.Gyms.List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, gyms)
+		payments, err := s.Payments.ListAll(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, payments)
+
+		err = s.UoW.Do(ctx, func(tx *sql.Tx) error {
+			for _, name := range []string{"First", "Second"} {
+				if _, err := s.Gyms.WithTx(tx).Create(ctx, name, "Address"); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		gyms, err = s.Gyms.List(ctx)
+		require.NoError(t, err)
+		assert.Len(t, gyms, 2)
+	})
+}
+
+func TestTransactor_CanceledContext(t *testing.T) {
+	run(t, func(t *testing.T, s *testutils.Stores) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		called := false
+		err := s.UoW.Do(ctx, func(tx *sql.Tx) error {
+			called = true
+			return nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, called)
+
+		// Хранилища тоже не выполняют запросы с отменённым контекстом
+		_, err = s.Gyms.Create(ctx, "Gym", "Address")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -22,8 +22,8 @@ func TestUserHandler_ListAndDelete(t *testing.T) {
 	adminToken := registerAndLoginAdminUser(t, r, db)
 
 	// Создаем несколько пользователей
-	testutils.CreateTestUser(t, db, "user1@test.com", "password123", false)
-	testutils.CreateTestUser(t, db, "user2@test.com", "password123", false)
+	testutils.CreateTestUser(t, db, "user1@test.com", "Str0ng-Passw0rd", false)
+	testutils.CreateTestUser(t, db, "user2@test.com", "Str0ng-Passw0rd", false)
 
 	// Получение списка пользователей (админ)
 	w := httptest.NewRecorder()
@@ -101,6 +101,7 @@ func TestMembershipHandler_Buy(t *testing.T) {
 	// Покупка membership
 	buyData := map[string]interface{}{
 		"membership_id": 1,
+		"method":        "card",
 	}
 	jsonData, _ = json.Marshal(buyData)
 	w = httptest.NewRecorder()
@@ -109,8 +110,7 @@ func TestMembershipHandler_Buy(t *testing.T) {
 	req.Header.Set("Authorization", "Bearer "+userToken)
 	r.ServeHTTP(w, req)
 
-	// Может быть ошибка 400 если чего-то не хватает, 500 если транзакции не поддерживаются
-	assert.Contains(t, []int{http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError}, w.Code)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
 }
 
 func TestClassHandler_Update(t *testing.T) {
@@ -147,11 +147,11 @@ func TestClassHandler_Update(t *testing.T) {
 
 // Хелперы
 func registerAndLoginAdminUser(t *testing.T, r *gin.Engine, db *sql.DB) string {
-	testutils.CreateTestUser(t, db, "admin123@test.com", "password123", true)
+	testutils.CreateTestUser(t, db, "admin123@test.com", "Str0ng-Passw0rd", true)
 
 	loginData := map[string]string{
 		"email":    "admin123@test.com",
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonData, _ := json.Marshal(loginData)
 	w := httptest.NewRecorder()
@@ -165,11 +165,11 @@ func registerAndLoginAdminUser(t *testing.T, r *gin.Engine, db *sql.DB) string {
 }
 
 func registerAndLoginUserWithDB(t *testing.T, r *gin.Engine, db *sql.DB, email string) string {
-	testutils.CreateTestUser(t, db, email, "password123", false)
+	testutils.CreateTestUser(t, db, email, "Str0ng-Passw0rd", false)
 
 	loginData := map[string]string{
 		"email":    email,
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonData, _ := json.Marshal(loginData)
 	w := httptest.NewRecorder()
@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func doWithAPIKey(r *gin.Engine, method, path, key string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("X-API-Key", key)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeys_ScopedAccessAndRevocation(t *testing.T) {
+	r, db := setupTestRouter(t)
+	adminToken := registerAndLoginAdmin(t, r, db)
+	gymA := testutils.CreateTestGym(t, db, "Gym A", "Address A")
+	gymB := testutils.CreateTestGym(t, db, "Gym B", "Address B")
+
+	// Неизвестный scope и управление ключами ключу не выдаются
+	w := doJSON(r, "POST", "/api/admin/api-keys", adminToken, map[string]interface{}{"name": "bad", "scopes": []string{"api_keys.manage"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(r, "POST", "/api/admin/api-keys", adminToken, map[string]interface{}{
+		"name": "turnstile", "scopes": []string{"bookings.view"}, "gym_id": gymA,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		ID     int    `json:"id"`
+		Key    string `json:"key"`
+		Prefix string `json:"prefix"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Contains(t, created.Key, created.Prefix)
+
+	// Открытый ключ в БД не хранится
+	var stored string
+	require.NoError(t, db.QueryRow(`SELECT key_hash FROM api_keys WHERE id = ?`, created.ID).Scan(&stored))
+	assert.NotContains(t, created.Key, stored)
+
+	// Ключ действует только в своём зале и в пределах своих scopes
+	w = doWithAPIKey(r, "GET", fmt.Sprintf("/api/admin/bookings?gym_id=%d", gymA), created.Key)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doWithAPIKey(r, "GET", fmt.Sprintf("/api/admin/bookings?gym_id=%d", gymB), created.Key)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doWithAPIKey(r, "GET", "/api/admin/payments/summary", created.Key)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doWithAPIKey(r, "GET", "/api/admin/api-keys", created.Key)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Заголовок Authorization: ApiKey тоже принимается
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/admin/bookings?gym_id=%d", gymA), nil)
+	req.Header.Set("Authorization", "ApiKey "+created.Key)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Время последнего использования видно в списке
+	w = doJSON(r, "GET", "/api/admin/api-keys", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var keys []struct {
+		ID         int        `json:"id"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &keys))
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt)
+	assert.NotContains(t, w.Body.String(), created.Key)
+
+	// После отзыва ключ сразу отклоняется
+	w = doJSON(r, "DELETE", fmt.Sprintf("/api/admin/api-keys/%d", created.ID), adminToken, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = doWithAPIKey(r, "GET", fmt.Sprintf("/api/admin/bookings?gym_id=%d", gymA), created.Key)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON(r, "DELETE", fmt.Sprintf("/api/admin/api-keys/%d", created.ID), adminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAPIKeys_InvalidKeyRejected(t *testing.T) {
+	r, _ := setupTestRouter(t)
+
+	w := doWithAPIKey(r, "GET", "/api/admin/bookings", "gsk_0000000000000000_nope")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doWithAPIKey(r, "GET", "/api/admin/bookings", "garbage")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
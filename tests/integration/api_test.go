@@ -7,10 +7,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
-	"Gym_StrongCode/config"
+	"Gym_StrongCode/internal/cache"
 	"Gym_StrongCode/internal/handler"
 	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/models"
 	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/internal/service"
 	"Gym_StrongCode/tests/testutils"
@@ -26,39 +29,68 @@ func setupTestRouter(t *testing.T) (*gin.Engine, *sql.DB) {
 
 	// Репозитории
 	userRepo := repository.NewUserRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
 	gymRepo := repository.NewGymRepository(db)
 	membershipRepo := repository.NewMembershipRepository(db)
 	trainerRepo := repository.NewTrainerRepository(db)
 	classRepo := repository.NewClassRepository(db)
 	bookingRepo := repository.NewBookingRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
+	currencyRepo := repository.NewCurrencyRepository(db)
+	taxRepo := repository.NewTaxRepository(db)
+	fiscalRepo := repository.NewFiscalRepository(db)
+	installmentRepo := repository.NewInstallmentRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
 
 	// Сервисы
-	cfg := &config.Config{
-		JWTSecret: "test-secret-key",
-		SMTPHost:  "",
-	}
-	authService := service.NewAuthService(userRepo, cfg.JWTSecret)
-	notificationService := service.NewNotificationService(cfg)
+	keyService := service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0)
+	authService := service.NewAuthService(userRepo, tokenRepo, repository.NewUnitOfWork(db), keyService, nil, 0, 0)
+	notificationService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), notification.NewUnsubscribeLinks("http://localhost/api/notifications/unsubscribe", "test-secret"), 0)
+	accountService := service.NewAccountService(userRepo, tokenRepo, repository.NewUnitOfWork(db), authService, nil, notificationService, "http://localhost", 0, 0)
+	currencyService := service.NewCurrencyService(currencyRepo, "KZT")
+	taxService := service.NewTaxService(taxRepo, paymentRepo)
+	fiscalService := service.NewFiscalService(fiscalRepo, paymentRepo, nil)
 	gymService := service.NewGymService(gymRepo)
-	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, db, notificationService)
+	alertRules, _ := notification.ParseAlertRules("", "alerts@test.com")
+	adminAlerts := service.NewAdminAlerts(notificationService, currencyService, alertRules, 1000000)
+	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, repository.NewUnitOfWork(db), notificationService, currencyService, taxService, fiscalService, adminAlerts)
 	trainerService := service.NewTrainerService(trainerRepo)
-	classService := service.NewClassService(classRepo, trainerRepo, gymRepo)
-	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, notificationService)
-	paymentService := service.NewPaymentService(paymentRepo)
+	classService := service.NewClassService(classRepo, trainerRepo, gymRepo, bookingRepo, repository.NewUnitOfWork(db), notificationService)
+	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, repository.NewUnitOfWork(db), notificationService, adminAlerts)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, adminAlerts)
+	reportService := service.NewReportService(reportRepo, paymentRepo)
+	roleService := service.NewRoleService(roleRepo, userRepo)
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+	twoFactorService := service.NewTwoFactorService(userRepo, tokenRepo, repository.NewUnitOfWork(db))
+	installmentService := service.NewInstallmentService(installmentRepo, membershipRepo, repository.NewUnitOfWork(db), membershipService, paymentService, adminAlerts, 3)
 
 	// Хендлеры
-	authHandler := handler.NewAuthHandler(authService)
+	authHandler := handler.NewAuthHandler(authService, accountService, service.NewLoginGuard(cache.NewMemoryLoginAttemptStore(), userRepo, notificationService, 0, 0, 0, 0))
 	userHandler := handler.NewUserHandler(userRepo)
 	gymHandler := handler.NewGymHandler(gymService)
-	membershipHandler := handler.NewMembershipHandler(membershipService)
+	membershipHandler := handler.NewMembershipHandler(membershipService, userRepo)
 	trainerHandler := handler.NewTrainerHandler(trainerService)
 	classHandler := handler.NewClassHandler(classService)
 	bookingHandler := handler.NewBookingHandler(bookingService, userRepo)
 	paymentHandler := handler.NewPaymentHandler(paymentService)
+	currencyHandler := handler.NewCurrencyHandler(currencyService)
+	taxHandler := handler.NewTaxHandler(taxService)
+	fiscalHandler := handler.NewFiscalHandler(fiscalService)
+	installmentHandler := handler.NewInstallmentHandler(installmentService)
+	reportHandler := handler.NewReportHandler(reportService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	campaignService := service.NewCampaignService(repository.NewCampaignRepository(db), repository.NewUnitOfWork(db), notificationService, notification.NewOpenTracker("http://localhost/api/notifications/open", "test-secret"))
+	campaignHandler := handler.NewCampaignHandler(campaignService)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
 
 	// Роутер
 	r := gin.Default()
+	routeTimeouts, _ := middleware.ParseRouteTimeouts("/api/admin/reports/*=60s", 30*time.Second)
+	r.Use(middleware.RequestTimeout(routeTimeouts))
+	r.GET("/.well-known/jwks.json", handler.NewJWKSHandler(keyService).JWKS)
 
 	api := r.Group("/api")
 	{
@@ -66,54 +98,143 @@ func setupTestRouter(t *testing.T) (*gin.Engine, *sql.DB) {
 
 		// Публичные
 		api.POST("/users/register", authHandler.Register)
+		api.POST("/users/forgot-password", authHandler.ForgotPassword)
+		api.POST("/users/reset-password", authHandler.ResetPassword)
+		api.POST("/users/verify-email", authHandler.VerifyEmail)
+		api.GET("/notifications/unsubscribe", notificationHandler.UnsubscribeInfo)
+		api.POST("/notifications/unsubscribe", notificationHandler.Unsubscribe)
+		api.GET("/notifications/open", campaignHandler.TrackOpen)
 		api.POST("/users/login", authHandler.Login)
+		api.POST("/users/login/2fa", authHandler.LoginMFA)
+		api.POST("/users/refresh", authHandler.Refresh)
 		api.GET("/classes", classHandler.List)
 		api.GET("/gyms", gymHandler.List)
 		api.GET("/memberships", membershipHandler.List)
+		api.GET("/memberships/:id/prices", membershipHandler.ListPrices)
 		api.GET("/trainers", trainerHandler.List)
 
 		// Авторизованные
 		authorized := api.Group("")
-		authorized.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+		authorized.Use(middleware.AuthMiddleware(keyService, authService))
 		{
+			authorized.POST("/users/logout", authHandler.Logout)
+			authorized.POST("/users/logout-all", authHandler.LogoutAll)
+			authorized.PUT("/me/password", authHandler.ChangePassword)
+			authorized.POST("/users/verify-email/resend", authHandler.ResendVerification)
+
+			authorized.POST("/me/2fa/setup", twoFactorHandler.Setup)
+			authorized.POST("/me/2fa/enable", twoFactorHandler.Enable)
+			authorized.POST("/me/2fa/disable", twoFactorHandler.Disable)
+			authorized.POST("/me/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+
 			authorized.GET("/me", userHandler.GetCurrent)
+			authorized.PUT("/me", userHandler.Update)
+			authorized.PUT("/me/push-token", userHandler.SetPushToken)
+			authorized.DELETE("/me/push-token", userHandler.DeletePushToken)
+			authorized.GET("/me/notifications", notificationHandler.GetPreferences)
+			authorized.PUT("/me/notifications", notificationHandler.UpdatePreferences)
 
 			authorized.POST("/bookings", bookingHandler.Create)
 			authorized.GET("/bookings", bookingHandler.ListUser)
 			authorized.DELETE("/bookings/:id", bookingHandler.Cancel)
 
 			authorized.POST("/memberships/buy", membershipHandler.Buy)
+			authorized.POST("/memberships/installments", installmentHandler.Create)
+			authorized.GET("/installment-plans", installmentHandler.ListMine)
+			authorized.GET("/installment-plans/:id", installmentHandler.GetMine)
 
 			authorized.POST("/payments", paymentHandler.CreateStandalone)
 		}
 
 		// Админские
 		admin := api.Group("/admin")
-		admin.Use(middleware.AuthMiddleware(cfg.JWTSecret))
-		admin.Use(middleware.AdminOnly())
+		admin.Use(middleware.AllowAPIKeys(apiKeyService, middleware.AuthMiddleware(keyService, authService)))
 		{
-			admin.GET("/users", userHandler.List)
-			admin.DELETE("/users/:id", userHandler.Delete)
-
-			admin.POST("/gyms", gymHandler.Create)
-			admin.PUT("/gyms/:id", gymHandler.Update)
-			admin.DELETE("/gyms/:id", gymHandler.Delete)
-
-			admin.POST("/trainers", trainerHandler.Create)
-			admin.PUT("/trainers/:id", trainerHandler.Update)
-			admin.DELETE("/trainers/:id", trainerHandler.Delete)
-
-			admin.POST("/classes", classHandler.Create)
-			admin.PUT("/classes/:id", classHandler.Update)
-			admin.DELETE("/classes/:id", classHandler.Delete)
-
-			admin.GET("/bookings", bookingHandler.ListAll)
-
-			admin.POST("/memberships", membershipHandler.Create)
-			admin.PUT("/memberships/:id", membershipHandler.Update)
-			admin.DELETE("/memberships/:id", membershipHandler.Delete)
-
-			admin.GET("/payments", paymentHandler.ListAll)
+			// Users & roles
+			users := admin.Group("/users")
+			users.GET("", middleware.RequirePermission(roleService, models.PermUsersView), userHandler.List)
+			users.DELETE("/:id", middleware.RequirePermission(roleService, models.PermUsersManage), userHandler.Delete)
+			users.POST("/:id/unlock", middleware.RequirePermission(roleService, models.PermUsersManage), authHandler.UnlockLogin)
+			users.GET("/:id/roles", middleware.RequirePermission(roleService, models.PermRolesManage), roleHandler.List)
+			users.POST("/:id/roles", middleware.RequireGymPermission(roleService, models.PermRolesManage, middleware.GymFromJSONBody("gym_id")), roleHandler.Assign)
+			users.DELETE("/:id/roles/:role_id", middleware.RequireGymPermission(roleService, models.PermRolesManage, middleware.GymFromLookup("role_id", roleService.GymOf)), roleHandler.Revoke)
+
+			// API keys
+			apiKeys := admin.Group("/api-keys", middleware.RequirePermission(roleService, models.PermAPIKeysManage))
+			apiKeys.POST("", apiKeyHandler.Create)
+			apiKeys.GET("", apiKeyHandler.List)
+			apiKeys.DELETE("/:id", apiKeyHandler.Revoke)
+
+			// Gyms
+			gymsManage := middleware.RequirePermission(roleService, models.PermGymsManage)
+			admin.POST("/gyms", gymsManage, gymHandler.Create)
+			admin.PUT("/gyms/:id", middleware.RequireGymPermission(roleService, models.PermGymsManage, middleware.GymFromParam("id")), gymHandler.Update)
+			admin.DELETE("/gyms/:id", gymsManage, gymHandler.Delete)
+
+			// Memberships, currencies, taxes
+			catalog := admin.Group("", middleware.RequirePermission(roleService, models.PermCatalogManage))
+			catalog.POST("/memberships", membershipHandler.Create)
+			catalog.PUT("/memberships/:id", membershipHandler.Update)
+			catalog.DELETE("/memberships/:id", membershipHandler.Delete)
+			catalog.PUT("/memberships/:id/prices/:currency", membershipHandler.SetPrice)
+			catalog.DELETE("/memberships/:id/prices/:currency", membershipHandler.DeletePrice)
+			catalog.GET("/exchange-rates", currencyHandler.ListRates)
+			catalog.PUT("/exchange-rates/:currency", currencyHandler.SetRate)
+			catalog.DELETE("/exchange-rates/:currency", currencyHandler.DeleteRate)
+			catalog.GET("/tax-rates", taxHandler.List)
+			catalog.POST("/tax-rates", taxHandler.Create)
+			catalog.PUT("/tax-rates/:id", taxHandler.Update)
+			catalog.DELETE("/tax-rates/:id", taxHandler.Delete)
+
+			// Trainers
+			trainers := admin.Group("/trainers", middleware.RequirePermission(roleService, models.PermTrainersManage))
+			trainers.POST("", trainerHandler.Create)
+			trainers.PUT("/:id", trainerHandler.Update)
+			trainers.DELETE("/:id", trainerHandler.Delete)
+
+			// Classes
+			classGym := middleware.RequireGymPermission(roleService, models.PermClassesManage, middleware.GymFromLookup("id", classService.GymOf))
+			// Перенос занятия в другой зал требует прав и в новом зале
+			classTargetGym := middleware.RequireGymPermission(roleService, models.PermClassesManage, middleware.GymFromJSONBody("gym_id"))
+			admin.POST("/classes", middleware.RequireGymPermission(roleService, models.PermClassesManage, middleware.GymFromJSONBody("gym_id")), classHandler.Create)
+			admin.PUT("/classes/:id", classGym, classTargetGym, classHandler.Update)
+			admin.DELETE("/classes/:id", classGym, classHandler.Delete)
+
+			// Payments & Bookings
+			admin.GET("/payments", middleware.RequireGymPermission(roleService, models.PermPaymentsView, middleware.GymFromQuery("gym_id")), paymentHandler.ListAll)
+			admin.GET("/payments/summary", middleware.RequirePermission(roleService, models.PermPaymentsView), paymentHandler.Summary)
+			admin.POST("/payments/:id/refund", middleware.RequireGymPermission(roleService, models.PermPaymentsRefund, middleware.GymFromLookup("id", paymentService.GymOf)), paymentHandler.Refund)
+			admin.GET("/bookings", middleware.RequireGymPermission(roleService, models.PermBookingsView, middleware.GymFromQuery("gym_id")), bookingHandler.ListAll)
+
+			// Reports
+			reports := admin.Group("/reports", middleware.RequirePermission(roleService, models.PermReportsView))
+			reports.GET("/tax", taxHandler.Summary)
+			reports.GET("/revenue", reportHandler.Revenue)
+			reports.POST("/reconciliation", reportHandler.Reconcile)
+
+			// Installments
+			installments := admin.Group("/installment-plans", middleware.RequirePermission(roleService, models.PermInstallmentsView))
+			installments.GET("", installmentHandler.ListAll)
+			installments.GET("/:id", installmentHandler.Get)
+
+			// Fiscal receipts
+			outbox := admin.Group("/fiscal/outbox", middleware.RequirePermission(roleService, models.PermFiscalManage))
+			outbox.GET("", fiscalHandler.List)
+			outbox.POST("/:id/retry", fiscalHandler.Retry)
+
+			notifications := admin.Group("/notifications", middleware.RequirePermission(roleService, models.PermNotificationsManage))
+			notifications.GET("/outbox", notificationHandler.List)
+			notifications.POST("/outbox/:id/resend", notificationHandler.Resend)
+			notifications.GET("/templates", notificationHandler.ListTemplates)
+			notifications.POST("/templates/:id/preview", notificationHandler.PreviewTemplate)
+
+			campaigns := admin.Group("/campaigns", middleware.RequirePermission(roleService, models.PermCampaignsManage))
+			campaigns.POST("/segment-preview", campaignHandler.PreviewSegment)
+			campaigns.GET("", campaignHandler.List)
+			campaigns.POST("", campaignHandler.Create)
+			campaigns.GET("/:id", campaignHandler.Get)
+			campaigns.POST("/:id/schedule", campaignHandler.Schedule)
+			campaigns.POST("/:id/cancel", campaignHandler.Cancel)
 		}
 	}
 
@@ -138,7 +259,7 @@ func TestRegisterAndLogin(t *testing.T) {
 	registerBody := map[string]string{
 		"name":     "Test User",
 		"email":    "test@example.com",
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonBody, _ := json.Marshal(registerBody)
 	w := httptest.NewRecorder()
@@ -157,7 +278,7 @@ func TestRegisterAndLogin(t *testing.T) {
 	// 2. Логин
 	loginBody := map[string]string{
 		"email":    "test@example.com",
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonBody, _ = json.Marshal(loginBody)
 	w = httptest.NewRecorder()
@@ -172,13 +293,52 @@ func TestRegisterAndLogin(t *testing.T) {
 	assert.NotEmpty(t, loginResp["token"])
 }
 
+func TestLogout_RevokesAccessToken(t *testing.T) {
+	r, db := setupTestRouter(t)
+	testutils.CreateTestUser(t, db, "user@example.com", "Str0ng-Passw0rd", false)
+
+	jsonBody, _ := json.Marshal(map[string]string{"email": "user@example.com", "password": "Str0ng-Passw0rd"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/users/login", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var tokens map[string]string
+	json.Unmarshal(w.Body.Bytes(), &tokens)
+	require.NotEmpty(t, tokens["refresh_token"])
+
+	jsonBody, _ = json.Marshal(map[string]string{"refresh_token": tokens["refresh_token"]})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/users/logout", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+tokens["token"])
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Токен после выхода не принимается
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens["token"])
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Refresh-токен этого входа тоже отозван
+	jsonBody, _ = json.Marshal(map[string]string{"refresh_token": tokens["refresh_token"]})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/users/refresh", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
 func TestRegister_DuplicateEmail(t *testing.T) {
 	r, _ := setupTestRouter(t)
 
 	registerBody := map[string]string{
 		"name":     "Test User",
 		"email":    "duplicate@example.com",
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 
 	// Первая регистрация
@@ -222,12 +382,12 @@ func TestGetCurrentUser(t *testing.T) {
 	r, db := setupTestRouter(t)
 
 	// Создаем пользователя и получаем токен
-	testutils.CreateTestUser(t, db, "user@example.com", "password123", false)
+	testutils.CreateTestUser(t, db, "user@example.com", "Str0ng-Passw0rd", false)
 
 	// Логинимся
 	loginBody := map[string]string{
 		"email":    "user@example.com",
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonBody, _ := json.Marshal(loginBody)
 	w := httptest.NewRecorder()
@@ -25,7 +25,7 @@ func TestAuthFlow_Old(t *testing.T) {
 	registerBody := map[string]string{
 		"name":     "Test User",
 		"email":    "test@example.com",
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonBody, _ := json.Marshal(registerBody)
 	w := httptest.NewRecorder()
@@ -38,7 +38,7 @@ func TestAuthFlow_Old(t *testing.T) {
 	// Login
 	loginBody := map[string]string{
 		"email":    "test@example.com",
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonBody, _ = json.Marshal(loginBody)
 	w = httptest.NewRecorder()
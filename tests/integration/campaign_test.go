@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaigns_AdminFlowAndOpenPixel(t *testing.T) {
+	r, db := setupTestRouter(t)
+	adminToken := registerAndLoginAdmin(t, r, db)
+	memberToken := registerAndLoginUser(t, r, db, "member@test.com")
+	registerAndLoginUser(t, r, db, "other@test.com")
+
+	gymID := createTestGymViaAPI(t, r, adminToken)
+	trainerID := createTestTrainerViaAPI(t, r, adminToken)
+	classID := createTestClassViaAPI(t, r, adminToken, gymID, trainerID)
+	_, err := db.Exec(`INSERT INTO bookings (user_id, class_id) SELECT id, ? FROM users WHERE email = 'member@test.com'`, classID)
+	require.NoError(t, err)
+
+	segment := map[string]interface{}{"gym_ids": []int{gymID}}
+	w := doJSON(r, "POST", "/api/admin/campaigns/segment-preview", adminToken, segment)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var preview models.SegmentPreview
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	require.Equal(t, 1, preview.Count)
+	assert.Equal(t, "member@test.com", preview.Sample[0].Email)
+
+	w = doJSON(r, "POST", "/api/admin/campaigns", memberToken, map[string]interface{}{"name": "x", "title": "x", "body": "x"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(r, "POST", "/api/admin/campaigns", adminToken, map[string]interface{}{
+		"name": "x", "title": "x", "body": "x", "segment": map[string]interface{}{"locales": []string{"de"}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(r, "POST", "/api/admin/campaigns", adminToken, map[string]interface{}{
+		"name": "Open day", "title": "День открытых дверей", "body": "Приходите в субботу", "segment": segment,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var campaign models.Campaign
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &campaign))
+	assert.Equal(t, "draft", campaign.Status)
+
+	w = doJSON(r, "POST", fmt.Sprintf("/api/admin/campaigns/%d/schedule", campaign.ID), adminToken,
+		map[string]string{"scheduled_at": "2030-01-01T10:00:00Z"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Открытие письма отмечается пикселем; чужой или подделанный токен картинку не ломает
+	_, err = db.Exec(`INSERT INTO campaign_recipients (campaign_id, user_id, status) SELECT ?, id, 'queued' FROM users WHERE email = 'member@test.com'`, campaign.ID)
+	require.NoError(t, err)
+	var memberID int
+	require.NoError(t, db.QueryRow(`SELECT id FROM users WHERE email = 'member@test.com'`).Scan(&memberID))
+	tracker := notification.NewOpenTracker("http://localhost/api/notifications/open", "test-secret")
+	for _, token := range []string{tracker.Token(campaign.ID, memberID), "forged"} {
+		w = doJSON(r, "GET", "/api/notifications/open?token="+token, "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/gif", w.Header().Get("Content-Type"))
+	}
+
+	w = doJSON(r, "GET", fmt.Sprintf("/api/admin/campaigns/%d", campaign.ID), adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &campaign))
+	assert.Equal(t, "scheduled", campaign.Status)
+	assert.Equal(t, "2030-01-01 10:00:00", campaign.ScheduledAt)
+	require.NotNil(t, campaign.Stats)
+	assert.Equal(t, 1, campaign.Stats.Opened)
+
+	w = doJSON(r, "GET", "/api/admin/campaigns?status=scheduled", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Open day"`)
+
+	w = doJSON(r, "POST", fmt.Sprintf("/api/admin/campaigns/%d/cancel", campaign.ID), adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(r, "POST", fmt.Sprintf("/api/admin/campaigns/%d/schedule", campaign.ID), adminToken, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doJSON(r, "GET", "/api/admin/campaigns/9999", adminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
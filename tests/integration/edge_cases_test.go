@@ -25,7 +25,7 @@ func TestConcurrentUserRegistration(t *testing.T) {
 		body := map[string]string{
 			"name":     "Test User",
 			"email":    email,
-			"password": "password123",
+			"password": "Str0ng-Passw0rd",
 		}
 		jsonBody, _ := json.Marshal(body)
 		w := httptest.NewRecorder()
@@ -46,12 +46,12 @@ func TestGymManagement_AdminOnly(t *testing.T) {
 	r, db := setupTestRouter(t)
 
 	// Создаем обычного пользователя
-	testutils.CreateTestUser(t, db, "regular@example.com", "password123", false)
+	testutils.CreateTestUser(t, db, "regular@example.com", "Str0ng-Passw0rd", false)
 
 	// Логинимся
 	loginBody := map[string]string{
 		"email":    "regular@example.com",
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonBody, _ := json.Marshal(loginBody)
 	w := httptest.NewRecorder()
@@ -79,10 +79,10 @@ func TestGymManagement_AdminOnly(t *testing.T) {
 	assert.Equal(t, http.StatusForbidden, w.Code)
 
 	// Теперь с админом
-	testutils.CreateTestUser(t, db, "admin@example.com", "password123", true)
+	testutils.CreateTestUser(t, db, "admin@example.com", "Str0ng-Passw0rd", true)
 	loginBody = map[string]string{
 		"email":    "admin@example.com",
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonBody, _ = json.Marshal(loginBody)
 	w = httptest.NewRecorder()
@@ -195,7 +195,7 @@ func TestLargePayload(t *testing.T) {
 	body := map[string]string{
 		"name":     largeString,
 		"email":    "test@example.com",
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 
 	jsonBody, _ := json.Marshal(body)
@@ -259,12 +259,12 @@ func TestMembershipHandler_CreateUpdateDelete(t *testing.T) {
 // Вспомогательные функции
 func registerAndLoginAdmin(t *testing.T, r *gin.Engine, db *sql.DB) string {
 	// Создаем админа напрямую в базе (используя функцию из testutils)
-	testutils.CreateTestUser(t, db, "admin@test.com", "password123", true)
+	testutils.CreateTestUser(t, db, "admin@test.com", "Str0ng-Passw0rd", true)
 
 	// Логин админа
 	loginData := map[string]string{
 		"email":    "admin@test.com",
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonData, _ := json.Marshal(loginData)
 	w := httptest.NewRecorder()
@@ -282,7 +282,7 @@ func registerAndLoginUser(t *testing.T, r *gin.Engine, db *sql.DB, email string)
 	registerData := map[string]string{
 		"name":     "Test User",
 		"email":    email,
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonData, _ := json.Marshal(registerData)
 	w := httptest.NewRecorder()
@@ -293,7 +293,7 @@ func registerAndLoginUser(t *testing.T, r *gin.Engine, db *sql.DB, email string)
 	// Логин
 	loginData := map[string]string{
 		"email":    email,
-		"password": "password123",
+		"password": "Str0ng-Passw0rd",
 	}
 	jsonData, _ = json.Marshal(loginData)
 	w = httptest.NewRecorder()
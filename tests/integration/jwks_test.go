@@ -0,0 +1,36 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"Gym_StrongCode/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKS_PublishesTokenKey(t *testing.T) {
+	r, db := setupTestRouter(t)
+	token := registerAndLoginUser(t, r, db, "jwks@test.com")
+	require.NotEmpty(t, token)
+
+	w := doJSON(r, "GET", "/.well-known/jwks.json", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var jwks models.JWKS
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &jwks))
+	require.NotEmpty(t, jwks.Keys)
+
+	// Токен подписан одним из опубликованных ключей
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", parsed.Method.Alg())
+	kids := make([]string, 0, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		kids = append(kids, key.Kid)
+	}
+	assert.Contains(t, kids, parsed.Header["kid"])
+}
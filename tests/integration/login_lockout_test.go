@@ -0,0 +1,37 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogin_LockoutAndAdminUnlock(t *testing.T) {
+	r, db := setupTestRouter(t)
+	adminToken := registerAndLoginAdmin(t, r, db)
+	userID := testutils.CreateTestUser(t, db, "locked@test.com", "Str0ng-Passw0rd", false)
+
+	wrong := map[string]string{"email": "locked@test.com", "password": "wrong-password"}
+	for i := 0; i < 5; i++ {
+		w := doJSON(r, "POST", "/api/users/login", "", wrong)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// Даже верный пароль не принимается, пока действует блокировка
+	w := doJSON(r, "POST", "/api/users/login", "", map[string]string{"email": "locked@test.com", "password": "Str0ng-Passw0rd"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	w = doJSON(r, "POST", fmt.Sprintf("/api/admin/users/%d/unlock", userID+100), adminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(r, "POST", fmt.Sprintf("/api/admin/users/%d/unlock", userID), adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON(r, "POST", "/api/users/login", "", map[string]string{"email": "locked@test.com", "password": "Str0ng-Passw0rd"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,226 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationOutbox_AdminListAndResend(t *testing.T) {
+	r, db := setupTestRouter(t)
+	adminToken := registerAndLoginAdmin(t, r, db)
+	userToken := registerAndLoginUser(t, r, db, "member@test.com")
+
+	// Письмо подтверждения email после регистрации попадает в очередь
+	w := doJSON(r, "GET", "/api/admin/notifications/outbox", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var entries []map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	require.NotEmpty(t, entries)
+	assert.Equal(t, "member@test.com", entries[0]["recipient"])
+	assert.NotContains(t, entries[0], "body", "текст письма с одноразовыми ссылками не отдаётся")
+
+	_, err := db.Exec(`UPDATE notification_outbox SET status = 'dead', attempts = 8`)
+	require.NoError(t, err)
+	id := int(entries[0]["id"].(float64))
+
+	w = doJSON(r, "POST", fmt.Sprintf("/api/admin/notifications/outbox/%d/resend", id), adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(r, "GET", "/api/admin/notifications/outbox?status=dead", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), fmt.Sprintf(`"id":%d,`, id))
+
+	w = doJSON(r, "POST", "/api/admin/notifications/outbox/9999/resend", adminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Обычному пользователю очередь недоступна
+	w = doJSON(r, "GET", "/api/admin/notifications/outbox", userToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestNotifications_UserLocaleAndTemplatePreview(t *testing.T) {
+	r, db := setupTestRouter(t)
+	adminToken := registerAndLoginAdmin(t, r, db)
+	userToken := registerAndLoginUser(t, r, db, "member@test.com")
+
+	// Язык писем задаётся в профиле
+	w := doJSON(r, "PUT", "/api/me", userToken, map[string]string{"name": "Member", "email": "member@test.com", "locale": "de"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(r, "PUT", "/api/me", userToken, map[string]string{"name": "Member", "email": "member@test.com", "locale": "kk"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"locale":"kk"`)
+
+	w = doJSON(r, "POST", "/api/users/forgot-password", "", map[string]string{"email": "member@test.com"})
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var template, locale, subject string
+	require.NoError(t, db.QueryRow(`
+		SELECT template, locale, subject FROM notification_outbox
+		WHERE recipient = 'member@test.com' ORDER BY id DESC LIMIT 1`).Scan(&template, &locale, &subject))
+	assert.Equal(t, "password_reset", template)
+	assert.Equal(t, "kk", locale)
+	assert.Equal(t, "Құпиясөзді қалпына келтіру", subject)
+
+	// Предпросмотр шаблонов для администратора
+	w = doJSON(r, "GET", "/api/admin/notifications/templates", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var templates []map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &templates))
+	assert.Len(t, templates, 13)
+
+	w = doJSON(r, "POST", "/api/admin/notifications/templates/booking_confirmed/preview?locale=en", adminToken,
+		map[string]string{"class_title": "<b>Boxing</b>"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var preview map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.Equal(t, "Class booking", preview["subject"])
+	assert.Contains(t, preview["html"], "&lt;b&gt;Boxing&lt;/b&gt;")
+	assert.Contains(t, preview["text"], `"<b>Boxing</b>"`)
+
+	w = doJSON(r, "POST", "/api/admin/notifications/templates/unknown/preview", adminToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON(r, "POST", "/api/admin/notifications/templates/booking_confirmed/preview?locale=de", adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(r, "GET", "/api/admin/notifications/templates", userToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestNotifications_UserChannelAddresses(t *testing.T) {
+	r, db := setupTestRouter(t)
+	userToken := registerAndLoginUser(t, r, db, "member@test.com")
+	profile := map[string]interface{}{"name": "Member", "email": "member@test.com"}
+
+	profile["phone"] = "8 701 000"
+	w := doJSON(r, "PUT", "/api/me", userToken, profile)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	profile["phone"] = "+77010000000"
+	w = doJSON(r, "PUT", "/api/me", userToken, profile)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"phone":"+77010000000"`)
+
+	// Без поля phone телефон не меняется, пустая строка — удаляет
+	delete(profile, "phone")
+	w = doJSON(r, "PUT", "/api/me", userToken, profile)
+	assert.Contains(t, w.Body.String(), `"phone":"+77010000000"`)
+	profile["phone"] = ""
+	w = doJSON(r, "PUT", "/api/me", userToken, profile)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), `"phone"`)
+
+	w = doJSON(r, "PUT", "/api/me/push-token", userToken, map[string]string{"token": "device-token-1"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var token *string
+	require.NoError(t, db.QueryRow(`SELECT push_token FROM users WHERE email = 'member@test.com'`).Scan(&token))
+	require.NotNil(t, token)
+	assert.Equal(t, "device-token-1", *token)
+	assert.NotContains(t, doJSON(r, "GET", "/api/me", userToken, nil).Body.String(), "device-token-1")
+
+	w = doJSON(r, "DELETE", "/api/me/push-token", userToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, db.QueryRow(`SELECT push_token FROM users WHERE email = 'member@test.com'`).Scan(&token))
+	assert.Nil(t, token)
+}
+
+func TestNotifications_PreferencesAndUnsubscribe(t *testing.T) {
+	r, db := setupTestRouter(t)
+	userToken := registerAndLoginUser(t, r, db, "member@test.com")
+
+	// По умолчанию подписан на всё, что можно отключить
+	w := doJSON(r, "GET", "/api/me/notifications", userToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var settings models.NotificationSettings
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+	assert.Equal(t, "Asia/Almaty", settings.Timezone)
+	require.NotEmpty(t, settings.Preferences)
+	for _, p := range settings.Preferences {
+		assert.NotEqual(t, notification.CategoryTransactional, p.Category)
+		assert.True(t, p.Enabled)
+	}
+
+	w = doJSON(r, "PUT", "/api/me/notifications", userToken, map[string]interface{}{
+		"timezone":          "Europe/Moscow",
+		"quiet_hours_start": "23:00",
+		"quiet_hours_end":   "07:30",
+		"preferences": []map[string]interface{}{
+			{"category": "reminders", "channel": "sms", "enabled": false},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+	assert.Equal(t, "Europe/Moscow", settings.Timezone)
+	assert.Equal(t, "07:30", settings.QuietHoursEnd)
+	assert.Contains(t, settings.Preferences, models.NotificationPreference{Category: "reminders", Channel: "sms", Enabled: false})
+	assert.Contains(t, settings.Preferences, models.NotificationPreference{Category: "reminders", Channel: "email", Enabled: true})
+
+	// Транзакционные письма не отключаются
+	w = doJSON(r, "PUT", "/api/me/notifications", userToken, map[string]interface{}{
+		"preferences": []map[string]interface{}{{"category": "transactional", "channel": "email", "enabled": false}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(r, "PUT", "/api/me/notifications", userToken, map[string]interface{}{"quiet_hours_start": "23:00"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Отписка по ссылке из письма: GET ничего не меняет, POST отключает категорию во всех каналах
+	var userID int
+	require.NoError(t, db.QueryRow(`SELECT id FROM users WHERE email = 'member@test.com'`).Scan(&userID))
+	token := notification.NewUnsubscribeLinks("", "test-secret").Token(userID, notification.CategoryMarketing)
+
+	w = doJSON(r, "GET", "/api/notifications/unsubscribe?token="+token, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"category":"marketing"`)
+	w = doJSON(r, "GET", "/api/me/notifications", userToken, nil)
+	assert.NotContains(t, w.Body.String(), `"category":"marketing","channel":"email","enabled":false`)
+
+	w = doJSON(r, "POST", "/api/notifications/unsubscribe?token="+token, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(r, "GET", "/api/me/notifications", userToken, nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+	for _, p := range settings.Preferences {
+		if p.Category == notification.CategoryMarketing {
+			assert.False(t, p.Enabled, p.Channel)
+		}
+	}
+
+	w = doJSON(r, "POST", "/api/notifications/unsubscribe?token="+token+"x", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestNotifications_ClassRescheduleAndCancel(t *testing.T) {
+	r, db := setupTestRouter(t)
+	adminToken := registerAndLoginAdmin(t, r, db)
+	registerAndLoginUser(t, r, db, "member@test.com")
+
+	gymID := createTestGymViaAPI(t, r, adminToken)
+	trainerID := createTestTrainerViaAPI(t, r, adminToken)
+	classID := createTestClassViaAPI(t, r, adminToken, gymID, trainerID)
+	_, err := db.Exec(`INSERT INTO bookings (user_id, class_id) SELECT id, ? FROM users WHERE email = 'member@test.com'`, classID)
+	require.NoError(t, err)
+
+	countOutbox := func(template string) int {
+		var n int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM notification_outbox WHERE template = ? AND recipient = 'member@test.com'`, template).Scan(&n))
+		return n
+	}
+
+	update := map[string]interface{}{
+		"title": "Yoga", "trainer_id": trainerID, "gym_id": gymID,
+		"start_time": "2030-01-10T19:00:00Z", "duration_min": 60, "capacity": 20,
+	}
+	w := doJSON(r, "PUT", fmt.Sprintf("/api/admin/classes/%d", classID), adminToken, update)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, countOutbox(notification.TemplateClassChanged))
+
+	w = doJSON(r, "DELETE", fmt.Sprintf("/api/admin/classes/%d", classID), adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, countOutbox(notification.TemplateClassCancelled))
+
+	w = doJSON(r, "PUT", fmt.Sprintf("/api/admin/classes/%d", classID), adminToken, update)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
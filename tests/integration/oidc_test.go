@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"Gym_StrongCode/config"
+	"Gym_StrongCode/internal/cache"
+	"Gym_StrongCode/internal/handler"
+	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDC_LoginFlowWithStubIdP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutils.SetupTestDB(t)
+	idp := testutils.NewStubIdP(t, "gym-client")
+
+	userRepo := repository.NewUserRepository(db)
+	keyService := service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0)
+	authService := service.NewAuthService(userRepo, repository.NewTokenRepository(db), repository.NewUnitOfWork(db), keyService, nil, 0, 0)
+	oidcService := service.NewOIDCService([]config.OIDCProvider{idp.Provider("corp")}, cache.NewMemoryOIDCStateStore(), userRepo, repository.NewIdentityRepository(db), repository.NewUnitOfWork(db))
+	oidcHandler := handler.NewOIDCHandler(oidcService, authService, nil, false)
+
+	r := gin.New()
+	r.GET("/api/auth/oidc/providers", oidcHandler.Providers)
+	r.GET("/api/auth/oidc/:provider/login", oidcHandler.Login)
+	r.GET("/api/auth/oidc/:provider/callback", oidcHandler.Callback)
+	r.GET("/api/me", middleware.AuthMiddleware(keyService, authService), handler.NewUserHandler(userRepo).GetCurrent)
+
+	w := doJSON(r, "GET", "/api/auth/oidc/providers", "", nil)
+	assert.JSONEq(t, `{"providers":["corp"]}`, w.Body.String())
+
+	w = doJSON(r, "GET", "/api/auth/oidc/unknown/login", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Редирект на провайдера, «вход» там и возврат на callback
+	w = doJSON(r, "GET", "/api/auth/oidc/corp/login", "", nil)
+	require.Equal(t, http.StatusFound, w.Code)
+	code, state := idp.Authorize(t, w.Header().Get("Location"), testutils.StubIdentity{
+		Subject: "corp-42", Email: "employee@corp.example", EmailVerified: true, Name: "Corp Employee",
+	})
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/auth/oidc/corp/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var tokens map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	accessToken, _ := tokens["token"].(string)
+	require.NotEmpty(t, accessToken)
+
+	w = doJSON(r, "GET", "/api/me", accessToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "employee@corp.example")
+
+	// Повтор callback с тем же state отклоняется
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,47 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister_PasswordPolicy(t *testing.T) {
+	r, _ := setupTestRouter(t)
+
+	for _, password := range []string{"short1!", "password123", "Password123!", "Maria-Ivanova-7"} {
+		w := doJSON(r, "POST", "/api/users/register", "", map[string]string{
+			"name": "Maria Ivanova", "email": "maria@example.com", "password": password,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, password)
+	}
+
+	w := doJSON(r, "POST", "/api/users/register", "", map[string]string{
+		"name": "Maria Ivanova", "email": "maria@example.com", "password": "Deadl1ft-Day",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
+
+func TestChangePassword(t *testing.T) {
+	r, db := setupTestRouter(t)
+	token := registerAndLoginUser(t, r, db, "changer@test.com")
+	require.NotEmpty(t, token)
+
+	w := doJSON(r, "PUT", "/api/me/password", token, map[string]string{"current_password": "wrong", "new_password": "Deadl1ft-Day"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON(r, "PUT", "/api/me/password", token, map[string]string{"current_password": "Str0ng-Passw0rd", "new_password": "qwerty123"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(r, "PUT", "/api/me/password", token, map[string]string{"current_password": "Str0ng-Passw0rd", "new_password": "Deadl1ft-Day"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Прежний токен отозван, вход — только с новым паролем
+	w = doJSON(r, "GET", "/api/me", token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON(r, "POST", "/api/users/login", "", map[string]string{"email": "changer@test.com", "password": "Str0ng-Passw0rd"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON(r, "POST", "/api/users/login", "", map[string]string{"email": "changer@test.com", "password": "Deadl1ft-Day"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
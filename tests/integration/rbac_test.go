@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func doJSON(r *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRBAC_GymScopedManager(t *testing.T) {
+	r, db := setupTestRouter(t)
+	adminToken := registerAndLoginAdmin(t, r, db)
+
+	gymA := testutils.CreateTestGym(t, db, "Gym A", "Address A")
+	gymB := testutils.CreateTestGym(t, db, "Gym B", "Address B")
+
+	managerToken := registerAndLoginUser(t, r, db, "manager@test.com")
+	staffToken := registerAndLoginUser(t, r, db, "staff@test.com")
+	var managerID, staffID int
+	require.NoError(t, db.QueryRow(`SELECT id FROM users WHERE email = 'manager@test.com'`).Scan(&managerID))
+	require.NoError(t, db.QueryRow(`SELECT id FROM users WHERE email = 'staff@test.com'`).Scan(&staffID))
+
+	// До назначения роли доступа нет
+	w := doJSON(r, "PUT", fmt.Sprintf("/api/admin/gyms/%d", gymA), managerToken, map[string]string{"name": "A", "address": "A"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Владелец назначает управляющего залом A
+	w = doJSON(r, "POST", fmt.Sprintf("/api/admin/users/%d/roles", managerID), adminToken, map[string]interface{}{"role": "manager", "gym_id": gymA})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doJSON(r, "PUT", fmt.Sprintf("/api/admin/gyms/%d", gymA), managerToken, map[string]string{"name": "Gym A+", "address": "A"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(r, "PUT", fmt.Sprintf("/api/admin/gyms/%d", gymB), managerToken, map[string]string{"name": "Gym B+", "address": "B"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(r, "POST", "/api/admin/gyms", managerToken, map[string]string{"name": "Gym C", "address": "C"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Списки — только по своему залу
+	w = doJSON(r, "GET", fmt.Sprintf("/api/admin/bookings?gym_id=%d", gymA), managerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(r, "GET", "/api/admin/bookings", managerToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Управляющий выдаёт роли персонала только в своём зале и не может назначать управляющих
+	w = doJSON(r, "POST", fmt.Sprintf("/api/admin/users/%d/roles", staffID), managerToken, map[string]interface{}{"role": "front_desk", "gym_id": gymA})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = doJSON(r, "POST", fmt.Sprintf("/api/admin/users/%d/roles", staffID), managerToken, map[string]interface{}{"role": "front_desk", "gym_id": gymB})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON(r, "POST", fmt.Sprintf("/api/admin/users/%d/roles", staffID), managerToken, map[string]interface{}{"role": "manager", "gym_id": gymA})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Администратор стойки видит бронирования зала, но не управляет залом
+	w = doJSON(r, "GET", fmt.Sprintf("/api/admin/bookings?gym_id=%d", gymA), staffToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(r, "PUT", fmt.Sprintf("/api/admin/gyms/%d", gymA), staffToken, map[string]string{"name": "X", "address": "X"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON(r, "GET", fmt.Sprintf("/api/admin/users/%d/roles", staffID), adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var roles []map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &roles))
+	require.Len(t, roles, 1)
+	assert.Equal(t, "front_desk", roles[0]["role"])
+}
+
+func TestRBAC_ClassMoveRequiresTargetGym(t *testing.T) {
+	r, db := setupTestRouter(t)
+	adminToken := registerAndLoginAdmin(t, r, db)
+
+	gymA := testutils.CreateTestGym(t, db, "Gym A", "Address A")
+	gymB := testutils.CreateTestGym(t, db, "Gym B", "Address B")
+	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
+	classID := testutils.CreateTestClass(t, db, "Yoga", trainerID, gymA, 10)
+
+	managerToken := registerAndLoginUser(t, r, db, "manager@test.com")
+	var managerID int
+	require.NoError(t, db.QueryRow(`SELECT id FROM users WHERE email = 'manager@test.com'`).Scan(&managerID))
+	w := doJSON(r, "POST", fmt.Sprintf("/api/admin/users/%d/roles", managerID), adminToken, map[string]interface{}{"role": "manager", "gym_id": gymA})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	class := func(gymID int) map[string]interface{} {
+		return map[string]interface{}{"title": "Yoga", "trainer_id": trainerID, "gym_id": gymID,
+			"start_time": "2030-01-02 10:00:00", "duration_min": 60, "capacity": 10}
+	}
+
+	// Управляющий залом A не может перенести занятие в чужой зал
+	w = doJSON(r, "PUT", fmt.Sprintf("/api/admin/classes/%d", classID), managerToken, class(gymB))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var gymID int
+	require.NoError(t, db.QueryRow(`SELECT gym_id FROM classes WHERE id = ?`, classID).Scan(&gymID))
+	assert.Equal(t, gymA, gymID)
+
+	w = doJSON(r, "PUT", fmt.Sprintf("/api/admin/classes/%d", classID), managerToken, class(gymA))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Управляющий обоими залами переносит занятие
+	w = doJSON(r, "POST", fmt.Sprintf("/api/admin/users/%d/roles", managerID), adminToken, map[string]interface{}{"role": "manager", "gym_id": gymB})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doJSON(r, "PUT", fmt.Sprintf("/api/admin/classes/%d", classID), managerToken, class(gymB))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
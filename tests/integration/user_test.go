@@ -66,7 +66,7 @@ func TestUserFlow_CompleteScenario(t *testing.T) {
 	registerBody := map[string]string{
 		"name":     "Alice Johnson",
 		"email":    "alice@example.com",
-		"password": "securepass123",
+		"password": "Secure-Passw0rd1",
 	}
 	jsonBody, _ := json.Marshal(registerBody)
 	w := httptest.NewRecorder()
@@ -78,7 +78,7 @@ func TestUserFlow_CompleteScenario(t *testing.T) {
 	// 2. Логин
 	loginBody := map[string]string{
 		"email":    "alice@example.com",
-		"password": "securepass123",
+		"password": "Secure-Passw0rd1",
 	}
 	jsonBody, _ = json.Marshal(loginBody)
 	w = httptest.NewRecorder()
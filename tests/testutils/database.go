@@ -22,6 +22,14 @@ func SetupTestDB(t *testing.T) *sql.DB {
 		email TEXT UNIQUE NOT NULL,
 		password_hash TEXT NOT NULL,
 		is_admin BOOLEAN DEFAULT 0,
+		token_version INTEGER NOT NULL DEFAULT 0,
+		email_verified INTEGER NOT NULL DEFAULT 0,
+		totp_secret TEXT,
+		totp_enabled INTEGER NOT NULL DEFAULT 0,
+		totp_last_step INTEGER NOT NULL DEFAULT 0,
+		locale TEXT NOT NULL DEFAULT 'ru',
+		phone TEXT,
+		push_token TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
@@ -67,6 +75,7 @@ func SetupTestDB(t *testing.T) *sql.DB {
 		class_id INTEGER NOT NULL,
 		status TEXT DEFAULT 'confirmed',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		reminded_for DATETIME,
 		FOREIGN KEY (user_id) REFERENCES users(id),
 		FOREIGN KEY (class_id) REFERENCES classes(id)
 	);
@@ -80,6 +89,16 @@ func SetupTestDB(t *testing.T) *sql.DB {
 		status TEXT DEFAULT 'pending',
 		description TEXT,
 		reference_id TEXT,
+		product_type TEXT DEFAULT 'other',
+		gym_id INTEGER,
+		net_cents INTEGER,
+		tax_cents INTEGER DEFAULT 0,
+		tax_rate_bp INTEGER DEFAULT 0,
+		fiscal_sign TEXT,
+		fiscal_url TEXT,
+		membership_id INTEGER,
+		refunded_cents INTEGER DEFAULT 0,
+		refunded_at DATETIME,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (user_id) REFERENCES users(id)
 	);
@@ -90,10 +109,231 @@ func SetupTestDB(t *testing.T) *sql.DB {
 		membership_id INTEGER NOT NULL,
 		start_date DATETIME NOT NULL,
 		end_date DATETIME NOT NULL,
+		active INTEGER DEFAULT 1,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (user_id) REFERENCES users(id),
 		FOREIGN KEY (membership_id) REFERENCES memberships(id)
 	);
+
+	CREATE TABLE membership_prices (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		membership_id INTEGER NOT NULL,
+		currency TEXT NOT NULL,
+		price_cents INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (membership_id) REFERENCES memberships(id),
+		UNIQUE (membership_id, currency)
+	);
+
+	CREATE TABLE exchange_rates (
+		currency TEXT PRIMARY KEY,
+		rate REAL NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE tax_rates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		product_type TEXT NOT NULL,
+		gym_id INTEGER,
+		rate_bp INTEGER NOT NULL,
+		inclusive BOOLEAN DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (gym_id) REFERENCES gyms(id)
+	);
+
+	CREATE TABLE fiscal_outbox (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		payment_id INTEGER NOT NULL UNIQUE,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		locked_until DATETIME,
+		last_error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (payment_id) REFERENCES payments(id)
+	);
+
+	CREATE TABLE notification_outbox (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		recipient TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		body TEXT NOT NULL,
+		text_body TEXT NOT NULL DEFAULT '',
+		template TEXT NOT NULL DEFAULT '',
+		locale TEXT NOT NULL DEFAULT '',
+		channel TEXT NOT NULL DEFAULT 'email',
+		fallback TEXT NOT NULL DEFAULT '',
+		unsubscribe_url TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'sending', 'sent', 'dead')),
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		locked_until DATETIME,
+		last_error TEXT,
+		sent_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE installment_plans (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		membership_id INTEGER NOT NULL,
+		user_membership_id INTEGER,
+		total_cents INTEGER NOT NULL,
+		currency TEXT NOT NULL,
+		method TEXT NOT NULL,
+		gym_id INTEGER,
+		installments_count INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'active',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (membership_id) REFERENCES memberships(id)
+	);
+
+	CREATE TABLE installments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		plan_id INTEGER NOT NULL,
+		seq INTEGER NOT NULL,
+		amount_cents INTEGER NOT NULL,
+		due_date DATE NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_date DATE NOT NULL,
+		payment_id INTEGER,
+		last_error TEXT,
+		paid_at DATETIME,
+		FOREIGN KEY (plan_id) REFERENCES installment_plans(id),
+		UNIQUE (plan_id, seq)
+	);
+
+	CREATE TABLE refresh_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		family_id TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		expires_at DATETIME NOT NULL,
+		revoked_at DATETIME,
+		replaced_by INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	CREATE TABLE revoked_access_tokens (
+		jti TEXT PRIMARY KEY,
+		expires_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE user_roles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		gym_id INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	CREATE TABLE recovery_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		code_hash TEXT NOT NULL,
+		used_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	CREATE TABLE api_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		prefix TEXT NOT NULL UNIQUE,
+		key_hash TEXT NOT NULL,
+		scopes TEXT NOT NULL,
+		gym_id INTEGER,
+		created_by INTEGER NOT NULL,
+		expires_at DATETIME,
+		last_used_at DATETIME,
+		revoked_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE user_identities (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		provider TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		email TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		UNIQUE(provider, subject)
+	);
+
+	CREATE TABLE signing_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kid TEXT NOT NULL UNIQUE,
+		algorithm TEXT NOT NULL,
+		private_key TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		rotated_at DATETIME,
+		expires_at DATETIME
+	);
+
+	CREATE TABLE user_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		purpose TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		expires_at DATETIME NOT NULL,
+		used_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	CREATE TABLE notification_settings (
+		user_id INTEGER PRIMARY KEY,
+		timezone TEXT NOT NULL DEFAULT 'Asia/Almaty',
+		quiet_hours_start TEXT,
+		quiet_hours_end TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	CREATE TABLE notification_preferences (
+		user_id INTEGER NOT NULL,
+		category TEXT NOT NULL,
+		channel TEXT NOT NULL,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, category, channel),
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	CREATE TABLE campaigns (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL,
+		segment TEXT NOT NULL DEFAULT '{}',
+		status TEXT NOT NULL DEFAULT 'draft',
+		scheduled_at DATETIME,
+		rate_per_minute INTEGER NOT NULL DEFAULT 60,
+		created_by INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		finished_at DATETIME,
+		FOREIGN KEY (created_by) REFERENCES users(id)
+	);
+
+	CREATE TABLE campaign_recipients (
+		campaign_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		notification_id INTEGER,
+		opened_at DATETIME,
+		PRIMARY KEY (campaign_id, user_id),
+		FOREIGN KEY (campaign_id) REFERENCES campaigns(id),
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
 	`
 
 	if _, err := db.Exec(schema); err != nil {
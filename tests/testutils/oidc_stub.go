@@ -0,0 +1,153 @@
+package testutils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// StubIdentity — пользователь, который «входит» на странице stub-провайдера
+type StubIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type stubAuthRequest struct {
+	identity      StubIdentity
+	nonce         string
+	redirectURI   string
+	codeChallenge string
+}
+
+// StubIdP — локальный провайдер OpenID Connect для тестов: discovery, JWKS и token endpoint с проверкой PKCE
+type StubIdP struct {
+	Server   *httptest.Server
+	ClientID string
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]stubAuthRequest
+}
+
+func NewStubIdP(t *testing.T, clientID string) *StubIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate stub IdP key: %v", err)
+	}
+	idp := &StubIdP{ClientID: clientID, key: key, codes: make(map[string]stubAuthRequest)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.Server.URL,
+			"authorization_endpoint": idp.Server.URL + "/authorize",
+			"token_endpoint":         idp.Server.URL + "/token",
+			"jwks_uri":               idp.Server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": "stub-key",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", idp.token)
+
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Server.Close)
+	return idp
+}
+
+// Provider возвращает настройки провайдера для config.OIDCProvider
+func (idp *StubIdP) Provider(name string) config.OIDCProvider {
+	return config.OIDCProvider{
+		Name:         name,
+		Issuer:       idp.Server.URL,
+		ClientID:     idp.ClientID,
+		ClientSecret: "stub-secret",
+		RedirectURL:  "http://localhost/api/auth/oidc/" + name + "/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+// Authorize имитирует вход пользователя на странице провайдера по адресу из редиректа
+// и возвращает code и state, с которыми провайдер вернул бы его на callback
+func (idp *StubIdP) Authorize(t *testing.T, authURL string, identity StubIdentity) (code, state string) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Invalid authorization URL: %v", err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != idp.ClientID || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("Unexpected authorization request: %s", authURL)
+	}
+
+	code = base64.RawURLEncoding.EncodeToString([]byte(identity.Subject + time.Now().String()))
+	idp.mu.Lock()
+	idp.codes[code] = stubAuthRequest{
+		identity:      identity,
+		nonce:         q.Get("nonce"),
+		redirectURI:   q.Get("redirect_uri"),
+		codeChallenge: q.Get("code_challenge"),
+	}
+	idp.mu.Unlock()
+	return code, q.Get("state")
+}
+
+func (idp *StubIdP) token(w http.ResponseWriter, r *http.Request) {
+	fail := func() {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+	}
+
+	idp.mu.Lock()
+	req, ok := idp.codes[r.PostFormValue("code")]
+	delete(idp.codes, r.PostFormValue("code"))
+	idp.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || r.PostFormValue("client_id") != idp.ClientID || r.PostFormValue("redirect_uri") != req.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != req.codeChallenge {
+		fail()
+		return
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            idp.Server.URL,
+		"aud":            idp.ClientID,
+		"sub":            req.identity.Subject,
+		"email":          req.identity.Email,
+		"email_verified": req.identity.EmailVerified,
+		"name":           req.identity.Name,
+		"nonce":          req.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "stub-key"
+	idToken, err := token.SignedString(idp.key)
+	if err != nil {
+		fail()
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"access_token": "stub-access", "token_type": "Bearer", "id_token": idToken})
+}
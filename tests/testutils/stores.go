@@ -0,0 +1,86 @@
+package testutils
+
+import (
+	"testing"
+
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/repository/memory"
+)
+
+// Stores — все хранилища одной реализации; сервисы принимают их через интерфейсы repository
+type Stores struct {
+	UoW           repository.Transactor
+	APIKeys       repository.APIKeyStore
+	Bookings      repository.BookingStore
+	Campaigns     repository.CampaignStore
+	Classes       repository.ClassStore
+	Currencies    repository.CurrencyStore
+	Fiscal        repository.FiscalStore
+	Gyms          repository.GymStore
+	Identities    repository.IdentityStore
+	Installments  repository.InstallmentStore
+	Memberships   repository.MembershipStore
+	Notifications repository.NotificationStore
+	Payments      repository.PaymentStore
+	Reports       repository.ReportStore
+	Roles         repository.RoleStore
+	SigningKeys   repository.SigningKeyStore
+	Taxes         repository.TaxStore
+	Tokens        repository.TokenStore
+	Trainers      repository.TrainerStore
+	Users         repository.UserStore
+}
+
+// NewSQLiteStores создает хранилища поверх in-memory SQLite
+func NewSQLiteStores(t *testing.T) *Stores {
+	db := SetupTestDB(t)
+	return &Stores{
+		UoW:           repository.NewUnitOfWork(db),
+		APIKeys:       repository.NewAPIKeyRepository(db),
+		Bookings:      repository.NewBookingRepository(db),
+		Campaigns:     repository.NewCampaignRepository(db),
+		Classes:       repository.NewClassRepository(db),
+		Currencies:    repository.NewCurrencyRepository(db),
+		Fiscal:        repository.NewFiscalRepository(db),
+		Gyms:          repository.NewGymRepository(db),
+		Identities:    repository.NewIdentityRepository(db),
+		Installments:  repository.NewInstallmentRepository(db),
+		Memberships:   repository.NewMembershipRepository(db),
+		Notifications: repository.NewNotificationRepository(db),
+		Payments:      repository.NewPaymentRepository(db),
+		Reports:       repository.NewReportRepository(db),
+		Roles:         repository.NewRoleRepository(db),
+		SigningKeys:   repository.NewSigningKeyRepository(db),
+		Taxes:         repository.NewTaxRepository(db),
+		Tokens:        repository.NewTokenRepository(db),
+		Trainers:      repository.NewTrainerRepository(db),
+		Users:         repository.NewUserRepository(db),
+	}
+}
+
+// NewMemoryStores создает хранилища в памяти — без базы, для быстрых тестов сервисов
+func NewMemoryStores() *Stores {
+	db := memory.NewDB()
+	return &Stores{
+		UoW:           db,
+		APIKeys:       memory.NewAPIKeyRepository(db),
+		Bookings:      memory.NewBookingRepository(db),
+		Campaigns:     memory.NewCampaignRepository(db),
+		Classes:       memory.NewClassRepository(db),
+		Currencies:    memory.NewCurrencyRepository(db),
+		Fiscal:        memory.NewFiscalRepository(db),
+		Gyms:          memory.NewGymRepository(db),
+		Identities:    memory.NewIdentityRepository(db),
+		Installments:  memory.NewInstallmentRepository(db),
+		Memberships:   memory.NewMembershipRepository(db),
+		Notifications: memory.NewNotificationRepository(db),
+		Payments:      memory.NewPaymentRepository(db),
+		Reports:       memory.NewReportRepository(db),
+		Roles:         memory.NewRoleRepository(db),
+		SigningKeys:   memory.NewSigningKeyRepository(db),
+		Taxes:         memory.NewTaxRepository(db),
+		Tokens:        memory.NewTokenRepository(db),
+		Trainers:      memory.NewTrainerRepository(db),
+		Users:         memory.NewUserRepository(db),
+	}
+}
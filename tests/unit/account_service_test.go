@@ -0,0 +1,182 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/cache"
+	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capturingNotifier запоминает письма вместо отправки
+type capturingNotifier struct {
+	mu   sync.Mutex
+	sent []notification.Message
+}
+
+func (n *capturingNotifier) NotifyUser(ctx context.Context, user *models.User, msg notification.Message) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, msg)
+}
+
+var tokenInLink = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+func (n *capturingNotifier) lastToken(t *testing.T) string {
+	require.NotEmpty(t, n.sent)
+	var link string
+	switch msg := n.sent[len(n.sent)-1].(type) {
+	case notification.PasswordReset:
+		link = msg.Link
+	case notification.EmailVerification:
+		link = msg.Link
+	}
+	m := tokenInLink.FindStringSubmatch(link)
+	require.Len(t, m, 2)
+	return m[1]
+}
+
+func setupAccountService(t *testing.T) (*service.AccountService, *service.AuthService, *capturingNotifier) {
+	db := testutils.SetupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	authService := service.NewAuthService(userRepo, tokenRepo, repository.NewUnitOfWork(db), service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0), nil, 0, 0)
+	notifier := &capturingNotifier{}
+	return service.NewAccountService(userRepo, tokenRepo, repository.NewUnitOfWork(db), authService, nil, notifier, "http://app", 0, 0), authService, notifier
+}
+
+func TestAccountService_PasswordReset(t *testing.T) {
+	ctx := context.Background()
+	accountService, authService, notifier := setupAccountService(t)
+	_, err := authService.Register(ctx, "Reset User", "reset@example.com", "Old-Passw0rd!")
+	require.NoError(t, err)
+	oldTokens, err := authService.Login(ctx, "reset@example.com", "Old-Passw0rd!")
+	require.NoError(t, err)
+
+	// Неизвестный email не раскрывается и письмо не уходит
+	require.NoError(t, accountService.RequestPasswordReset(ctx, "nobody@example.com"))
+	assert.Empty(t, notifier.sent)
+
+	require.NoError(t, accountService.RequestPasswordReset(ctx, "reset@example.com"))
+	first := notifier.lastToken(t)
+	require.NoError(t, accountService.RequestPasswordReset(ctx, "reset@example.com"))
+	token := notifier.lastToken(t)
+
+	// Действует только последний выданный токен
+	assert.ErrorIs(t, accountService.ResetPassword(ctx, first, "New-Passw0rd!"), service.ErrInvalidUserToken)
+
+	// Слабый пароль отклоняется, не расходуя ссылку
+	assert.ErrorIs(t, accountService.ResetPassword(ctx, token, "newpassword"), service.ErrWeakPassword)
+
+	require.NoError(t, accountService.ResetPassword(ctx, token, "New-Passw0rd!"))
+	assert.ErrorIs(t, accountService.ResetPassword(ctx, token, "An0ther-Passw0rd"), service.ErrInvalidUserToken)
+
+	_, err = authService.Login(ctx, "reset@example.com", "Old-Passw0rd!")
+	assert.Error(t, err)
+	_, err = authService.Login(ctx, "reset@example.com", "New-Passw0rd!")
+	assert.NoError(t, err)
+
+	// Старые сессии завершены
+	_, err = authService.Refresh(ctx, oldTokens.RefreshToken)
+	assert.Error(t, err)
+}
+
+func TestAccountService_PasswordResetEndsServerSessions(t *testing.T) {
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+	db := testutils.SetupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	authService := service.NewAuthService(userRepo, tokenRepo, repository.NewUnitOfWork(db), service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0), nil, 0, 0)
+	store := cache.NewMemorySessionStore(time.Hour)
+	sessionService := service.NewSessionService(authService, store)
+	notifier := &capturingNotifier{}
+	accountService := service.NewAccountService(userRepo, tokenRepo, repository.NewUnitOfWork(db), authService, store, notifier, "http://app", 0, 0)
+
+	_, err := authService.Register(ctx, "Reset User", "reset@example.com", "Old-Passw0rd!")
+	require.NoError(t, err)
+	session, err := sessionService.Login(ctx, "reset@example.com", "Old-Passw0rd!", "", "", "")
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(middleware.SessionAuthMiddleware(store, authService))
+	r.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+	get := func() int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me", nil)
+		req.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: session.ID})
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, get())
+
+	require.NoError(t, accountService.RequestPasswordReset(ctx, "reset@example.com"))
+	require.NoError(t, accountService.ResetPassword(ctx, notifier.lastToken(t), "New-Passw0rd!"))
+
+	// Сессия, которую мог перехватить злоумышленник, больше не действует
+	assert.Equal(t, http.StatusUnauthorized, get())
+	sessions, err := store.ListUserSessions(ctx, session.UserID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestAccountService_VerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	accountService, authService, notifier := setupAccountService(t)
+	user, err := authService.Register(ctx, "Verify User", "verify@example.com", "Str0ng-Passw0rd")
+	require.NoError(t, err)
+	assert.False(t, user.EmailVerified)
+
+	require.NoError(t, accountService.SendVerification(ctx, user.ID))
+	token := notifier.lastToken(t)
+
+	assert.ErrorIs(t, accountService.VerifyEmail(ctx, "bogus"), service.ErrInvalidUserToken)
+	require.NoError(t, accountService.VerifyEmail(ctx, token))
+
+	verified, err := accountService.IsEmailVerified(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, verified)
+
+	assert.ErrorIs(t, accountService.SendVerification(ctx, user.ID), service.ErrEmailAlreadyVerified)
+	assert.ErrorIs(t, accountService.VerifyEmail(ctx, token), service.ErrInvalidUserToken)
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+	accountService, authService, notifier := setupAccountService(t)
+	user, err := authService.Register(ctx, "Buyer", "buyer@example.com", "Str0ng-Passw0rd")
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", user.ID) })
+	r.Use(middleware.RequireVerifiedEmail(accountService))
+	r.POST("/bookings", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/bookings", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	require.NoError(t, accountService.SendVerification(ctx, user.ID))
+	require.NoError(t, accountService.VerifyEmail(ctx, notifier.lastToken(t)))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/bookings", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAlertRules(t *testing.T) {
+	// Без правил все оповещения уходят на NOTIFY_ADMIN_EMAIL, без адреса — выключены
+	rules, err := notification.ParseAlertRules("", "admin@gym.kz")
+	require.NoError(t, err)
+	assert.Len(t, rules, len(notification.AlertKinds))
+	assert.Equal(t, []string{"admin@gym.kz"}, rules[notification.AlertRefund])
+
+	rules, err = notification.ParseAlertRules("", "")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	rules, err = notification.ParseAlertRules("large_payment; refund=finance@gym.kz, owner@gym.kz", "admin@gym.kz")
+	require.NoError(t, err)
+	assert.Equal(t, notification.AlertRules{
+		notification.AlertLargePayment: {"admin@gym.kz"},
+		notification.AlertRefund:       {"finance@gym.kz", "owner@gym.kz"},
+	}, rules)
+
+	_, err = notification.ParseAlertRules("payday=admin@gym.kz", "")
+	assert.ErrorIs(t, err, notification.ErrUnknownAlert)
+	_, err = notification.ParseAlertRules("refund=finance", "")
+	assert.Error(t, err)
+}
+
+// alertsOutbox возвращает шаблоны оповещений, поставленных в очередь на адрес to
+func alertsOutbox(t *testing.T, db *sql.DB, to string) []string {
+	rows, err := db.Query(`SELECT template FROM notification_outbox WHERE recipient = ? ORDER BY id`, to)
+	require.NoError(t, err)
+	defer rows.Close()
+	templates := []string{}
+	for rows.Next() {
+		var template string
+		require.NoError(t, rows.Scan(&template))
+		templates = append(templates, template)
+	}
+	return templates
+}
+
+func TestAdminAlerts_PaymentsAndRefunds(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	paymentRepo := repository.NewPaymentRepository(db)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	rules, err := notification.ParseAlertRules("large_payment;refund=finance@gym.kz", "admin@gym.kz")
+	require.NoError(t, err)
+	alerts := service.NewAdminAlerts(notifService, currencyService, rules, 10000000)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, service.NewTaxService(repository.NewTaxRepository(db), paymentRepo),
+		service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil), alerts)
+
+	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
+
+	_, err = paymentService.Create(ctx, userID, 9999999, "", "card", "small", "")
+	require.NoError(t, err)
+	assert.Empty(t, alertsOutbox(t, db, "admin@gym.kz"), "платёж меньше порога")
+
+	large, err := paymentService.Create(ctx, userID, 12345678, "", "card", "annual plan", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{notification.TemplateLargePaymentAlert}, alertsOutbox(t, db, "admin@gym.kz"))
+
+	var text string
+	require.NoError(t, db.QueryRow(`SELECT text_body FROM notification_outbox WHERE template = ?`, notification.TemplateLargePaymentAlert).Scan(&text))
+	assert.Contains(t, text, "123 456.78 KZT")
+	assert.Contains(t, text, "annual plan")
+
+	_, err = paymentService.Refund(ctx, large.ID, 2345678)
+	require.NoError(t, err)
+	assert.Equal(t, []string{notification.TemplateRefundAlert}, alertsOutbox(t, db, "finance@gym.kz"))
+	require.NoError(t, db.QueryRow(`SELECT text_body FROM notification_outbox WHERE template = ?`, notification.TemplateRefundAlert).Scan(&text))
+	assert.Contains(t, text, "23 456.78 KZT")
+	assert.Contains(t, text, "100 000.00 KZT")
+	assert.Len(t, alertsOutbox(t, db, "admin@gym.kz"), 1, "возвраты настроены только на finance@")
+}
+
+func TestAdminAlerts_ClassFullAndFailedRenewal(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	membershipRepo := repository.NewMembershipRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	rules, err := notification.ParseAlertRules("", "admin@gym.kz")
+	require.NoError(t, err)
+	alerts := service.NewAdminAlerts(notifService, currencyService, rules, 0)
+
+	bookingService := service.NewBookingService(repository.NewBookingRepository(db), repository.NewClassRepository(db), membershipRepo, repository.NewUnitOfWork(db), notifService, alerts)
+	userRepo := repository.NewUserRepository(db)
+	gymID := testutils.CreateTestGym(t, db, "Gym", "Address")
+	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
+	classID := testutils.CreateTestClass(t, db, "Boxing", trainerID, gymID, 2)
+
+	for _, email := range []string{"a@test.com", "b@test.com"} {
+		user, err := userRepo.GetByID(ctx, testutils.CreateTestUser(t, db, email, "password", false))
+		require.NoError(t, err)
+		require.NoError(t, bookingService.Create(ctx, user, classID))
+	}
+	assert.Equal(t, []string{notification.TemplateClassFullAlert}, alertsOutbox(t, db, "admin@gym.kz"))
+
+	// Неудачное списание по рассрочке
+	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, repository.NewUnitOfWork(db), notifService, currencyService, taxService, fiscalService, alerts)
+	charger := &flakyCharger{next: service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, alerts)}
+	installmentService := service.NewInstallmentService(repository.NewInstallmentRepository(db), membershipRepo, repository.NewUnitOfWork(db), membershipService, charger, alerts, 3)
+
+	userID := testutils.CreateTestUser(t, db, "c@test.com", "password", false)
+	membershipID := testutils.CreateTestMembership(t, db, "Annual", 365, 1200000)
+	plan, err := installmentService.CreatePlan(ctx, userID, membershipID, 3, "card", "", nil)
+	require.NoError(t, err)
+
+	secondDue, err := time.Parse("2006-01-02", plan.Installments[1].DueDate[:10])
+	require.NoError(t, err)
+	charger.fail = true
+	_, err = installmentService.ProcessDue(ctx, secondDue)
+	require.NoError(t, err)
+	assert.Equal(t, []string{notification.TemplateClassFullAlert, notification.TemplateRenewalFailedAlert}, alertsOutbox(t, db, "admin@gym.kz"))
+}
@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	adminID := testutils.CreateTestUser(t, db, "admin@test.com", "Str0ng-Passw0rd", true)
+	svc := service.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+
+	key, rawKey, err := svc.Create(ctx, adminID, "bi", []string{"reports.view"}, nil, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, rawKey, key.KeyHash)
+
+	authenticated, err := svc.AuthenticateAPIKey(ctx, rawKey)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, authenticated.ID)
+	assert.True(t, authenticated.HasScope("reports.view"))
+
+	// Подделанный секрет при верном префиксе не принимается
+	_, err = svc.AuthenticateAPIKey(ctx, rawKey+"x")
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+
+	require.NoError(t, svc.Revoke(ctx, key.ID))
+	_, err = svc.AuthenticateAPIKey(ctx, rawKey)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+	assert.ErrorIs(t, svc.Revoke(ctx, key.ID), service.ErrAPIKeyNotFound)
+}
+
+func TestAPIKeyService_Validation(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	adminID := testutils.CreateTestUser(t, db, "admin@test.com", "Str0ng-Passw0rd", true)
+	svc := service.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+
+	_, _, err := svc.Create(ctx, adminID, "x", nil, nil, nil)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKeyScope)
+	_, _, err = svc.Create(ctx, adminID, "x", []string{"roles.manage"}, nil, nil)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKeyScope)
+
+	past := time.Now().Add(-time.Hour)
+	_, _, err = svc.Create(ctx, adminID, "x", []string{"reports.view"}, nil, &past)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKeyExpiry)
+}
+
+func TestAPIKeyService_ExpiredKeyRejected(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	adminID := testutils.CreateTestUser(t, db, "admin@test.com", "Str0ng-Passw0rd", true)
+	svc := service.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+
+	soon := time.Now().Add(time.Hour)
+	key, rawKey, err := svc.Create(ctx, adminID, "turnstile", []string{"bookings.view"}, nil, &soon)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`UPDATE api_keys SET expires_at = ? WHERE id = ?`, time.Now().Add(-time.Minute).UTC().Format("2006-01-02 15:04:05"), key.ID)
+	require.NoError(t, err)
+	_, err = svc.AuthenticateAPIKey(ctx, rawKey)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+}
@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"context"
 	"testing"
 
 	"Gym_StrongCode/internal/repository"
@@ -13,11 +14,12 @@ import (
 )
 
 func TestAuthService_Register(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	userRepo := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo, "test-secret")
+	authService := service.NewAuthService(userRepo, repository.NewTokenRepository(db), repository.NewUnitOfWork(db), service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0), nil, 0, 0)
 
-	user, err := authService.Register("Test User", "test@example.com", "password123")
+	user, err := authService.Register(ctx, "Test User", "test@example.com", "Str0ng-Passw0rd")
 
 	require.NoError(t, err)
 	assert.NotZero(t, user.ID)
@@ -26,59 +28,114 @@ func TestAuthService_Register(t *testing.T) {
 }
 
 func TestAuthService_Register_DuplicateEmail(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	userRepo := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo, "test-secret")
+	authService := service.NewAuthService(userRepo, repository.NewTokenRepository(db), repository.NewUnitOfWork(db), service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0), nil, 0, 0)
 
 	// Первая регистрация
-	_, err := authService.Register("Test User", "duplicate@example.com", "password123")
+	_, err := authService.Register(ctx, "Test User", "duplicate@example.com", "Str0ng-Passw0rd")
 	require.NoError(t, err)
 
 	// Повторная регистрация с тем же email
-	user, err := authService.Register("Another User", "duplicate@example.com", "password456")
+	user, err := authService.Register(ctx, "Another User", "duplicate@example.com", "An0ther-Passw0rd")
 
 	assert.Error(t, err)
 	assert.Nil(t, user)
 }
 
 func TestAuthService_Login_Success(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	userRepo := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo, "test-secret")
+	authService := service.NewAuthService(userRepo, repository.NewTokenRepository(db), repository.NewUnitOfWork(db), service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0), nil, 0, 0)
 
 	// Регистрируем пользователя
-	authService.Register("Test User", "test@example.com", "password123")
+	authService.Register(ctx, "Test User", "test@example.com", "Str0ng-Passw0rd")
 
 	// Логинимся
-	token, err := authService.Login("test@example.com", "password123")
+	tokens, err := authService.Login(ctx, "test@example.com", "Str0ng-Passw0rd")
 
 	require.NoError(t, err)
-	assert.NotEmpty(t, token)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
 }
 
 func TestAuthService_Login_InvalidPassword(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	userRepo := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo, "test-secret")
+	authService := service.NewAuthService(userRepo, repository.NewTokenRepository(db), repository.NewUnitOfWork(db), service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0), nil, 0, 0)
 
 	// Создаем пользователя
 	hash, _ := bcrypt.GenerateFromPassword([]byte("correctpass"), bcrypt.DefaultCost)
-	userRepo.Create("Test", "test@example.com", string(hash), false)
+	userRepo.Create(ctx, "Test", "test@example.com", string(hash), false)
 
 	// Пытаемся логиниться с неверным паролем
-	token, err := authService.Login("test@example.com", "wrongpassword")
+	tokens, err := authService.Login(ctx, "test@example.com", "wrongpassword")
 
 	assert.Error(t, err)
-	assert.Empty(t, token)
+	assert.Nil(t, tokens)
 }
 
 func TestAuthService_Login_UserNotFound(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	userRepo := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo, "test-secret")
+	authService := service.NewAuthService(userRepo, repository.NewTokenRepository(db), repository.NewUnitOfWork(db), service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0), nil, 0, 0)
 
-	token, err := authService.Login("notfound@example.com", "password123")
+	tokens, err := authService.Login(ctx, "notfound@example.com", "Str0ng-Passw0rd")
 
 	assert.Error(t, err)
-	assert.Empty(t, token)
+	assert.Nil(t, tokens)
+}
+
+func TestAuthService_Refresh_RotatesAndDetectsReuse(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	authService := service.NewAuthService(userRepo, repository.NewTokenRepository(db), repository.NewUnitOfWork(db), service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0), nil, 0, 0)
+
+	authService.Register(ctx, "Test User", "test@example.com", "Str0ng-Passw0rd")
+	tokens, err := authService.Login(ctx, "test@example.com", "Str0ng-Passw0rd")
+	require.NoError(t, err)
+
+	rotated, err := authService.Refresh(ctx, tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, rotated.RefreshToken)
+
+	// Повторное использование старого токена отзывает всю цепочку
+	_, err = authService.Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, service.ErrRefreshTokenReused)
+
+	_, err = authService.Refresh(ctx, rotated.RefreshToken)
+	assert.ErrorIs(t, err, service.ErrRefreshTokenReused)
+
+	_, err = authService.Refresh(ctx, "unknown")
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+}
+
+func TestAuthService_LogoutAll_RevokesTokens(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	authService := service.NewAuthService(userRepo, repository.NewTokenRepository(db), repository.NewUnitOfWork(db), service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0), nil, 0, 0)
+
+	user, err := authService.Register(ctx, "Test User", "test@example.com", "Str0ng-Passw0rd")
+	require.NoError(t, err)
+	tokens, err := authService.Login(ctx, "test@example.com", "Str0ng-Passw0rd")
+	require.NoError(t, err)
+
+	require.NoError(t, authService.CheckAccessToken(ctx, user.ID, "jti-1", 0))
+
+	require.NoError(t, authService.LogoutAll(ctx, user.ID))
+	assert.ErrorIs(t, authService.CheckAccessToken(ctx, user.ID, "jti-1", 0), service.ErrTokenRevoked)
+	assert.NoError(t, authService.CheckAccessToken(ctx, user.ID, "jti-1", 1))
+
+	_, err = authService.Refresh(ctx, tokens.RefreshToken)
+	assert.Error(t, err)
+
+	// Удалённый пользователь не может пользоваться старыми токенами
+	require.NoError(t, userRepo.Delete(ctx, user.ID))
+	assert.ErrorIs(t, authService.CheckAccessToken(ctx, user.ID, "jti-1", 1), service.ErrTokenRevoked)
 }
@@ -3,6 +3,7 @@ package unit
 import (
 	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/tests/testutils"
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,6 +11,7 @@ import (
 )
 
 func TestBookingRepository_ListAll(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -19,10 +21,10 @@ func TestBookingRepository_ListAll(t *testing.T) {
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	classID := testutils.CreateTestClass(t, db, "Test Class", trainerID, gymID, 20)
 
-	_, err := repo.Create(userID, classID)
+	_, err := repo.Create(ctx, userID, classID)
 	require.NoError(t, err)
 
-	bookings, err := repo.ListAll()
+	bookings, err := repo.ListAll(ctx)
 	require.NoError(t, err)
 	assert.NotEmpty(t, bookings)
 }
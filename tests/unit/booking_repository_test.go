@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"context"
 	"testing"
 
 	"Gym_StrongCode/internal/repository"
@@ -11,6 +12,7 @@ import (
 )
 
 func TestBookingRepository_Create(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	repo := repository.NewBookingRepository(db)
 
@@ -19,13 +21,14 @@ func TestBookingRepository_Create(t *testing.T) {
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	classID := testutils.CreateTestClass(t, db, "Yoga", trainerID, gymID, 10)
 
-	bookingID, err := repo.Create(userID, classID)
+	bookingID, err := repo.Create(ctx, userID, classID)
 
 	require.NoError(t, err)
 	assert.NotZero(t, bookingID)
 }
 
 func TestBookingRepository_Exists(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	repo := repository.NewBookingRepository(db)
 
@@ -35,20 +38,21 @@ func TestBookingRepository_Exists(t *testing.T) {
 	classID := testutils.CreateTestClass(t, db, "Yoga", trainerID, gymID, 10)
 
 	// Создаем бронирование
-	repo.Create(userID, classID)
+	repo.Create(ctx, userID, classID)
 
 	// Проверяем существование
-	exists, err := repo.Exists(userID, classID)
+	exists, err := repo.Exists(ctx, userID, classID)
 	require.NoError(t, err)
 	assert.True(t, exists)
 
 	// Проверяем несуществующее бронирование
-	exists, err = repo.Exists(userID, 9999)
+	exists, err = repo.Exists(ctx, userID, 9999)
 	require.NoError(t, err)
 	assert.False(t, exists)
 }
 
 func TestBookingRepository_GetByUser(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	repo := repository.NewBookingRepository(db)
 
@@ -59,16 +63,17 @@ func TestBookingRepository_GetByUser(t *testing.T) {
 	classID2 := testutils.CreateTestClass(t, db, "Pilates", trainerID, gymID, 15)
 
 	// Создаем несколько бронирований
-	repo.Create(userID, classID1)
-	repo.Create(userID, classID2)
+	repo.Create(ctx, userID, classID1)
+	repo.Create(ctx, userID, classID2)
 
-	bookings, err := repo.GetByUser(userID)
+	bookings, err := repo.GetByUser(ctx, userID)
 
 	require.NoError(t, err)
 	assert.Len(t, bookings, 2)
 }
 
 func TestBookingRepository_Cancel(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	repo := repository.NewBookingRepository(db)
 
@@ -77,13 +82,13 @@ func TestBookingRepository_Cancel(t *testing.T) {
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	classID := testutils.CreateTestClass(t, db, "Yoga", trainerID, gymID, 10)
 
-	bookingID, _ := repo.Create(userID, classID)
+	bookingID, _ := repo.Create(ctx, userID, classID)
 
 	// Отменяем бронирование
-	err := repo.Cancel(int(bookingID), userID)
+	err := repo.Cancel(ctx, int(bookingID), userID)
 	require.NoError(t, err)
 
 	// Проверяем, что оно удалено
-	exists, _ := repo.Exists(userID, classID)
+	exists, _ := repo.Exists(ctx, userID, classID)
 	assert.False(t, exists)
 }
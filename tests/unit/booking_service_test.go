@@ -1,9 +1,10 @@
 package unit
 
 import (
+	"context"
 	"testing"
 
-	"Gym_StrongCode/config"
+	"Gym_StrongCode/internal/notification"
 	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/internal/service"
 	"Gym_StrongCode/internal/utils"
@@ -14,6 +15,7 @@ import (
 )
 
 func TestBookingService_Create(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 	utils.InitLogger()
@@ -21,77 +23,82 @@ func TestBookingService_Create(t *testing.T) {
 	bookingRepo := repository.NewBookingRepository(db)
 	classRepo := repository.NewClassRepository(db)
 	membershipRepo := repository.NewMembershipRepository(db)
-	notifService := service.NewNotificationService(&config.Config{})
-	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, notifService)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, repository.NewUnitOfWork(db), notifService, nil)
 
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
 	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	classID := testutils.CreateTestClass(t, db, "Test Class", trainerID, gymID, 20)
 
-	err := bookingService.Create(userID, classID, "user@test.com")
+	user, err := repository.NewUserRepository(db).GetByID(ctx, userID)
+	require.NoError(t, err)
+	err = bookingService.Create(ctx, user, classID)
 	require.NoError(t, err)
 }
 
 func TestBookingService_ListUser(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	bookingRepo := repository.NewBookingRepository(db)
 	classRepo := repository.NewClassRepository(db)
 	membershipRepo := repository.NewMembershipRepository(db)
-	notifService := service.NewNotificationService(&config.Config{})
-	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, notifService)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, repository.NewUnitOfWork(db), notifService, nil)
 
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
 	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	classID := testutils.CreateTestClass(t, db, "Test Class", trainerID, gymID, 20)
-	bookingRepo.Create(userID, classID)
+	bookingRepo.Create(ctx, userID, classID)
 
-	bookings, err := bookingService.ListUser(userID)
+	bookings, err := bookingService.ListUser(ctx, userID)
 	require.NoError(t, err)
 	assert.NotNil(t, bookings)
 }
 
 func TestBookingService_ListAll(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	bookingRepo := repository.NewBookingRepository(db)
 	classRepo := repository.NewClassRepository(db)
 	membershipRepo := repository.NewMembershipRepository(db)
-	notifService := service.NewNotificationService(&config.Config{})
-	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, notifService)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, repository.NewUnitOfWork(db), notifService, nil)
 
 	// Создаем тестовое бронирование для проверки
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
 	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	classID := testutils.CreateTestClass(t, db, "Test Class", trainerID, gymID, 20)
-	bookingRepo.Create(userID, classID)
+	bookingRepo.Create(ctx, userID, classID)
 
-	bookings, err := bookingService.ListAll()
+	bookings, err := bookingService.ListAll(ctx)
 	require.NoError(t, err)
 	assert.NotNil(t, bookings)
 }
 
 func TestBookingService_Cancel(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	bookingRepo := repository.NewBookingRepository(db)
 	classRepo := repository.NewClassRepository(db)
 	membershipRepo := repository.NewMembershipRepository(db)
-	notifService := service.NewNotificationService(&config.Config{})
-	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, notifService)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	bookingService := service.NewBookingService(bookingRepo, classRepo, membershipRepo, repository.NewUnitOfWork(db), notifService, nil)
 
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
 	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	classID := testutils.CreateTestClass(t, db, "Test Class", trainerID, gymID, 20)
-	bookingID, _ := bookingRepo.Create(userID, classID)
+	bookingID, _ := bookingRepo.Create(ctx, userID, classID)
 
-	err := bookingService.Cancel(int(bookingID), userID)
+	err := bookingService.Cancel(ctx, int(bookingID), userID)
 	require.NoError(t, err)
 }
@@ -0,0 +1,185 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// campaignFixture — три клиента: yoga — ходит в северный филиал, абонемент кончается через 10 дней;
+// boxer — без абонемента, занимается в южном; quiet — отписан от рассылок
+type campaignFixture struct {
+	db                 *sql.DB
+	north, south       int
+	yoga, boxer, quiet int
+	notifService       *service.NotificationService
+	campaignService    *service.CampaignService
+	tracker            *notification.OpenTracker
+	email              *notification.FakeChannel
+}
+
+func setupCampaigns(t *testing.T) *campaignFixture {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	f := &campaignFixture{db: db, email: notification.NewFakeChannel(notification.ChannelEmail)}
+	f.notifService = service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, f.email),
+		notification.NewUnsubscribeLinks("https://gym.test/unsubscribe", "secret"), 1)
+	f.tracker = notification.NewOpenTracker("https://gym.test/open", "secret")
+	f.campaignService = service.NewCampaignService(repository.NewCampaignRepository(db), repository.NewUnitOfWork(db), f.notifService, f.tracker)
+
+	f.north = testutils.CreateTestGym(t, db, "North Branch", "Address")
+	f.south = testutils.CreateTestGym(t, db, "South Branch", "Address")
+	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
+	yogaClass := testutils.CreateTestClass(t, db, "Morning Yoga", trainerID, f.north, 20)
+	boxingClass := testutils.CreateTestClass(t, db, "Boxing", trainerID, f.south, 20)
+	membershipID := testutils.CreateTestMembership(t, db, "Monthly", 30, 1000000)
+
+	f.yoga = testutils.CreateTestUser(t, db, "yoga@test.com", "Str0ng-Passw0rd", false)
+	f.boxer = testutils.CreateTestUser(t, db, "boxer@test.com", "Str0ng-Passw0rd", false)
+	f.quiet = testutils.CreateTestUser(t, db, "quiet@test.com", "Str0ng-Passw0rd", false)
+
+	bookings := repository.NewBookingRepository(db)
+	_, err := bookings.Create(ctx, f.yoga, yogaClass)
+	require.NoError(t, err)
+	_, err = bookings.Create(ctx, f.boxer, boxingClass)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO user_memberships (user_id, membership_id, start_date, end_date) VALUES (?, ?, date('now', '-20 days'), date('now', '+10 days'))`,
+		f.yoga, membershipID)
+	require.NoError(t, err)
+
+	require.NoError(t, f.notifService.UpdatePreferences(ctx, f.quiet, &models.NotificationSettings{Preferences: []models.NotificationPreference{
+		{Category: notification.CategoryMarketing, Channel: notification.ChannelEmail, Enabled: false},
+	}}))
+	return f
+}
+
+func segmentUsers(t *testing.T, f *campaignFixture, seg models.Segment) []int {
+	ctx := context.Background()
+	preview, err := f.campaignService.PreviewSegment(ctx, &seg)
+	require.NoError(t, err)
+	ids := []int{}
+	for _, u := range preview.Sample {
+		ids = append(ids, u.ID)
+	}
+	assert.Equal(t, preview.Count, len(ids))
+	return ids
+}
+
+func TestCampaignService_Segments(t *testing.T) {
+	ctx := context.Background()
+	f := setupCampaigns(t)
+	yes, no := true, false
+	today := time.Now()
+
+	assert.Equal(t, []int{f.yoga, f.boxer, f.quiet}, segmentUsers(t, f, models.Segment{}))
+	assert.Equal(t, []int{f.yoga}, segmentUsers(t, f, models.Segment{GymIDs: []int{f.north}}))
+	assert.Equal(t, []int{f.yoga}, segmentUsers(t, f, models.Segment{ActiveMembership: &yes}))
+	assert.Equal(t, []int{f.boxer, f.quiet}, segmentUsers(t, f, models.Segment{ActiveMembership: &no}))
+	assert.Equal(t, []int{f.yoga}, segmentUsers(t, f, models.Segment{BookedClassTitle: "yoga"}))
+	assert.Equal(t, []int{f.yoga}, segmentUsers(t, f, models.Segment{
+		GymIDs:                []int{f.north},
+		MembershipExpiresFrom: today.Format("2006-01-02"),
+		MembershipExpiresTo:   today.AddDate(0, 0, 15).Format("2006-01-02"),
+	}))
+	assert.Empty(t, segmentUsers(t, f, models.Segment{MembershipExpiresTo: today.AddDate(0, 0, 5).Format("2006-01-02")}))
+	assert.Empty(t, segmentUsers(t, f, models.Segment{GymIDs: []int{f.south}, BookedClassTitle: "yoga"}))
+
+	for _, seg := range []models.Segment{
+		{MembershipExpiresFrom: "next month"},
+		{ActiveMembership: &no, MembershipIDs: []int{1}},
+		{Locales: []string{"de"}},
+	} {
+		_, err := f.campaignService.PreviewSegment(ctx, &seg)
+		assert.ErrorIs(t, err, service.ErrInvalidCampaign, "%+v", seg)
+	}
+}
+
+func TestCampaignService_ThrottledSendingAndStats(t *testing.T) {
+	ctx := context.Background()
+	f := setupCampaigns(t)
+	now := time.Now()
+
+	campaign, err := f.campaignService.Create(ctx, &models.Campaign{
+		Name: "Spring", Title: "Весеннее расписание", Body: "Новые группы с понедельника.",
+		ScheduledAt: now.Add(time.Hour).Format(time.RFC3339), RatePerMinute: 2,
+	}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "scheduled", campaign.Status)
+
+	// До назначенного времени ничего не отправляется
+	n, err := f.campaignService.ProcessDue(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// Первая минута — не больше rate_per_minute писем
+	n, err = f.campaignService.ProcessDue(ctx, now.Add(61*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	got, err := f.campaignService.Get(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "sending", got.Status)
+	assert.Equal(t, models.CampaignStats{Recipients: 3, Pending: 1, Queued: 2}, *got.Stats)
+
+	// Отписанный от рассылок пропускается, и кампания завершается
+	n, err = f.campaignService.ProcessDue(ctx, now.Add(62*time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	_, err = f.notifService.ProcessPending(ctx, time.Now())
+	require.NoError(t, err)
+
+	sent := f.email.Sent()
+	require.Len(t, sent, 2)
+	assert.Equal(t, "Весеннее расписание", sent[0].Subject)
+	assert.Contains(t, sent[0].Text, "Новые группы с понедельника.")
+	assert.NotEmpty(t, sent[0].UnsubscribeURL)
+	assert.Contains(t, sent[0].HTML, `<img src="https://gym.test/open?token=`)
+
+	require.NoError(t, f.campaignService.TrackOpen(ctx, f.tracker.Token(campaign.ID, f.yoga)))
+	require.NoError(t, f.campaignService.TrackOpen(ctx, f.tracker.Token(campaign.ID, f.yoga)))
+	assert.ErrorIs(t, f.campaignService.TrackOpen(ctx, "forged"), notification.ErrInvalidTrackingLink)
+
+	got, err = f.campaignService.Get(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "sent", got.Status)
+	assert.Equal(t, models.CampaignStats{Recipients: 3, Skipped: 1, Sent: 2, Opened: 1}, *got.Stats)
+
+	assert.ErrorIs(t, f.campaignService.Cancel(ctx, campaign.ID), service.ErrCampaignState)
+	assert.ErrorIs(t, f.campaignService.Schedule(ctx, 9999, ""), service.ErrCampaignNotFound)
+}
+
+func TestCampaignService_DraftScheduleAndCancel(t *testing.T) {
+	ctx := context.Background()
+	f := setupCampaigns(t)
+
+	_, err := f.campaignService.Create(ctx, &models.Campaign{Name: "x", Title: "x", Body: "x", RatePerMinute: 5000}, 0)
+	assert.ErrorIs(t, err, service.ErrInvalidCampaign)
+	_, err = f.campaignService.Create(ctx, &models.Campaign{Name: "x", Title: "x", Body: "x", ScheduledAt: "tomorrow"}, 0)
+	assert.ErrorIs(t, err, service.ErrInvalidCampaign)
+
+	draft, err := f.campaignService.Create(ctx, &models.Campaign{Name: "Yoga week", Title: "Yoga", Body: "Join us",
+		Segment: models.Segment{BookedClassTitle: "yoga"}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "draft", draft.Status)
+	assert.Equal(t, 60, draft.RatePerMinute)
+
+	n, err := f.campaignService.ProcessDue(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, n, "черновик не рассылается")
+
+	require.NoError(t, f.campaignService.Schedule(ctx, draft.ID, ""))
+	require.NoError(t, f.campaignService.Cancel(ctx, draft.ID))
+	n, err = f.campaignService.ProcessDue(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, n, "отменённая кампания не рассылается")
+	assert.ErrorIs(t, f.campaignService.Schedule(ctx, draft.ID, ""), service.ErrCampaignState)
+}
@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 )
 
 func TestClassRepository_Create(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -30,13 +32,14 @@ func TestClassRepository_Create(t *testing.T) {
 		Capacity:    20,
 	}
 
-	created, err := repo.Create(class)
+	created, err := repo.Create(ctx, class)
 	require.NoError(t, err)
 	assert.NotZero(t, created.ID)
 	assert.Equal(t, "Yoga Class", created.Title)
 }
 
 func TestClassRepository_GetByID(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -45,13 +48,14 @@ func TestClassRepository_GetByID(t *testing.T) {
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	classID := testutils.CreateTestClass(t, db, "Test Class", trainerID, gymID, 20)
 
-	class, err := repo.GetByID(classID)
+	class, err := repo.GetByID(ctx, classID)
 	require.NoError(t, err)
 	assert.Equal(t, classID, class.ID)
 	assert.Equal(t, "Test Class", class.Title)
 }
 
 func TestClassRepository_List(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -61,12 +65,13 @@ func TestClassRepository_List(t *testing.T) {
 	testutils.CreateTestClass(t, db, "Class 1", trainerID, gymID, 20)
 	testutils.CreateTestClass(t, db, "Class 2", trainerID, gymID, 20)
 
-	classes, err := repo.List()
+	classes, err := repo.List(ctx)
 	require.NoError(t, err)
 	assert.GreaterOrEqual(t, len(classes), 2)
 }
 
 func TestClassRepository_Update(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -86,15 +91,16 @@ func TestClassRepository_Update(t *testing.T) {
 		Capacity:    25,
 	}
 
-	err := repo.Update(classID, updated)
+	err := repo.Update(ctx, classID, updated)
 	require.NoError(t, err)
 
-	class, _ := repo.GetByID(classID)
+	class, _ := repo.GetByID(ctx, classID)
 	assert.Equal(t, "New Title", class.Title)
 	assert.Equal(t, 90, class.DurationMin)
 }
 
 func TestClassRepository_Delete(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -103,14 +109,15 @@ func TestClassRepository_Delete(t *testing.T) {
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	classID := testutils.CreateTestClass(t, db, "Test Class", trainerID, gymID, 20)
 
-	err := repo.Delete(classID)
+	err := repo.Delete(ctx, classID)
 	require.NoError(t, err)
 
-	_, err = repo.GetByID(classID)
+	_, err = repo.GetByID(ctx, classID)
 	assert.Error(t, err)
 }
 
 func TestClassRepository_GetBookingCount(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -119,7 +126,7 @@ func TestClassRepository_GetBookingCount(t *testing.T) {
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	classID := testutils.CreateTestClass(t, db, "Test Class", trainerID, gymID, 20)
 
-	count, err := repo.GetBookingCount(classID)
+	count, err := repo.GetBookingCount(ctx, classID)
 	require.NoError(t, err)
 	assert.Equal(t, 0, count)
 }
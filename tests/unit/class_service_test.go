@@ -2,9 +2,12 @@ package unit
 
 import (
 	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
 	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/internal/service"
 	"Gym_StrongCode/tests/testutils"
+	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -12,14 +15,28 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// setupClassService собирает ClassService с очередью уведомлений; письма уходят в FakeChannel
+func setupClassService(t *testing.T, db *sql.DB) (*service.ClassService, func() []notification.Envelope) {
+	ctx := context.Background()
+	email := notification.NewFakeChannel(notification.ChannelEmail)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, email), nil, 1)
+	classService := service.NewClassService(repository.NewClassRepository(db), repository.NewTrainerRepository(db), repository.NewGymRepository(db),
+		repository.NewBookingRepository(db), repository.NewUnitOfWork(db), notifService)
+
+	deliver := func() []notification.Envelope {
+		_, err := notifService.ProcessPending(ctx, time.Now())
+		require.NoError(t, err)
+		return email.Sent()
+	}
+	return classService, deliver
+}
+
 func TestClassService_Create(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
-	classRepo := repository.NewClassRepository(db)
-	trainerRepo := repository.NewTrainerRepository(db)
-	gymRepo := repository.NewGymRepository(db)
-	classService := service.NewClassService(classRepo, trainerRepo, gymRepo)
+	classService, _ := setupClassService(t, db)
 
 	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
@@ -34,38 +51,34 @@ func TestClassService_Create(t *testing.T) {
 		Capacity:    20,
 	}
 
-	created, err := classService.Create(class)
+	created, err := classService.Create(ctx, class)
 	require.NoError(t, err)
 	assert.NotZero(t, created.ID)
 	assert.Equal(t, "Yoga", created.Title)
 }
 
 func TestClassService_List(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
-	classRepo := repository.NewClassRepository(db)
-	trainerRepo := repository.NewTrainerRepository(db)
-	gymRepo := repository.NewGymRepository(db)
-	classService := service.NewClassService(classRepo, trainerRepo, gymRepo)
+	classService, _ := setupClassService(t, db)
 
 	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	testutils.CreateTestClass(t, db, "Class 1", trainerID, gymID, 20)
 
-	classes, err := classService.List()
+	classes, err := classService.List(ctx)
 	require.NoError(t, err)
 	assert.NotEmpty(t, classes)
 }
 
 func TestClassService_Update(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
-	classRepo := repository.NewClassRepository(db)
-	trainerRepo := repository.NewTrainerRepository(db)
-	gymRepo := repository.NewGymRepository(db)
-	classService := service.NewClassService(classRepo, trainerRepo, gymRepo)
+	classService, _ := setupClassService(t, db)
 
 	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
@@ -81,23 +94,135 @@ func TestClassService_Update(t *testing.T) {
 		Capacity:    25,
 	}
 
-	err := classService.Update(classID, updated)
+	err := classService.Update(ctx, classID, updated)
 	require.NoError(t, err)
 }
 
 func TestClassService_Delete(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
-	classRepo := repository.NewClassRepository(db)
-	trainerRepo := repository.NewTrainerRepository(db)
-	gymRepo := repository.NewGymRepository(db)
-	classService := service.NewClassService(classRepo, trainerRepo, gymRepo)
+	classService, _ := setupClassService(t, db)
 
 	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
 	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
 	classID := testutils.CreateTestClass(t, db, "Test Class", trainerID, gymID, 20)
 
-	err := classService.Delete(classID)
+	err := classService.Delete(ctx, classID)
+	require.NoError(t, err)
+}
+
+func TestClassService_UpdateNotifiesAttendees(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	defer db.Close()
+	classService, deliver := setupClassService(t, db)
+
+	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
+	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
+	newTrainerID := testutils.CreateTestTrainer(t, db, "Aliya", "Bio")
+	classID := testutils.CreateTestClass(t, db, "Yoga", trainerID, gymID, 20)
+	userID := testutils.CreateTestUser(t, db, "member@test.com", "Str0ng-Passw0rd", false)
+	_, err := repository.NewBookingRepository(db).Create(ctx, userID, classID)
+	require.NoError(t, err)
+
+	class, err := repository.NewClassRepository(db).GetByID(ctx, classID)
+	require.NoError(t, err)
+
+	// Смена названия не касается записавшихся
+	class.Title = "Morning Yoga"
+	require.NoError(t, classService.Update(ctx, classID, class))
+	assert.Empty(t, deliver())
+
+	class.StartTime = "2030-01-10 19:00:00"
+	class.TrainerID = newTrainerID
+	require.NoError(t, classService.Update(ctx, classID, class))
+	sent := deliver()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "member@test.com", sent[0].To)
+	assert.Equal(t, notification.TemplateClassChanged, sent[0].Template)
+	assert.Contains(t, sent[0].Text, "→ 2030-01-10 19:00:00")
+	assert.Contains(t, sent[0].Text, "Новый тренер: Aliya")
+
+	assert.ErrorIs(t, classService.Update(ctx, 9999, class), service.ErrClassNotFound)
+}
+
+func TestClassService_DeleteCancelsBookings(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	defer db.Close()
+	classService, deliver := setupClassService(t, db)
+
+	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
+	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
+	classID := testutils.CreateTestClass(t, db, "Yoga", trainerID, gymID, 20)
+	bookingRepo := repository.NewBookingRepository(db)
+	for _, email := range []string{"a@test.com", "b@test.com"} {
+		userID := testutils.CreateTestUser(t, db, email, "Str0ng-Passw0rd", false)
+		_, err := bookingRepo.Create(ctx, userID, classID)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, classService.Delete(ctx, classID))
+	sent := deliver()
+	require.Len(t, sent, 2)
+	assert.Equal(t, notification.TemplateClassCancelled, sent[0].Template)
+
+	bookings, err := bookingRepo.ListAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, bookings)
+	assert.ErrorIs(t, classService.Delete(ctx, classID), service.ErrClassNotFound)
+}
+
+func TestClassReminderService_SendDue(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	defer db.Close()
+	email := notification.NewFakeChannel(notification.ChannelEmail)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, email), nil, 1)
+	bookingRepo := repository.NewBookingRepository(db)
+	reminders := service.NewClassReminderService(bookingRepo, repository.NewUnitOfWork(db), notifService, 3*time.Hour)
+
+	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
+	trainerID := testutils.CreateTestTrainer(t, db, "Trainer", "Bio")
+	soonID := testutils.CreateTestClass(t, db, "Soon", trainerID, gymID, 20)
+	laterID := testutils.CreateTestClass(t, db, "Tomorrow", trainerID, gymID, 20)
+	userID := testutils.CreateTestUser(t, db, "member@test.com", "Str0ng-Passw0rd", false)
+	for _, classID := range []int{soonID, laterID} {
+		_, err := bookingRepo.Create(ctx, userID, classID)
+		require.NoError(t, err)
+	}
+
+	now := time.Now()
+	setStart := func(at time.Time) {
+		_, err := db.Exec(`UPDATE classes SET start_time = ? WHERE id = ?`, at.UTC().Format(time.RFC3339), soonID)
+		require.NoError(t, err)
+	}
+	setStart(now.Add(2 * time.Hour))
+
+	n, err := reminders.SendDue(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "занятие через сутки ещё рано напоминать")
+	n, err = reminders.SendDue(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, n, "повторно не напоминаем")
+
+	// После переноса напоминание уходит снова
+	setStart(now.Add(150 * time.Minute))
+	n, err = reminders.SendDue(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = notifService.ProcessPending(ctx, time.Now())
+	require.NoError(t, err)
+	sent := email.Sent()
+	require.Len(t, sent, 2)
+	assert.Equal(t, notification.TemplateClassReminder, sent[0].Template)
+	assert.Contains(t, sent[0].Subject, "Soon")
+
+	// Выключенные напоминания
+	n, err = service.NewClassReminderService(bookingRepo, repository.NewUnitOfWork(db), notifService, 0).SendDue(ctx, now.Add(23*time.Hour))
 	require.NoError(t, err)
+	assert.Zero(t, n)
 }
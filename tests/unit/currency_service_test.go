@@ -0,0 +1,169 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyService_SetRate(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+
+	rate, err := currencyService.SetRate(ctx, "usd", 520.5)
+	require.NoError(t, err)
+	assert.Equal(t, "USD", rate.Currency)
+	assert.Equal(t, 520.5, rate.Rate)
+
+	// Обновление существующего курса
+	rate, err = currencyService.SetRate(ctx, "USD", 510)
+	require.NoError(t, err)
+	assert.Equal(t, 510.0, rate.Rate)
+
+	rates, err := currencyService.ListRates(ctx)
+	require.NoError(t, err)
+	assert.Len(t, rates, 1)
+
+	// Невалидные значения
+	_, err = currencyService.SetRate(ctx, "KZT", 1)
+	assert.Error(t, err)
+	_, err = currencyService.SetRate(ctx, "DOLLAR", 500)
+	assert.Error(t, err)
+	_, err = currencyService.SetRate(ctx, "EUR", 0)
+	assert.Error(t, err)
+}
+
+func TestCurrencyService_ResolveAndConvert(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+
+	code, err := currencyService.Resolve(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, "KZT", code)
+
+	_, err = currencyService.Resolve(ctx, "USD")
+	assert.ErrorIs(t, err, service.ErrUnsupportedCurrency)
+
+	_, err = currencyService.SetRate(ctx, "USD", 500)
+	require.NoError(t, err)
+
+	code, err = currencyService.Resolve(ctx, " usd ")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", code)
+
+	// 10.00 USD -> 5000.00 KZT
+	converted, err := currencyService.ConvertToBase(ctx, 1000, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, 500000, converted)
+
+	converted, err = currencyService.ConvertToBase(ctx, 1000, "KZT")
+	require.NoError(t, err)
+	assert.Equal(t, 1000, converted)
+}
+
+func TestMembershipService_PriceIn(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	membershipRepo := repository.NewMembershipRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, repository.NewUnitOfWork(db), notifService, currencyService, taxService, fiscalService, nil)
+
+	membershipID := testutils.CreateTestMembership(t, db, "Monthly", 30, 1500000)
+	membership, err := membershipRepo.GetByID(ctx, membershipID)
+	require.NoError(t, err)
+
+	// Базовая валюта берётся из самого тарифа
+	currency, price, err := membershipService.PriceIn(ctx, membership, "")
+	require.NoError(t, err)
+	assert.Equal(t, "KZT", currency)
+	assert.Equal(t, 1500000, price)
+
+	// Без курса цену в USD задать нельзя
+	_, err = membershipService.SetPrice(ctx, membershipID, "USD", 3000)
+	assert.Error(t, err)
+
+	_, err = currencyService.SetRate(ctx, "USD", 500)
+	require.NoError(t, err)
+
+	// Курс есть, но цены в прайс-листе нет
+	_, _, err = membershipService.PriceIn(ctx, membership, "USD")
+	assert.Error(t, err)
+
+	_, err = membershipService.SetPrice(ctx, membershipID, "usd", 3000)
+	require.NoError(t, err)
+
+	currency, price, err = membershipService.PriceIn(ctx, membership, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", currency)
+	assert.Equal(t, 3000, price)
+
+	prices, err := membershipService.ListPrices(ctx, membershipID)
+	require.NoError(t, err)
+	assert.Len(t, prices, 1)
+
+	require.NoError(t, membershipService.DeletePrice(ctx, membershipID, "USD"))
+	prices, err = membershipService.ListPrices(ctx, membershipID)
+	require.NoError(t, err)
+	assert.Empty(t, prices)
+}
+
+func TestPaymentService_Summary(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	paymentRepo := repository.NewPaymentRepository(db)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, nil)
+
+	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
+
+	_, err := currencyService.SetRate(ctx, "USD", 500)
+	require.NoError(t, err)
+
+	_, err = paymentService.Create(ctx, userID, 100000, "", "card", "", "")
+	require.NoError(t, err)
+	_, err = paymentService.Create(ctx, userID, 1000, "USD", "card", "", "")
+	require.NoError(t, err)
+
+	// Возвраты вычитаются: частичный — на свою сумму, полный — целиком
+	partial, err := paymentService.Create(ctx, userID, 20000, "", "card", "", "")
+	require.NoError(t, err)
+	_, err = paymentService.Refund(ctx, partial.ID, 5000)
+	require.NoError(t, err)
+	full, err := paymentService.Create(ctx, userID, 400, "USD", "card", "", "")
+	require.NoError(t, err)
+	_, err = paymentService.Refund(ctx, full.ID, 0)
+	require.NoError(t, err)
+
+	// Платёж в валюте без курса через сервис создать нельзя
+	_, err = paymentService.Create(ctx, userID, 1000, "EUR", "card", "", "")
+	assert.ErrorIs(t, err, service.ErrUnsupportedCurrency)
+
+	// ...но он мог остаться с тех пор, как курс был удалён
+	_, err = paymentRepo.CreateStandalone(ctx, userID, 2000, "EUR", "card", "completed", "", "")
+	require.NoError(t, err)
+
+	summary, err := paymentService.Summary(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "KZT", summary.BaseCurrency)
+	assert.Equal(t, 100000+15000+500000, summary.TotalBaseCents)
+	assert.Equal(t, 115000, summary.ByCurrency["KZT"])
+	assert.Equal(t, 1000, summary.ByCurrency["USD"])
+	assert.Equal(t, []string{"EUR"}, summary.MissingRates)
+}
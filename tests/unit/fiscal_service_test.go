@@ -0,0 +1,186 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/fiscal"
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiscalService_RegistersReceipt(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	paymentRepo := repository.NewPaymentRepository(db)
+	sender := fiscal.NewFakeSender()
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, sender)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, nil)
+
+	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
+
+	payment, err := paymentService.Charge(ctx, &models.Payment{UserID: userID, AmountCents: 5000, Method: "card"})
+	require.NoError(t, err)
+
+	entries, err := fiscalService.List(ctx, "pending")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, payment.ID, entries[0].PaymentID)
+
+	sent, err := fiscalService.ProcessPending(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, sender.Sent(), 1)
+	assert.Equal(t, 5000, sender.Sent()[0].AmountCents)
+
+	stored, err := paymentRepo.GetByID(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, stored.FiscalSign)
+	assert.Contains(t, stored.FiscalURL, stored.FiscalSign)
+
+	// Повторно чек не отправляется
+	sent, err = fiscalService.ProcessPending(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+}
+
+func TestFiscalService_RetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	paymentRepo := repository.NewPaymentRepository(db)
+	fiscalRepo := repository.NewFiscalRepository(db)
+	sender := fiscal.NewFakeSender()
+	fiscalService := service.NewFiscalService(fiscalRepo, paymentRepo, sender)
+
+	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
+	payment, err := paymentRepo.CreateStandalone(ctx, userID, 3000, "KZT", "cash", "completed", "", "")
+	require.NoError(t, err)
+	require.NoError(t, fiscalService.Enqueue(ctx, nil, payment))
+
+	// Оператор недоступен — запись остаётся в очереди с отложенной попыткой
+	sender.FailNext(1)
+	now := time.Now().Add(time.Minute)
+	sent, err := fiscalService.ProcessPending(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	entries, err := fiscalService.List(ctx, "pending")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].Attempts)
+	assert.NotEmpty(t, entries[0].LastError)
+
+	// До истечения задержки повторной попытки нет
+	sent, err = fiscalService.ProcessPending(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	sent, err = fiscalService.ProcessPending(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	// После исчерпания попыток запись переходит в failed и возвращается вручную
+	payment, err = paymentRepo.CreateStandalone(ctx, userID, 1000, "KZT", "cash", "completed", "", "")
+	require.NoError(t, err)
+	require.NoError(t, fiscalService.Enqueue(ctx, nil, payment))
+
+	sender.FailNext(100)
+	for i := 0; i < 10; i++ {
+		now = now.Add(24 * time.Hour)
+		_, err = fiscalService.ProcessPending(ctx, now)
+		require.NoError(t, err)
+	}
+
+	failed, err := fiscalService.List(ctx, "failed")
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+
+	sender.FailNext(0)
+	require.NoError(t, fiscalService.Retry(ctx, failed[0].ID))
+	sent, err = fiscalService.ProcessPending(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	assert.Error(t, fiscalService.Retry(ctx, failed[0].ID))
+}
+
+func TestFiscalService_ReclaimsStuckReceipts(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	paymentRepo := repository.NewPaymentRepository(db)
+	fiscalRepo := repository.NewFiscalRepository(db)
+	sender := fiscal.NewFakeSender()
+	fiscalService := service.NewFiscalService(fiscalRepo, paymentRepo, sender)
+
+	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
+	payment, err := paymentRepo.CreateStandalone(ctx, userID, 3000, "KZT", "cash", "completed", "", "")
+	require.NoError(t, err)
+	require.NoError(t, fiscalService.Enqueue(ctx, nil, payment))
+
+	// Другой экземпляр забрал чек и упал, не отметив результат
+	now := time.Now().Add(time.Minute)
+	claimed, err := fiscalRepo.ClaimDue(ctx, now, 10, 5*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	sent, err := fiscalService.ProcessPending(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "чек ещё числится за другим воркером")
+	assert.Error(t, fiscalService.Retry(ctx, claimed[0].ID), "взятый воркером чек вручную не возвращается")
+
+	sent, err = fiscalService.ProcessPending(ctx, now.Add(10*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, sender.Sent(), 1)
+}
+
+// stoppingSender регистрирует чек и тут же отменяет контекст воркера, как StopWorker
+type stoppingSender struct {
+	*fiscal.FakeSender
+	stop context.CancelFunc
+}
+
+func (s stoppingSender) SendReceipt(ctx context.Context, receipt *models.FiscalReceipt) (*models.FiscalRegistration, error) {
+	reg, err := s.FakeSender.SendReceipt(ctx, receipt)
+	s.stop()
+	return reg, err
+}
+
+func TestFiscalService_SavesResultAfterWorkerStop(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	paymentRepo := repository.NewPaymentRepository(db)
+	sender := fiscal.NewFakeSender()
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, stoppingSender{sender, stop})
+
+	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
+	payment, err := paymentRepo.CreateStandalone(ctx, userID, 3000, "KZT", "cash", "completed", "", "")
+	require.NoError(t, err)
+	require.NoError(t, fiscalService.Enqueue(ctx, nil, payment))
+
+	sent, err := fiscalService.ProcessPending(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	// Зарегистрированный во время остановки чек отмечен и повторно не уйдёт
+	entries, err := fiscalService.List(context.Background(), "sent")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	stored, err := paymentRepo.GetByID(context.Background(), payment.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, stored.FiscalSign)
+	require.Len(t, sender.Sent(), 1)
+}
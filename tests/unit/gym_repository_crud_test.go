@@ -3,6 +3,7 @@ package unit
 import (
 	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/tests/testutils"
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,30 +11,32 @@ import (
 )
 
 func TestGymRepository_Update(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	repo := repository.NewGymRepository(db)
 	gymID := testutils.CreateTestGym(t, db, "Old Name", "Old Address")
 
-	err := repo.Update(gymID, "New Name", "New Address")
+	err := repo.Update(ctx, gymID, "New Name", "New Address")
 	require.NoError(t, err)
 
-	gym, _ := repo.GetByID(gymID)
+	gym, _ := repo.GetByID(ctx, gymID)
 	assert.Equal(t, "New Name", gym.Name)
 	assert.Equal(t, "New Address", gym.Address)
 }
 
 func TestGymRepository_Delete(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	repo := repository.NewGymRepository(db)
 	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
 
-	err := repo.Delete(gymID)
+	err := repo.Delete(ctx, gymID)
 	require.NoError(t, err)
 
-	_, err = repo.GetByID(gymID)
+	_, err = repo.GetByID(ctx, gymID)
 	assert.Error(t, err)
 }
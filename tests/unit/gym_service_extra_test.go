@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"context"
 	"testing"
 
 	"Gym_StrongCode/internal/repository"
@@ -11,6 +12,7 @@ import (
 )
 
 func TestGymService_Update(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -19,11 +21,12 @@ func TestGymService_Update(t *testing.T) {
 
 	gymID := testutils.CreateTestGym(t, db, "Old Name", "Old Address")
 
-	err := gymService.Update(gymID, "New Name", "New Address")
+	err := gymService.Update(ctx, gymID, "New Name", "New Address")
 	require.NoError(t, err)
 }
 
 func TestGymService_Delete(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -32,6 +35,6 @@ func TestGymService_Delete(t *testing.T) {
 
 	gymID := testutils.CreateTestGym(t, db, "Test Gym", "Address")
 
-	err := gymService.Delete(gymID)
+	err := gymService.Delete(ctx, gymID)
 	require.NoError(t, err)
 }
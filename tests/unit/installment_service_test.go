@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyCharger проводит платежи через PaymentService, пока не выставлен fail
+type flakyCharger struct {
+	next service.PaymentCharger
+	fail bool
+}
+
+func (c *flakyCharger) Prepare(ctx context.Context, p *models.Payment) error {
+	return c.next.Prepare(ctx, p)
+}
+
+func (c *flakyCharger) ChargeTx(ctx context.Context, tx *sql.Tx, p *models.Payment) (*models.Payment, error) {
+	if c.fail {
+		return nil, errors.New("card declined")
+	}
+	return c.next.ChargeTx(ctx, tx, p)
+}
+
+func TestInstallmentService_PlanLifecycle(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	membershipRepo := repository.NewMembershipRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, repository.NewUnitOfWork(db), notifService, currencyService, taxService, fiscalService, nil)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, nil)
+
+	charger := &flakyCharger{next: paymentService}
+	installmentService := service.NewInstallmentService(repository.NewInstallmentRepository(db), membershipRepo, repository.NewUnitOfWork(db), membershipService, charger, nil, 3)
+
+	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
+	membershipID := testutils.CreateTestMembership(t, db, "Annual", 365, 15000001)
+
+	// Слишком много платежей для тарифа или неверное число
+	_, err := installmentService.CreatePlan(ctx, userID, membershipID, 1, "card", "", nil)
+	assert.Error(t, err)
+	_, err = installmentService.CreatePlan(ctx, userID, membershipID, 12, "crypto", "", nil)
+	assert.Error(t, err)
+
+	plan, err := installmentService.CreatePlan(ctx, userID, membershipID, 12, "card", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "active", plan.Status)
+	require.Len(t, plan.Installments, 12)
+	assert.Equal(t, 1250001, plan.Installments[0].AmountCents)
+	assert.Equal(t, 1250000, plan.Installments[11].AmountCents)
+	assert.Equal(t, "paid", plan.Installments[0].Status)
+	assert.Equal(t, "pending", plan.Installments[1].Status)
+	require.NotNil(t, plan.UserMembershipID)
+
+	active, err := membershipRepo.HasActiveMembership(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	// Второй платёж не прошёл — в пределах льготного периода подписка работает
+	secondDue, err := time.Parse("2006-01-02", plan.Installments[1].DueDate[:10])
+	require.NoError(t, err)
+	charger.fail = true
+
+	paid, err := installmentService.ProcessDue(ctx, secondDue)
+	require.NoError(t, err)
+	assert.Zero(t, paid)
+
+	plan, err = installmentService.GetForUser(ctx, plan.ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "active", plan.Status)
+	assert.Equal(t, 1, plan.Installments[1].Attempts)
+	assert.NotEmpty(t, plan.Installments[1].LastError)
+
+	// Льготный период истёк — подписка приостановлена
+	_, err = installmentService.ProcessDue(ctx, secondDue.AddDate(0, 0, 4))
+	require.NoError(t, err)
+
+	plan, err = installmentService.Get(ctx, plan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "suspended", plan.Status)
+
+	active, err = membershipRepo.HasActiveMembership(ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, active)
+
+	// Долг погашен — подписка возобновлена
+	charger.fail = false
+	paid, err = installmentService.ProcessDue(ctx, secondDue.AddDate(0, 0, 5))
+	require.NoError(t, err)
+	assert.Equal(t, 1, paid)
+
+	plan, err = installmentService.Get(ctx, plan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "active", plan.Status)
+	assert.Equal(t, "paid", plan.Installments[1].Status)
+
+	active, err = membershipRepo.HasActiveMembership(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	// Чужой план недоступен
+	_, err = installmentService.GetForUser(ctx, plan.ID, userID+1)
+	assert.ErrorIs(t, err, service.ErrInstallmentPlanNotFound)
+
+	plans, err := installmentService.ListAll(ctx, "active")
+	require.NoError(t, err)
+	assert.Len(t, plans, 1)
+}
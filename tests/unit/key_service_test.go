@@ -0,0 +1,102 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyService_RotationWithOverlap(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	keyService := service.NewKeyService(repository.NewSigningKeyRepository(db), "RS256", 24*time.Hour, time.Hour)
+	require.NoError(t, keyService.Init(ctx))
+
+	claims := jwt.MapClaims{"user_id": 1, "exp": time.Now().Add(10 * time.Minute).Unix()}
+	oldToken, err := keyService.Sign(ctx, claims)
+	require.NoError(t, err)
+
+	jwks := keyService.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "RS256", jwks.Keys[0].Alg)
+	assert.NotEmpty(t, jwks.Keys[0].N)
+
+	// Ротация ещё не нужна
+	rotated, err := keyService.RotateIfDue(ctx, time.Now())
+	require.NoError(t, err)
+	assert.False(t, rotated)
+
+	rotated, err = keyService.RotateIfDue(ctx, time.Now().Add(25*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, rotated)
+
+	// Новый ключ сначала только публикуется: подпись прежняя, пока не истекут кэши JWKS
+	assert.Len(t, keyService.JWKS().Keys, 2)
+	pendingToken, err := keyService.Sign(ctx, claims)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(pendingToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, jwks.Keys[0].Kid, parsed.Header["kid"])
+
+	rotated, err = keyService.RotateIfDue(ctx, time.Now().Add(service.JWKSMaxAge))
+	require.NoError(t, err)
+	assert.False(t, rotated)
+
+	rotated, err = keyService.RotateIfDue(ctx, time.Now().Add(25*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, rotated)
+
+	// Новые токены подписываются новым ключом, старые ещё принимаются
+	newToken, err := keyService.Sign(ctx, claims)
+	require.NoError(t, err)
+	parsed, _, err = jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.NotEqual(t, jwks.Keys[0].Kid, parsed.Header["kid"])
+	assert.Len(t, keyService.JWKS().Keys, 2)
+
+	_, err = keyService.ParseToken(ctx, oldToken)
+	assert.NoError(t, err)
+	_, err = keyService.ParseToken(ctx, newToken)
+	assert.NoError(t, err)
+
+	// После окончания перекрытия прежний ключ удаляется
+	_, err = keyService.RotateIfDue(ctx, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, keyService.JWKS().Keys, 1)
+	_, err = keyService.ParseToken(ctx, oldToken)
+	assert.Error(t, err)
+}
+
+func TestKeyService_SharedAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	first := service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0)
+	second := service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0)
+	require.NoError(t, first.Init(ctx))
+	require.NoError(t, second.Init(ctx))
+
+	// Второй инстанс использует уже созданный ключ, а не создаёт свой
+	assert.Equal(t, first.JWKS(), second.JWKS())
+	assert.Equal(t, "OKP", first.JWKS().Keys[0].Kty)
+
+	token, err := first.Sign(ctx, jwt.MapClaims{"user_id": 1})
+	require.NoError(t, err)
+	_, err = second.ParseToken(ctx, token)
+	assert.NoError(t, err)
+
+	// Ключ, созданный другим инстансом, подхватывается по незнакомому kid
+	require.NoError(t, first.Rotate(ctx))
+	token, err = first.Sign(ctx, jwt.MapClaims{"user_id": 1})
+	require.NoError(t, err)
+	_, err = second.ParseToken(ctx, token)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,108 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/cache"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginGuard_LockoutBackoffAndUnlock(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	userID := testutils.CreateTestUser(t, db, "victim@example.com", "Str0ng-Passw0rd", false)
+	notifier := &capturingNotifier{}
+	guard := service.NewLoginGuard(cache.NewMemoryLoginAttemptStore(), userRepo, notifier, 3, 100, time.Minute, 10*time.Minute)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, guard.Failure(ctx, "victim@example.com", "10.0.0.1"))
+		require.NoError(t, guard.Check(ctx, "victim@example.com", "10.0.0.1"))
+	}
+	require.NoError(t, guard.Failure(ctx, "victim@example.com", "10.0.0.2"))
+
+	// Третья неудача блокирует аккаунт независимо от IP и регистра email
+	var locked *service.LoginLockedError
+	require.True(t, errors.As(guard.Check(ctx, "Victim@Example.com", "10.0.0.3"), &locked))
+	assert.InDelta(t, time.Minute.Seconds(), locked.RetryAfter.Seconds(), 1)
+	assert.Len(t, notifier.sent, 1)
+
+	// Каждая следующая неудача удваивает блокировку, уведомление не повторяется
+	require.NoError(t, guard.Failure(ctx, "victim@example.com", "10.0.0.1"))
+	require.True(t, errors.As(guard.Check(ctx, "victim@example.com", "10.0.0.1"), &locked))
+	assert.InDelta(t, (2 * time.Minute).Seconds(), locked.RetryAfter.Seconds(), 1)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, guard.Failure(ctx, "victim@example.com", "10.0.0.1"))
+	}
+	require.True(t, errors.As(guard.Check(ctx, "victim@example.com", "10.0.0.1"), &locked))
+	assert.InDelta(t, (10 * time.Minute).Seconds(), locked.RetryAfter.Seconds(), 1)
+	assert.Len(t, notifier.sent, 1)
+
+	// Другие аккаунты не затронуты
+	assert.NoError(t, guard.Check(ctx, "other@example.com", "10.0.0.9"))
+
+	assert.ErrorIs(t, guard.Unlock(ctx, userID+100), service.ErrUserNotFound)
+	require.NoError(t, guard.Unlock(ctx, userID))
+	assert.NoError(t, guard.Check(ctx, "victim@example.com", "10.0.0.9"))
+}
+
+func TestLoginGuard_ConcurrentFailures(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	testutils.CreateTestUser(t, db, "victim@example.com", "Str0ng-Passw0rd", false)
+	store := cache.NewMemoryLoginAttemptStore()
+	notifier := &capturingNotifier{}
+	guard := service.NewLoginGuard(store, repository.NewUserRepository(db), notifier, 5, 100, time.Minute, 10*time.Minute)
+
+	// Параллельный перебор с разных адресов: ни одна неудача не теряется
+	const attempts = 20
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, guard.Failure(ctx, "victim@example.com", fmt.Sprintf("10.0.1.%d", i)))
+		}(i)
+	}
+	wg.Wait()
+
+	counted, err := store.GetAttempts(ctx, "account:victim@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, counted)
+	assert.Equal(t, attempts, counted.Failures)
+
+	var locked *service.LoginLockedError
+	require.True(t, errors.As(guard.Check(ctx, "victim@example.com", "10.0.2.1"), &locked))
+	assert.Greater(t, locked.RetryAfter, time.Duration(0))
+	// Блокировка наступает один раз — и уведомление одно
+	assert.Len(t, notifier.sent, 1)
+}
+
+func TestLoginGuard_PerIPLimit(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	guard := service.NewLoginGuard(cache.NewMemoryLoginAttemptStore(), repository.NewUserRepository(db), &capturingNotifier{}, 5, 3, time.Minute, time.Hour)
+
+	// Перебор по разным аккаунтам с одного адреса
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		require.NoError(t, guard.Failure(ctx, email, "203.0.113.5"))
+	}
+
+	var locked *service.LoginLockedError
+	assert.True(t, errors.As(guard.Check(ctx, "d@example.com", "203.0.113.5"), &locked))
+	assert.NoError(t, guard.Check(ctx, "d@example.com", "203.0.113.6"))
+
+	// Успешный вход сбрасывает только счётчик аккаунта
+	require.NoError(t, guard.Success(ctx, "a@example.com"))
+	assert.Error(t, guard.Check(ctx, "a@example.com", "203.0.113.5"))
+}
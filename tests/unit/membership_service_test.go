@@ -1,9 +1,11 @@
 package unit
 
 import (
+	"context"
 	"testing"
 
-	"Gym_StrongCode/config"
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
 	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/internal/service"
 	"Gym_StrongCode/tests/testutils"
@@ -13,67 +15,129 @@ import (
 )
 
 func TestMembershipService_List(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	membershipRepo := repository.NewMembershipRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
-	notifService := service.NewNotificationService(&config.Config{})
-	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, db, notifService)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, repository.NewUnitOfWork(db), notifService, currencyService, taxService, fiscalService, nil)
 
 	// Создаем тестовую подписку
 	testutils.CreateTestMembership(t, db, "Basic", 30, 5000)
 
-	memberships, err := membershipService.List()
+	memberships, err := membershipService.List(ctx)
 	require.NoError(t, err)
 	assert.NotEmpty(t, memberships)
 }
 
 func TestMembershipService_Buy(t *testing.T) {
-	t.Skip("MembershipService.Buy требует корректной работы с транзакциями - оставляем для интеграционных тестов")
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	defer db.Close()
+
+	membershipRepo := repository.NewMembershipRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, repository.NewUnitOfWork(db), notifService, currencyService, taxService, fiscalService, nil)
+
+	userID := testutils.CreateTestUser(t, db, "buyer@test.com", "password", false)
+	user, err := userRepo.GetByID(ctx, userID)
+	require.NoError(t, err)
+	membershipID := testutils.CreateTestMembership(t, db, "Monthly", 30, 1500000)
+
+	result, err := membershipService.Buy(ctx, user, membershipID, "card", "", nil)
+	require.NoError(t, err)
+	payment := result["payment"].(*models.Payment)
+	assert.Equal(t, 1500000, payment.AmountCents)
+
+	active, err := membershipRepo.HasActiveMembership(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	var fiscal int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM fiscal_outbox WHERE payment_id = ?`, payment.ID).Scan(&fiscal))
+	assert.Equal(t, 1, fiscal)
+
+	var recipient, text string
+	require.NoError(t, db.QueryRow(`SELECT recipient, text_body FROM notification_outbox WHERE template = ?`, notification.TemplateMembershipActivated).Scan(&recipient, &text))
+	assert.Equal(t, "buyer@test.com", recipient)
+	assert.Contains(t, text, "15 000.00 KZT")
+
+	// Сбой на любом шаге откатывает покупку целиком: ни платежа, ни подписки без чека
+	_, err = db.Exec(`DROP TABLE fiscal_outbox`)
+	require.NoError(t, err)
+	_, err = membershipService.Buy(ctx, user, membershipID, "card", "", nil)
+	assert.Error(t, err)
+
+	var payments, memberships int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM payments`).Scan(&payments))
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM user_memberships`).Scan(&memberships))
+	assert.Equal(t, 1, payments)
+	assert.Equal(t, 1, memberships)
 }
 
 func TestMembershipService_Create(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	membershipRepo := repository.NewMembershipRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
-	notifService := service.NewNotificationService(&config.Config{})
-	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, db, notifService)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, repository.NewUnitOfWork(db), notifService, currencyService, taxService, fiscalService, nil)
 
-	membership, err := membershipService.Create("Gold", 60, 25000)
+	membership, err := membershipService.Create(ctx, "Gold", 60, 25000)
 	require.NoError(t, err)
 	assert.NotZero(t, membership.ID)
 	assert.Equal(t, "Gold", membership.Name)
 }
 
 func TestMembershipService_Update(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	membershipRepo := repository.NewMembershipRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
-	notifService := service.NewNotificationService(&config.Config{})
-	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, db, notifService)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, repository.NewUnitOfWork(db), notifService, currencyService, taxService, fiscalService, nil)
 
 	membershipID := testutils.CreateTestMembership(t, db, "Old Name", 30, 10000)
 
-	err := membershipService.Update(membershipID, "New Name", 45, 12000)
+	err := membershipService.Update(ctx, membershipID, "New Name", 45, 12000)
 	require.NoError(t, err)
 }
 
 func TestMembershipService_Delete(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	membershipRepo := repository.NewMembershipRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
-	notifService := service.NewNotificationService(&config.Config{})
-	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, db, notifService)
+	notifService := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	membershipService := service.NewMembershipService(membershipRepo, paymentRepo, repository.NewUnitOfWork(db), notifService, currencyService, taxService, fiscalService, nil)
 
 	membershipID := testutils.CreateTestMembership(t, db, "To Delete", 30, 10000)
 
-	err := membershipService.Delete(membershipID)
+	err := membershipService.Delete(ctx, membershipID)
 	require.NoError(t, err)
 }
@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/internal/utils"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Сервисы работают с хранилищами в памяти так же, как с SQLite
+func TestBookingService_MemoryStores(t *testing.T) {
+	ctx := context.Background()
+	utils.InitLogger()
+	s := testutils.NewMemoryStores()
+
+	notifService := service.NewNotificationService(s.Notifications, s.UoW, notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+	bookingService := service.NewBookingService(s.Bookings, s.Classes, s.Memberships, s.UoW, notifService, nil)
+
+	user, err := s.Users.Create(ctx, "User", "user@test.com", "hash", false)
+	require.NoError(t, err)
+	gym, err := s.Gyms.Create(ctx, "Test Gym", "Address")
+	require.NoError(t, err)
+	class, err := s.Classes.Create(ctx, &models.Class{Title: "Test Class", GymID: gym.ID, StartTime: "2030-01-02 10:00:00", DurationMin: 60, Capacity: 20})
+	require.NoError(t, err)
+
+	require.NoError(t, bookingService.Create(ctx, user, class.ID))
+
+	bookings, err := bookingService.ListUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, bookings, 1)
+	queued, err := s.Notifications.List(ctx, "pending")
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	assert.Equal(t, "user@test.com", queued[0].Recipient)
+
+	// Бронирование несуществующего занятия ничего не меняет
+	assert.Error(t, bookingService.Create(ctx, user, class.ID+1))
+	bookings, err = bookingService.ListUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, bookings, 1)
+}
+
+// Откат транзакции не теряет записи, сделанные параллельно вне неё
+func TestMemoryStores_RollbackKeepsOutsideWrites(t *testing.T) {
+	ctx := context.Background()
+	s := testutils.NewMemoryStores()
+
+	failed := errors.New("rollback")
+	done := make(chan error, 1)
+	err := s.UoW.Do(ctx, func(tx *sql.Tx) error {
+		if _, err := s.Gyms.WithTx(tx).Create(ctx, "Inside", "Address"); err != nil {
+			return err
+		}
+		go func() {
+			_, err := s.Gyms.Create(ctx, "Outside", "Address")
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			t.Error("write outside the transaction was not blocked")
+			done <- err
+		case <-time.After(50 * time.Millisecond):
+		}
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	require.NoError(t, <-done)
+
+	gyms, err := s.Gyms.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, gyms, 1)
+	assert.Equal(t, "Outside", gyms[0].Name)
+}
@@ -1,40 +1,53 @@
 package unit
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
-	"Gym_StrongCode/config"
 	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
+func setupKeyService(t *testing.T) *service.KeyService {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	keyService := service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0)
+	require.NoError(t, keyService.Init(ctx))
+	return keyService
+}
+
 func TestAuthMiddleware_ValidToken(t *testing.T) {
+	ctx := context.Background()
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 
-	cfg := &config.Config{JWTSecret: "test-secret"}
+	keyService := setupKeyService(t)
 
-	r.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	r.Use(middleware.AuthMiddleware(keyService, nil))
 	r.GET("/test", func(c *gin.Context) {
 		userID := c.GetInt("user_id")
 		c.JSON(http.StatusOK, gin.H{"user_id": userID})
 	})
 
 	// Создаем валидный токен
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	tokenString, err := keyService.Sign(ctx, jwt.MapClaims{
 		"user_id":  1,
 		"email":    "test@test.com",
 		"is_admin": false,
 		"exp":      time.Now().Add(24 * time.Hour).Unix(),
 	})
-	tokenString, _ := token.SignedString([]byte(cfg.JWTSecret))
+	require.NoError(t, err)
 
 	// Тестируем запрос с валидным токеном
 	w := httptest.NewRecorder()
@@ -49,9 +62,9 @@ func TestAuthMiddleware_NoToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 
-	cfg := &config.Config{JWTSecret: "test-secret"}
+	keyService := setupKeyService(t)
 
-	r.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	r.Use(middleware.AuthMiddleware(keyService, nil))
 	r.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"success": true})
 	})
@@ -68,9 +81,9 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 
-	cfg := &config.Config{JWTSecret: "test-secret"}
+	keyService := setupKeyService(t)
 
-	r.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	r.Use(middleware.AuthMiddleware(keyService, nil))
 	r.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"success": true})
 	})
@@ -85,24 +98,25 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 }
 
 func TestAuthMiddleware_ExpiredToken(t *testing.T) {
+	ctx := context.Background()
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 
-	cfg := &config.Config{JWTSecret: "test-secret"}
+	keyService := setupKeyService(t)
 
-	r.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	r.Use(middleware.AuthMiddleware(keyService, nil))
 	r.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"success": true})
 	})
 
 	// Создаем истекший токен
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	tokenString, err := keyService.Sign(ctx, jwt.MapClaims{
 		"user_id":  1,
 		"email":    "test@test.com",
 		"is_admin": false,
 		"exp":      time.Now().Add(-24 * time.Hour).Unix(), // Истек вчера
 	})
-	tokenString, _ := token.SignedString([]byte(cfg.JWTSecret))
+	require.NoError(t, err)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/test", nil)
@@ -112,6 +126,38 @@ func TestAuthMiddleware_ExpiredToken(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
 
+func TestAuthMiddleware_RejectsUnexpectedAlgorithms(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	keyService := setupKeyService(t)
+
+	r.Use(middleware.AuthMiddleware(keyService, nil))
+	r.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	})
+
+	claims := jwt.MapClaims{"user_id": 1, "exp": time.Now().Add(time.Hour).Unix()}
+	kid := keyService.JWKS().Keys[0].Kid
+
+	// HS256 с открытым ключом в качестве секрета и неподписанный токен не принимаются
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hmacToken.Header["kid"] = kid
+	hmacString, _ := hmacToken.SignedString([]byte(keyService.JWKS().Keys[0].X))
+	noneToken := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	noneToken.Header["kid"] = kid
+	noneString, _ := noneToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	for _, tokenString := range []string{hmacString, noneString} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+}
+
 func TestAdminOnly_AdminUser(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
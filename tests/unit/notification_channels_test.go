@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationRouter_PlanFollowsRouteAndAddresses(t *testing.T) {
+	routes, err := notification.ParseRoutes("booking_confirmed=push,sms,email; *=sms,email")
+	require.NoError(t, err)
+	router := notification.NewRouter(routes,
+		notification.NewFakeChannel(notification.ChannelEmail),
+		notification.NewFakeChannel(notification.ChannelSMS),
+	)
+
+	// push не подключён, телефона нет — остаётся только email
+	plan := router.Plan(notification.TemplateBookingConfirmed, notification.Recipient{Email: "a@test.com"})
+	assert.Equal(t, []notification.Delivery{{Channel: "email", To: "a@test.com"}}, plan)
+
+	plan = router.Plan(notification.TemplateLoginLocked, notification.Recipient{Email: "a@test.com", Phone: "+77010000000"})
+	assert.Equal(t, []notification.Delivery{{Channel: "sms", To: "+77010000000"}, {Channel: "email", To: "a@test.com"}}, plan)
+
+	assert.Empty(t, router.Plan(notification.TemplateLoginLocked, notification.Recipient{}))
+
+	_, err = notification.ParseRoutes("booking_confirmed=pigeon")
+	assert.ErrorIs(t, err, notification.ErrUnknownChannel)
+	_, err = notification.ParseRoutes("no_such_template=email")
+	assert.ErrorIs(t, err, notification.ErrUnknownTemplate)
+}
+
+func TestNotificationService_FallsBackToNextChannel(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	sms := notification.NewFakeChannel(notification.ChannelSMS)
+	email := notification.NewFakeChannel(notification.ChannelEmail)
+	routes, err := notification.ParseRoutes("login_locked=sms,email")
+	require.NoError(t, err)
+	svc := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(routes, sms, email), nil, 1)
+
+	rcpt := notification.Recipient{Email: "a@test.com", Phone: "+77010000000", Locale: "en"}
+	require.NoError(t, svc.Notify(ctx, nil, rcpt, notification.LoginLocked{Name: "A", LockedFor: time.Minute}))
+
+	// SMS-шлюз недоступен — сообщение сразу переходит в email с новым запасом попыток
+	sms.FailNext(1)
+	now := time.Now()
+	sent, err := svc.ProcessPending(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	entries, err := svc.List(ctx, "pending")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "email", entries[0].Channel)
+	assert.Equal(t, "a@test.com", entries[0].Recipient)
+	assert.Zero(t, entries[0].Attempts)
+	assert.Contains(t, entries[0].LastError, "sms:")
+
+	sent, err = svc.ProcessPending(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Empty(t, sms.Sent())
+	require.Len(t, email.Sent(), 1)
+	assert.Equal(t, "Sign-in temporarily locked", email.Sent()[0].Subject)
+}
+
+func TestHTTPChannels_PayloadsAndErrors(t *testing.T) {
+	var gotAuth, gotSignature, gotTimestamp string
+	var gotBody []byte
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotSignature = r.Header.Get("X-Webhook-Signature")
+		gotTimestamp = r.Header.Get("X-Webhook-Timestamp")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	env := notification.Envelope{To: "+77010000000", Template: "login_locked", Locale: "ru", Subject: "Тема", Text: "Текст", HTML: "<p>Текст</p>"}
+	ctx := context.Background()
+
+	require.NoError(t, notification.NewSMSChannel(server.URL, "sms-token", "StrongCode").Send(ctx, env))
+	assert.Equal(t, "Bearer sms-token", gotAuth)
+	assert.JSONEq(t, `{"from":"StrongCode","to":"+77010000000","text":"Текст"}`, string(gotBody))
+
+	require.NoError(t, notification.NewPushChannel(server.URL, "push-key").Send(ctx, env))
+	assert.Equal(t, "Bearer push-key", gotAuth)
+	var push map[string]interface{}
+	require.NoError(t, json.Unmarshal(gotBody, &push))
+	assert.Equal(t, map[string]interface{}{"title": "Тема", "body": "Текст"}, push["notification"])
+
+	// Получатель вебхука проверяет подпись тела общим секретом
+	require.NoError(t, notification.NewWebhookChannel(server.URL, "hook-secret").Send(ctx, env))
+	assert.Equal(t, "sha256="+notification.WebhookSignature("hook-secret", gotTimestamp, gotBody), gotSignature)
+	var hook notification.Envelope
+	require.NoError(t, json.Unmarshal(gotBody, &hook))
+	assert.Equal(t, env, hook)
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, notification.NewSMSChannel(server.URL, "sms-token", "StrongCode").Send(ctx, env))
+}
@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietHours_Defer(t *testing.T) {
+	quiet, err := notification.NewQuietHours("22:00", "08:00", "Asia/Almaty")
+	require.NoError(t, err)
+	almaty := quiet.Location
+
+	// Днём отправляем сразу
+	noon := time.Date(2026, 3, 10, 12, 0, 0, 0, almaty)
+	assert.Equal(t, noon, quiet.Defer(noon))
+
+	// Вечером — утром следующего дня, после полуночи — утром того же дня
+	evening := time.Date(2026, 3, 10, 23, 30, 0, 0, almaty)
+	assert.True(t, quiet.Defer(evening).Equal(time.Date(2026, 3, 11, 8, 0, 0, 0, almaty)))
+	night := time.Date(2026, 3, 11, 3, 0, 0, 0, almaty)
+	assert.True(t, quiet.Defer(night).Equal(time.Date(2026, 3, 11, 8, 0, 0, 0, almaty)))
+
+	// Время сервера в UTC сравнивается с местным временем пользователя
+	assert.True(t, quiet.Defer(evening.UTC()).Equal(time.Date(2026, 3, 11, 8, 0, 0, 0, almaty)))
+
+	none, err := notification.NewQuietHours("", "", "Asia/Almaty")
+	require.NoError(t, err)
+	assert.Equal(t, night, none.Defer(night))
+
+	_, err = notification.NewQuietHours("25:00", "08:00", "Asia/Almaty")
+	assert.ErrorIs(t, err, notification.ErrInvalidQuietHours)
+}
+
+func TestUnsubscribeLinks_RoundTripAndTampering(t *testing.T) {
+	links := notification.NewUnsubscribeLinks("https://gym.test/api/notifications/unsubscribe", "secret")
+
+	token := links.Token(42, notification.CategoryMarketing)
+	assert.Equal(t, "https://gym.test/api/notifications/unsubscribe?token="+token, links.URL(42, notification.CategoryMarketing))
+
+	userID, category, err := links.Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, 42, userID)
+	assert.Equal(t, notification.CategoryMarketing, category)
+
+	// Подмена пользователя или чужой секрет
+	forged := notification.NewUnsubscribeLinks("", "other").Token(42, notification.CategoryMarketing)
+	_, _, err = links.Parse(forged)
+	assert.ErrorIs(t, err, notification.ErrInvalidUnsubscribeLink)
+	_, _, err = links.Parse(links.Token(43, notification.CategoryMarketing)[:8] + token[8:])
+	assert.ErrorIs(t, err, notification.ErrInvalidUnsubscribeLink)
+	_, _, err = links.Parse("garbage")
+	assert.ErrorIs(t, err, notification.ErrInvalidUnsubscribeLink)
+}
+
+// promoMessage — нетранзакционное сообщение для проверки подписок
+type promoMessage struct{}
+
+func (promoMessage) TemplateID() string { return "promo" }
+func (promoMessage) Category() string   { return notification.CategoryMarketing }
+
+func TestNotificationService_PreferencesDoNotBlockTransactional(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	userID := testutils.CreateTestUser(t, db, "member@test.com", "Str0ng-Passw0rd", false)
+	svc := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+
+	var prefs []models.NotificationPreference
+	for _, category := range []string{notification.CategoryReminders, notification.CategoryMarketing} {
+		for _, channel := range notification.Channels {
+			prefs = append(prefs, models.NotificationPreference{Category: category, Channel: channel, Enabled: false})
+		}
+	}
+	require.NoError(t, svc.UpdatePreferences(ctx, userID, &models.NotificationSettings{Preferences: prefs}))
+
+	rcpt := notification.Recipient{UserID: userID, Email: "member@test.com"}
+	require.NoError(t, svc.Notify(ctx, nil, rcpt, promoMessage{}), "отписка — не ошибка, сообщение просто не ставится")
+	require.NoError(t, svc.Notify(ctx, nil, rcpt, notification.LoginLocked{Name: "A", LockedFor: time.Minute}))
+
+	var templates []string
+	rows, err := db.Query(`SELECT template FROM notification_outbox`)
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var tpl string
+		require.NoError(t, rows.Scan(&tpl))
+		templates = append(templates, tpl)
+	}
+	assert.Equal(t, []string{notification.TemplateLoginLocked}, templates)
+
+	settings, err := svc.Preferences(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, notification.DefaultTimezone, settings.Timezone)
+	for _, p := range settings.Preferences {
+		assert.False(t, p.Enabled, "%s/%s", p.Category, p.Channel)
+	}
+}
+
+func TestNotificationService_UpdatePreferencesValidation(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	userID := testutils.CreateTestUser(t, db, "member@test.com", "Str0ng-Passw0rd", false)
+	svc := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+
+	cases := []models.NotificationSettings{
+		{Timezone: "Mars/Olympus"},
+		{QuietHoursStart: "22:00"},
+		{QuietHoursStart: "22:00", QuietHoursEnd: "8:00"},
+		{Preferences: []models.NotificationPreference{{Category: "spam", Channel: notification.ChannelEmail}}},
+		{Preferences: []models.NotificationPreference{{Category: notification.CategoryMarketing, Channel: "fax"}}},
+	}
+	for _, c := range cases {
+		assert.ErrorIs(t, svc.UpdatePreferences(ctx, userID, &c), service.ErrInvalidNotificationPreference, "%+v", c)
+	}
+
+	err := svc.UpdatePreferences(ctx, userID, &models.NotificationSettings{Preferences: []models.NotificationPreference{
+		{Category: notification.CategoryTransactional, Channel: notification.ChannelEmail},
+	}})
+	assert.ErrorIs(t, err, service.ErrTransactionalOptOut)
+}
+
+func TestNotificationService_QuietHoursDoNotDelayRemindersPastClass(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	userID := testutils.CreateTestUser(t, db, "member@test.com", "Str0ng-Passw0rd", false)
+	svc := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+
+	// Тихие часы идут сейчас и закончатся примерно через 3 часа
+	now := time.Now().UTC()
+	require.NoError(t, svc.UpdatePreferences(ctx, userID, &models.NotificationSettings{
+		QuietHoursStart: now.Add(-time.Hour).Format("15:04"),
+		QuietHoursEnd:   now.Add(3 * time.Hour).Format("15:04"),
+		Timezone:        "UTC",
+	}))
+
+	rcpt := notification.Recipient{UserID: userID, Email: "member@test.com"}
+	soon := notification.ClassReminder{Name: "A", ClassTitle: "Soon", StartTime: "soon", StartsAt: now.Add(2 * time.Hour)}
+	id, err := svc.Enqueue(ctx, nil, rcpt, soon)
+	require.NoError(t, err)
+	assert.Zero(t, id, "напоминание пришло бы после начала занятия")
+
+	later := notification.ClassReminder{Name: "A", ClassTitle: "Later", StartTime: "later", StartsAt: now.Add(5 * time.Hour)}
+	id, err = svc.Enqueue(ctx, nil, rcpt, later)
+	require.NoError(t, err)
+	assert.NotZero(t, id)
+
+	var nextAttempt string
+	require.NoError(t, db.QueryRow(`SELECT next_attempt_at FROM notification_outbox WHERE id = ?`, id).Scan(&nextAttempt))
+	assert.Greater(t, nextAttempt, now.Add(2*time.Hour).Format("2006-01-02 15:04:05"), "отложено до конца тихих часов")
+}
@@ -0,0 +1,170 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/notification"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMessage = notification.MembershipActivated{PlanName: "Безлимит", DurationDays: 30}
+
+func TestNotificationService_DeliversQueuedEmails(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	sender := notification.NewFakeChannel(notification.ChannelEmail)
+	svc := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, sender), nil, 1)
+
+	msg := notification.LoginLocked{Name: "A", LockedFor: time.Minute}
+	require.NoError(t, svc.Notify(ctx, nil, notification.Recipient{Email: "a@test.com", Locale: "ru"}, msg))
+	require.NoError(t, svc.Notify(ctx, nil, notification.Recipient{Email: "b@test.com", Locale: "en"}, msg))
+	assert.ErrorIs(t, svc.Notify(ctx, nil, notification.Recipient{Locale: "ru"}, msg), service.ErrNoRecipient)
+
+	sent, err := svc.ProcessPending(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	emails := sender.Sent()
+	require.Len(t, emails, 2)
+	// Каждое письмо — на языке своего получателя, в текстовой и HTML-версии
+	assert.Equal(t, "Вход временно заблокирован", emails[0].Subject)
+	assert.Equal(t, "Sign-in temporarily locked", emails[1].Subject)
+	assert.Contains(t, emails[1].Text, "locked for 1 min")
+	assert.Contains(t, emails[1].HTML, "<p>A, there were")
+
+	// Повторная обработка ничего не отправляет, текст отправленных писем стёрт
+	sent, err = svc.ProcessPending(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	var body, text string
+	require.NoError(t, db.QueryRow(`SELECT body, text_body FROM notification_outbox WHERE recipient = 'a@test.com'`).Scan(&body, &text))
+	assert.Empty(t, body)
+	assert.Empty(t, text)
+}
+
+func TestNotificationService_RetriesWithBackoffAndDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	sender := notification.NewFakeChannel(notification.ChannelEmail)
+	svc := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, sender), nil, 1)
+
+	require.NoError(t, svc.Notify(ctx, nil, notification.Recipient{Email: "member@test.com"}, testMessage))
+	sender.FailNext(100)
+
+	now := time.Now()
+	sent, err := svc.ProcessPending(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	// До истечения задержки письмо не берётся повторно
+	sent, err = svc.ProcessPending(ctx, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	entries, err := svc.List(ctx, "pending")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].Attempts)
+
+	// Исчерпав попытки, письмо уходит в dead
+	for i := 0; i < 10; i++ {
+		now = now.Add(7 * time.Hour)
+		_, err = svc.ProcessPending(ctx, now)
+		require.NoError(t, err)
+	}
+	dead, err := svc.List(ctx, "dead")
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, 8, dead[0].Attempts)
+	assert.NotEmpty(t, dead[0].LastError)
+
+	// Ручная переотправка
+	sender.FailNext(0)
+	require.NoError(t, svc.Resend(ctx, dead[0].ID))
+	sent, err = svc.ProcessPending(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.ErrorIs(t, svc.Resend(ctx, dead[0].ID), service.ErrNotificationDelivered)
+	assert.ErrorIs(t, svc.Resend(ctx, 9999), service.ErrNotificationNotFound)
+}
+
+func TestNotificationService_EnqueueFollowsTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	svc := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, notification.NewFakeChannel(notification.ChannelEmail)), nil, 0)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, svc.Notify(ctx, tx, notification.Recipient{Email: "rolled@test.com"}, testMessage))
+	require.NoError(t, tx.Rollback())
+
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, svc.Notify(ctx, tx, notification.Recipient{Email: "kept@test.com"}, testMessage))
+	require.NoError(t, tx.Commit())
+
+	entries, err := svc.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "kept@test.com", entries[0].Recipient)
+}
+
+func TestNotificationService_ReclaimsStuckEmails(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	sender := notification.NewFakeChannel(notification.ChannelEmail)
+	repo := repository.NewNotificationRepository(db)
+	svc := service.NewNotificationService(repo, repository.NewUnitOfWork(db), notification.NewRouter(nil, sender), nil, 0)
+
+	require.NoError(t, svc.Notify(ctx, nil, notification.Recipient{Email: "stuck@test.com"}, testMessage))
+
+	// Воркер забрал письмо и упал, не отметив результат
+	now := time.Now()
+	claimed, err := repo.ClaimDue(ctx, now, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	sent, err := svc.ProcessPending(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "письмо ещё числится за другим воркером")
+
+	sent, err = svc.ProcessPending(ctx, now.Add(5*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+}
+
+// stoppingChannel отправляет письмо и тут же отменяет контекст воркера, как StopWorker
+type stoppingChannel struct {
+	*notification.FakeChannel
+	stop context.CancelFunc
+}
+
+func (c stoppingChannel) Send(ctx context.Context, env notification.Envelope) error {
+	err := c.FakeChannel.Send(ctx, env)
+	c.stop()
+	return err
+}
+
+func TestNotificationService_SavesResultAfterWorkerStop(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	sender := notification.NewFakeChannel(notification.ChannelEmail)
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	svc := service.NewNotificationService(repository.NewNotificationRepository(db), repository.NewUnitOfWork(db), notification.NewRouter(nil, stoppingChannel{sender, stop}), nil, 1)
+
+	require.NoError(t, svc.Notify(ctx, nil, notification.Recipient{Email: "member@test.com"}, testMessage))
+	sent, err := svc.ProcessPending(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	// Отправленное во время остановки письмо отмечено и повторно не уйдёт
+	entries, err := svc.List(context.Background(), "sent")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	require.Len(t, sender.Sent(), 1)
+}
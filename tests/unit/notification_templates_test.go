@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/notification"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationTemplates_AllTranslated(t *testing.T) {
+	templates := notification.DefaultTemplates()
+
+	for _, info := range templates.List() {
+		assert.Equal(t, notification.Locales, info.Locales, info.ID)
+		for _, locale := range notification.Locales {
+			rendered, err := templates.Render(locale, info.Sample, "")
+			require.NoError(t, err, "%s/%s", locale, info.ID)
+			assert.Equal(t, locale, rendered.Locale)
+			assert.NotEmpty(t, rendered.Subject, "%s/%s", locale, info.ID)
+			assert.NotEmpty(t, rendered.Text, "%s/%s", locale, info.ID)
+			assert.NotEmpty(t, rendered.HTML, "%s/%s", locale, info.ID)
+			assert.NotContains(t, rendered.Text, "<p>", "%s/%s", locale, info.ID)
+		}
+	}
+}
+
+func TestNotificationTemplates_LocaleFallbackAndEscaping(t *testing.T) {
+	templates := notification.DefaultTemplates()
+	msg := notification.PasswordReset{Name: "Dana", Link: `https://app/reset?token=a"b`, ValidFor: 90 * time.Minute}
+
+	// Незнакомый язык — письмо на языке по умолчанию; региональный вариант сводится к языку
+	rendered, err := templates.Render("de", msg, "")
+	require.NoError(t, err)
+	assert.Equal(t, notification.DefaultLocale, rendered.Locale)
+	assert.Contains(t, rendered.Text, "1 ч 30 мин")
+
+	rendered, err = templates.Render("en-US", msg, "")
+	require.NoError(t, err)
+	assert.Equal(t, "en", rendered.Locale)
+	assert.Contains(t, rendered.Text, "valid for 1 h 30 min")
+
+	// В HTML данные экранируются, в текстовой версии — нет
+	assert.Contains(t, rendered.HTML, `token=a%22b`)
+	assert.Contains(t, rendered.Text, `token=a"b`)
+}
+
+func TestNotificationTemplates_Preview(t *testing.T) {
+	templates := notification.DefaultTemplates()
+
+	rendered, err := templates.Preview(notification.TemplateMembershipActivated, "kk", []byte(`{"plan_name": "Premium"}`))
+	require.NoError(t, err)
+	assert.Contains(t, rendered.Text, "«Premium»")
+	assert.Contains(t, rendered.Text, "30 күн", "остальные поля берутся из примера")
+
+	_, err = templates.Preview("missing", "ru", nil)
+	assert.ErrorIs(t, err, notification.ErrUnknownTemplate)
+	_, err = templates.Preview(notification.TemplateLoginLocked, "fr", nil)
+	assert.ErrorIs(t, err, notification.ErrUnsupportedLocale)
+	_, err = templates.Preview(notification.TemplateLoginLocked, "ru", []byte(`{"locked_for": "soon"}`))
+	assert.ErrorIs(t, err, notification.ErrInvalidTemplateData)
+}
+
+func TestNotificationTemplates_UnsubscribeFooter(t *testing.T) {
+	templates := notification.DefaultTemplates()
+	msg := notification.MembershipActivated{PlanName: "Premium", DurationDays: 30}
+
+	rendered, err := templates.Render("kk", msg, "https://gym.test/unsubscribe?token=a&b")
+	require.NoError(t, err)
+	assert.Contains(t, rendered.Text, "https://gym.test/unsubscribe?token=a&b")
+	assert.Contains(t, rendered.HTML, `href="https://gym.test/unsubscribe?token=a&amp;b"`)
+
+	rendered, err = templates.Render("kk", msg, "")
+	require.NoError(t, err)
+	assert.NotContains(t, rendered.Text, "unsubscribe")
+}
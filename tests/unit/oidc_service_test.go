@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"Gym_StrongCode/config"
+	"Gym_StrongCode/internal/cache"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCService_LinkOrCreateByVerifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	idp := testutils.NewStubIdP(t, "gym-client")
+	userRepo := repository.NewUserRepository(db)
+	oidcService := service.NewOIDCService([]config.OIDCProvider{idp.Provider("corp")}, cache.NewMemoryOIDCStateStore(), userRepo, repository.NewIdentityRepository(db), repository.NewUnitOfWork(db))
+
+	signIn := func(identity testutils.StubIdentity) (string, string) {
+		authURL, err := oidcService.AuthURL("corp")
+		require.NoError(t, err)
+		return idp.Authorize(t, authURL, identity)
+	}
+
+	// Новый пользователь создаётся с подтверждённым email
+	code, state := signIn(testutils.StubIdentity{Subject: "sub-1", Email: "new@corp.example", EmailVerified: true, Name: "New Member"})
+	created, err := oidcService.Callback(ctx, "corp", code, state)
+	require.NoError(t, err)
+	assert.Equal(t, "New Member", created.Name)
+	assert.True(t, created.EmailVerified)
+
+	// state одноразовый
+	_, err = oidcService.Callback(ctx, "corp", code, state)
+	assert.ErrorIs(t, err, service.ErrInvalidOIDCState)
+
+	// Повторный вход находит пользователя по привязке, даже если email у провайдера сменился
+	code, state = signIn(testutils.StubIdentity{Subject: "sub-1", Email: "renamed@corp.example", EmailVerified: true})
+	again, err := oidcService.Callback(ctx, "corp", code, state)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, again.ID)
+
+	// Существующий аккаунт с подтверждённым email привязывается
+	existingID := testutils.CreateTestUser(t, db, "member@example.com", "Str0ng-Passw0rd", false)
+	require.NoError(t, userRepo.MarkEmailVerified(ctx, existingID))
+	code, state = signIn(testutils.StubIdentity{Subject: "sub-2", Email: "member@example.com", EmailVerified: true})
+	linked, err := oidcService.Callback(ctx, "corp", code, state)
+	require.NoError(t, err)
+	assert.Equal(t, existingID, linked.ID)
+
+	// Без подтверждения email у провайдера вход не выполняется
+	code, state = signIn(testutils.StubIdentity{Subject: "sub-3", Email: "unverified@corp.example"})
+	_, err = oidcService.Callback(ctx, "corp", code, state)
+	assert.ErrorIs(t, err, service.ErrOIDCEmailNotVerified)
+
+	// Аккаунт с неподтверждённым email не привязывается: его пароль мог задать кто-то другой
+	testutils.CreateTestUser(t, db, "victim@corp.example", "squatter-password", false)
+	code, state = signIn(testutils.StubIdentity{Subject: "sub-4", Email: "victim@corp.example", EmailVerified: true})
+	_, err = oidcService.Callback(ctx, "corp", code, state)
+	assert.ErrorIs(t, err, service.ErrOIDCAccountNotVerified)
+
+	// Неизвестный провайдер и поддельный state
+	_, err = oidcService.AuthURL("google")
+	assert.ErrorIs(t, err, service.ErrUnknownOIDCProvider)
+	_, err = oidcService.Callback(ctx, "corp", "code", "forged-state")
+	assert.ErrorIs(t, err, service.ErrInvalidOIDCState)
+}
@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := service.NewPasswordPolicy(10, 3, 0)
+
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"too short", "Ab1!", false},
+		{"too few classes", "onlylowercaseletters", false},
+		{"common password", "Password123!", false},
+		{"common password in other case", "QWERTY12345", false},
+		{"contains name", "Alice-Str0ng", false},
+		{"contains email local part", "xX-asmith-99", false},
+		{"too long for bcrypt", "Aa1!" + string(make([]byte, 80)), false},
+		{"strong", "Tr4ck-Squat-Rack", true},
+		{"cyrillic letters count as classes", "Жим-лёжа-2025", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, "asmith@example.com", "Alice Smith")
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, service.ErrWeakPassword)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_HashUsesConfiguredCost(t *testing.T) {
+	policy := service.NewPasswordPolicy(0, 0, bcrypt.MinCost+1)
+
+	hash, err := policy.Hash("Tr4ck-Squat-Rack")
+	require.NoError(t, err)
+	cost, err := bcrypt.Cost([]byte(hash))
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost+1, cost)
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	keyService := service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0)
+	authService := service.NewAuthService(userRepo, repository.NewTokenRepository(db), repository.NewUnitOfWork(db), keyService, service.NewPasswordPolicy(0, 0, bcrypt.MinCost), 0, 0)
+
+	user, err := authService.Register(ctx, "Change User", "change@example.com", "Str0ng-Passw0rd")
+	require.NoError(t, err)
+	oldTokens, err := authService.Login(ctx, "change@example.com", "Str0ng-Passw0rd")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, authService.ChangePassword(ctx, user.ID, "wrong-password", "New-Passw0rd!"), service.ErrInvalidPassword)
+	assert.ErrorIs(t, authService.ChangePassword(ctx, user.ID, "Str0ng-Passw0rd", "short"), service.ErrWeakPassword)
+
+	require.NoError(t, authService.ChangePassword(ctx, user.ID, "Str0ng-Passw0rd", "New-Passw0rd!"))
+	_, err = authService.Login(ctx, "change@example.com", "Str0ng-Passw0rd")
+	assert.Error(t, err)
+	_, err = authService.Login(ctx, "change@example.com", "New-Passw0rd!")
+	assert.NoError(t, err)
+
+	// Прежние токены после смены пароля не действуют
+	_, err = authService.Refresh(ctx, oldTokens.RefreshToken)
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package unit
 import (
 	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/tests/testutils"
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,13 +11,14 @@ import (
 )
 
 func TestPaymentRepository_CreateStandalone(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	repo := repository.NewPaymentRepository(db)
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
 
-	payment, err := repo.CreateStandalone(userID, 10000, "USD", "card", "completed", "Test payment", "REF001")
+	payment, err := repo.CreateStandalone(ctx, userID, 10000, "USD", "card", "completed", "Test payment", "REF001")
 	require.NoError(t, err)
 	assert.NotZero(t, payment.ID)
 	assert.Equal(t, userID, payment.UserID)
@@ -25,36 +27,38 @@ func TestPaymentRepository_CreateStandalone(t *testing.T) {
 }
 
 func TestPaymentRepository_GetByID(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	repo := repository.NewPaymentRepository(db)
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
-	created, _ := repo.CreateStandalone(userID, 5000, "USD", "card", "pending", "", "")
+	created, _ := repo.CreateStandalone(ctx, userID, 5000, "USD", "card", "pending", "", "")
 
-	payment, err := repo.GetByID(created.ID)
+	payment, err := repo.GetByID(ctx, created.ID)
 	require.NoError(t, err)
 	assert.Equal(t, created.ID, payment.ID)
 	assert.Equal(t, "pending", payment.Status)
 }
 
 func TestPaymentRepository_GetByUser(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	repo := repository.NewPaymentRepository(db)
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
 	// Создаем несколько платежей с разными статусами
-	repo.CreateStandalone(userID, 1000, "USD", "card", "completed", "", "")
-	repo.CreateStandalone(userID, 2000, "USD", "card", "pending", "", "")
+	repo.CreateStandalone(ctx, userID, 1000, "USD", "card", "completed", "", "")
+	repo.CreateStandalone(ctx, userID, 2000, "USD", "card", "pending", "", "")
 
 	// Получаем все платежи пользователя
-	payments, err := repo.GetByUser(userID, "")
+	payments, err := repo.GetByUser(ctx, userID, "")
 	require.NoError(t, err)
 	assert.GreaterOrEqual(t, len(payments), 2)
 
 	// Фильтруем только завершенные платежи
-	completed, err := repo.GetByUser(userID, "completed")
+	completed, err := repo.GetByUser(ctx, userID, "completed")
 	require.NoError(t, err)
 	assert.GreaterOrEqual(t, len(completed), 1)
 	for _, p := range completed {
@@ -63,14 +67,15 @@ func TestPaymentRepository_GetByUser(t *testing.T) {
 }
 
 func TestPaymentRepository_ListAll(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	repo := repository.NewPaymentRepository(db)
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
-	repo.CreateStandalone(userID, 1000, "USD", "card", "completed", "", "")
+	repo.CreateStandalone(ctx, userID, 1000, "USD", "card", "completed", "", "")
 
-	payments, err := repo.ListAll()
+	payments, err := repo.ListAll(ctx)
 	require.NoError(t, err)
 	assert.NotEmpty(t, payments)
 }
@@ -4,6 +4,7 @@ import (
 	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/internal/service"
 	"Gym_StrongCode/tests/testutils"
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,60 +12,76 @@ import (
 )
 
 func TestPaymentService_Create(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	paymentRepo := repository.NewPaymentRepository(db)
-	paymentService := service.NewPaymentService(paymentRepo)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, nil)
 
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
 
-	payment, err := paymentService.Create(userID, 5000, "card", "Test payment", "REF123")
+	payment, err := paymentService.Create(ctx, userID, 5000, "", "card", "Test payment", "REF123")
 	require.NoError(t, err)
 	assert.NotZero(t, payment.ID)
 }
 
 func TestPaymentService_GetByUser(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	paymentRepo := repository.NewPaymentRepository(db)
-	paymentService := service.NewPaymentService(paymentRepo)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, nil)
 
 	// Создаем пользователя и платеж
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
-	paymentRepo.CreateStandalone(userID, 1000, "USD", "card", "completed", "", "")
+	paymentRepo.CreateStandalone(ctx, userID, 1000, "USD", "card", "completed", "", "")
 
-	payments, err := paymentService.GetByUser(userID, "")
+	payments, err := paymentService.GetByUser(ctx, userID, "")
 	require.NoError(t, err)
 	assert.NotEmpty(t, payments)
 }
 
 func TestPaymentService_ListAll(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	paymentRepo := repository.NewPaymentRepository(db)
-	paymentService := service.NewPaymentService(paymentRepo)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, nil)
 
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
-	paymentRepo.CreateStandalone(userID, 1000, "USD", "card", "completed", "", "")
+	paymentRepo.CreateStandalone(ctx, userID, 1000, "USD", "card", "completed", "", "")
 
-	payments, err := paymentService.ListAll()
+	payments, err := paymentService.ListAll(ctx)
 	require.NoError(t, err)
 	assert.NotEmpty(t, payments)
 }
 
 func TestPaymentService_CreateStandalone(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	paymentRepo := repository.NewPaymentRepository(db)
-	paymentService := service.NewPaymentService(paymentRepo)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, nil)
 
 	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
 
-	payment, err := paymentService.CreateStandalone(userID, 10000, "cash")
+	payment, err := paymentService.CreateStandalone(ctx, userID, 10000, "", "cash")
 	require.NoError(t, err)
 	assert.NotZero(t, payment.ID)
 	assert.Equal(t, "completed", payment.Status)
@@ -0,0 +1,120 @@
+package unit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportService_RevenueNetsRefunds(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	paymentRepo := repository.NewPaymentRepository(db)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, nil)
+	reportService := service.NewReportService(repository.NewReportRepository(db), paymentRepo)
+
+	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
+	membershipID := testutils.CreateTestMembership(t, db, "Monthly", 30, 10000)
+
+	card, err := paymentService.Charge(ctx, &models.Payment{UserID: userID, AmountCents: 10000, Method: "card", MembershipID: &membershipID})
+	require.NoError(t, err)
+	_, err = paymentService.Charge(ctx, &models.Payment{UserID: userID, AmountCents: 4000, Method: "cash"})
+	require.NoError(t, err)
+
+	// Частичный возврат, затем возврат остатка
+	refunded, err := paymentService.Refund(ctx, card.ID, 3000)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", refunded.Status)
+	assert.Equal(t, 3000, refunded.RefundedCents)
+
+	_, err = paymentService.Refund(ctx, card.ID, 8000)
+	assert.Error(t, err)
+
+	today := time.Now().UTC().Format("2006-01-02")
+	rows, err := reportService.Revenue(ctx, "method", today, today)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "card", rows[0].Key)
+	assert.Equal(t, 10000, rows[0].GrossCents)
+	assert.Equal(t, 3000, rows[0].RefundedCents)
+	assert.Equal(t, 7000, rows[0].RevenueCents)
+	assert.Equal(t, "cash", rows[1].Key)
+	assert.Equal(t, 4000, rows[1].RevenueCents)
+
+	rows, err = reportService.Revenue(ctx, "plan", today, today)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Monthly", rows[0].Label)
+
+	refunded, err = paymentService.Refund(ctx, card.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "refunded", refunded.Status)
+
+	rows, err = reportService.Revenue(ctx, "status", today, today)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "completed", rows[0].Key)
+	assert.Equal(t, "refunded", rows[1].Key)
+	assert.Zero(t, rows[1].RevenueCents)
+
+	_, err = reportService.Revenue(ctx, "weekday", today, today)
+	assert.Error(t, err)
+}
+
+func TestReportService_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	paymentRepo := repository.NewPaymentRepository(db)
+	reportService := service.NewReportService(repository.NewReportRepository(db), paymentRepo)
+
+	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
+
+	matched, err := paymentRepo.CreateStandalone(ctx, userID, 5000, "KZT", "card", "completed", "", "")
+	require.NoError(t, err)
+	wrongAmount, err := paymentRepo.CreateStandalone(ctx, userID, 7000, "KZT", "card", "completed", "", "")
+	require.NoError(t, err)
+	missing, err := paymentRepo.CreateStandalone(ctx, userID, 9000, "KZT", "qr_code", "completed", "", "")
+	require.NoError(t, err)
+	_, err = paymentRepo.CreateStandalone(ctx, userID, 1000, "KZT", "cash", "completed", "", "")
+	require.NoError(t, err)
+
+	statement := "payment_id,amount_cents,currency\n" +
+		strconv.Itoa(matched.ID) + ",5000,KZT\n" +
+		strconv.Itoa(wrongAmount.ID) + ",6500,KZT\n" +
+		"99999,1200,KZT\n" +
+		strconv.Itoa(matched.ID) + ",5000,KZT\n"
+
+	today := time.Now().UTC().Format("2006-01-02")
+	result, err := reportService.Reconcile(ctx, strings.NewReader(statement), today, today, "")
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.StatementRows)
+	assert.Equal(t, 1, result.Matched)
+
+	kinds := make(map[string]int)
+	for _, m := range result.Mismatches {
+		kinds[m.Kind] = m.PaymentID
+	}
+	assert.Equal(t, wrongAmount.ID, kinds[models.MismatchAmount])
+	assert.Equal(t, 99999, kinds[models.MismatchMissingInRecords])
+	assert.Equal(t, matched.ID, kinds[models.MismatchDuplicate])
+	assert.Equal(t, missing.ID, kinds[models.MismatchMissingInStatement])
+	assert.Len(t, result.Mismatches, 4)
+
+	_, err = reportService.Reconcile(ctx, strings.NewReader("id,amount\n1,2\n"), today, today, "")
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"context"
 	"testing"
 
 	"Gym_StrongCode/internal/repository"
@@ -12,11 +13,12 @@ import (
 )
 
 func TestGymService_Create(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	gymRepo := repository.NewGymRepository(db)
 	gymService := service.NewGymService(gymRepo)
 
-	gym, err := gymService.Create("Test Gym", "Test Address")
+	gym, err := gymService.Create(ctx, "Test Gym", "Test Address")
 
 	require.NoError(t, err)
 	assert.NotZero(t, gym.ID)
@@ -25,40 +27,42 @@ func TestGymService_Create(t *testing.T) {
 }
 
 func TestGymService_List(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	gymRepo := repository.NewGymRepository(db)
 	gymService := service.NewGymService(gymRepo)
 
 	// Создаем несколько залов
-	gymService.Create("Gym 1", "Address 1")
-	gymService.Create("Gym 2", "Address 2")
+	gymService.Create(ctx, "Gym 1", "Address 1")
+	gymService.Create(ctx, "Gym 2", "Address 2")
 
-	gyms, err := gymService.List()
+	gyms, err := gymService.List(ctx)
 
 	require.NoError(t, err)
 	assert.Len(t, gyms, 2)
 }
 
 func TestTrainerService_CRUD(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	trainerRepo := repository.NewTrainerRepository(db)
 	trainerService := service.NewTrainerService(trainerRepo)
 
 	// Создание тренера
-	trainer, err := trainerService.Create("John Coach", "Expert in CrossFit")
+	trainer, err := trainerService.Create(ctx, "John Coach", "Expert in CrossFit")
 	require.NoError(t, err)
 	assert.NotZero(t, trainer.ID)
 
 	// Получение списка
-	trainers, err := trainerService.List()
+	trainers, err := trainerService.List(ctx)
 	require.NoError(t, err)
 	assert.NotEmpty(t, trainers)
 
 	// Обновление данных
-	err = trainerService.Update(trainer.ID, "John Pro Coach", "Master in CrossFit")
+	err = trainerService.Update(ctx, trainer.ID, "John Pro Coach", "Master in CrossFit")
 	require.NoError(t, err)
 
 	// Удаление
-	err = trainerService.Delete(trainer.ID)
+	err = trainerService.Delete(ctx, trainer.ID)
 	require.NoError(t, err)
 }
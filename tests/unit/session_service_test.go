@@ -0,0 +1,191 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/cache"
+	"Gym_StrongCode/internal/middleware"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sessionFixture struct {
+	db          *sql.DB
+	authService *service.AuthService
+	sessions    *service.SessionService
+	store       *cache.MemorySessionStore
+}
+
+func newSessionFixture(t *testing.T, ttl time.Duration) *sessionFixture {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	authService := service.NewAuthService(repository.NewUserRepository(db), repository.NewTokenRepository(db), repository.NewUnitOfWork(db), service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0), nil, 0, 0)
+	_, err := authService.Register(ctx, "Session User", "session@example.com", "Str0ng-Passw0rd")
+	require.NoError(t, err)
+
+	store := cache.NewMemorySessionStore(ttl)
+	return &sessionFixture{db: db, authService: authService, sessions: service.NewSessionService(authService, store), store: store}
+}
+
+func setupSessionService(t *testing.T, ttl time.Duration) (*service.SessionService, *cache.MemorySessionStore) {
+	f := newSessionFixture(t, ttl)
+	return f.sessions, f.store
+}
+
+func TestSessionService_MultipleSessionsAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	sessionService, _ := setupSessionService(t, time.Hour)
+
+	phone, err := sessionService.Login(ctx, "session@example.com", "Str0ng-Passw0rd", "", "phone", "10.0.0.1")
+	require.NoError(t, err)
+	laptop, err := sessionService.Login(ctx, "session@example.com", "Str0ng-Passw0rd", "", "laptop", "10.0.0.2")
+	require.NoError(t, err)
+	assert.NotEqual(t, phone.ID, laptop.ID)
+
+	sessions, err := sessionService.List(ctx, phone.UserID)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	// Чужую сессию отозвать нельзя
+	assert.ErrorIs(t, sessionService.Revoke(ctx, phone.UserID+1, laptop.ID), service.ErrSessionNotFound)
+
+	require.NoError(t, sessionService.Revoke(ctx, phone.UserID, laptop.ID))
+	sessions, err = sessionService.List(ctx, phone.UserID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, phone.ID, sessions[0].ID)
+
+	require.NoError(t, sessionService.LogoutAll(ctx, phone.UserID))
+	sessions, err = sessionService.List(ctx, phone.UserID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestSessionService_Login_WrongPassword(t *testing.T) {
+	ctx := context.Background()
+	sessionService, _ := setupSessionService(t, time.Hour)
+
+	session, err := sessionService.Login(ctx, "session@example.com", "wrong", "", "", "")
+	assert.Error(t, err)
+	assert.Nil(t, session)
+}
+
+func TestSessionAuthMiddleware(t *testing.T) {
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+	f := newSessionFixture(t, time.Hour)
+	sessionService, store := f.sessions, f.store
+
+	r := gin.New()
+	r.Use(middleware.SessionAuthMiddleware(store, f.authService))
+	r.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetInt("user_id")})
+	})
+
+	session, err := sessionService.Login(ctx, "session@example.com", "Str0ng-Passw0rd", "", "", "")
+	require.NoError(t, err)
+
+	// Сессия по заголовку
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set(middleware.SessionHeaderName, session.ID)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Сессия по cookie; срок действия сдвигается
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/test", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: session.ID})
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	extended, err := store.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	assert.True(t, extended.ExpiresAt.After(session.ExpiresAt))
+
+	// После выхода сессия не принимается
+	require.NoError(t, sessionService.Logout(ctx, session.ID))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/test", nil)
+	req.Header.Set(middleware.SessionHeaderName, session.ID)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Без сессии
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/test", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestSessionAuthMiddleware_RevalidatesUser(t *testing.T) {
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+	f := newSessionFixture(t, time.Hour)
+
+	r := gin.New()
+	r.Use(middleware.SessionAuthMiddleware(f.store, f.authService))
+	r.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"is_admin": middleware.IsAdmin(c)})
+	})
+	get := func(sessionID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set(middleware.SessionHeaderName, sessionID)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	_, err := f.db.Exec(`UPDATE users SET is_admin = 1 WHERE email = 'session@example.com'`)
+	require.NoError(t, err)
+	session, err := f.sessions.Login(ctx, "session@example.com", "Str0ng-Passw0rd", "", "", "")
+	require.NoError(t, err)
+	w := get(session.ID)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"is_admin": true}`, w.Body.String())
+
+	// Снятые права действуют сразу, а не после истечения сессии
+	_, err = f.db.Exec(`UPDATE users SET is_admin = 0 WHERE id = ?`, session.UserID)
+	require.NoError(t, err)
+	w = get(session.ID)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"is_admin": false}`, w.Body.String())
+
+	// Отзыв всех токенов пользователя завершает и сессии
+	require.NoError(t, f.authService.LogoutAll(ctx, session.UserID))
+	assert.Equal(t, http.StatusUnauthorized, get(session.ID).Code)
+
+	// Сессия удалённого пользователя не принимается
+	session, err = f.sessions.Login(ctx, "session@example.com", "Str0ng-Passw0rd", "", "", "")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, get(session.ID).Code)
+	_, err = f.db.Exec(`DELETE FROM users WHERE id = ?`, session.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, get(session.ID).Code)
+}
+
+func TestMemorySessionStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	store := cache.NewMemorySessionStore(20 * time.Millisecond)
+
+	session, err := store.CreateSession(ctx, 1, "a@example.com", false, 0, "", "")
+	require.NoError(t, err)
+
+	time.Sleep(40 * time.Millisecond)
+	_, err = store.ExtendSession(ctx, session.ID)
+	assert.Error(t, err)
+
+	sessions, err := store.ListUserSessions(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
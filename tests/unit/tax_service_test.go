@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/models"
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateTax(t *testing.T) {
+	// 12% внутри цены: 11200 = 10000 + 1200
+	b := service.CalculateTax(11200, &models.TaxRate{RateBP: 1200, Inclusive: true})
+	assert.Equal(t, 10000, b.NetCents)
+	assert.Equal(t, 1200, b.TaxCents)
+	assert.Equal(t, 11200, b.GrossCents)
+
+	// 12% сверху цены
+	b = service.CalculateTax(10000, &models.TaxRate{RateBP: 1200, Inclusive: false})
+	assert.Equal(t, 10000, b.NetCents)
+	assert.Equal(t, 1200, b.TaxCents)
+	assert.Equal(t, 11200, b.GrossCents)
+
+	// Без ставки налог не начисляется
+	b = service.CalculateTax(5000, nil)
+	assert.Equal(t, 5000, b.NetCents)
+	assert.Zero(t, b.TaxCents)
+	assert.Equal(t, 5000, b.GrossCents)
+}
+
+func TestTaxService_RateValidation(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), repository.NewPaymentRepository(db))
+
+	_, err := taxService.CreateRate(ctx, &models.TaxRate{Name: "VAT", ProductType: "unknown", RateBP: 1200})
+	assert.Error(t, err)
+
+	_, err = taxService.CreateRate(ctx, &models.TaxRate{Name: "VAT", ProductType: models.ProductMembership, RateBP: 20000})
+	assert.Error(t, err)
+
+	rate, err := taxService.CreateRate(ctx, &models.TaxRate{Name: "VAT", ProductType: models.ProductMembership, RateBP: 1200, Inclusive: true})
+	require.NoError(t, err)
+	assert.NotZero(t, rate.ID)
+
+	rates, err := taxService.ListRates(ctx)
+	require.NoError(t, err)
+	assert.Len(t, rates, 1)
+}
+
+func TestPaymentService_Charge_GymSpecificTax(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	paymentRepo := repository.NewPaymentRepository(db)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, nil)
+
+	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
+	gymID := testutils.CreateTestGym(t, db, "North Branch", "Astana")
+
+	// Общая ставка — внутри цены, для North Branch — сверху
+	_, err := taxService.CreateRate(ctx, &models.TaxRate{Name: "VAT", ProductType: models.ProductMembership, RateBP: 1200, Inclusive: true})
+	require.NoError(t, err)
+	_, err = taxService.CreateRate(ctx, &models.TaxRate{Name: "VAT North", ProductType: models.ProductMembership, GymID: &gymID, RateBP: 1000, Inclusive: false})
+	require.NoError(t, err)
+
+	payment, err := paymentService.Charge(ctx, &models.Payment{
+		UserID: userID, AmountCents: 11200, Method: "card", ProductType: models.ProductMembership,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 11200, payment.AmountCents)
+	assert.Equal(t, 10000, payment.NetCents)
+	assert.Equal(t, 1200, payment.TaxCents)
+	assert.Equal(t, 1200, payment.TaxRateBP)
+
+	payment, err = paymentService.Charge(ctx, &models.Payment{
+		UserID: userID, AmountCents: 10000, Method: "card", ProductType: models.ProductMembership, GymID: &gymID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 11000, payment.AmountCents)
+	assert.Equal(t, 10000, payment.NetCents)
+	assert.Equal(t, 1000, payment.TaxCents)
+	require.NotNil(t, payment.GymID)
+	assert.Equal(t, gymID, *payment.GymID)
+
+	// Для продукта без ставки налог нулевой
+	payment, err = paymentService.Charge(ctx, &models.Payment{
+		UserID: userID, AmountCents: 3000, Method: "cash", ProductType: models.ProductMerchandise,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3000, payment.NetCents)
+	assert.Zero(t, payment.TaxCents)
+
+	_, err = paymentService.Charge(ctx, &models.Payment{UserID: userID, AmountCents: 3000, Method: "cash", ProductType: "cars"})
+	assert.Error(t, err)
+}
+
+func TestTaxService_Summary(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+
+	paymentRepo := repository.NewPaymentRepository(db)
+	currencyService := service.NewCurrencyService(repository.NewCurrencyRepository(db), "KZT")
+	taxService := service.NewTaxService(repository.NewTaxRepository(db), paymentRepo)
+	fiscalService := service.NewFiscalService(repository.NewFiscalRepository(db), paymentRepo, nil)
+	paymentService := service.NewPaymentService(paymentRepo, repository.NewUnitOfWork(db), currencyService, taxService, fiscalService, nil)
+
+	userID := testutils.CreateTestUser(t, db, "user@test.com", "password", false)
+
+	_, err := taxService.CreateRate(ctx, &models.TaxRate{Name: "VAT", ProductType: models.ProductMembership, RateBP: 1200, Inclusive: true})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = paymentService.Charge(ctx, &models.Payment{UserID: userID, AmountCents: 11200, Method: "card", ProductType: models.ProductMembership})
+		require.NoError(t, err)
+	}
+	_, err = paymentService.Charge(ctx, &models.Payment{UserID: userID, AmountCents: 500, Method: "cash"})
+	require.NoError(t, err)
+
+	// Возвраты уменьшают налог пропорционально: половина платежа — половина налога, полный возврат — ноль
+	for _, refund := range []int{5600, 11200} {
+		payment, err := paymentService.Charge(ctx, &models.Payment{UserID: userID, AmountCents: 11200, Method: "card", ProductType: models.ProductMembership})
+		require.NoError(t, err)
+		_, err = paymentService.Refund(ctx, payment.ID, refund)
+		require.NoError(t, err)
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	rows, err := taxService.Summary(ctx, today, today)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, models.ProductMembership, rows[0].ProductType)
+	assert.Equal(t, 4, rows[0].PaymentsCount)
+	assert.Equal(t, 20000+5000, rows[0].NetCents)
+	assert.Equal(t, 2400+600, rows[0].TaxCents)
+	assert.Equal(t, 22400+5600, rows[0].GrossCents)
+
+	assert.Equal(t, models.ProductOther, rows[1].ProductType)
+	assert.Zero(t, rows[1].TaxCents)
+
+	_, err = taxService.Summary(ctx, "2025-02-01", "2025-01-01")
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRouteTimeouts(t *testing.T) {
+	timeouts, err := middleware.ParseRouteTimeouts(
+		"/api/admin/reports/*=60s; /api/admin/reports/revenue=90s; POST /api/memberships/buy=15s; /api/admin/*=0", 30*time.Second)
+	require.NoError(t, err)
+
+	assert.Equal(t, 30*time.Second, timeouts.For("GET", "/api/classes"))
+	assert.Equal(t, 60*time.Second, timeouts.For("GET", "/api/admin/reports/attendance"))
+	// Точное совпадение важнее префикса
+	assert.Equal(t, 90*time.Second, timeouts.For("GET", "/api/admin/reports/revenue"))
+	// Правило с методом не действует на другие методы
+	assert.Equal(t, 15*time.Second, timeouts.For("POST", "/api/memberships/buy"))
+	assert.Equal(t, 30*time.Second, timeouts.For("GET", "/api/memberships/buy"))
+	// Более короткий префикс с 0 — без дедлайна
+	assert.Equal(t, time.Duration(0), timeouts.For("GET", "/api/admin/users"))
+
+	for _, spec := range []string{
+		"/api/classes",
+		"/api/classes=soon",
+		"/api/classes=-1s",
+		"api/classes=5s",
+		"GET POST /api/classes=5s",
+	} {
+		_, err := middleware.ParseRouteTimeouts(spec, time.Second)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	timeouts, err := middleware.ParseRouteTimeouts("/slow=20ms; /unbounded=0", time.Minute)
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(middleware.RequestTimeout(timeouts))
+	waitForCancel := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(time.Second):
+			c.JSON(http.StatusOK, gin.H{"deadline": false})
+		}
+	}
+	r.GET("/slow", waitForCancel)
+	r.GET("/unbounded", func(c *gin.Context) {
+		_, hasDeadline := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"deadline": hasDeadline})
+	})
+	r.GET("/fast", func(c *gin.Context) {
+		_, hasDeadline := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"deadline": hasDeadline})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Contains(t, w.Body.String(), "request timed out")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/unbounded", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"deadline": false}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"deadline": true}`, w.Body.String())
+}
@@ -0,0 +1,91 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/internal/service"
+	"Gym_StrongCode/internal/utils"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	// Тестовый вектор RFC 6238 (SHA1, секрет "12345678901234567890"), последние 6 цифр
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Unix(59, 0)))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	code, err = utils.TOTPCode(secret, utils.TOTPStep(time.Unix(1111111109, 0)))
+	require.NoError(t, err)
+	assert.Equal(t, "081804", code)
+
+	_, ok := utils.ValidateTOTP(secret, "287082", time.Unix(89, 0))
+	assert.True(t, ok, "код предыдущего шага принимается")
+	_, ok = utils.ValidateTOTP(secret, "287082", time.Unix(150, 0))
+	assert.False(t, ok)
+}
+
+func TestTwoFactor_EnrollAndLogin(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	userRepo := repository.NewUserRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	authService := service.NewAuthService(userRepo, tokenRepo, repository.NewUnitOfWork(db), service.NewKeyService(repository.NewSigningKeyRepository(db), "EdDSA", 0, 0), nil, 0, 0)
+	twoFactorService := service.NewTwoFactorService(userRepo, tokenRepo, repository.NewUnitOfWork(db))
+
+	user, err := authService.Register(ctx, "Staff", "staff@example.com", "Str0ng-Passw0rd")
+	require.NoError(t, err)
+
+	setup, err := twoFactorService.Setup(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Contains(t, setup.OTPAuthURI, "otpauth://totp/")
+	assert.Contains(t, setup.OTPAuthURI, "secret="+setup.Secret)
+
+	step := utils.TOTPStep(time.Now())
+	current, _ := utils.TOTPCode(setup.Secret, step)
+	next, _ := utils.TOTPCode(setup.Secret, step+1)
+
+	_, err = twoFactorService.Enable(ctx, user.ID, "000000")
+	assert.ErrorIs(t, err, service.ErrInvalidTwoFactorCode)
+	recovery, err := twoFactorService.Enable(ctx, user.ID, current)
+	require.NoError(t, err)
+	assert.Len(t, recovery, 10)
+
+	// Пароля недостаточно — нужен второй шаг
+	pair, err := authService.Login(ctx, "staff@example.com", "Str0ng-Passw0rd")
+	require.NoError(t, err)
+	assert.True(t, pair.MFARequired)
+	assert.Empty(t, pair.AccessToken)
+
+	// Уже использованный код не принимается повторно
+	_, err = authService.LoginMFA(ctx, pair.MFAToken, current)
+	assert.ErrorIs(t, err, service.ErrInvalidTwoFactorCode)
+
+	tokens, err := authService.LoginMFA(ctx, pair.MFAToken, next)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	// Код восстановления одноразовый
+	_, err = authService.LoginMFA(ctx, pair.MFAToken, recovery[0])
+	require.NoError(t, err)
+	_, err = authService.LoginMFA(ctx, pair.MFAToken, recovery[0])
+	assert.ErrorIs(t, err, service.ErrInvalidTwoFactorCode)
+
+	_, err = authService.LoginMFA(ctx, "not-a-token", recovery[1])
+	assert.ErrorIs(t, err, service.ErrInvalidMFAToken)
+
+	assert.ErrorIs(t, twoFactorService.Disable(ctx, user.ID, "wrong", recovery[1]), service.ErrInvalidPassword)
+	require.NoError(t, twoFactorService.Disable(ctx, user.ID, "Str0ng-Passw0rd", recovery[1]))
+
+	pair, err = authService.Login(ctx, "staff@example.com", "Str0ng-Passw0rd")
+	require.NoError(t, err)
+	assert.False(t, pair.MFARequired)
+	assert.NotEmpty(t, pair.AccessToken)
+}
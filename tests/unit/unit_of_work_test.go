@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"Gym_StrongCode/internal/repository"
+	"Gym_StrongCode/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func countGyms(t *testing.T, db *sql.DB) int {
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM gyms`).Scan(&n))
+	return n
+}
+
+func TestUnitOfWork_CommitAndRollback(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	defer db.Close()
+
+	uow := repository.NewUnitOfWork(db)
+	gymRepo := repository.NewGymRepository(db)
+
+	// Ошибка на втором шаге откатывает и первый
+	failed := errors.New("second step failed")
+	err := uow.Do(ctx, func(tx *sql.Tx) error {
+		if _, err := gymRepo.WithTx(tx).Create(ctx, "First", "Address"); err != nil {
+			return err
+		}
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, 0, countGyms(t, db))
+
+	err = uow.Do(ctx, func(tx *sql.Tx) error {
+		for _, name := range []string{"First", "Second"} {
+			if _, err := gymRepo.WithTx(tx).Create(ctx, name, "Address"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, countGyms(t, db))
+}
+
+func TestUnitOfWork_RetriesWhenBusy(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.SetupTestDB(t)
+	defer db.Close()
+
+	uow := repository.NewUnitOfWork(db)
+	gymRepo := repository.NewGymRepository(db)
+
+	attempts := 0
+	err := uow.Do(ctx, func(tx *sql.Tx) error {
+		attempts++
+		if _, err := gymRepo.WithTx(tx).Create(ctx, "Gym", "Address"); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			return fmt.Errorf("create gym: %w", errors.New("database is locked (5) (SQLITE_BUSY)"))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 1, countGyms(t, db), "первая попытка откачена")
+
+	// Прочие ошибки не повторяются
+	attempts = 0
+	err = uow.Do(ctx, func(tx *sql.Tx) error {
+		attempts++
+		return errors.New("UNIQUE constraint failed")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestIsBusy(t *testing.T) {
+	assert.True(t, repository.IsBusy(errors.New("database is locked")))
+	assert.True(t, repository.IsBusy(errors.New("database table is locked: gyms")))
+	assert.True(t, repository.IsBusy(fmt.Errorf("commit: %w", errors.New("SQLITE_BUSY"))))
+	assert.False(t, repository.IsBusy(sql.ErrNoRows))
+	assert.False(t, repository.IsBusy(nil))
+}
+
+func TestUnitOfWork_CanceledContext(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Отменённый контекст не начинает транзакцию и прерывает запросы репозиториев
+	called := false
+	err := repository.NewUnitOfWork(db).Do(ctx, func(tx *sql.Tx) error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+
+	_, err = repository.NewGymRepository(db).Create(ctx, "Gym", "Address")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, countGyms(t, db))
+}
@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"context"
 	"testing"
 
 	"Gym_StrongCode/internal/repository"
@@ -11,10 +12,11 @@ import (
 )
 
 func TestUserRepository_Create(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	repo := repository.NewUserRepository(db)
 
-	user, err := repo.Create("John Doe", "john@example.com", "hashedpassword", false)
+	user, err := repo.Create(ctx, "John Doe", "john@example.com", "hashedpassword", false)
 
 	require.NoError(t, err)
 	assert.NotZero(t, user.ID)
@@ -24,14 +26,15 @@ func TestUserRepository_Create(t *testing.T) {
 }
 
 func TestUserRepository_GetByEmail(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	repo := repository.NewUserRepository(db)
 
 	// Создаем пользователя
-	created, _ := repo.Create("Jane Doe", "jane@example.com", "hashedpass", false)
+	created, _ := repo.Create(ctx, "Jane Doe", "jane@example.com", "hashedpass", false)
 
 	// Получаем по email
-	user, err := repo.GetByEmail("jane@example.com")
+	user, err := repo.GetByEmail(ctx, "jane@example.com")
 
 	require.NoError(t, err)
 	assert.Equal(t, created.ID, user.ID)
@@ -40,22 +43,24 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 }
 
 func TestUserRepository_GetByEmail_NotFound(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	repo := repository.NewUserRepository(db)
 
-	user, err := repo.GetByEmail("nonexistent@example.com")
+	user, err := repo.GetByEmail(ctx, "nonexistent@example.com")
 
 	assert.Error(t, err)
 	assert.Nil(t, user)
 }
 
 func TestUserRepository_GetByID(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	repo := repository.NewUserRepository(db)
 
-	created, _ := repo.Create("Bob Smith", "bob@example.com", "hashedpass", true)
+	created, _ := repo.Create(ctx, "Bob Smith", "bob@example.com", "hashedpass", true)
 
-	user, err := repo.GetByID(created.ID)
+	user, err := repo.GetByID(ctx, created.ID)
 
 	require.NoError(t, err)
 	assert.Equal(t, created.ID, user.ID)
@@ -64,32 +69,34 @@ func TestUserRepository_GetByID(t *testing.T) {
 }
 
 func TestUserRepository_List(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	repo := repository.NewUserRepository(db)
 
 	// Создаем несколько пользователей
-	repo.Create("User 1", "user1@example.com", "pass1", false)
-	repo.Create("User 2", "user2@example.com", "pass2", false)
-	repo.Create("User 3", "user3@example.com", "pass3", true)
+	repo.Create(ctx, "User 1", "user1@example.com", "pass1", false)
+	repo.Create(ctx, "User 2", "user2@example.com", "pass2", false)
+	repo.Create(ctx, "User 3", "user3@example.com", "pass3", true)
 
-	users, err := repo.List()
+	users, err := repo.List(ctx)
 
 	require.NoError(t, err)
 	assert.Len(t, users, 3)
 }
 
 func TestUserRepository_Delete(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	repo := repository.NewUserRepository(db)
 
-	created, _ := repo.Create("Delete Me", "delete@example.com", "pass", false)
+	created, _ := repo.Create(ctx, "Delete Me", "delete@example.com", "pass", false)
 
 	// Удаляем
-	err := repo.Delete(created.ID)
+	err := repo.Delete(ctx, created.ID)
 	require.NoError(t, err)
 
 	// Проверяем, что пользователь удален
-	user, err := repo.GetByID(created.ID)
+	user, err := repo.GetByID(ctx, created.ID)
 	assert.Error(t, err)
 	assert.Nil(t, user)
 }
@@ -4,6 +4,7 @@ import (
 	"Gym_StrongCode/internal/repository"
 	"Gym_StrongCode/internal/service"
 	"Gym_StrongCode/tests/testutils"
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,6 +12,7 @@ import (
 )
 
 func TestUserService_GetByID(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -18,24 +20,26 @@ func TestUserService_GetByID(t *testing.T) {
 	userService := service.NewUserService(userRepo)
 	userID := testutils.CreateTestUser(t, db, "test@example.com", "password", false)
 
-	user, err := userService.GetByID(userID)
+	user, err := userService.GetByID(ctx, userID)
 	require.NoError(t, err)
 	assert.Equal(t, userID, user.ID)
 	assert.Equal(t, "test@example.com", user.Email)
 }
 
 func TestUserService_GetByID_NotFound(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
 	userRepo := repository.NewUserRepository(db)
 	userService := service.NewUserService(userRepo)
 
-	_, err := userService.GetByID(99999)
+	_, err := userService.GetByID(ctx, 99999)
 	require.Error(t, err)
 }
 
 func TestUserService_List(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -45,12 +49,13 @@ func TestUserService_List(t *testing.T) {
 	testutils.CreateTestUser(t, db, "user1@test.com", "password", false)
 	testutils.CreateTestUser(t, db, "user2@test.com", "password", false)
 
-	users, err := userService.List()
+	users, err := userService.List(ctx)
 	require.NoError(t, err)
 	assert.GreaterOrEqual(t, len(users), 2)
 }
 
 func TestUserService_Delete(t *testing.T) {
+	ctx := context.Background()
 	db := testutils.SetupTestDB(t)
 	defer db.Close()
 
@@ -58,9 +63,9 @@ func TestUserService_Delete(t *testing.T) {
 	userService := service.NewUserService(userRepo)
 	userID := testutils.CreateTestUser(t, db, "delete@test.com", "password", false)
 
-	err := userService.Delete(userID)
+	err := userService.Delete(ctx, userID)
 	require.NoError(t, err)
 
-	_, err = userService.GetByID(userID)
+	_, err = userService.GetByID(ctx, userID)
 	assert.Error(t, err)
 }